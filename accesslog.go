@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"regexp"
+	"time"
+)
+
+// requestIDPattern is what an X-Request-ID from the client must look like to
+// be kept; anything else is replaced rather than written to the logs.
+var requestIDPattern = regexp.MustCompile(`^[A-Za-z0-9._-]{1,64}$`)
+
+// requestInfo follows a request through its handler for the access log.
+type requestInfo struct {
+	id      string
+	changed *Session // session the request changed, if any
+}
+
+type requestInfoKey struct{}
+
+// RequestID returns the ID of the request ctx belongs to, empty outside one.
+func RequestID(ctx context.Context) string {
+	if info, ok := ctx.Value(requestInfoKey{}).(*requestInfo); ok {
+		return info.id
+	}
+	return ""
+}
+
+// noteChange marks r as one that changes sess, so once it succeeds the
+// session's event log records it under the request's ID.
+func noteChange(r *http.Request, sess *Session) {
+	if info, ok := r.Context().Value(requestInfoKey{}).(*requestInfo); ok {
+		info.changed = sess
+	}
+}
+
+// withAccessLog gives every request an ID, echoed in the X-Request-ID
+// response header, and logs it once it completes. A WebSocket or event
+// stream is logged when it closes. Requests that change a session are also
+// recorded as Command events in its event log.
+func withAccessLog(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		id := r.Header.Get("X-Request-ID")
+		if !requestIDPattern.MatchString(id) {
+			b := make([]byte, 8)
+			rand.Read(b)
+			id = hex.EncodeToString(b)
+		}
+		info := &requestInfo{id: id}
+		w.Header().Set("X-Request-ID", id)
+		rec := &accessRecorder{ResponseWriter: w}
+		h.ServeHTTP(rec, r.WithContext(context.WithValue(r.Context(), requestInfoKey{}, info)))
+
+		status := rec.status
+		if status == 0 {
+			status = http.StatusOK
+		}
+		attrs := []any{
+			"id", id,
+			"method", r.Method,
+			"path", r.URL.Path,
+			"status", status,
+			"bytes", rec.bytes,
+			"duration", time.Since(start).Round(time.Microsecond),
+			"client", clientKey(r),
+		}
+		if info.changed != nil {
+			attrs = append(attrs, "session", info.changed.ID)
+			if status < http.StatusBadRequest {
+				info.changed.Sim.LogCommand(id, r.Method+" "+r.URL.Path+" from "+clientKey(r))
+			}
+		}
+		slog.Info("request", attrs...)
+	})
+}
+
+// accessRecorder notes the status and size of a response. It passes
+// hijacking through for WebSocket upgrades and unwraps for
+// http.ResponseController.
+type accessRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *accessRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *accessRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *accessRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *accessRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response cannot be hijacked")
+	}
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestAccessLog(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	defer sess.Sim.Stop()
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stop", handleStop)
+	mux.HandleFunc("/state", handleState)
+	h := withAccessLog(mux)
+
+	tests := []struct {
+		name    string
+		method  string
+		path    string
+		id      string
+		keepID  bool
+		command bool // want a Command event under the request's ID
+	}{
+		{"change", http.MethodPost, "/stop", "op-42", true, true},
+		{"generated id", http.MethodPost, "/stop", "", false, true},
+		{"unsafe id replaced", http.MethodPost, "/stop", "a b\nc", false, true},
+		{"read", http.MethodGet, "/state", "op-43", true, false},
+		{"failed change", http.MethodGet, "/stop", "op-44", true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.id != "" {
+				r.Header.Set("X-Request-ID", tt.id)
+			}
+			rec := httptest.NewRecorder()
+			h.ServeHTTP(rec, r)
+			id := rec.Header().Get("X-Request-ID")
+			if id == "" || (id == tt.id) != tt.keepID {
+				t.Fatalf("X-Request-ID %q for %q", id, tt.id)
+			}
+			var found bool
+			for _, ev := range sess.Sim.Events(0) {
+				if ev.Type == simulation.EventCommand && ev.RequestID == id {
+					found = true
+				}
+			}
+			if found != tt.command {
+				t.Errorf("Command event logged = %v, want %v", found, tt.command)
+			}
+		})
+	}
+}
@@ -0,0 +1,124 @@
+package simulation
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// acmiKinds gives the Tacview type, color and coalition of each entity type.
+var acmiKinds = map[string][3]string{
+	"Target":  {"Air+FixedWing", "Red", "Enemies"},
+	"Missile": {"Weapon+Missile", "Blue", "Allies"},
+}
+
+// WriteACMI writes a recording in Tacview's text ACMI 2.1 format, with the
+// local frame placed at origin. Objects are written when they move, events
+// become Tacview events, and destroyed objects are removed.
+func WriteACMI(w io.Writer, rec *Recording, origin geo.Origin) error {
+	out := bufio.NewWriter(w)
+	fmt.Fprintf(out, "FileType=text/acmi/tacview\nFileVersion=2.1\n")
+	fmt.Fprintf(out, "0,ReferenceTime=%s\n", rec.Created.UTC().Format(time.RFC3339))
+	fmt.Fprintf(out, "0,Title=%s\n", acmiText(globeTitle(rec)))
+
+	a := acmiWriter{out: out, ids: make(map[string]string), last: make(map[string]string), gone: make(map[string]bool)}
+	targetOf := make(map[string]string)
+	events := rec.Events
+	for _, st := range rec.Frames {
+		fmt.Fprintf(out, "#%s\n", num(st.Time))
+		for _, eng := range st.Engagements {
+			targetOf[eng.MissileID] = eng.TargetID
+		}
+		for _, e := range st.Entities {
+			if a.gone[e.ID] {
+				continue
+			}
+			lat, lon, alt := geodetic(origin, e.Position)
+			yaw, pitch := attitude(e.Velocity)
+			t := fmt.Sprintf("%.7f|%.7f|%.1f|0|%.1f|%.1f", lon, lat, alt, pitch, yaw)
+			id, known := a.ids[e.ID]
+			if known && t == a.last[e.ID] {
+				continue
+			}
+			a.last[e.ID] = t
+			if !known {
+				id = fmt.Sprintf("%x", 0x100+len(a.ids))
+				a.ids[e.ID] = id
+				kind, ok := acmiKinds[string(e.Type)]
+				if !ok {
+					kind = [3]string{"Misc", "Grey", "Neutrals"}
+				}
+				fmt.Fprintf(out, "%s,T=%s,Type=%s,Name=%s,Color=%s,Coalition=%s\n", id, t, kind[0], acmiText(e.ID), kind[1], kind[2])
+				continue
+			}
+			fmt.Fprintf(out, "%s,T=%s\n", id, t)
+		}
+		for len(events) > 0 && events[0].Time <= st.Time {
+			a.event(events[0], targetOf)
+			events = events[1:]
+		}
+	}
+	for _, ev := range events {
+		a.event(ev, targetOf)
+	}
+	return out.Flush()
+}
+
+// acmiWriter tracks the objects written so far.
+type acmiWriter struct {
+	out  *bufio.Writer
+	ids  map[string]string // entity ID to ACMI object ID
+	last map[string]string // last transform written per entity
+	gone map[string]bool   // removed entities
+}
+
+// event writes ev as a Tacview event, removing whatever it destroyed.
+func (a *acmiWriter) event(ev Event, targetOf map[string]string) {
+	id, ok := a.ids[ev.EntityID]
+	if !ok {
+		fmt.Fprintf(a.out, "0,Event=Bookmark|%s\n", acmiText(ev.Message))
+		return
+	}
+	switch ev.Type {
+	case EventIntercept:
+		fmt.Fprintf(a.out, "0,Event=Destroyed|%s|%s\n", a.ids[targetOf[ev.EntityID]], acmiText(ev.Message))
+		a.remove(ev.EntityID)
+		a.remove(targetOf[ev.EntityID])
+	case EventCrash, EventImpact:
+		fmt.Fprintf(a.out, "0,Event=Destroyed|%s|%s\n", id, acmiText(ev.Message))
+		a.remove(ev.EntityID)
+	case EventOutOfBounds:
+		fmt.Fprintf(a.out, "0,Event=LeftArea|%s|%s\n", id, acmiText(ev.Message))
+		a.remove(ev.EntityID)
+	default:
+		fmt.Fprintf(a.out, "0,Event=Message|%s|%s\n", id, acmiText(ev.Message))
+	}
+}
+
+func (a *acmiWriter) remove(entityID string) {
+	if id, ok := a.ids[entityID]; ok && !a.gone[entityID] {
+		fmt.Fprintf(a.out, "-%s\n", id)
+		a.gone[entityID] = true
+	}
+}
+
+// attitude returns the heading (clockwise from north) and pitch of a
+// velocity in the Y-up frame, in degrees.
+func attitude(v vector.Vector3) (yaw, pitch float64) {
+	yaw = math.Atan2(v.X, v.Z) * 180 / math.Pi
+	if yaw < 0 {
+		yaw += 360
+	}
+	return yaw, math.Atan2(v.Y, math.Hypot(v.X, v.Z)) * 180 / math.Pi
+}
+
+// acmiText escapes the separators ACMI gives meaning to.
+func acmiText(s string) string {
+	return strings.NewReplacer(",", `\,`, "\n", " ").Replace(s)
+}
@@ -0,0 +1,37 @@
+package simulation
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/geo"
+)
+
+func TestWriteACMI(t *testing.T) {
+	rec := recordRun(t, 30) // the default scenario intercepts at t=10.4
+	var buf bytes.Buffer
+	if err := WriteACMI(&buf, rec, geo.Origin{Lat: 36, Lon: -115}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if lines[0] != "FileType=text/acmi/tacview" || lines[1] != "FileVersion=2.1" {
+		t.Fatalf("header %q", lines[:2])
+	}
+	tests := []struct {
+		name, want string
+	}{
+		{"target declared", ",Type=Air+FixedWing,Name="},
+		{"interceptor declared", ",Type=Weapon+Missile,Name="},
+		{"launch message", ",Event=Message|"},
+		{"intercept", ",Event=Destroyed|"},
+		{"destroyed objects removed", "\n-10"},
+		{"placed near the origin", "|36.0"},
+	}
+	for _, tt := range tests {
+		if !strings.Contains(out, tt.want) {
+			t.Errorf("%s: no %q in output", tt.name, tt.want)
+		}
+	}
+}
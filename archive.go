@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"missile-intercept-sim/internal/runstore"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// archiveQueueSize bounds the runs waiting to be written to the database.
+const archiveQueueSize = 256
+
+// maxListedRuns caps one page of /api/runs.
+const maxListedRuns = 1000
+
+// runArchive writes every session's finished runs to the run database from
+// a background goroutine, so a slow database never stalls a simulation.
+type runArchive struct {
+	store        *runstore.Store
+	trajectories bool // keep the frames of recorded runs
+	queue        chan runstore.Run
+	stopped      chan struct{}
+}
+
+// archive is the run database, nil when none is configured.
+var archive *runArchive
+
+func newRunArchive(store *runstore.Store, trajectories bool) *runArchive {
+	return &runArchive{
+		store:        store,
+		trajectories: trajectories,
+		queue:        make(chan runstore.Run, archiveQueueSize),
+		stopped:      make(chan struct{}),
+	}
+}
+
+// sessionArchiver hands one session's finished runs to the archive.
+type sessionArchiver struct {
+	archive *runArchive
+	session string
+}
+
+// ArchiveRun queues run for writing, dropping it if the queue is full.
+func (s sessionArchiver) ArchiveRun(run simulation.RunArchive) {
+	r := runstore.Run{Session: s.session, Report: run.Report, Events: run.Events}
+	if s.archive.trajectories {
+		r.Frames = run.Frames
+	}
+	select {
+	case s.archive.queue <- r:
+	default:
+		slog.Warn("runs: archive queue full, dropping a run", "session", s.session)
+	}
+}
+
+// Run writes queued runs until ctx is done.
+func (a *runArchive) Run(ctx context.Context) {
+	defer close(a.stopped)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case r := <-a.queue:
+			a.save(context.Background(), r)
+		}
+	}
+}
+
+// Close writes the runs still queued, including those the session shutdown
+// just finished, and closes the database.
+func (a *runArchive) Close(ctx context.Context) error {
+	<-a.stopped
+	for {
+		select {
+		case r := <-a.queue:
+			a.save(ctx, r)
+		default:
+			return a.store.Close()
+		}
+	}
+}
+
+func (a *runArchive) save(ctx context.Context, r runstore.Run) {
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+	if _, err := a.store.Save(ctx, r); err != nil {
+		slog.Error("runs: save failed", "err", err)
+	}
+}
+
+// runFilter reads a run filter from the query: session, scenario, guidance,
+// result, since and until (RFC 3339), before (a run ID) and limit.
+func runFilter(q url.Values) (runstore.Filter, error) {
+	f := runstore.Filter{
+		Session:  q.Get("session"),
+		Scenario: q.Get("scenario"),
+		Guidance: q.Get("guidance"),
+		Result:   q.Get("result"),
+		Limit:    100,
+	}
+	var err error
+	for name, t := range map[string]*time.Time{"since": &f.Since, "until": &f.Until} {
+		if v := q.Get(name); v != "" {
+			if *t, err = time.Parse(time.RFC3339, v); err != nil {
+				return f, errors.New(name + " must be an RFC 3339 time")
+			}
+		}
+	}
+	if v := q.Get("before"); v != "" {
+		if f.Before, err = strconv.ParseInt(v, 10, 64); err != nil || f.Before < 1 {
+			return f, errors.New("before must be a run ID")
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		if f.Limit, err = strconv.Atoi(v); err != nil || f.Limit < 1 || f.Limit > maxListedRuns {
+			return f, errors.New("limit must be between 1 and " + strconv.Itoa(maxListedRuns))
+		}
+	}
+	return f, nil
+}
+
+// handleRuns queries the run database: GET lists runs matching the filter
+// newest first, or with ?id= returns one run with its events, and its
+// trajectory if ?frames=1. DELETE ?id= removes a run.
+func handleRuns(w http.ResponseWriter, r *http.Request) {
+	if archive == nil {
+		writeError(w, "No run database configured", http.StatusNotFound)
+		return
+	}
+	q := r.URL.Query()
+	var id int64
+	if v := q.Get("id"); v != "" {
+		var err error
+		if id, err = strconv.ParseInt(v, 10, 64); err != nil {
+			writeError(w, "id must be a run ID", http.StatusBadRequest)
+			return
+		}
+	}
+	switch {
+	case r.Method == http.MethodGet && id == 0:
+		f, err := runFilter(q)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		runs, err := archive.store.List(r.Context(), f)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(runs)
+	case r.Method == http.MethodGet:
+		run, err := archive.store.Get(r.Context(), id, q.Get("frames") == "1")
+		if err != nil {
+			writeRunError(w, err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(run)
+	case r.Method == http.MethodDelete && id != 0:
+		if err := archive.store.Delete(r.Context(), id); err != nil {
+			writeRunError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodDelete:
+		writeError(w, "id is required", http.StatusBadRequest)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleCompareRuns aggregates the stored runs matching the filter into
+// groups by ?by=, one of runstore.CompareKeys, defaulting to day.
+func handleCompareRuns(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if archive == nil {
+		writeError(w, "No run database configured", http.StatusNotFound)
+		return
+	}
+	f, err := runFilter(r.URL.Query())
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	by := r.URL.Query().Get("by")
+	if by == "" {
+		by = "day"
+	}
+	if !slices.Contains(runstore.CompareKeys, by) {
+		writeError(w, "by must be one of "+strings.Join(runstore.CompareKeys, ", "), http.StatusBadRequest)
+		return
+	}
+	groups, err := archive.store.Compare(r.Context(), f, by)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(groups)
+}
+
+func writeRunError(w http.ResponseWriter, err error) {
+	if errors.Is(err, runstore.ErrNotFound) {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeError(w, err.Error(), http.StatusInternalServerError)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/runstore"
+)
+
+func TestRunArchive(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "runs.db")
+	store, err := runstore.Open(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	archive = newRunArchive(store, false)
+	defer func() { archive = nil }()
+	sess := newSession("archived")
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	sess.Sim.SetSeed(42)
+	sess.Sim.Reset()
+	if _, err := sess.Sim.Advance(1200); err != nil {
+		t.Fatal(err)
+	}
+
+	// Run or, once it stops, Close writes the queued run.
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	archive.Run(ctx)
+	if err := archive.Close(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	store, err = runstore.Open(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer store.Close()
+	archive = newRunArchive(store, false)
+
+	tests := []struct {
+		name   string
+		method string
+		target string
+		status int
+	}{
+		{"list", http.MethodGet, "/api/v1/runs?session=archived", http.StatusOK},
+		{"one", http.MethodGet, "/api/v1/runs?id=1", http.StatusOK},
+		{"missing", http.MethodGet, "/api/v1/runs?id=99", http.StatusNotFound},
+		{"bad since", http.MethodGet, "/api/v1/runs?since=yesterday", http.StatusBadRequest},
+		{"compare", http.MethodGet, "/api/v1/runs/compare?by=scenario", http.StatusOK},
+		{"compare by nothing", http.MethodGet, "/api/v1/runs/compare?by=colour", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r := httptest.NewRequest(tt.method, tt.target, nil)
+		if r.URL.Path == "/api/v1/runs" {
+			handleRuns(w, r)
+		} else {
+			handleCompareRuns(w, r)
+		}
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d: %s", tt.name, w.Code, tt.status, w.Body)
+		}
+		if tt.name == "list" {
+			var runs []runstore.Summary
+			json.NewDecoder(w.Body).Decode(&runs)
+			if len(runs) != 1 || runs[0].Result != "Intercepted" || runs[0].Session != "archived" {
+				t.Errorf("stored runs %+v", runs)
+			}
+		}
+	}
+}
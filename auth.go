@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// role is what a token lets its bearer do. Roles are ordered: a controller
+// can do everything an observer can.
+type role int
+
+const (
+	roleNone       role = iota
+	roleObserver        // read state, stream, list
+	roleController      // also start, stop, launch, load scenarios...
+)
+
+// apiToken grants a role to whoever presents it.
+type apiToken struct {
+	token string
+	role  role
+}
+
+// apiTokens are the configured tokens. With none, authentication is off and
+// every client is a controller, which suits a local dev setup.
+var apiTokens []apiToken
+
+// addTokens registers the comma-separated tokens in list with role r.
+func addTokens(list string, r role) {
+	for _, t := range strings.Split(list, ",") {
+		if t = strings.TrimSpace(t); t != "" {
+			apiTokens = append(apiTokens, apiToken{t, r})
+		}
+	}
+}
+
+// roleOf returns the role granted to token, comparing in constant time.
+func roleOf(token string) role {
+	if len(apiTokens) == 0 {
+		return roleController
+	}
+	granted := roleNone
+	for _, t := range apiTokens {
+		if subtle.ConstantTimeCompare([]byte(t.token), []byte(token)) == 1 && t.role > granted {
+			granted = t.role
+		}
+	}
+	return granted
+}
+
+// authorize returns the role of the request's token, taken from an
+// "Authorization: Bearer" header or, for browsers opening a WebSocket, the
+// ?token= parameter.
+func authorize(r *http.Request) role {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("token")
+	}
+	return roleOf(token)
+}
+
+// requireRole lets a request through if its token grants the role the
+// method needs: observer for reads, controller for anything that changes
+// state.
+func requireRole(h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		need := roleController
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			need = roleObserver
+		}
+		switch got := authorize(r); {
+		case got == roleNone:
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, "Missing or unknown token", http.StatusUnauthorized)
+		case got < need:
+			writeError(w, "Observer tokens cannot change the simulation", http.StatusForbidden)
+		default:
+			h(w, r)
+		}
+	}
+}
+
+// grpcObserverMethods are the gRPC methods an observer may call.
+var grpcObserverMethods = map[string]bool{
+	"/missileintercept.v1.Simulator/GetState":    true,
+	"/missileintercept.v1.Simulator/StreamState": true,
+}
+
+// authorizeGRPC checks the "authorization" metadata of a call to method.
+func authorizeGRPC(ctx context.Context, method string) error {
+	var token string
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get("authorization"); len(v) > 0 {
+			token = strings.TrimPrefix(v[0], "Bearer ")
+		}
+	}
+	need := roleController
+	if grpcObserverMethods[method] {
+		need = roleObserver
+	}
+	switch got := roleOf(token); {
+	case got == roleNone:
+		return status.Error(codes.Unauthenticated, "missing or unknown token")
+	case got < need:
+		return status.Error(codes.PermissionDenied, "observer tokens cannot change the simulation")
+	}
+	return nil
+}
+
+// grpcAuth returns server options enforcing token roles on every call.
+func grpcAuth() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := authorizeGRPC(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := authorizeGRPC(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
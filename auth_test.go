@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func withTokens(t *testing.T) {
+	t.Helper()
+	saved := apiTokens
+	t.Cleanup(func() { apiTokens = saved })
+	apiTokens = nil
+	addTokens("ctl-1, ctl-2", roleController)
+	addTokens("obs", roleObserver)
+}
+
+func TestRequireRole(t *testing.T) {
+	withTokens(t)
+	ok := func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNoContent) }
+	tests := []struct {
+		name   string
+		method string
+		header string
+		query  string
+		want   int
+	}{
+		{"no token", http.MethodGet, "", "", http.StatusUnauthorized},
+		{"unknown token", http.MethodGet, "Bearer nope", "", http.StatusUnauthorized},
+		{"observer reads", http.MethodGet, "Bearer obs", "", http.StatusNoContent},
+		{"observer writes", http.MethodPost, "Bearer obs", "", http.StatusForbidden},
+		{"controller writes", http.MethodPost, "Bearer ctl-2", "", http.StatusNoContent},
+		{"token in query", http.MethodGet, "", "?token=obs", http.StatusNoContent},
+		{"header without scheme", http.MethodGet, "ctl-1", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/v1/start"+tt.query, nil)
+			if tt.header != "" {
+				r.Header.Set("Authorization", tt.header)
+			}
+			rec := httptest.NewRecorder()
+			requireRole(ok)(rec, r)
+			if rec.Code != tt.want {
+				t.Errorf("got %d, want %d", rec.Code, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthDisabledWithoutTokens(t *testing.T) {
+	saved := apiTokens
+	defer func() { apiTokens = saved }()
+	apiTokens = nil
+	if got := roleOf(""); got != roleController {
+		t.Errorf("role without tokens = %v, want controller", got)
+	}
+}
+
+func TestAuthorizeGRPC(t *testing.T) {
+	withTokens(t)
+	tests := []struct {
+		method string
+		token  string
+		want   codes.Code
+	}{
+		{"/missileintercept.v1.Simulator/GetState", "", codes.Unauthenticated},
+		{"/missileintercept.v1.Simulator/StreamState", "Bearer obs", codes.OK},
+		{"/missileintercept.v1.Simulator/Start", "Bearer obs", codes.PermissionDenied},
+		{"/missileintercept.v1.Simulator/Start", "Bearer ctl-1", codes.OK},
+	}
+	for _, tt := range tests {
+		ctx := context.Background()
+		if tt.token != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", tt.token))
+		}
+		if got := status.Code(authorizeGRPC(ctx, tt.method)); got != tt.want {
+			t.Errorf("%s with %q: %v, want %v", tt.method, tt.token, got, tt.want)
+		}
+	}
+}
+
+func TestObserverCannotCommand(t *testing.T) {
+	sess := newSession("test")
+	defer sess.Hub.Close()
+	conn := dialHub(t, sess, "observer=1")
+	for _, tt := range []struct {
+		cmd string
+		ok  bool
+	}{
+		{`{"id":"1","type":"start"}`, false},
+		{`{"id":"2","type":"keyframe"}`, true},
+	} {
+		var cmd Command
+		json.Unmarshal([]byte(tt.cmd), &cmd)
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(tt.cmd)); err != nil {
+			t.Fatal(err)
+		}
+		if ack := nextAck(t, conn, cmd.ID); ack.OK != tt.ok {
+			t.Errorf("%s: ack %+v, want ok %v", tt.cmd, ack, tt.ok)
+		}
+	}
+	if st := sess.Sim.GetState(); st.Status == "Running" {
+		t.Error("observer started the simulation")
+	}
+}
@@ -0,0 +1,198 @@
+package simulation
+
+import (
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/stochastic"
+)
+
+// BatchConfig describes a headless Monte Carlo campaign.
+type BatchConfig struct {
+	Runs           int     `json:"runs"`
+	Seed           uint64  `json:"seed"` // 0 picks a seed from the clock
+	MaxTime        float64 `json:"maxTime"`
+	Guidance       string  `json:"guidance"`
+	PositionJitter float64 `json:"positionJitter"`    // m, 1-sigma on target start position
+	VelocityJitter float64 `json:"velocityJitter"`    // m/s, 1-sigma on target velocity
+	Workers        int     `json:"workers,omitempty"` // replicas flown at once, 0 for one per CPU
+}
+
+// RunResult is the outcome of one batch replica.
+type RunResult struct {
+	Run          int     `json:"run"`
+	Seed         uint64  `json:"seed"`
+	Status       string  `json:"status"`
+	Intercept    bool    `json:"intercept"`
+	MissDistance float64 `json:"missDistance"`
+	TimeOfFlight float64 `json:"timeOfFlight"`
+}
+
+// BatchReport summarizes a completed campaign.
+type BatchReport struct {
+	Config     BatchConfig        `json:"config"`
+	Scenario   *scenario.Scenario `json:"scenario"` // as run; load it and reset with a result's seed to replay that replica
+	Intercepts int                `json:"intercepts"`
+	Pk         float64            `json:"pk"`
+	MeanMiss   float64            `json:"meanMiss"`
+	MeanFlight float64            `json:"meanFlight"`
+	Stats      BatchStats         `json:"stats"`
+	WallTime   float64            `json:"wallTime"` // seconds
+	Results    []RunResult        `json:"results"`
+}
+
+// DefaultMaxTime is the simulated time, in s, after which a headless run
+// with no limit of its own is stopped as a timeout.
+const DefaultMaxTime = 120.0
+
+// defaultBatchJitter is the position jitter of a batch that sets none.
+const defaultBatchJitter = 250.0
+
+// campaignScenario is sc with the batch's guidance override and jitter
+// written into it, so a replica is reproduced exactly by loading it and
+// resetting with the replica's seed.
+func campaignScenario(sc *scenario.Scenario, cfg BatchConfig) *scenario.Scenario {
+	run := sc.Clone()
+	for i := range run.Entities {
+		e := &run.Entities[i]
+		switch e.Role {
+		case scenario.RoleInterceptor:
+			if cfg.Guidance != "" {
+				e.Guidance = cfg.Guidance
+			}
+		case scenario.RoleTarget:
+			r := scenario.Randomization{}
+			if e.Random != nil {
+				r = *e.Random
+			}
+			r.PositionSigma = cfg.PositionJitter
+			r.VelocitySigma = cfg.VelocityJitter
+			e.Random = &r
+		}
+	}
+	return run
+}
+
+// StepLogger receives the state after every step of a replica. LogStep is
+// called with the simulator's lock held and must copy whatever it keeps.
+type StepLogger interface {
+	LogStep(state *SimulationState) error
+}
+
+// CampaignLog records every step of every replica of a campaign.
+type CampaignLog interface {
+	OpenRun(run int, seed uint64) (StepLogger, error)
+	CloseRun(run int, result RunResult) error
+}
+
+// RunBatch runs cfg.Runs randomized replicas of sc as fast as possible,
+// cfg.Workers at a time, and collects their outcomes in run order. Each
+// replica has its own simulator and random source, so the outcomes do not
+// depend on the number of workers. A guidance mode the simulator cannot fly,
+// in cfg or in sc, fails with an *UnknownGuidanceError before any replica.
+func RunBatch(sc *scenario.Scenario, cfg BatchConfig) (BatchReport, error) {
+	return RunBatchLogged(sc, cfg, nil)
+}
+
+// RunBatchLogged is RunBatch recording each replica's steps to log, if not
+// nil, which must accept replicas concurrently. A logging error ends the
+// campaign early; the report covers the replicas finished by then.
+func RunBatchLogged(sc *scenario.Scenario, cfg BatchConfig, log CampaignLog) (BatchReport, error) {
+	if err := CheckGuidanceMode(cfg.Guidance); err != nil {
+		return BatchReport{}, err
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = uint64(time.Now().UnixNano())
+	}
+	if cfg.MaxTime <= 0 {
+		cfg.MaxTime = DefaultMaxTime
+	}
+	if cfg.PositionJitter == 0 && cfg.VelocityJitter == 0 {
+		cfg.PositionJitter = defaultBatchJitter
+	}
+	// The campaign RNG only hands out per-replica seeds; each replica draws
+	// everything else, jitter included, from its own source so it can be
+	// replayed alone.
+	rng := stochastic.New(cfg.Seed)
+	start := time.Now()
+
+	run := campaignScenario(sc, cfg)
+	if err := CheckScenarioGuidance(run); err != nil {
+		return BatchReport{}, err
+	}
+	seeds := make([]uint64, cfg.Runs)
+	for i := range seeds {
+		seeds[i] = rng.Uint64()
+	}
+	results := make([]RunResult, cfg.Runs)
+	done := make([]bool, cfg.Runs)
+	err := forEach(cfg.Runs, cfg.Workers, func(i int) error {
+		result, err := runReplica(run, i, seeds[i], cfg.MaxTime, log)
+		if err != nil {
+			return err
+		}
+		results[i], done[i] = result, true
+		return nil
+	})
+
+	report := BatchReport{Config: cfg, Scenario: run, Results: make([]RunResult, 0, cfg.Runs)}
+	for i, result := range results {
+		if !done[i] {
+			continue
+		}
+		report.Results = append(report.Results, result)
+		if result.Intercept {
+			report.Intercepts++
+		}
+		report.MeanMiss += result.MissDistance
+		report.MeanFlight += result.TimeOfFlight
+	}
+	if len(report.Results) > 0 {
+		n := float64(len(report.Results))
+		report.Pk = float64(report.Intercepts) / n
+		report.MeanMiss /= n
+		report.MeanFlight /= n
+	}
+	report.Stats = batchStats(report.Results, report.Intercepts)
+	report.WallTime = time.Since(start).Seconds()
+	return report, err
+}
+
+// runReplica flies replica i of a campaign over sc with the given seed,
+// logging its steps to log if not nil.
+func runReplica(sc *scenario.Scenario, i int, seed uint64, maxTime float64, log CampaignLog) (RunResult, error) {
+	sim := NewSimulator()
+	sim.SetLogger(logging.Discard)
+	sim.HistoryDuration = 0
+	sim.TrailDuration = 0
+	sim.Seed = seed
+	sim.Scenario = sc
+	sim.Reset()
+
+	var steps StepLogger
+	if log != nil {
+		var err error
+		if steps, err = log.OpenRun(i, seed); err != nil {
+			return RunResult{}, err
+		}
+	}
+	state, err := sim.runLogged(maxTime, steps)
+	if err != nil {
+		return RunResult{}, err
+	}
+	result := RunResult{
+		Run:          i,
+		Seed:         state.Seed,
+		Status:       state.Status,
+		Intercept:    state.Intercept,
+		MissDistance: state.MissDistance,
+		TimeOfFlight: state.Time,
+	}
+	if log != nil {
+		if err := log.CloseRun(i, result); err != nil {
+			return RunResult{}, err
+		}
+	}
+	return result, nil
+}
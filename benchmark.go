@@ -0,0 +1,90 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"time"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+// GuidanceModes lists the guidance laws the simulator can fly.
+var GuidanceModes = []string{"ProNav", "PurePursuit", "LeadPursuit"}
+
+// BenchmarkCell is the campaign result for one guidance law on one scenario.
+type BenchmarkCell struct {
+	Scenario   string  `json:"scenario"`
+	Guidance   string  `json:"guidance"`
+	Runs       int     `json:"runs"`
+	Intercepts int     `json:"intercepts"`
+	Pk         float64 `json:"pk"`
+	PkLow      float64 `json:"pkLow"`
+	PkHigh     float64 `json:"pkHigh"`
+	MeanMiss   float64 `json:"meanMiss"`
+	MeanFlight float64 `json:"meanFlight"`
+}
+
+// BenchmarkReport is the law-by-scenario comparison matrix, scenario-major.
+type BenchmarkReport struct {
+	Config    BatchConfig     `json:"config"`
+	Scenarios []string        `json:"scenarios"`
+	Guidance  []string        `json:"guidance"`
+	Cells     []BenchmarkCell `json:"cells"`
+	WallTime  float64         `json:"wallTime"` // seconds
+}
+
+// BenchmarkSize returns the number of runs a benchmark with cfg performs.
+func BenchmarkSize(cfg BatchConfig) int {
+	return len(scenario.Builtins()) * len(GuidanceModes) * cfg.Runs
+}
+
+// RunBenchmark flies every guidance law through every built-in scenario,
+// cfg.Runs randomized replicas each. cfg.Guidance is ignored. Every cell uses
+// the same campaign seed so the laws face identical perturbations.
+func RunBenchmark(cfg BatchConfig) BenchmarkReport {
+	if cfg.Seed == 0 {
+		cfg.Seed = uint64(time.Now().UnixNano())
+	}
+	cfg.Guidance = ""
+	start := time.Now()
+
+	report := BenchmarkReport{Config: cfg, Guidance: GuidanceModes}
+	for _, sc := range scenario.Builtins() {
+		report.Scenarios = append(report.Scenarios, sc.Name)
+		for _, law := range GuidanceModes {
+			lawCfg := cfg
+			lawCfg.Guidance = law
+			// Every law and built-in scenario is one the simulator flies.
+			r, _ := RunBatch(sc, lawCfg)
+			report.Cells = append(report.Cells, BenchmarkCell{
+				Scenario:   sc.Name,
+				Guidance:   law,
+				Runs:       len(r.Results),
+				Intercepts: r.Intercepts,
+				Pk:         r.Pk,
+				PkLow:      r.Stats.PkLow,
+				PkHigh:     r.Stats.PkHigh,
+				MeanMiss:   r.MeanMiss,
+				MeanFlight: r.MeanFlight,
+			})
+		}
+	}
+	report.WallTime = time.Since(start).Seconds()
+	return report
+}
+
+// WriteCSV writes the matrix as CSV, one row per cell.
+func (r BenchmarkReport) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"scenario", "guidance", "runs", "intercepts", "pk", "pk_low", "pk_high", "mean_miss", "mean_flight"})
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	for _, c := range r.Cells {
+		cw.Write([]string{
+			c.Scenario, c.Guidance, strconv.Itoa(c.Runs), strconv.Itoa(c.Intercepts),
+			f(c.Pk), f(c.PkLow), f(c.PkHigh), f(c.MeanMiss), f(c.MeanFlight),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
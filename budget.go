@@ -0,0 +1,71 @@
+package simulation
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+)
+
+// Step budget enforcement. A real-time loop has Dt / TimeScale of wall time
+// for each step; a step that takes longer is an overrun, and a run of them
+// means simulated time is falling behind the clock.
+const (
+	overrunWarnInterval = time.Second // at most one Overrun event this often
+	degradeAfter        = 30          // consecutive overruns that trigger degraded mode
+	degradedSensorRate  = 10.0        // Hz sensors are held to while degraded
+)
+
+// stepBudget tracks step durations against the real-time budget. The
+// counters are atomic so Health can read them without the lock; the rest
+// belongs to the loop goroutine.
+type stepBudget struct {
+	last      atomic.Int64  // ns, duration of the latest timed step
+	max       atomic.Int64  // ns, longest timed step
+	overruns  atomic.Uint64 // timed steps over budget
+	degraded  atomic.Bool   // sensor rates are capped
+	streak    int           // consecutive overruns
+	lastWarn  time.Time     // when the last Overrun event was logged
+	warnCount uint64        // overruns when it was
+}
+
+// timeStep records a step of the real-time loop that took d against a
+// budget of budget, warning of overruns and, if AutoDegrade is set,
+// switching to degraded fidelity once they persist.
+func (s *Simulator) timeStep(d, budget time.Duration) {
+	b := &s.budget
+	b.last.Store(int64(d))
+	if int64(d) > b.max.Load() {
+		b.max.Store(int64(d))
+	}
+	if d <= budget {
+		b.streak = 0
+		return
+	}
+	n := b.overruns.Add(1)
+	b.streak++
+	now := time.Now()
+	warn := now.Sub(b.lastWarn) >= overrunWarnInterval
+	degrade := s.AutoDegrade && b.streak >= degradeAfter && !b.degraded.Load()
+	if !warn && !degrade {
+		return
+	}
+	s.lock()
+	defer s.mu.Unlock()
+	if warn {
+		s.logEventLocked(EventOverrun, "", fmt.Sprintf("Step took %.1fms of a %.1fms real-time budget; %d overruns since the last warning",
+			float64(d)/1e6, float64(budget)/1e6, n-b.warnCount))
+		b.lastWarn, b.warnCount = now, n
+	}
+	if degrade {
+		s.degradeLocked()
+	}
+}
+
+// degradeLocked caps every sensor's update rate, the largest cost of a
+// step that does not change the physics. Reset restores full fidelity.
+// Callers must hold s.mu.
+func (s *Simulator) degradeLocked() {
+	s.sensorSched.maxRate = degradedSensorRate
+	s.budget.degraded.Store(true)
+	s.logEventLocked(EventDegraded, "", fmt.Sprintf("Sensor updates capped at %g Hz to keep up with real time", degradedSensorRate))
+}
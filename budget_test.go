@@ -0,0 +1,62 @@
+package simulation
+
+import (
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestStepBudget(t *testing.T) {
+	const budget = 16 * time.Millisecond
+	tests := []struct {
+		name         string
+		autoDegrade  bool
+		steps        []time.Duration
+		wantOverruns uint64
+		wantWarnings int
+		wantDegraded bool
+	}{
+		{"within budget", true, []time.Duration{5 * time.Millisecond, budget}, 0, 0, false},
+		{"one slow step", true, []time.Duration{5 * time.Millisecond, 40 * time.Millisecond, 5 * time.Millisecond}, 1, 1, false},
+		{"sustained, degrading", true, repeat(20*time.Millisecond, degradeAfter), degradeAfter, 1, true},
+		{"sustained, not degrading", false, repeat(20*time.Millisecond, degradeAfter), degradeAfter, 1, false},
+		{"interrupted streak", true, append(repeat(20*time.Millisecond, degradeAfter-1), time.Millisecond, 20*time.Millisecond), degradeAfter, 1, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSimulator()
+			s.SetLogger(logging.Discard)
+			s.AutoDegrade = tt.autoDegrade
+			for _, d := range tt.steps {
+				s.timeStep(d, budget)
+			}
+			h := s.Health()
+			warnings := 0
+			for _, e := range s.Events(0) {
+				if e.Type == EventOverrun {
+					warnings++
+				}
+			}
+			if h.Overruns != tt.wantOverruns || warnings != tt.wantWarnings || h.Degraded != tt.wantDegraded {
+				t.Errorf("overruns %d, warnings %d, degraded %v; want %d, %d, %v",
+					h.Overruns, warnings, h.Degraded, tt.wantOverruns, tt.wantWarnings, tt.wantDegraded)
+			}
+			if h.Degraded != (s.sensorSched.maxRate == degradedSensorRate) {
+				t.Errorf("degraded %v with sensor cap %g", h.Degraded, s.sensorSched.maxRate)
+			}
+			s.Reset()
+			if s.Health().Degraded || s.sensorSched.maxRate != 0 {
+				t.Error("reset kept degraded mode")
+			}
+		})
+	}
+}
+
+func repeat(d time.Duration, n int) []time.Duration {
+	out := make([]time.Duration, n)
+	for i := range out {
+		out[i] = d
+	}
+	return out
+}
@@ -0,0 +1,185 @@
+package simulation
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+
+	"missile-intercept-sim/internal/parquet"
+	"missile-intercept-sim/internal/units"
+)
+
+// telemetryColumns is the schema of a campaign's per-step telemetry: one
+// row per entity per step, vectors in the local frame (X east, Y up, Z
+// north).
+var telemetryColumns = []parquet.Column{
+	{Name: "step", Type: parquet.Int64},
+	{Name: "time", Type: parquet.Double, Unit: string(units.Second)},
+	{Name: "entity", Type: parquet.String},
+	{Name: "type", Type: parquet.String},
+	{Name: "x", Type: parquet.Double, Unit: string(units.Metre)},
+	{Name: "y", Type: parquet.Double, Unit: string(units.Metre)},
+	{Name: "z", Type: parquet.Double, Unit: string(units.Metre)},
+	{Name: "vx", Type: parquet.Double, Unit: string(units.MetresPerSecond)},
+	{Name: "vy", Type: parquet.Double, Unit: string(units.MetresPerSecond)},
+	{Name: "vz", Type: parquet.Double, Unit: string(units.MetresPerSecond)},
+	{Name: "ax", Type: parquet.Double, Unit: string(units.MetresPerSecond2)},
+	{Name: "ay", Type: parquet.Double, Unit: string(units.MetresPerSecond2)},
+	{Name: "az", Type: parquet.Double, Unit: string(units.MetresPerSecond2)},
+}
+
+// summaryColumns is the schema of a campaign's summary.parquet, one row per
+// replica.
+var summaryColumns = []parquet.Column{
+	{Name: "run", Type: parquet.Int64},
+	{Name: "seed", Type: parquet.String}, // uint64 does not fit INT64
+	{Name: "status", Type: parquet.String},
+	{Name: "intercept", Type: parquet.Int64},
+	{Name: "miss_distance", Type: parquet.Double, Unit: string(units.Metre)},
+	{Name: "time_of_flight", Type: parquet.Double, Unit: string(units.Second)},
+}
+
+// ParquetCampaign writes a campaign's telemetry to Parquet under Dir, one
+// file per replica at telemetry/run=<n>/part-0.parquet, so pandas, DuckDB
+// and Spark read Dir/telemetry as one table partitioned by run. Close adds
+// Dir/summary.parquet with every replica's outcome.
+type ParquetCampaign struct {
+	Dir      string
+	Scenario string
+
+	mu      sync.Mutex
+	open    map[int]*parquetRun
+	results []RunResult
+}
+
+// NewParquetCampaign creates dir, which must not exist yet, for a campaign.
+func NewParquetCampaign(dir, scenario string) (*ParquetCampaign, error) {
+	if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+	if err := os.Mkdir(filepath.Join(dir, "telemetry"), 0o755); err != nil {
+		return nil, err
+	}
+	return &ParquetCampaign{Dir: dir, Scenario: scenario, open: make(map[int]*parquetRun)}, nil
+}
+
+type parquetRun struct {
+	f    *os.File
+	w    *parquet.Writer
+	step int64
+}
+
+// OpenRun starts replica run's file.
+func (c *ParquetCampaign) OpenRun(run int, seed uint64) (StepLogger, error) {
+	dir := filepath.Join(c.Dir, "telemetry", "run="+strconv.Itoa(run))
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.Create(filepath.Join(dir, "part-0.parquet"))
+	if err != nil {
+		return nil, err
+	}
+	w, err := parquet.NewWriter(f, telemetryColumns, parquet.Options{
+		Codec: parquet.Gzip,
+		Metadata: map[string]string{
+			"scenario": c.Scenario,
+			"seed":     strconv.FormatUint(seed, 10),
+			"run":      strconv.Itoa(run),
+			"frame":    "local ENU as X east, Y up, Z north; m, m/s, m/s²",
+		},
+	})
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	r := &parquetRun{f: f, w: w}
+	c.mu.Lock()
+	c.open[run] = r
+	c.mu.Unlock()
+	return r, nil
+}
+
+// LogStep appends a row per entity.
+func (r *parquetRun) LogStep(state *SimulationState) error {
+	r.step++
+	for _, e := range state.Entities {
+		p, v, a := e.Position, e.Velocity, e.Acceleration
+		if err := r.w.Append(r.step, state.Time, e.ID, string(e.Type),
+			p.X, p.Y, p.Z, v.X, v.Y, v.Z, a.X, a.Y, a.Z); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CloseRun writes replica run's outcome into its footer and closes it.
+func (c *ParquetCampaign) CloseRun(run int, result RunResult) error {
+	c.mu.Lock()
+	r, ok := c.open[run]
+	delete(c.open, run)
+	c.results = append(c.results, result)
+	c.mu.Unlock()
+	if !ok {
+		return fmt.Errorf("campaign: run %d is not open", run)
+	}
+	r.w.SetMetadata("status", result.Status)
+	r.w.SetMetadata("miss_distance", strconv.FormatFloat(result.MissDistance, 'g', -1, 64))
+	r.w.SetMetadata("time_of_flight", strconv.FormatFloat(result.TimeOfFlight, 'g', -1, 64))
+	return r.close()
+}
+
+func (r *parquetRun) close() error {
+	if err := r.w.Close(); err != nil {
+		r.f.Close()
+		return err
+	}
+	return r.f.Close()
+}
+
+// Close closes the files of replicas a failed campaign left open and
+// writes summary.parquet.
+func (c *ParquetCampaign) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var first error
+	for run, r := range c.open {
+		if err := r.close(); err != nil && first == nil {
+			first = err
+		}
+		delete(c.open, run)
+	}
+	f, err := os.Create(filepath.Join(c.Dir, "summary.parquet"))
+	if err != nil {
+		return err
+	}
+	w, err := parquet.NewWriter(f, summaryColumns, parquet.Options{Metadata: map[string]string{"scenario": c.Scenario}})
+	if err != nil {
+		f.Close()
+		return err
+	}
+	slices.SortFunc(c.results, func(a, b RunResult) int { return a.Run - b.Run })
+	for _, r := range c.results {
+		intercept := int64(0)
+		if r.Intercept {
+			intercept = 1
+		}
+		if err := w.Append(int64(r.Run), strconv.FormatUint(r.Seed, 10), r.Status, intercept, r.MissDistance, r.TimeOfFlight); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := w.Close(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return first
+}
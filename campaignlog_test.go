@@ -0,0 +1,50 @@
+package simulation
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+func TestParquetCampaign(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "campaign")
+	c, err := NewParquetCampaign(dir, "default")
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := RunBatchLogged(scenario.Default(), BatchConfig{Runs: 3, Seed: 7, MaxTime: 5}, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Results) != 3 {
+		t.Fatalf("%d results, want 3", len(report.Results))
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "telemetry", "run=*", "part-0.parquet"))
+	files = append(files, filepath.Join(dir, "summary.parquet"))
+	if len(files) != 4 {
+		t.Fatalf("wrote %v", files)
+	}
+	for _, name := range files {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.HasPrefix(data, []byte("PAR1")) || !bytes.HasSuffix(data, []byte("PAR1")) {
+			t.Errorf("%s is not a Parquet file", name)
+		}
+		if !bytes.Contains(data, []byte("time_of_flight")) {
+			t.Errorf("%s has no outcome in its footer", name)
+		}
+	}
+
+	if _, err := NewParquetCampaign(dir, "default"); err == nil {
+		t.Error("reused an existing campaign directory")
+	}
+}
@@ -0,0 +1,446 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// commands are the subcommands of the binary. With none, it serves.
+var commands = []struct {
+	name, summary string
+	run           func(args []string) error
+}{
+	{"serve", "serve the simulator over HTTP, WebSocket and gRPC", cmdServe},
+	{"run", "fly one scenario headlessly and print its outcome report", cmdRun},
+	{"batch", "fly a Monte Carlo campaign and print its report", cmdBatch},
+	{"replay", "serve a recorded run for playback", cmdReplay},
+	{"perf", "time the physics step on built-in scenarios", cmdPerf},
+	{"diff", "compare two recorded runs and report where they diverge", cmdDiff},
+	{"render", "draw a recorded run as plan and profile images", cmdRender},
+	{"validate", "check scenario files for errors and physical problems", cmdValidate},
+	{"selftest", "check the physics and guidance against analytic solutions", cmdSelfTest},
+	{"watch", "follow a running server's session on a terminal dashboard", cmdWatch},
+}
+
+func main() {
+	args := os.Args[1:]
+	name := "serve"
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		usage(os.Stdout)
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		if err := cmd.run(args); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+	fmt.Fprintf(os.Stderr, "unknown command %q\n\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+// usage lists the subcommands.
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s <command> [flags]\n\nCommands:\n", filepath.Base(os.Args[0]))
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintf(w, "\nWith no command, serve. Run '<command> -h' for its flags.\n")
+}
+
+// newFlagSet creates the flag set of a subcommand taking the given
+// positional arguments.
+func newFlagSet(name, args string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s %s [flags]%s\n\nFlags:\n", filepath.Base(os.Args[0]), name, args)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func cmdServe(args []string) error {
+	fs := newFlagSet("serve", "")
+	serve(fs, args, func() error {
+		if fs.NArg() > 0 {
+			return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+		}
+		return nil
+	})
+	return nil
+}
+
+func cmdRun(args []string) error {
+	fs := newFlagSet("run", "")
+	var cfg simulation.RunConfig
+	name := fs.String("scenario", "default", "scenario to fly: a built-in, a library name, or a JSON file")
+	fs.Uint64Var(&cfg.Seed, "seed", 0, "RNG seed; 0 draws one")
+	fs.StringVar(&cfg.Guidance, "guidance", "", "guidance law for every interceptor; empty keeps the scenario's")
+	fs.Float64Var(&cfg.MaxTime, "max-time", 0, "s of simulated time before the run is stopped as a timeout; 0 for the default")
+	out := fs.String("o", "-", `file to write the outcome report to as JSON, "-" for stdout`)
+	trajectory := fs.String("csv", "", `file to write every step to as CSV time series, "-" for stdout; empty writes none`)
+	entity := fs.String("entity", "", "keep only this entity's rows in the CSV")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	if *out == "-" && *trajectory == "-" {
+		return errors.New("the report and the CSV cannot both go to stdout")
+	}
+	if err := simulation.CheckGuidanceMode(cfg.Guidance); err != nil {
+		return err
+	}
+	sc, err := scenarioArg(*name)
+	if err != nil {
+		return err
+	}
+
+	var csvLog *simulation.CSVLog
+	var csvFile *os.File
+	if *trajectory != "" {
+		csvFile = os.Stdout
+		if *trajectory != "-" {
+			if csvFile, err = os.Create(*trajectory); err != nil {
+				return err
+			}
+			defer csvFile.Close()
+		}
+		if csvLog, err = simulation.NewCSVLog(csvFile, *entity); err != nil {
+			return err
+		}
+		cfg.Log = csvLog
+	}
+	report, err := simulation.RunScenario(sc, cfg)
+	if err != nil {
+		return err
+	}
+	if csvLog != nil {
+		if err := csvLog.Flush(); err != nil {
+			return err
+		}
+		if csvFile != os.Stdout {
+			if err := csvFile.Close(); err != nil {
+				return err
+			}
+		}
+	}
+	if *out == "-" {
+		return printJSON(os.Stdout, report)
+	}
+	f, err := os.Create(*out)
+	if err != nil {
+		return err
+	}
+	if err := printJSON(f, report); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+func cmdBatch(args []string) error {
+	fs := newFlagSet("batch", "")
+	var cfg simulation.BatchConfig
+	name := fs.String("scenario", "default", "scenario to fly: a built-in, a library name, or a JSON file")
+	fs.IntVar(&cfg.Runs, "runs", 100, "replicas to fly")
+	fs.Uint64Var(&cfg.Seed, "seed", 0, "campaign seed; 0 draws one")
+	fs.Float64Var(&cfg.MaxTime, "max-time", 0, "s of simulated time per replica; 0 for the default")
+	fs.StringVar(&cfg.Guidance, "guidance", "", "guidance law for every interceptor; empty keeps the scenario's")
+	fs.Float64Var(&cfg.PositionJitter, "position-jitter", 0, "m, 1-sigma on target start position")
+	fs.Float64Var(&cfg.VelocityJitter, "velocity-jitter", 0, "m/s, 1-sigma on target velocity")
+	fs.IntVar(&cfg.Workers, "workers", 0, "replicas flown at once; 0 for one per CPU")
+	parquet := fs.String("parquet", "", "directory to log every step of every replica to as Parquet; empty disables it")
+	fs.Parse(args)
+	sc, err := scenarioArg(*name)
+	if err != nil {
+		return err
+	}
+	if cfg.Runs <= 0 {
+		return errors.New("runs must be positive")
+	}
+	if cfg.Workers < 0 || cfg.Workers > simulation.MaxWorkers {
+		return fmt.Errorf("workers must be between 0 and %d", simulation.MaxWorkers)
+	}
+	if err := simulation.CheckGuidanceMode(cfg.Guidance); err != nil {
+		return err
+	}
+
+	if *parquet == "" {
+		report, err := simulation.RunBatch(sc, cfg)
+		if err != nil {
+			return err
+		}
+		return printJSON(os.Stdout, report)
+	}
+	campaign, err := simulation.NewParquetCampaign(*parquet, sc.Name)
+	if err != nil {
+		return err
+	}
+	report, err := simulation.RunBatchLogged(sc, cfg, campaign)
+	if cerr := campaign.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, report)
+}
+
+func cmdReplay(args []string) error {
+	fs := newFlagSet("replay", " recording.json")
+	speed := fs.Float64("speed", 1, "playback speed, 1 for real time")
+	rate := fs.Float64("rate", 0, "frames per simulated second to resample to; 0 plays every recorded frame")
+	serve(fs, args, func() error {
+		if fs.NArg() != 1 {
+			return errors.New("replay needs exactly one recording file")
+		}
+		if *rate < 0 || *rate > maxResampleRate {
+			return fmt.Errorf("rate must be between 0 and %d", maxResampleRate)
+		}
+		path := fs.Arg(0)
+		rec, err := simulation.LoadRecording(filepath.Dir(path), filepath.Base(path))
+		if err != nil {
+			return err
+		}
+		if *rate > 0 {
+			rec = rec.Resample(1 / *rate)
+		}
+		sess, _ := sessions.Get(defaultSessionID)
+		sess.Sim.Stop()
+		sess.SetPlayer(simulation.NewPlayer(rec, *speed))
+		return nil
+	})
+	return nil
+}
+
+func cmdDiff(args []string) error {
+	fs := newFlagSet("diff", " a.json b.json")
+	tolerance := fs.Float64("tolerance", 0, "m and m/s of difference to ignore; 0 reports any")
+	asJSON := fs.Bool("json", false, "print the comparison as JSON")
+	fs.Parse(args)
+	if fs.NArg() != 2 {
+		return errors.New("diff needs exactly two recording files")
+	}
+	var recs [2]*simulation.Recording
+	for i, path := range fs.Args() {
+		rec, err := simulation.LoadRecording(filepath.Dir(path), filepath.Base(path))
+		if err != nil {
+			return err
+		}
+		recs[i] = rec
+	}
+	d, err := simulation.DiffRuns(recs[0], recs[1], *tolerance)
+	if err != nil {
+		return err
+	}
+	if *asJSON {
+		if err := printJSON(os.Stdout, d); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("%d frames compared every %g s", d.Frames, d.Dt)
+		if d.Resampled {
+			fmt.Print(" (resampled)")
+		}
+		fmt.Printf("; runs last %.2f s and %.2f s\n", d.DurationA, d.DurationB)
+		if fd := d.FirstDivergence; fd != nil {
+			fmt.Printf("first divergence at step %d, t=%.3f s: %s %s %s\n", fd.Step, fd.Time, fd.Entity, fd.Field, fd.Detail)
+		}
+		for _, e := range d.Entities {
+			fmt.Printf("  %-14s max %.3g m at %.2f s, final %.3g m, max %.3g m/s\n", e.ID, e.MaxDelta, e.MaxDeltaTime, e.FinalDelta, e.MaxSpeedDelta)
+		}
+		for _, id := range d.OnlyA {
+			fmt.Printf("  %-14s only in %s\n", id, fs.Arg(0))
+		}
+		for _, id := range d.OnlyB {
+			fmt.Printf("  %-14s only in %s\n", id, fs.Arg(1))
+		}
+	}
+	if !d.Identical {
+		return errors.New("runs differ")
+	}
+	if !*asJSON {
+		fmt.Println("runs are identical")
+	}
+	return nil
+}
+
+func cmdRender(args []string) error {
+	fs := newFlagSet("render", " recording.json")
+	var opts simulation.PlotOptions
+	dir := fs.String("o", ".", "directory to write the images to")
+	fs.IntVar(&opts.Width, "width", simulation.DefaultPlotWidth, "image width in pixels; the height is half")
+	fs.IntVar(&opts.Every, "every", 1, "draw every nth frame, and always the last")
+	rate := fs.Float64("rate", 0, "frames per simulated second to resample to first; 0 keeps every recorded frame")
+	fs.BoolVar(&opts.Last, "last", false, "draw only the last frame, the whole run at a glance")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("render needs exactly one recording file")
+	}
+	if *rate < 0 || *rate > maxResampleRate {
+		return fmt.Errorf("rate must be between 0 and %d", maxResampleRate)
+	}
+	path := fs.Arg(0)
+	rec, err := simulation.LoadRecording(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return err
+	}
+	if *rate > 0 {
+		rec = rec.Resample(1 / *rate)
+	}
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	n := 0
+	err = simulation.PlotFrames(rec, opts, func(i int, img image.Image) error {
+		name := filepath.Join(*dir, fmt.Sprintf("%s-%05d.png", rec.Name, i))
+		if opts.Last {
+			name = filepath.Join(*dir, rec.Name+".png")
+		}
+		f, err := os.Create(name)
+		if err != nil {
+			return err
+		}
+		if err := png.Encode(f, img); err != nil {
+			f.Close()
+			return err
+		}
+		n++
+		return f.Close()
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "wrote %d images to %s\n", n, *dir)
+	return nil
+}
+
+func cmdPerf(args []string) error {
+	fs := newFlagSet("perf", "")
+	var cfg simulation.PerfConfig
+	names := fs.String("scenarios", "", "comma-separated built-in scenarios; empty for the whole library")
+	fs.IntVar(&cfg.Steps, "steps", simulation.DefaultPerfSteps, "steps timed per scenario")
+	fs.Uint64Var(&cfg.Seed, "seed", 0, "RNG seed; 0 for a fixed default, so reports compare")
+	fs.Parse(args)
+	for _, name := range strings.Split(*names, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			cfg.Scenarios = append(cfg.Scenarios, name)
+		}
+	}
+	report, err := simulation.RunPerf(cfg)
+	if err != nil {
+		return err
+	}
+	return printJSON(os.Stdout, report)
+}
+
+func cmdValidate(args []string) error {
+	fs := newFlagSet("validate", " scenario.json...")
+	asJSON := fs.Bool("json", false, "print the diagnostics as JSON, keyed by file")
+	strict := fs.Bool("strict", false, "fail on warnings as well as errors")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		return errors.New("name at least one scenario file")
+	}
+	report := make(map[string][]simulation.Diagnostic, fs.NArg())
+	failed := 0
+	for _, path := range fs.Args() {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		diags, err := simulation.ValidateScenario(f)
+		f.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		report[path] = diags
+		if simulation.HasErrors(diags) || (*strict && len(diags) > 0) {
+			failed++
+		}
+		if !*asJSON {
+			for _, d := range diags {
+				fmt.Printf("%s: %s: %s\n", path, d.Severity, d.String())
+			}
+		}
+	}
+	if *asJSON {
+		if err := printJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d scenarios failed", failed, fs.NArg())
+	}
+	return nil
+}
+
+func cmdSelfTest(args []string) error {
+	fs := newFlagSet("selftest", "")
+	asJSON := fs.Bool("json", false, "print the report as JSON")
+	fs.Parse(args)
+	if fs.NArg() > 0 {
+		return fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+	report := simulation.RunSelfTest()
+	if *asJSON {
+		if err := printJSON(os.Stdout, report); err != nil {
+			return err
+		}
+	} else {
+		for _, res := range report.Results {
+			verdict := "PASS"
+			if !res.Passed {
+				verdict = "FAIL"
+			}
+			fmt.Printf("%s  %-18s error %.3g %s (tolerance %.3g)  %s\n", verdict, res.Name, res.Error, res.Unit, res.Tolerance, res.Detail)
+		}
+	}
+	if !report.Passed {
+		return errors.New("analytic checks failed")
+	}
+	return nil
+}
+
+// scenarioArg resolves a scenario named on the command line: a JSON file
+// if one exists at that path, otherwise a built-in or library scenario.
+func scenarioArg(name string) (*scenario.Scenario, error) {
+	if f, err := os.Open(name); err == nil {
+		defer f.Close()
+		sc, err := scenario.Decode(f)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		return sc, nil
+	}
+	if sc, ok := loadScenario(name); ok {
+		return sc, nil
+	}
+	return nil, fmt.Errorf("unknown scenario %q", name)
+}
+
+// printJSON writes v to w as indented JSON.
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
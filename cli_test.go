@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+func TestScenarioArg(t *testing.T) {
+	saved := scenariosDir
+	defer func() { scenariosDir = saved }()
+	scenariosDir = t.TempDir()
+
+	dir := t.TempDir()
+	doc, err := json.Marshal(scenario.Crossing())
+	if err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(dir, "mine.json")
+	if err := os.WriteFile(file, doc, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	broken := filepath.Join(dir, "broken.json")
+	if err := os.WriteFile(broken, []byte("{"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{"head-on", "head-on", false},
+		{file, "crossing", false},
+		{broken, "", true},
+		{"nope", "", true},
+	}
+	for _, tt := range tests {
+		sc, err := scenarioArg(tt.arg)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("scenarioArg(%q): err = %v, want error %v", tt.arg, err, tt.wantErr)
+		}
+		if err == nil && sc.Name != tt.want {
+			t.Errorf("scenarioArg(%q) = %q, want %q", tt.arg, sc.Name, tt.want)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Command is a control message sent by a WebSocket client. Type selects the
+// action and the remaining fields are its arguments; ID is echoed in the
+// acknowledgement so the client can match it to the request.
+type Command struct {
+	ID   string `json:"id,omitempty"`
+	Type string `json:"type"` // start, stop, guidance, launch, step, timescale, keyframe
+
+	Mode        string  `json:"mode,omitempty"`        // guidance
+	Interceptor string  `json:"interceptor,omitempty"` // launch
+	Target      string  `json:"target,omitempty"`      // launch, defaults to the assigned target
+	Count       int     `json:"count,omitempty"`       // step, default 1
+	Scale       float64 `json:"scale,omitempty"`       // timescale
+
+	Lease string `json:"lease,omitempty"` // control lease, defaulting to the connection's
+}
+
+// CommandAck answers one Command. It is queued on the client's stream
+// between state frames.
+type CommandAck struct {
+	Ack   string `json:"ack"` // the command's ID
+	Type  string `json:"type"`
+	OK    bool   `json:"ok"`
+	Error string `json:"error,omitempty"`
+}
+
+// execute runs cmd for client c. A command that panics fails like any
+// other, leaving the client connected.
+func (h *Hub) execute(c *hubClient, cmd Command) (err error) {
+	defer recoverError(&err, cmd.Type+" command")
+	if cmd.Type == "keyframe" {
+		h.requestKeyframe(c)
+		return nil
+	}
+	if !c.Control {
+		return fmt.Errorf("observers cannot send %s commands", cmd.Type)
+	}
+	lease := cmd.Lease
+	if lease == "" {
+		lease = c.Lease
+	}
+	if err := h.sess.CheckControl(lease, time.Now()); err != nil {
+		return err
+	}
+	if err := runCommand(h.sess, cmd); err != nil {
+		return err
+	}
+	h.sess.Sim.LogCommand(c.RequestID, cmd.Type+" command from "+c.addr)
+	return nil
+}
+
+// runCommand runs cmd against the session, the same as the matching REST call.
+func runCommand(sess *Session, cmd Command) error {
+	sim := sess.Sim
+	switch cmd.Type {
+	case "start":
+		return sim.Start()
+	case "stop":
+		sim.Stop()
+	case "guidance":
+		return sim.SetGuidanceMode(cmd.Mode)
+	case "launch":
+		return sim.Launch(cmd.Interceptor, cmd.Target)
+	case "step":
+		n := cmd.Count
+		if n == 0 {
+			n = 1
+		}
+		if n < 1 || n > maxStepCount {
+			return fmt.Errorf("count must be between 1 and %d", maxStepCount)
+		}
+		_, err := sim.Advance(n)
+		return err
+	case "timescale":
+		return sim.SetTimeScale(cmd.Scale)
+	default:
+		return fmt.Errorf("unknown command %q", cmd.Type)
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+)
+
+// compactEncode encodes JSON-encoded state in format with every vector, an
+// object of exactly x, y and z, written as an [x, y, z] array of float32s.
+// Dropping the field names and half the precision, which is still
+// decimetres at a thousand kilometres, halves the vectors in a frame.
+func compactEncode(msg []byte, format string) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(msg))
+	// Numbers stay exact until they are known not to be vector components:
+	// seeds and run counters do not fit a float64.
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return marshal(compactVectors(v), format)
+}
+
+// compactVectors rewrites a decoded JSON value in place, returning it.
+func compactVectors(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		if vec, ok := compactVector(v); ok {
+			return vec
+		}
+		for k, e := range v {
+			v[k] = compactVectors(e)
+		}
+	case []any:
+		for i, e := range v {
+			v[i] = compactVectors(e)
+		}
+	case json.Number:
+		return number(v)
+	}
+	return v
+}
+
+// compactVector returns m as a float32 array if it is a vector.
+func compactVector(m map[string]any) ([3]float32, bool) {
+	var out [3]float32
+	if len(m) != 3 {
+		return out, false
+	}
+	for i, k := range []string{"x", "y", "z"} {
+		n, ok := m[k].(json.Number)
+		if !ok {
+			return out, false
+		}
+		f, err := n.Float64()
+		if err != nil {
+			return out, false
+		}
+		out[i] = float32(f)
+	}
+	return out, true
+}
+
+// number converts a JSON number to the narrowest Go type holding it
+// exactly, so msgpack encodes it as a number rather than a string.
+func number(n json.Number) any {
+	if i, err := strconv.ParseInt(string(n), 10, 64); err == nil {
+		return i
+	}
+	if u, err := strconv.ParseUint(string(n), 10, 64); err == nil {
+		return u
+	}
+	f, _ := n.Float64()
+	return f
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/pkg/vector"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestCompactEncode(t *testing.T) {
+	sess := newSession("compact")
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	state := sess.State()
+	state.Seed = 1<<64 - 1 // beyond float64's exact integers
+	// Mid-flight values, which take all of a float64's digits.
+	for i, e := range state.Entities {
+		f := float64(i) + 1.0/3
+		e.Position = vector.Vector3{X: 12345.678 * f, Y: 4321.0987 * f, Z: -9876.5432 * f}
+		e.Velocity = vector.Vector3{X: 123.456 * f, Y: -7.891 * f, Z: 654.321 * f}
+		e.Acceleration = vector.Vector3{X: 0.123 * f, Y: -9.81 * f, Z: 3.21 * f}
+	}
+	full, err := json.Marshal(state)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := compactEncode(full, FormatJSON)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(msg) >= len(full) {
+		t.Errorf("compact frame is %d bytes, full %d", len(msg), len(full))
+	}
+
+	var got struct {
+		Seed     uint64 `json:"seed"`
+		Entities []struct {
+			ID       string     `json:"id"`
+			Position [3]float32 `json:"position"`
+			Velocity [3]float32 `json:"velocity"`
+		} `json:"entities"`
+	}
+	if err := json.Unmarshal(msg, &got); err != nil {
+		t.Fatalf("%v in %s", err, msg)
+	}
+	if got.Seed != state.Seed || len(got.Entities) != len(state.Entities) {
+		t.Fatalf("seed %d and %d entities, want %d and %d", got.Seed, len(got.Entities), state.Seed, len(state.Entities))
+	}
+	for i, e := range state.Entities {
+		p := got.Entities[i].Position
+		if got.Entities[i].ID != e.ID || p != [3]float32{float32(e.Position.X), float32(e.Position.Y), float32(e.Position.Z)} {
+			t.Errorf("entity %d: %+v, want %s at %v", i, got.Entities[i], e.ID, e.Position)
+		}
+	}
+
+	bin, err := compactEncode(full, FormatMsgpack)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := msgpack.Unmarshal(bin, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	pos := decoded["entities"].([]any)[0].(map[string]any)["position"].([]any)
+	if _, ok := pos[0].(float32); !ok || len(pos) != 3 {
+		t.Errorf("msgpack position %#v", pos)
+	}
+	if decoded["seed"] != state.Seed {
+		t.Errorf("msgpack seed %#v", decoded["seed"])
+	}
+}
+
+func TestCompactVectorSize(t *testing.T) {
+	trail := make([]vector.Vector3, 100)
+	for i := range trail {
+		f := float64(i) + 1.0/3
+		trail[i] = vector.Vector3{X: 12345.678 * f, Y: 4321.0987 * f, Z: -9876.5432 * f}
+	}
+	for _, format := range []string{FormatJSON, FormatMsgpack} {
+		full, _ := json.Marshal(trail)
+		if format == FormatMsgpack {
+			full, _ = marshal(trail, format)
+		}
+		js, _ := json.Marshal(trail)
+		msg, err := compactEncode(js, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(msg) > len(full)*55/100 {
+			t.Errorf("%s: compact vectors take %d bytes, full %d", format, len(msg), len(full))
+		}
+	}
+}
+
+func TestCompactStream(t *testing.T) {
+	sess := newSession("compact-stream")
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	conn := dialHub(t, sess, "compact=1&fields=time,entities.position")
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var frame struct {
+		Time     *float64 `json:"time"`
+		Entities []struct {
+			ID       string     `json:"id"`
+			Position [3]float32 `json:"position"`
+		} `json:"entities"`
+	}
+	if err := json.Unmarshal(data, &frame); err != nil || frame.Time == nil || len(frame.Entities) == 0 || frame.Entities[0].ID == "" {
+		t.Fatalf("frame %s: %v", data, err)
+	}
+	if bytes.Contains(data, []byte(`"x"`)) {
+		t.Errorf("frame still has vector objects: %s", data)
+	}
+
+	for _, q := range []string{"compact=1&delta=1", "compact=1&engine=unity"} {
+		if _, err := parseClientOptions(httptest.NewRequest("GET", "/ws?"+q, nil)); err == nil {
+			t.Errorf("accepted %s", q)
+		}
+	}
+}
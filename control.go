@@ -0,0 +1,209 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// controlIdle is how long a controller may go without changing the session
+// before its hold lapses and anyone may claim it.
+const controlIdle = 2 * time.Minute
+
+// controlLease is the hold of the one client allowed to change a session.
+// Its id is the secret the holder sends with every change.
+type controlLease struct {
+	id, name string
+	since    time.Time
+	lastSeen time.Time
+}
+
+// ControlStatus describes who controls a session, without the lease itself.
+type ControlStatus struct {
+	Held  bool       `json:"held"`
+	Owner string     `json:"owner,omitempty"`
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// ControlHeldError rejects a change from a client without the session's
+// control lease.
+type ControlHeldError struct {
+	Owner string
+}
+
+func (e *ControlHeldError) Error() string {
+	return fmt.Sprintf("session is controlled by %s; take over control to change it", e.Owner)
+}
+
+// errNotController rejects releasing control the client does not hold.
+var errNotController = errors.New("control lease is not held")
+
+// heldLocked returns the session's live lease, nil if there is none or it
+// has lapsed. Callers must hold s.mu.
+func (s *Session) heldLocked(now time.Time) *controlLease {
+	if s.control == nil || now.Sub(s.control.lastSeen) > controlIdle {
+		return nil
+	}
+	return s.control
+}
+
+// ClaimControl gives the caller control of the session under name and
+// returns its lease. A holder claiming again with its own lease renews it.
+// Control held by another client is only taken when takeover is set, the
+// caller having confirmed it means to take it.
+func (s *Session) ClaimControl(lease, name string, takeover bool, now time.Time) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := s.heldLocked(now)
+	if held != nil && held.id == lease {
+		held.lastSeen = now
+		return lease, nil
+	}
+	if held != nil && !takeover {
+		return "", &ControlHeldError{Owner: held.name}
+	}
+	s.control = &controlLease{id: newLeaseID(), name: name, since: now, lastSeen: now}
+	return s.control.id, nil
+}
+
+// newLeaseID returns an unguessable lease ID.
+func newLeaseID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// ReleaseControl gives up control held under lease.
+func (s *Session) ReleaseControl(lease string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if held := s.heldLocked(now); held == nil || held.id != lease {
+		return errNotController
+	}
+	s.control = nil
+	return nil
+}
+
+// CheckControl reports whether a change sent with lease may go ahead,
+// renewing the lease if it is the holder's. Anyone may change a session
+// nobody controls.
+func (s *Session) CheckControl(lease string, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := s.heldLocked(now)
+	if held == nil {
+		return nil
+	}
+	if held.id != lease {
+		return &ControlHeldError{Owner: held.name}
+	}
+	held.lastSeen = now
+	return nil
+}
+
+// Control returns who controls the session.
+func (s *Session) Control(now time.Time) ControlStatus {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	held := s.heldLocked(now)
+	if held == nil {
+		return ControlStatus{}
+	}
+	since := held.since
+	return ControlStatus{Held: true, Owner: held.name, Since: &since}
+}
+
+// leaseOf returns the control lease a request carries, in the
+// X-Control-Lease header or, for clients that cannot set headers, ?lease=.
+func leaseOf(r *http.Request) string {
+	if lease := r.Header.Get("X-Control-Lease"); lease != "" {
+		return lease
+	}
+	return r.URL.Query().Get("lease")
+}
+
+// controlledSession resolves the request's session like sessionFor and, for
+// a request that changes it, also checks the request holds control. It
+// writes a 409 naming the controller if not.
+func controlledSession(w http.ResponseWriter, r *http.Request) (*Session, bool) {
+	sess, ok := sessionFor(w, r)
+	if !ok || r.Method == http.MethodGet || r.Method == http.MethodHead {
+		return sess, ok
+	}
+	return sess, checkControl(w, r, sess)
+}
+
+// checkControl writes a 409 and returns false if r does not hold control
+// of sess. Otherwise r is noted as changing sess, for its event log.
+func checkControl(w http.ResponseWriter, r *http.Request, sess *Session) bool {
+	if err := sess.CheckControl(leaseOf(r), time.Now()); err != nil {
+		writeErrorDetails(w, err.Error(), http.StatusConflict, sess.Control(time.Now()))
+		return false
+	}
+	noteChange(r, sess)
+	return true
+}
+
+// ControlChange tells a session's stream clients that control changed hands.
+type ControlChange struct {
+	Control ControlStatus `json:"control"`
+}
+
+// handleControl reports who controls the session on GET, claims control on
+// POST and releases it on DELETE. A claim names its client and sets
+// takeover to take control another client holds; the response carries the
+// lease to send as X-Control-Lease with every change. Stream clients are
+// told of each change.
+func handleControl(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	now := time.Now()
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sess.Control(now))
+	case http.MethodPost:
+		type ClaimRequest struct {
+			Name     string `json:"name"`
+			Takeover bool   `json:"takeover"`
+		}
+		var req ClaimRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		if req.Name == "" {
+			req.Name = clientKey(r)
+		}
+		lease, err := sess.ClaimControl(leaseOf(r), req.Name, req.Takeover, now)
+		if err != nil {
+			writeErrorDetails(w, err.Error(), http.StatusConflict, sess.Control(now))
+			return
+		}
+		type ClaimResponse struct {
+			Lease string `json:"lease"`
+			ControlStatus
+		}
+		status := sess.Control(now)
+		if lease != leaseOf(r) {
+			sess.Hub.announce("control", ControlChange{status})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(ClaimResponse{lease, status})
+	case http.MethodDelete:
+		if err := sess.ReleaseControl(leaseOf(r), now); err != nil {
+			writeError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		sess.Hub.announce("control", ControlChange{sess.Control(now)})
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
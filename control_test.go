@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestControlLease(t *testing.T) {
+	sess := newSession("test")
+	defer sess.Hub.Close()
+	now := time.Unix(0, 0)
+	alice, err := sess.ClaimControl("", "alice", false, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var bob string
+	tests := []struct {
+		name    string
+		advance time.Duration
+		do      func() error
+		held    bool // want a ControlHeldError
+	}{
+		{"holder may act", 0, func() error { return sess.CheckControl(alice, now) }, false},
+		{"others may not", 0, func() error { return sess.CheckControl("", now) }, true},
+		{"claim refused", 0, func() error { _, err := sess.ClaimControl("", "bob", false, now); return err }, true},
+		{"holder renews", 0, func() error { _, err := sess.ClaimControl(alice, "alice", false, now); return err }, false},
+		{"takeover", 0, func() error { bob, err = sess.ClaimControl("", "bob", true, now); return err }, false},
+		{"old holder locked out", 0, func() error { return sess.CheckControl(alice, now) }, true},
+		{"use keeps the lease", controlIdle, func() error { return sess.CheckControl(bob, now) }, false},
+		{"still held", controlIdle, func() error { return sess.CheckControl("", now) }, true},
+		{"lapsed", controlIdle + time.Second, func() error { return sess.CheckControl("", now) }, false},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		err := tt.do()
+		var held *ControlHeldError
+		if errors.As(err, &held) != tt.held || (err != nil && !tt.held) {
+			t.Errorf("%s: err = %v, want held=%v", tt.name, err, tt.held)
+		}
+	}
+	if err := sess.ReleaseControl(bob, now); !errors.Is(err, errNotController) {
+		t.Errorf("releasing a lapsed lease: %v", err)
+	}
+}
+
+func TestControlledRequests(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	defer sess.Sim.Stop()
+
+	claim := httptest.NewRecorder()
+	handleControl(claim, httptest.NewRequest(http.MethodPost, "/api/v1/control", strings.NewReader(`{"name": "console"}`)))
+	var got struct {
+		Lease string `json:"lease"`
+		ControlStatus
+	}
+	if err := json.Unmarshal(claim.Body.Bytes(), &got); err != nil || got.Lease == "" || got.Owner != "console" {
+		t.Fatalf("claim: %d %s", claim.Code, claim.Body)
+	}
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		lease   string
+		body    string
+		want    int
+	}{
+		{"observe without lease", handleState, http.MethodGet, "", "", http.StatusOK},
+		{"stop without lease", handleStop, http.MethodPost, "", "", http.StatusConflict},
+		{"stop with wrong lease", handleStop, http.MethodPost, "nope", "", http.StatusConflict},
+		{"stop with lease", handleStop, http.MethodPost, got.Lease, "", http.StatusOK},
+		{"claim refused", handleControl, http.MethodPost, "", `{"name": "tablet"}`, http.StatusConflict},
+		{"release by other", handleControl, http.MethodDelete, "", "", http.StatusConflict},
+		{"release", handleControl, http.MethodDelete, got.Lease, "", http.StatusNoContent},
+		{"free for all", handleStop, http.MethodPost, "", "", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", strings.NewReader(tt.body))
+			if tt.lease != "" {
+				r.Header.Set("X-Control-Lease", tt.lease)
+			}
+			rec := httptest.NewRecorder()
+			tt.handler(rec, r)
+			if rec.Code != tt.want {
+				t.Errorf("got %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+		})
+	}
+}
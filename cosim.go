@@ -0,0 +1,244 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/simulation"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// maxCosimMessage bounds one line of the co-simulation protocol.
+const maxCosimMessage = 1 << 20
+
+// cosimRequest is one line from a co-simulation master such as a Simulink
+// model. Op is one of:
+//
+//	init   take control of the session, pause it and fly its interceptors
+//	       on the master's commands
+//	step   apply Commands, then advance to Time, or by Steps when non-zero
+//	reset  reset the session, keeping the master in control
+type cosimRequest struct {
+	Op       string                    `json:"op"`
+	Token    string                    `json:"token,omitempty"`    // init
+	Session  string                    `json:"session,omitempty"`  // init; the server's default when empty
+	Takeover bool                      `json:"takeover,omitempty"` // init
+	Seed     *uint64                   `json:"seed,omitempty"`     // reset
+	Time     float64                   `json:"time,omitempty"`     // step
+	Steps    int                       `json:"steps,omitempty"`    // step
+	Commands map[string]vector.Vector3 `json:"commands,omitempty"` // step; acceleration per interceptor ID
+}
+
+// cosimReply answers every request with the truth state after it, so the
+// master can check its clock against Time.
+type cosimReply struct {
+	Time     float64            `json:"time"`
+	Dt       float64            `json:"dt"`
+	Steps    int                `json:"steps"`
+	Status   string             `json:"status"`
+	Entities []*entities.Entity `json:"entities"`
+	Error    string             `json:"error,omitempty"`
+}
+
+// cosimServer lets an external model drive a session in lockstep over TCP,
+// one JSON object per line each way. The master owns simulated time: the
+// session stays paused and only advances when told to, and interceptors
+// fly the acceleration the master last commanded for them. The master holds
+// the session's control lease while connected, so other clients cannot
+// start or step the session underneath it.
+type cosimServer struct {
+	ln      net.Listener
+	session string
+}
+
+// newCosimServer listens for masters on addr. Masters that don't name a
+// session drive session.
+func newCosimServer(addr, session string) (*cosimServer, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &cosimServer{ln: ln, session: session}, nil
+}
+
+// Run serves masters until ctx is done.
+func (c *cosimServer) Run(ctx context.Context) {
+	stop := context.AfterFunc(ctx, func() { c.ln.Close() })
+	defer stop()
+	for {
+		conn, err := c.ln.Accept()
+		if err != nil {
+			if ctx.Err() == nil {
+				slog.Error("cosim: accept", "err", err)
+			}
+			return
+		}
+		go c.serve(ctx, conn)
+	}
+}
+
+// cosimLink is one master's hold on a session.
+type cosimLink struct {
+	name  string
+	sess  *Session
+	lease string
+	law   *simulation.CommandGuidance
+}
+
+// serve answers one master's requests until it disconnects, then hands the
+// session back.
+func (c *cosimServer) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+	link := &cosimLink{name: "cosim " + conn.RemoteAddr().String()}
+	defer link.release()
+	scanner := bufio.NewScanner(conn)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxCosimMessage)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req cosimRequest
+		var reply cosimReply
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			reply.Error = "invalid message"
+		} else {
+			reply = c.handle(link, req)
+		}
+		if err := enc.Encode(reply); err != nil {
+			return
+		}
+	}
+}
+
+// handle runs one request.
+func (c *cosimServer) handle(link *cosimLink, req cosimRequest) cosimReply {
+	now := time.Now()
+	if req.Op == "init" {
+		if err := c.init(link, req, now); err != nil {
+			return cosimReply{Error: err.Error()}
+		}
+		return link.reply(0, nil)
+	}
+	if link.sess == nil {
+		return cosimReply{Error: "send init first"}
+	}
+	// Every request renews the lease, or claims a fresh one if it went idle
+	// unclaimed; a takeover by another client ends the master's hold.
+	lease, err := link.sess.ClaimControl(link.lease, link.name, false, now)
+	if err != nil {
+		return link.reply(0, errors.New("lost control of the session"))
+	}
+	link.lease = lease
+	sim := link.sess.Sim
+	switch req.Op {
+	case "step":
+		if err := checkCosimStep(sim, req); err != nil {
+			return link.reply(0, err)
+		}
+		link.law.SetCommands(req.Commands)
+		var n int
+		var err error
+		if req.Steps > 0 {
+			n, err = sim.Advance(req.Steps)
+		} else {
+			n, err = sim.AdvanceTo(req.Time)
+		}
+		return link.reply(n, err)
+	case "reset":
+		if req.Seed != nil {
+			sim.SetSeed(*req.Seed)
+		}
+		sim.Reset()
+		sim.SetGuidanceMode(simulation.GuidanceExternal)
+		return link.reply(0, nil)
+	default:
+		return link.reply(0, errors.New("unknown op "+req.Op))
+	}
+}
+
+// init checks the master's token, takes control of its session and pauses it.
+func (c *cosimServer) init(link *cosimLink, req cosimRequest, now time.Time) error {
+	if link.sess != nil {
+		return errors.New("already initialised")
+	}
+	if roleOf(req.Token) < roleController {
+		return errors.New("missing or unknown controller token")
+	}
+	id := req.Session
+	if id == "" {
+		id = c.session
+	}
+	sess, ok := sessions.Get(id)
+	if !ok {
+		return errors.New("unknown session " + id)
+	}
+	lease, err := sess.ClaimControl("", link.name, req.Takeover, now)
+	if err != nil {
+		return err
+	}
+	sess.Hub.announce("control", ControlChange{sess.Control(now)})
+	link.sess, link.lease = sess, lease
+	link.law = simulation.NewCommandGuidance(link.name)
+	sess.Sim.Stop()
+	sess.Sim.SetExternalGuidance(link.law)
+	sess.Sim.SetGuidanceMode(simulation.GuidanceExternal)
+	return nil
+}
+
+// checkCosimStep rejects a step request that would hold the session locked
+// for more than maxStepCount steps, as /api/step does: a scenario with no
+// time limit never ends a step to a time far ahead on its own.
+func checkCosimStep(sim *simulation.Simulator, req cosimRequest) error {
+	if req.Steps < 0 || req.Steps > maxStepCount {
+		return fmt.Errorf("steps must be between 0 and %d", maxStepCount)
+	}
+	if req.Steps > 0 {
+		return nil
+	}
+	if math.IsNaN(req.Time) || math.IsInf(req.Time, 0) {
+		return errors.New("time must be finite")
+	}
+	now, dt := sim.GetState().Time, sim.Manifest().Dt
+	if req.Time > now+maxStepCount*dt {
+		return fmt.Errorf("time %g is more than %d steps ahead of the simulation at %g", req.Time, maxStepCount, now)
+	}
+	return nil
+}
+
+// reply reports the session's state after a request that took n steps.
+func (l *cosimLink) reply(n int, err error) cosimReply {
+	state := l.sess.Sim.GetState()
+	r := cosimReply{
+		Time:     state.Time,
+		Dt:       l.sess.Sim.Manifest().Dt,
+		Steps:    n,
+		Status:   state.Status,
+		Entities: state.Entities,
+	}
+	if err != nil {
+		r.Error = err.Error()
+	}
+	return r
+}
+
+// release gives up the session, if the master still holds it, and stops
+// flying on its commands.
+func (l *cosimLink) release() {
+	if l.sess == nil {
+		return
+	}
+	now := time.Now()
+	if l.sess.ReleaseControl(l.lease, now) != nil {
+		return
+	}
+	l.sess.Sim.SetExternalGuidance(nil)
+	l.sess.Hub.announce("control", ControlChange{l.sess.Control(now)})
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"math"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestCosimLockstep(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	defer sess.Sim.Stop()
+
+	c, err := newCosimServer("127.0.0.1:0", defaultSessionID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Run(ctx)
+	conn, err := net.Dial("tcp", c.ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	lines := bufio.NewScanner(conn)
+
+	dt := sess.Sim.Dt
+	tests := []struct {
+		name     string
+		send     string
+		wantErr  string
+		wantTime float64
+	}{
+		{"step before init", `{"op": "step", "time": 1}`, "init first", 0},
+		{"garbage", `{"op":`, "invalid message", 0},
+		{"init", `{"op": "init"}`, "", 0},
+		{"step to time", `{"op": "step", "time": 0.48, "commands": {"interceptor-1": {"x": 0, "y": 50, "z": 0}}}`, "", 30 * dt},
+		{"step by count", `{"op": "step", "steps": 10}`, "", 40 * dt},
+		{"time behind", `{"op": "step", "time": 0.1}`, "behind", 40 * dt},
+		{"too many steps", `{"op": "step", "steps": 2147483647}`, "steps must be between", 40 * dt},
+		{"negative steps", `{"op": "step", "steps": -1}`, "steps must be between", 40 * dt},
+		{"time too far ahead", `{"op": "step", "time": 1e12}`, "steps ahead", 40 * dt},
+		{"reset", `{"op": "reset", "seed": 7}`, "", 0},
+		{"unknown", `{"op": "fly"}`, "unknown op", 0},
+	}
+	for _, tt := range tests {
+		conn.SetDeadline(time.Now().Add(5 * time.Second))
+		if _, err := conn.Write([]byte(tt.send + "\n")); err != nil {
+			t.Fatal(err)
+		}
+		if !lines.Scan() {
+			t.Fatalf("%s: no reply: %v", tt.name, lines.Err())
+		}
+		var reply cosimReply
+		if err := json.Unmarshal(lines.Bytes(), &reply); err != nil {
+			t.Fatal(err)
+		}
+		if tt.wantErr == "" && reply.Error != "" || !strings.Contains(reply.Error, tt.wantErr) {
+			t.Errorf("%s: error %q, want %q", tt.name, reply.Error, tt.wantErr)
+		}
+		if math.Abs(reply.Time-tt.wantTime) > 1e-9 {
+			t.Errorf("%s: time %v, want %v", tt.name, reply.Time, tt.wantTime)
+		}
+	}
+
+	if got := sess.Control(time.Now()); !got.Held || !strings.HasPrefix(got.Owner, "cosim ") {
+		t.Errorf("master does not hold the session: %+v", got)
+	}
+	if st, ok := sess.Sim.ExternalGuidanceStatus(); !ok || st.Replies != 1 {
+		t.Errorf("external guidance %+v, attached %v", st, ok)
+	}
+	conn.Close()
+	deadline := time.Now().Add(5 * time.Second)
+	for sess.Control(time.Now()).Held {
+		if time.Now().After(deadline) {
+			t.Fatal("control still held after the master left")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if _, ok := sess.Sim.ExternalGuidanceStatus(); ok {
+		t.Error("commands still attached after the master left")
+	}
+}
+
+func TestCheckCosimStep(t *testing.T) {
+	sim := simulation.NewSimulator()
+	sim.SetLogger(logging.Discard)
+	ahead := maxStepCount * sim.Dt
+	tests := []struct {
+		name    string
+		req     cosimRequest
+		wantErr string
+	}{
+		{"steps", cosimRequest{Steps: maxStepCount}, ""},
+		{"too many steps", cosimRequest{Steps: maxStepCount + 1}, "steps must be between"},
+		{"time", cosimRequest{Time: ahead}, ""},
+		{"too far ahead", cosimRequest{Time: ahead + 1}, "steps ahead"},
+		{"NaN", cosimRequest{Time: math.NaN()}, "finite"},
+		{"infinite", cosimRequest{Time: math.Inf(1)}, "finite"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkCosimStep(sim, tt.req)
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,117 @@
+package simulation
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/units"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// csvHeader names the columns WriteCSV produces. The guidance columns are
+// empty except for interceptors in flight.
+var csvHeader = []string{
+	"t", "entity", "type",
+	"x", "y", "z",
+	"vx", "vy", "vz",
+	"ax", "ay", "az",
+	"commanded_g", // interceptors: guidance command without gravity
+	"target",      // interceptors: the target being guided on
+	"los_rate",    // interceptors: line-of-sight rate to the target, rad/s
+}
+
+// WriteCSV writes a recording as time series in long form, one row per
+// entity per frame, ready for a spreadsheet or pandas. A non-empty entity
+// keeps only that entity's rows.
+func WriteCSV(w io.Writer, rec *Recording, entity string) error {
+	c, err := NewCSVLog(w, entity)
+	if err != nil {
+		return err
+	}
+	for i := range rec.Frames {
+		if err := c.LogStep(&rec.Frames[i]); err != nil {
+			return err
+		}
+	}
+	return c.Flush()
+}
+
+// CSVLog is a StepLogger writing each state as it comes in the form of
+// WriteCSV, so a run's time series needs no recording held in memory.
+type CSVLog struct {
+	out    *csv.Writer
+	entity string
+	row    []string
+}
+
+// NewCSVLog writes the header to w and returns a log for the rows. A
+// non-empty entity keeps only that entity's rows.
+func NewCSVLog(w io.Writer, entity string) (*CSVLog, error) {
+	c := &CSVLog{out: csv.NewWriter(w), entity: entity, row: make([]string, 0, len(csvHeader))}
+	if err := c.out.Write(csvHeader); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LogStep writes a row per entity of st.
+func (c *CSVLog) LogStep(st *SimulationState) error {
+	gravity := vector.Vector3{Y: -units.G}
+	// Guidance columns are filled while an interceptor is in flight.
+	flying := make(map[string]string, len(st.Engagements))
+	for _, eng := range st.Engagements {
+		if eng.Status == "Flying" {
+			flying[eng.MissileID] = eng.TargetID
+		}
+	}
+	byID := make(map[string]*entities.Entity, len(st.Entities))
+	for _, e := range st.Entities {
+		byID[e.ID] = e
+	}
+	for _, e := range st.Entities {
+		if c.entity != "" && e.ID != c.entity {
+			continue
+		}
+		row := append(c.row[:0], num(st.Time), e.ID, string(e.Type))
+		for _, v := range []vector.Vector3{e.Position, e.Velocity, e.Acceleration} {
+			row = append(row, num(v.X), num(v.Y), num(v.Z))
+		}
+		if targetID, ok := flying[e.ID]; ok {
+			cmd := e.Acceleration.Sub(gravity).Magnitude() / units.G
+			los := ""
+			if t := byID[targetID]; t != nil {
+				los = num(losRate(t.Position.Sub(e.Position), t.Velocity.Sub(e.Velocity)))
+			}
+			row = append(row, num(cmd), targetID, los)
+		} else {
+			row = append(row, "", "", "")
+		}
+		if err := c.out.Write(row); err != nil {
+			return err
+		}
+		c.row = row
+	}
+	return nil
+}
+
+// Flush writes out any buffered rows.
+func (c *CSVLog) Flush() error {
+	c.out.Flush()
+	return c.out.Error()
+}
+
+// losRate is the rotation rate of the line of sight r, given the relative
+// velocity v: the part of v across r over the range.
+func losRate(r, v vector.Vector3) float64 {
+	rng := r.Magnitude()
+	if rng == 0 {
+		return 0
+	}
+	return v.Reject(r).Magnitude() / rng
+}
+
+func num(v float64) string {
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
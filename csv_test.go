@@ -0,0 +1,97 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"math"
+	"strconv"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestLOSRate(t *testing.T) {
+	tests := []struct {
+		name string
+		r, v vector.Vector3
+		want float64
+	}{
+		{"closing head-on", vector.Vector3{X: 1000}, vector.Vector3{X: -300}, 0},
+		{"crossing", vector.Vector3{X: 1000}, vector.Vector3{Z: 100}, 0.1},
+		{"oblique", vector.Vector3{X: 500}, vector.Vector3{X: -30, Y: 40}, 0.08},
+		{"zero range", vector.Vector3{}, vector.Vector3{X: 1}, 0},
+	}
+	for _, tt := range tests {
+		if got := losRate(tt.r, tt.v); math.Abs(got-tt.want) > 1e-12 {
+			t.Errorf("%s: losRate = %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
+
+// recordRun records the default scenario for up to maxTime seconds and
+// reads the recording back from disk.
+func recordRun(t *testing.T, maxTime float64) *Recording {
+	t.Helper()
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	s.Seed = 42
+	s.RecordDir = t.TempDir()
+	s.Reset()
+	if err := s.SetRecording(true); err != nil {
+		t.Fatal(err)
+	}
+	s.RunToCompletion(maxTime)
+	s.Close()
+	list, err := ListRecordings(s.RecordDir)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("recordings %v, %v", list, err)
+	}
+	rec, err := LoadRecording(s.RecordDir, list[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rec
+}
+
+func TestWriteCSV(t *testing.T) {
+	rec := recordRun(t, 3)
+
+	var buf bytes.Buffer
+	if err := WriteCSV(&buf, rec, ""); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := 1 + len(rec.Frames)*len(rec.Frames[0].Entities); len(rows) != want {
+		t.Fatalf("%d rows, want %d", len(rows), want)
+	}
+	guided := 0
+	for _, row := range rows[1:] {
+		if row[13] == "" {
+			continue
+		}
+		guided++
+		if g, err := strconv.ParseFloat(row[12], 64); err != nil || g < 0 {
+			t.Fatalf("bad commanded g in %v", row)
+		}
+		if _, err := strconv.ParseFloat(row[14], 64); err != nil {
+			t.Fatalf("bad LOS rate in %v", row)
+		}
+	}
+	if guided == 0 {
+		t.Error("no interceptor rows carry guidance columns")
+	}
+
+	buf.Reset()
+	id := rec.Frames[0].Entities[0].ID
+	if err := WriteCSV(&buf, rec, id); err != nil {
+		t.Fatal(err)
+	}
+	rows, _ = csv.NewReader(&buf).ReadAll()
+	if len(rows) != 1+len(rec.Frames) {
+		t.Errorf("entity filter kept %d rows, want %d", len(rows), 1+len(rec.Frames))
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// keyframeInterval is how many frames a delta client receives between
+// unrequested keyframes, about one per second at the broadcast rate.
+const keyframeInterval = 30
+
+// DeltaFrame is one message of a delta stream. A keyframe carries the whole
+// state; the frames after it carry only what changed since the frame before.
+// Seq increases by one per frame so a client that sees a gap knows to ask
+// for a keyframe.
+type DeltaFrame struct {
+	Seq      uint64                     `json:"seq"`
+	Keyframe bool                       `json:"keyframe,omitempty"`
+	State    json.RawMessage            `json:"state,omitempty"`    // keyframes only
+	Changed  map[string]json.RawMessage `json:"changed,omitempty"`  // top-level fields that changed, null when cleared
+	Entities []json.RawMessage          `json:"entities,omitempty"` // entities that are new or changed
+	Removed  []string                   `json:"removed,omitempty"`  // IDs of entities that are gone
+}
+
+// deltaSource is one tick's state split up for diffing. It is built once
+// per tick and view and shared by every delta client on it.
+type deltaSource struct {
+	full     json.RawMessage
+	fields   map[string]json.RawMessage // every top-level field but entities
+	entities map[string]json.RawMessage
+	order    []string // entity IDs in state order
+}
+
+// splitState splits the JSON encoding of a state. The state may have been
+// filtered, so entities are optional.
+func splitState(full []byte) (*deltaSource, error) {
+	src := &deltaSource{full: full, entities: make(map[string]json.RawMessage)}
+	if err := json.Unmarshal(full, &src.fields); err != nil {
+		return nil, err
+	}
+	raw, ok := src.fields["entities"]
+	if !ok {
+		return src, nil
+	}
+	delete(src.fields, "entities")
+	var entities []json.RawMessage
+	if err := json.Unmarshal(raw, &entities); err != nil {
+		return nil, err
+	}
+	for _, e := range entities {
+		var id struct {
+			ID string `json:"id"`
+		}
+		if err := json.Unmarshal(e, &id); err != nil {
+			return nil, err
+		}
+		src.entities[id.ID] = e
+		src.order = append(src.order, id.ID)
+	}
+	return src, nil
+}
+
+// deltaTracker is the per-client side of a delta stream: what the client
+// last received. Callers must hold the hub's lock.
+type deltaTracker struct {
+	seq      uint64
+	sinceKey int
+	needKey  bool
+	prev     *deltaSource
+}
+
+// frame encodes src relative to the last frame sent.
+func (d *deltaTracker) frame(src *deltaSource) ([]byte, error) {
+	d.seq++
+	f := DeltaFrame{Seq: d.seq}
+	if d.prev == nil || d.needKey || d.sinceKey+1 >= keyframeInterval {
+		f.Keyframe = true
+		f.State = src.full
+		d.needKey = false
+		d.sinceKey = 0
+	} else {
+		d.sinceKey++
+		for k, v := range src.fields {
+			if !bytes.Equal(d.prev.fields[k], v) {
+				if f.Changed == nil {
+					f.Changed = make(map[string]json.RawMessage)
+				}
+				f.Changed[k] = v
+			}
+		}
+		for k := range d.prev.fields {
+			if _, ok := src.fields[k]; !ok {
+				if f.Changed == nil {
+					f.Changed = make(map[string]json.RawMessage)
+				}
+				f.Changed[k] = json.RawMessage("null")
+			}
+		}
+		for _, id := range src.order {
+			if e := src.entities[id]; !bytes.Equal(d.prev.entities[id], e) {
+				f.Entities = append(f.Entities, e)
+			}
+		}
+		for _, id := range d.prev.order {
+			if _, ok := src.entities[id]; !ok {
+				f.Removed = append(f.Removed, id)
+			}
+		}
+	}
+	d.prev = src
+	return json.Marshal(f)
+}
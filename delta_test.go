@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/simulation"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func deltaState(t float64, status, reason string, ids ...string) simulation.SimulationState {
+	st := simulation.SimulationState{Time: t, Status: status, Reason: reason}
+	for _, id := range ids {
+		st.Entities = append(st.Entities, &entities.Entity{ID: id, Position: vector.Vector3{X: t}})
+	}
+	return st
+}
+
+func TestDeltaTracker(t *testing.T) {
+	frozen := deltaState(1, "Running", "", "a")
+	frozen.Entities[0].Position.X = 0
+
+	tests := []struct {
+		name     string
+		prev     simulation.SimulationState
+		next     simulation.SimulationState
+		changed  []string
+		entities []string
+		removed  []string
+	}{
+		{
+			name:     "time and positions",
+			prev:     deltaState(0, "Running", "", "a", "b"),
+			next:     deltaState(1, "Running", "", "a", "b"),
+			changed:  []string{"time"},
+			entities: []string{"a", "b"},
+		},
+		{
+			name:     "entity added and removed",
+			prev:     deltaState(1, "Running", "", "a", "b"),
+			next:     deltaState(1, "Running", "", "a", "c"),
+			entities: []string{"c"},
+			removed:  []string{"b"},
+		},
+		{
+			name:    "field set and cleared",
+			prev:    deltaState(1, "Stopped", "ground", "a"),
+			next:    deltaState(1, "Running", "", "a"),
+			changed: []string{"reason", "status"},
+		},
+		{
+			name:     "nothing moved",
+			prev:     frozen,
+			next:     frozen,
+			entities: nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := &deltaTracker{}
+			if f := deltaFrame(t, d, tt.prev); !f.Keyframe || f.Seq != 1 {
+				t.Fatalf("first frame = %+v, want keyframe 1", f)
+			}
+			f := deltaFrame(t, d, tt.next)
+			if f.Keyframe || f.Seq != 2 {
+				t.Fatalf("second frame seq %d keyframe %v, want delta 2", f.Seq, f.Keyframe)
+			}
+			var changed []string
+			for k, v := range f.Changed {
+				changed = append(changed, k)
+				if k == "reason" && string(v) != "null" {
+					t.Errorf("cleared field sent as %s, want null", v)
+				}
+			}
+			sort.Strings(changed)
+			var ids []string
+			for _, raw := range f.Entities {
+				var e entities.Entity
+				if err := json.Unmarshal(raw, &e); err != nil {
+					t.Fatal(err)
+				}
+				ids = append(ids, e.ID)
+			}
+			if !reflect.DeepEqual(changed, tt.changed) || !reflect.DeepEqual(ids, tt.entities) || !reflect.DeepEqual(f.Removed, tt.removed) {
+				t.Errorf("changed %v entities %v removed %v, want %v %v %v", changed, ids, f.Removed, tt.changed, tt.entities, tt.removed)
+			}
+		})
+	}
+}
+
+func TestDeltaTrackerKeyframes(t *testing.T) {
+	d := &deltaTracker{}
+	st := deltaState(0, "Running", "", "a")
+	for i := 1; i <= 2*keyframeInterval; i++ {
+		f := deltaFrame(t, d, st)
+		if want := (i-1)%keyframeInterval == 0; f.Keyframe != want {
+			t.Fatalf("frame %d keyframe = %v, want %v", i, f.Keyframe, want)
+		}
+	}
+	d.needKey = true
+	if f := deltaFrame(t, d, st); !f.Keyframe {
+		t.Error("requested keyframe not sent")
+	}
+	if f := deltaFrame(t, d, st); f.Keyframe {
+		t.Error("keyframe repeated after the request was served")
+	}
+}
+
+func deltaFrame(t *testing.T, d *deltaTracker, st simulation.SimulationState) DeltaFrame {
+	t.Helper()
+	full, err := json.Marshal(st)
+	if err != nil {
+		t.Fatal(err)
+	}
+	src, err := splitState(full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := d.frame(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var f DeltaFrame
+	if err := json.Unmarshal(msg, &f); err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
@@ -0,0 +1,179 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Divergence is the first place two runs differ.
+type Divergence struct {
+	Step   int     `json:"step"` // index of the compared frame
+	Time   float64 `json:"time"` // s, in the first run
+	Entity string  `json:"entity,omitempty"`
+	Field  string  `json:"field"` // position, velocity, status or engagement
+	Delta  float64 `json:"delta,omitempty"`
+	Detail string  `json:"detail"`
+}
+
+// EntityDiff is how far one entity's trajectory differs between two runs.
+type EntityDiff struct {
+	ID              string      `json:"id"`
+	FirstDivergence *Divergence `json:"firstDivergence,omitempty"`
+	MaxDelta        float64     `json:"maxDelta"` // m
+	MaxDeltaTime    float64     `json:"maxDeltaTime"`
+	MaxSpeedDelta   float64     `json:"maxSpeedDelta"` // m/s, of the velocity difference
+	FinalDelta      float64     `json:"finalDelta"`    // m, at the last compared frame
+}
+
+// RunDiff compares two recorded runs aligned in time.
+type RunDiff struct {
+	A         string  `json:"a"`
+	B         string  `json:"b"`
+	Dt        float64 `json:"dt"` // s between compared frames
+	Frames    int     `json:"frames"`
+	Resampled bool    `json:"resampled"` // the steps or starts differed, so both were interpolated onto one grid
+	Tolerance float64 `json:"tolerance"` // m and m/s below which a difference is ignored
+
+	Identical       bool         `json:"identical"`
+	FirstDivergence *Divergence  `json:"firstDivergence,omitempty"` // earliest of any entity or the telemetry
+	FirstTelemetry  *Divergence  `json:"firstTelemetry,omitempty"`  // first run status or engagement difference
+	MaxDelta        float64      `json:"maxDelta"`                  // m, over every entity
+	MaxDeltaEntity  string       `json:"maxDeltaEntity,omitempty"`
+	Entities        []EntityDiff `json:"entities"`
+	OnlyA           []string     `json:"onlyA,omitempty"` // entities in one run only
+	OnlyB           []string     `json:"onlyB,omitempty"`
+	DurationA       float64      `json:"durationA"`
+	DurationB       float64      `json:"durationB"`
+}
+
+// DiffRuns aligns two recordings in time and reports where their
+// trajectories and telemetry part ways: the first divergent frame, and how
+// far apart each entity gets. Recordings with the same step and start are
+// compared frame for frame, so two runs of one seed come out identical
+// only if they are bit for bit; otherwise both are resampled to the
+// coarser step over the time they share. Differences no larger than
+// tolerance are ignored.
+func DiffRuns(a, b *Recording, tolerance float64) (*RunDiff, error) {
+	if len(a.Frames) == 0 || len(b.Frames) == 0 {
+		return nil, fmt.Errorf("both recordings need frames")
+	}
+	if tolerance < 0 || math.IsNaN(tolerance) {
+		return nil, fmt.Errorf("tolerance must not be negative")
+	}
+	d := &RunDiff{A: a.Name, B: b.Name, Dt: a.Dt, Tolerance: tolerance, DurationA: a.Duration(), DurationB: b.Duration()}
+	fa, fb := a.Frames, b.Frames
+	if a.Dt != b.Dt || fa[0].Time != fb[0].Time {
+		d.Dt, d.Resampled = max(a.Dt, b.Dt), true
+		fa, fb = a.Resample(d.Dt).Frames, b.Resample(d.Dt).Frames
+	}
+
+	byID := make(map[string]int) // index in d.Entities
+	seenA, seenB := make(map[string]bool), make(map[string]bool)
+	for i, j := 0, 0; i < len(fa) && j < len(fb); {
+		// Pair frames within half a step; skip whichever run is behind.
+		switch ta, tb := fa[i].Time, fb[j].Time; {
+		case tb < ta-d.Dt/2:
+			j++
+			continue
+		case ta < tb-d.Dt/2:
+			i++
+			continue
+		}
+		d.compare(d.Frames, &fa[i], &fb[j], byID, seenA, seenB)
+		d.Frames++
+		i++
+		j++
+	}
+	for _, e := range d.Entities {
+		if e.FirstDivergence != nil && (d.FirstDivergence == nil || e.FirstDivergence.Step < d.FirstDivergence.Step) {
+			d.FirstDivergence = e.FirstDivergence
+		}
+	}
+	if t := d.FirstTelemetry; t != nil && (d.FirstDivergence == nil || t.Step < d.FirstDivergence.Step) {
+		d.FirstDivergence = t
+	}
+	for id := range seenA {
+		if !seenB[id] {
+			d.OnlyA = append(d.OnlyA, id)
+		}
+	}
+	for id := range seenB {
+		if !seenA[id] {
+			d.OnlyB = append(d.OnlyB, id)
+		}
+	}
+	slices.Sort(d.OnlyA)
+	slices.Sort(d.OnlyB)
+	d.Identical = d.FirstDivergence == nil && len(d.OnlyA) == 0 && len(d.OnlyB) == 0 && len(fa) == len(fb)
+	return d, nil
+}
+
+// compare records the differences between frames a and b, the step'th pair.
+func (d *RunDiff) compare(step int, a, b *SimulationState, byID map[string]int, seenA, seenB map[string]bool) {
+	diverged := func(field, entity string, delta float64, detail string) *Divergence {
+		return &Divergence{Step: step, Time: a.Time, Entity: entity, Field: field, Delta: delta, Detail: detail}
+	}
+	inB := make(map[string]int, len(b.Entities))
+	for k, e := range b.Entities {
+		inB[e.ID] = k
+		seenB[e.ID] = true
+	}
+	for _, ea := range a.Entities {
+		seenA[ea.ID] = true
+		k, ok := inB[ea.ID]
+		if !ok {
+			continue
+		}
+		eb := b.Entities[k]
+		n, ok := byID[ea.ID]
+		if !ok {
+			n = len(d.Entities)
+			byID[ea.ID] = n
+			d.Entities = append(d.Entities, EntityDiff{ID: ea.ID})
+		}
+		ed := &d.Entities[n]
+		delta := ea.Position.Distance(eb.Position)
+		speed := ea.Velocity.Distance(eb.Velocity)
+		if delta > ed.MaxDelta {
+			ed.MaxDelta, ed.MaxDeltaTime = delta, a.Time
+		}
+		if delta > d.MaxDelta {
+			d.MaxDelta, d.MaxDeltaEntity = delta, ea.ID
+		}
+		ed.MaxSpeedDelta = max(ed.MaxSpeedDelta, speed)
+		ed.FinalDelta = delta
+		if ed.FirstDivergence != nil {
+			continue
+		}
+		switch {
+		case delta > d.Tolerance:
+			ed.FirstDivergence = diverged("position", ea.ID, delta, fmt.Sprintf("%.3g m apart", delta))
+		case speed > d.Tolerance:
+			ed.FirstDivergence = diverged("velocity", ea.ID, speed, fmt.Sprintf("%.3g m/s apart", speed))
+		}
+	}
+	if d.FirstTelemetry != nil {
+		return
+	}
+	if a.Status != b.Status || a.Reason != b.Reason {
+		d.FirstTelemetry = diverged("status", "", 0, fmt.Sprintf("%s %s vs %s %s", a.Status, a.Reason, b.Status, b.Reason))
+		return
+	}
+	engB := make(map[string]EngagementStatus, len(b.Engagements))
+	for _, eng := range b.Engagements {
+		engB[eng.MissileID] = eng
+	}
+	for _, ea := range a.Engagements {
+		eb, ok := engB[ea.MissileID]
+		switch {
+		case !ok:
+			d.FirstTelemetry = diverged("engagement", ea.MissileID, 0, "engaging in the first run only")
+		case ea.TargetID != eb.TargetID || ea.Status != eb.Status:
+			d.FirstTelemetry = diverged("engagement", ea.MissileID, 0, fmt.Sprintf("%s on %s vs %s on %s", ea.Status, ea.TargetID, eb.Status, eb.TargetID))
+		default:
+			continue
+		}
+		return
+	}
+}
@@ -0,0 +1,89 @@
+package simulation
+
+import (
+	"testing"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestDiffRuns(t *testing.T) {
+	// nudge moves missile-1 by dx in every frame from the ith.
+	nudge := func(rec *Recording, from int, dx float64) *Recording {
+		for i := range rec.Frames {
+			rec.Frames[i] = cloneState(rec.Frames[i])
+			if i >= from {
+				rec.Frames[i].Entities[1].Position = rec.Frames[i].Entities[1].Position.Add(vector.Vector3{X: dx})
+			}
+		}
+		return rec
+	}
+	tests := []struct {
+		name      string
+		b         *Recording
+		tolerance float64
+		identical bool
+		step      int // of the first divergence, -1 for none
+		field     string
+		maxDelta  float64
+		resampled bool
+	}{
+		{"identical", plotRecording(10), 0, true, -1, "", 0, false},
+		{"nudged", nudge(plotRecording(10), 4, 2), 0, false, 4, "position", 2, false},
+		{"within tolerance", nudge(plotRecording(10), 4, 2), 3, true, -1, "", 2, false},
+		{"shorter", func() *Recording {
+			rec := plotRecording(10)
+			rec.Frames = rec.Frames[:7]
+			return rec
+		}(), 0, false, -1, "", 0, false},
+		{"finer step", func() *Recording {
+			rec := plotRecording(19)
+			rec.Dt = 0.05
+			for i := range rec.Frames {
+				rec.Frames[i].Time = float64(i) * rec.Dt
+			}
+			return rec
+		}(), 1e-6, true, -1, "", 0, true},
+		{"status", func() *Recording {
+			rec := plotRecording(10)
+			rec.Frames[6].Status = "Intercepted"
+			return rec
+		}(), 0, false, 6, "status", 0, false},
+	}
+	for _, tt := range tests {
+		d, err := DiffRuns(plotRecording(10), tt.b, tt.tolerance)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if d.Identical != tt.identical || d.Resampled != tt.resampled {
+			t.Errorf("%s: identical %v, resampled %v; want %v, %v", tt.name, d.Identical, d.Resampled, tt.identical, tt.resampled)
+		}
+		switch fd := d.FirstDivergence; {
+		case tt.step < 0 && fd != nil:
+			t.Errorf("%s: diverged at step %d: %+v", tt.name, fd.Step, fd)
+		case tt.step >= 0 && (fd == nil || fd.Step != tt.step || fd.Field != tt.field):
+			t.Errorf("%s: first divergence %+v, want %s at step %d", tt.name, fd, tt.field, tt.step)
+		}
+		if tt.maxDelta > 0 && (d.MaxDelta < tt.maxDelta-1e-9 || d.MaxDelta > tt.maxDelta+1e-9 || d.MaxDeltaEntity != "missile-1") {
+			t.Errorf("%s: max delta %g on %s, want %g on missile-1", tt.name, d.MaxDelta, d.MaxDeltaEntity, tt.maxDelta)
+		}
+	}
+
+	extra := plotRecording(10)
+	for i := range extra.Frames {
+		extra.Frames[i] = cloneState(extra.Frames[i])
+		extra.Frames[i].Entities = extra.Frames[i].Entities[:1]
+	}
+	d, err := DiffRuns(plotRecording(10), extra, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Identical || len(d.OnlyA) != 1 || d.OnlyA[0] != "missile-1" {
+		t.Errorf("entity missing from one run: identical %v, only in a %v", d.Identical, d.OnlyA)
+	}
+	if _, err := DiffRuns(plotRecording(10), &Recording{}, 0); err == nil {
+		t.Error("compared a recording with no frames")
+	}
+	if _, err := DiffRuns(plotRecording(10), plotRecording(10), -1); err == nil {
+		t.Error("accepted a negative tolerance")
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"missile-intercept-sim/internal/dis"
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// disKinds gives the DIS force and entity type of each entity type: the
+// target as an opposing fixed-wing aircraft, interceptors as friendly
+// guided anti-air munitions.
+var disKinds = map[string]struct {
+	force uint8
+	typ   dis.EntityType
+}{
+	"Target":  {dis.ForceOpposing, dis.EntityType{Kind: 1, Domain: 2, Category: 1}},
+	"Missile": {dis.ForceFriendly, dis.EntityType{Kind: 2, Domain: 1, Country: 225, Category: 1}},
+}
+
+// disResults gives the detonation result of events that end a weapon.
+var disResults = map[string]uint8{
+	simulation.EventIntercept:   dis.ResultEntityProximate,
+	simulation.EventCrash:       dis.ResultGroundImpact,
+	simulation.EventSpent:       dis.ResultNone,
+	simulation.EventOutOfBounds: dis.ResultNone,
+}
+
+// disOutput sends one session to a DIS exercise over UDP: an Entity State
+// PDU per entity at a fixed rate, which doubles as the DIS heartbeat, a Fire
+// PDU per launch and a Detonation PDU when a weapon is done. The flat local
+// frame is placed on the globe at origin.
+type disOutput struct {
+	conn     net.Conn
+	session  string
+	exercise uint8
+	site     uint16
+	app      uint16
+	origin   geo.Origin
+	interval time.Duration
+
+	ids    map[string]uint16 // entity IDs as DIS entity numbers
+	events uint16            // last event number
+}
+
+// newDISOutput sends to addr, typically a broadcast address such as
+// 255.255.255.255:3000.
+func newDISOutput(addr, session string, exercise uint8, site, app uint16, origin geo.Origin, rate float64) (*disOutput, error) {
+	if !(rate > 0 && rate <= maxClientRate) {
+		return nil, fmt.Errorf("DIS rate must be above 0 and at most %d", maxClientRate)
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &disOutput{
+		conn: conn, session: session, exercise: exercise, site: site, app: app, origin: origin,
+		interval: time.Duration(float64(time.Second) / rate),
+		ids:      make(map[string]uint16),
+	}, nil
+}
+
+// Run sends PDUs until ctx is done.
+func (d *disOutput) Run(ctx context.Context) {
+	defer d.conn.Close()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	seen := make(eventCursor)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sess, ok := sessions.Get(d.session)
+			if !ok {
+				continue
+			}
+			if err := d.tick(sess, seen, now); err != nil {
+				slog.Error("dis", "err", err)
+			}
+		}
+	}
+}
+
+// tick sends the session's entity states and a PDU for each new weapon
+// event. Send errors are reported but do not stop the others.
+func (d *disOutput) tick(sess *Session, seen eventCursor, now time.Time) error {
+	state := sess.State()
+	byID := make(map[string]*entities.Entity, len(state.Entities))
+	var errs []error
+	for _, e := range state.Entities {
+		byID[e.ID] = e
+		kind := disKinds[string(e.Type)]
+		pdu := dis.EntityState{
+			Exercise:     d.exercise,
+			Time:         now,
+			ID:           d.entityID(e.ID),
+			Force:        kind.force,
+			Type:         kind.typ,
+			Location:     d.location(e.Position.X, e.Position.Y, e.Position.Z),
+			Velocity:     d.vector(e.Velocity.X, e.Velocity.Y, e.Velocity.Z),
+			Acceleration: d.vector(e.Acceleration.X, e.Acceleration.Y, e.Acceleration.Z),
+			Orientation:  d.orientation(e),
+			Marking:      e.ID,
+		}
+		errs = append(errs, d.send(pdu.Marshal()))
+	}
+	targets := make(map[string]string)
+	for _, eng := range state.Engagements {
+		targets[eng.MissileID] = eng.TargetID
+	}
+	for _, ev := range seen.next(sess) {
+		e, ok := byID[ev.EntityID]
+		if !ok {
+			continue
+		}
+		result, done := disResults[ev.Type]
+		if ev.Type != simulation.EventLaunch && !done {
+			continue
+		}
+		d.events++
+		base := dis.EventPDU{
+			Exercise: d.exercise,
+			Time:     now,
+			// The launcher is not modelled, so the weapon fires itself.
+			Shooter:  d.entityID(e.ID),
+			Munition: d.entityID(e.ID),
+			Event:    dis.EntityID{Site: d.site, Application: d.app, Entity: d.events},
+			Location: d.location(e.Position.X, e.Position.Y, e.Position.Z),
+			Velocity: d.vector(e.Velocity.X, e.Velocity.Y, e.Velocity.Z),
+			Warhead:  disKinds[string(e.Type)].typ,
+			Quantity: 1,
+		}
+		if target, ok := targets[e.ID]; ok {
+			base.Target = d.entityID(target)
+		}
+		if ev.Type == simulation.EventLaunch {
+			pdu := dis.Fire{EventPDU: base}
+			errs = append(errs, d.send(pdu.Marshal()))
+		} else {
+			pdu := dis.Detonation{EventPDU: base, Result: result}
+			errs = append(errs, d.send(pdu.Marshal()))
+		}
+	}
+	seen.prune(sessions.List())
+	return errors.Join(errs...)
+}
+
+func (d *disOutput) send(pdu []byte) error {
+	_, err := d.conn.Write(pdu)
+	return err
+}
+
+// entityID returns the DIS ID of an entity, numbering entities in the order
+// they are first seen.
+func (d *disOutput) entityID(id string) dis.EntityID {
+	n, ok := d.ids[id]
+	if !ok {
+		n = uint16(len(d.ids) + 1)
+		d.ids[id] = n
+	}
+	return dis.EntityID{Site: d.site, Application: d.app, Entity: n}
+}
+
+// location converts a local Y-up position to ECEF.
+func (d *disOutput) location(x, y, z float64) dis.Vector {
+	ex, ey, ez := d.origin.ECEF(x, z, y)
+	return dis.Vector{ex, ey, ez}
+}
+
+// vector turns a local Y-up vector into ECEF axes.
+func (d *disOutput) vector(x, y, z float64) dis.Vector {
+	ex, ey, ez := d.origin.Rotate(x, z, y)
+	return dis.Vector{ex, ey, ez}
+}
+
+// orientation points the entity's nose along its velocity, wings level.
+func (d *disOutput) orientation(e *entities.Entity) dis.Orientation {
+	v := e.Velocity
+	speed := math.Sqrt(v.X*v.X + v.Y*v.Y + v.Z*v.Z)
+	if speed == 0 {
+		v.Z, speed = 1, 1 // at rest, face north
+	}
+	level := math.Hypot(v.X, v.Z)
+	right := d.vector(1, 0, 0) // climbing straight up, wings east
+	if level > 0 {
+		right = d.vector(v.Z/level, 0, -v.X/level)
+	}
+	return dis.OrientationFromAxes(d.vector(v.X/speed, v.Y/speed, v.Z/speed), right)
+}
+
+// parseOriginFlag parses "lat,lon[,alt]".
+func parseOriginFlag(s string) (geo.Origin, error) {
+	var o geo.Origin
+	parts := strings.Split(s, ",")
+	if len(parts) < 2 || len(parts) > 3 {
+		return o, fmt.Errorf("origin must be lat,lon[,alt], got %q", s)
+	}
+	dst := []*float64{&o.Lat, &o.Lon, &o.Alt}
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return o, fmt.Errorf("origin must be lat,lon[,alt], got %q", s)
+		}
+		*dst[i] = f
+	}
+	if !o.Valid() {
+		return o, fmt.Errorf("origin %q is out of range", s)
+	}
+	return o, nil
+}
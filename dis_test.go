@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestDISOutput(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+
+	lis, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	origin, err := parseOriginFlag("36.2,-115.0,900")
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := newDISOutput(lis.LocalAddr().String(), defaultSessionID, 7, 1, 2, origin, 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer out.conn.Close()
+
+	// received reads PDUs until the socket goes quiet, counting them by type.
+	received := func() map[uint8]int {
+		counts := make(map[uint8]int)
+		buf := make([]byte, 1500)
+		for {
+			lis.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			n, _, err := lis.ReadFrom(buf)
+			if err != nil {
+				return counts
+			}
+			if n < 12 || buf[1] != 7 {
+				t.Fatalf("bad PDU % x", buf[:min(n, 12)])
+			}
+			counts[buf[2]]++
+		}
+	}
+	seen := make(eventCursor)
+	if err := out.tick(sess, seen, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	entities := len(sess.State().Entities)
+	if got := received(); got[1] != entities || got[3] != 0 {
+		t.Errorf("first tick sent %v, want %d entity states only", got, entities)
+	}
+	if _, err := sess.Sim.Advance(1200); err != nil { // past the intercept
+		t.Fatal(err)
+	}
+	if err := out.tick(sess, seen, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if got := received(); got[3] != 1 {
+		t.Errorf("after the intercept sent %v, want one detonation", got)
+	}
+}
+
+func TestParseOriginFlag(t *testing.T) {
+	tests := []struct {
+		in string
+		ok bool
+	}{
+		{"36.2,-115", true},
+		{"36.2, -115, 900", true},
+		{"36.2", false},
+		{"north,east", false},
+		{"95,0", false},
+	}
+	for _, tt := range tests {
+		if _, err := parseOriginFlag(tt.in); (err == nil) != tt.ok {
+			t.Errorf("parseOriginFlag(%q): err = %v", tt.in, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// minLaunchElevation keeps launchers from firing flat at low tracks, rad.
+const minLaunchElevation = 10 * math.Pi / 180
+
+// launchLocked takes an interceptor off the launcher against th.
+// Callers must hold s.mu.
+func (s *Simulator) launchLocked(ic *Interceptor, th *Threat, why string) {
+	ic.Status = "Flying"
+	ic.LaunchTime = s.State.Time
+	if ic.Target != th {
+		ic.Target = th
+		ic.Seeker.Track = nil
+		ic.Locked = false
+	}
+	ic.MissDistance = ic.Missile.Position.Distance(th.Entity.Position)
+	ic.ClosestApproach = s.State.Time
+	ic.endgame, ic.cpa = endgame{}, endgame{}
+	s.logEventLocked(EventLaunch, ic.Missile.ID, "Launched at "+th.Entity.ID+why)
+}
+
+// doctrineLocked commits ready interceptors against radar tracks inside the
+// launch range, a salvo at a time, highest-priority threat first. Callers
+// must hold s.mu.
+func (s *Simulator) doctrineLocked(now float64) {
+	d := s.Doctrine
+	if d.HoldFire {
+		return
+	}
+	for _, th := range s.rankedThreatsLocked() {
+		track, ok := s.Radar.Tracks[th.Entity.ID]
+		if !ok {
+			continue
+		}
+		if d.Policy != scenario.PolicyShootShoot {
+			// Shoot-look-shoot: hold while a salvo is in the air, then look
+			// for LookTime before re-engaging a survivor.
+			if s.engagedLocked(th) {
+				continue
+			}
+			if th.Engaged {
+				th.Engaged = false
+				th.NextShot = now + d.LookTime
+			}
+		}
+		if now < th.NextShot {
+			continue
+		}
+		salvo, tier, minRange, maxRange := d.Salvo, "", 0.0, d.LaunchRange
+		if len(d.Tiers) > 0 {
+			t := s.tierLocked(th, track.Position)
+			if t == nil {
+				continue
+			}
+			tier, minRange, maxRange = t.Name, t.MinRange, t.MaxRange
+			if t.Salvo > 0 {
+				salvo = t.Salvo
+			}
+		}
+		if salvo == 0 {
+			salvo = 1
+		}
+		launched := 0
+		for launched < salvo {
+			ic := s.nearestReadyLocked(track.Position, tier, minRange, maxRange)
+			if ic == nil {
+				break
+			}
+			slewLauncher(ic, track.Position)
+			why := fmt.Sprintf(" (doctrine, salvo %d)", th.Shots+1)
+			if tier != "" {
+				why = fmt.Sprintf(" (%s tier, salvo %d)", tier, th.Shots+1)
+			}
+			s.launchLocked(ic, th, why)
+			launched++
+		}
+		if launched > 0 {
+			th.Shots++
+			th.LayerShots++
+			th.Engaged = true
+			if d.Policy == scenario.PolicyShootShoot {
+				th.NextShot = now + d.LookTime
+			}
+		}
+	}
+}
+
+// slewLauncher points a ready interceptor at pos before firing, never below
+// the launcher's minimum elevation.
+func slewLauncher(ic *Interceptor, pos vector.Vector3) {
+	los := pos.Sub(ic.Missile.Position)
+	ground := math.Hypot(los.X, los.Z)
+	los.Y = max(los.Y, ground*math.Tan(minLaunchElevation))
+	ic.Missile.Velocity = los.Normalize().Mul(ic.Missile.Velocity.Magnitude())
+}
+
+// Launch fires a ready interceptor by hand, at targetID or, when that is
+// empty, at the interceptor's assigned target. It works with or without a
+// doctrine and ignores hold fire.
+func (s *Simulator) Launch(interceptorID, targetID string) error {
+	s.lock()
+	defer s.mu.Unlock()
+	if s.finishedLocked() {
+		return fmt.Errorf("run has finished")
+	}
+	var ic *Interceptor
+	for _, cand := range s.Interceptors {
+		if cand.Missile.ID == interceptorID {
+			ic = cand
+		}
+	}
+	if ic == nil {
+		return fmt.Errorf("unknown interceptor %q", interceptorID)
+	}
+	if ic.Status != "Ready" {
+		return fmt.Errorf("interceptor %s is %s, not on the launcher", interceptorID, ic.Status)
+	}
+	th := ic.Target
+	if targetID != "" {
+		th = nil
+		for _, cand := range s.Threats {
+			if cand.Entity.ID == targetID {
+				th = cand
+			}
+		}
+		if th == nil {
+			return fmt.Errorf("unknown target %q", targetID)
+		}
+	}
+	if !th.Live() {
+		return fmt.Errorf("target %s is no longer a threat", th.Entity.ID)
+	}
+	s.overrideLocked("launch", map[string]string{"interceptor": interceptorID, "target": th.Entity.ID})
+	slewLauncher(ic, th.Entity.Position)
+	s.launchLocked(ic, th, " (manual)")
+	return nil
+}
+
+// engagedLocked reports whether any interceptor is flying at th.
+func (s *Simulator) engagedLocked(th *Threat) bool {
+	for _, ic := range s.Interceptors {
+		if ic.Status == "Flying" && ic.Target == th {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestReadyLocked returns the ready interceptor of the given tier closest
+// to pos, if one is between minRange and maxRange of it.
+func (s *Simulator) nearestReadyLocked(pos vector.Vector3, tier string, minRange, maxRange float64) *Interceptor {
+	var best *Interceptor
+	bestDist := maxRange
+	for _, ic := range s.Interceptors {
+		if ic.Status != "Ready" || ic.Tier != tier {
+			continue
+		}
+		if d := ic.Missile.Position.Distance(pos); d >= minRange && d <= bestDist {
+			best, bestDist = ic, d
+		}
+	}
+	return best
+}
+
+// SetHoldFire stops (or resumes) doctrine launches. It fails when the
+// scenario has no doctrine.
+func (s *Simulator) SetHoldFire(hold bool) error {
+	s.lock()
+	defer s.mu.Unlock()
+	if s.Doctrine == nil {
+		return fmt.Errorf("scenario has no launch doctrine")
+	}
+	s.Doctrine.HoldFire = hold
+	s.overrideLocked("holdFire", hold)
+	if hold {
+		s.logEventLocked(EventPhase, "", "Hold fire")
+	} else {
+		s.logEventLocked(EventPhase, "", "Weapons free")
+	}
+	return nil
+}
+
+// GetDoctrine returns a copy of the active doctrine, or nil without one.
+func (s *Simulator) GetDoctrine() *scenario.Doctrine {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return copyDoctrine(s.Doctrine)
+}
+
+// copyDoctrine copies d so runtime changes such as hold fire stay local.
+func copyDoctrine(d *scenario.Doctrine) *scenario.Doctrine {
+	if d == nil {
+		return nil
+	}
+	cp := *d
+	return &cp
+}
@@ -0,0 +1,227 @@
+package simulation
+
+import (
+	"math"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/guidance"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/sensors"
+	"missile-intercept-sim/pkg/intercept"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Threat is a target together with its scripted maneuvers.
+type Threat struct {
+	Entity    *entities.Entity
+	Maneuvers []scenario.Maneuver
+	Ballistic bool // falls under gravity
+	Destroyed bool
+	Impacted  bool // reached the ground unintercepted
+	Priority  int  // rank from threat evaluation, 1 highest; 0 once down
+
+	// Launch doctrine bookkeeping.
+	Shots      int     // salvos fired at this threat
+	Engaged    bool    // a salvo was fired and has not been assessed yet
+	NextShot   float64 // s, earliest time of the next salvo
+	Layer      int     // index of the doctrine tier responsible for the threat
+	LayerShots int     // salvos fired by that tier
+}
+
+// Live reports whether the threat is still in the air.
+func (th *Threat) Live() bool {
+	return !th.Destroyed && !th.Impacted
+}
+
+// Interceptor is a missile together with its guidance, seeker and assigned target.
+type Interceptor struct {
+	Missile      *entities.Entity
+	Target       *Threat
+	Seeker       *sensors.Seeker
+	GuidanceLaw  guidance.GuidanceLaw
+	GuidanceName string
+	Tier         string  // doctrine tier, empty without layered defense
+	Gain         float64 // guidance command multiplier
+	LaunchTime   float64 // s
+	Status       string  // Ready, Flying, Intercepted, Crashed, OutOfBounds, Spent
+	MissDistance float64 // closest approach to the assigned target so far
+	Locked       bool    // seeker held the target at its last update
+
+	// Flight metrics for the outcome report.
+	ClosestApproach float64 // s, time MissDistance was reached
+	FlightTime      float64 // s
+	MaxG            float64 // peak commanded load factor
+	PeakSpeed       float64 // m/s
+	BurnoutTime     float64 // s, time of peak speed; stands in for motor burnout
+
+	// Endgame miss analysis.
+	MissVector vector.Vector3 // target relative to the missile at closest approach
+	endgame    endgame        // accumulating since the window opened
+	cpa        endgame        // as it stood at closest approach
+	deficit    vector.Vector3 // commanded acceleration lost to the limit this step
+	sensorErr  float64        // m, perceived target position error this step
+}
+
+// EngagementStatus summarizes one interceptor's engagement in the broadcast state.
+type EngagementStatus struct {
+	MissileID    string  `json:"missileId"`
+	TargetID     string  `json:"targetId"`
+	Guidance     string  `json:"guidance"`
+	Tier         string  `json:"tier,omitempty"`
+	Status       string  `json:"status"`
+	MissDistance float64 `json:"missDistance"`
+
+	// Closing geometry, published while the interceptor is flying at a
+	// closing target.
+	ClosingVelocity float64         `json:"closingVelocity"`    // m/s, positive when closing
+	TimeToGo        float64         `json:"timeToGo,omitempty"` // s, range over closing velocity
+	PIP             *vector.Vector3 `json:"pip,omitempty"`      // predicted intercept point
+
+	// Relative geometry in degrees, published alongside the closing geometry.
+	AspectAngle          float64 `json:"aspectAngle"`          // off the target's tail to the missile: 0 tail chase, 180 head-on
+	AntennaTrainAngle    float64 `json:"antennaTrainAngle"`    // off the missile's nose to the target
+	HeadingCrossingAngle float64 `json:"headingCrossingAngle"` // between the two velocity vectors
+}
+
+// world is the mutable part of the simulator: everything a snapshot has to
+// capture beyond scalar settings.
+type world struct {
+	state        SimulationState
+	threats      []*Threat
+	interceptors []*Interceptor
+	radar        *sensors.Radar
+}
+
+// clone deep-copies the world, remapping every entity pointer so the copy
+// shares nothing with the original.
+func (w world) clone() world {
+	out := world{state: cloneState(w.state), radar: w.radar.Clone()}
+	remap := make(map[*entities.Entity]*entities.Entity, len(w.state.Entities))
+	for i, e := range w.state.Entities {
+		remap[e] = out.state.Entities[i]
+	}
+	threats := make(map[*Threat]*Threat, len(w.threats))
+	for _, th := range w.threats {
+		cp := &Threat{
+			Entity:     remap[th.Entity],
+			Maneuvers:  th.Maneuvers,
+			Ballistic:  th.Ballistic,
+			Destroyed:  th.Destroyed,
+			Impacted:   th.Impacted,
+			Priority:   th.Priority,
+			Shots:      th.Shots,
+			Engaged:    th.Engaged,
+			NextShot:   th.NextShot,
+			Layer:      th.Layer,
+			LayerShots: th.LayerShots,
+		}
+		threats[th] = cp
+		out.threats = append(out.threats, cp)
+	}
+	for _, ic := range w.interceptors {
+		cp := *ic
+		cp.Missile = remap[ic.Missile]
+		cp.Target = threats[ic.Target]
+		cp.Seeker = ic.Seeker.Clone()
+		out.interceptors = append(out.interceptors, &cp)
+	}
+	return out
+}
+
+// liveTargetsLocked returns the entities of threats still in the air.
+func (s *Simulator) liveTargetsLocked() []*entities.Entity {
+	var live []*entities.Entity
+	for _, th := range s.Threats {
+		if th.Live() {
+			live = append(live, th.Entity)
+		}
+	}
+	return live
+}
+
+// retargetLocked hands an interceptor whose target was destroyed by someone
+// else, or hit the ground, to the nearest surviving threat. It reports whether one was found.
+func (s *Simulator) retargetLocked(ic *Interceptor) bool {
+	var best *Threat
+	bestDist := 0.0
+	for _, th := range s.Threats {
+		if !th.Live() {
+			continue
+		}
+		d := ic.Missile.Position.Distance(th.Entity.Position)
+		if best == nil || d < bestDist {
+			best, bestDist = th, d
+		}
+	}
+	if best == nil {
+		return false
+	}
+	ic.Target = best
+	ic.Seeker.Track = nil
+	ic.Locked = false
+	ic.MissDistance = bestDist
+	ic.ClosestApproach = s.State.Time
+	ic.endgame, ic.cpa = endgame{}, endgame{}
+	s.logEventLocked(EventRetarget, ic.Missile.ID, "Retargeted to "+best.Entity.ID)
+	return true
+}
+
+// engagementsLocked builds the per-interceptor summary for the state.
+func (s *Simulator) engagementsLocked() []EngagementStatus {
+	out := make([]EngagementStatus, 0, len(s.Interceptors))
+	for _, ic := range s.Interceptors {
+		es := EngagementStatus{
+			MissileID:    ic.Missile.ID,
+			TargetID:     ic.Target.Entity.ID,
+			Guidance:     ic.GuidanceName,
+			Tier:         ic.Tier,
+			Status:       ic.Status,
+			MissDistance: ic.MissDistance,
+		}
+		if ic.Status == "Flying" && ic.Target.Live() {
+			es.ClosingVelocity, es.TimeToGo, es.PIP = closingGeometry(ic.Missile, ic.Target.Entity)
+			es.AspectAngle, es.AntennaTrainAngle, es.HeadingCrossingAngle = geometryAngles(ic.Missile, ic.Target.Entity)
+		}
+		out = append(out, es)
+	}
+	return out
+}
+
+// closingGeometry returns the closing velocity between a missile and its
+// target and, while they are closing, the time-to-go and the predicted
+// intercept point: where the target, holding its velocity and
+// acceleration, meets the missile flying straight at its current speed, or
+// failing that where the target will be at the time-to-go.
+func closingGeometry(m, t *entities.Entity) (float64, float64, *vector.Vector3) {
+	los := t.Position.Sub(m.Position)
+	rng := los.Magnitude()
+	if rng == 0 {
+		return 0, 0, nil
+	}
+	vc := -los.Dot(t.Velocity.Sub(m.Velocity)) / rng
+	if vc <= 0 {
+		return vc, 0, nil
+	}
+	tgo := rng / vc
+	pip := t.Position.AddScaled(t.Velocity, tgo)
+	if ti, ok := intercept.Time(los, t.Velocity, t.Acceleration, m.Velocity.Magnitude()); ok {
+		pip = m.Position.Add(intercept.Point(los, t.Velocity, t.Acceleration, ti))
+	}
+	return vc, tgo, &pip
+}
+
+// geometryAngles returns the aspect angle, antenna train angle and heading
+// crossing angle between a missile and its target, in degrees.
+func geometryAngles(m, t *entities.Entity) (aa, ata, hca float64) {
+	los := t.Position.Sub(m.Position)
+	aa = 180 - angleBetween(t.Velocity, los.Mul(-1))
+	ata = angleBetween(m.Velocity, los)
+	hca = angleBetween(m.Velocity, t.Velocity)
+	return aa, ata, hca
+}
+
+// angleBetween returns the angle between two vectors in degrees, or 0 when
+// either is zero.
+func angleBetween(a, b vector.Vector3) float64 {
+	return a.AngleTo(b) * 180 / math.Pi
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/simulation"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Game engines a client can ask for frames in with ?engine=.
+const (
+	// EngineUnity is metres with X east, Y up and Z north: Unity's
+	// left-handed axes, with +Z forward as north, which is the local frame
+	// unchanged.
+	EngineUnity = "unity"
+	// EngineUnreal is centimetres with X north, Y east and Z up: Unreal's
+	// left-handed forward, right and up axes.
+	EngineUnreal = "unreal"
+)
+
+// engineEpoch is where frames' serverTime counts from. time.Since reads the
+// monotonic clock, so serverTime never jumps with wall-clock changes.
+var engineEpoch = time.Now()
+
+// engineFrame is a state frame for a game-engine frontend. Engines render
+// a little behind the newest frame and interpolate between the two around
+// their render time by serverTime, extrapolating along velocity and
+// acceleration when a frame is late. seq counts the hub's broadcasts, so a
+// gap means frames were skipped; a change of run means the simulation was
+// reset or restored and entities should snap rather than glide.
+type engineFrame struct {
+	Seq        uint64             `json:"seq"`
+	ServerTime float64            `json:"serverTime"` // s since the server started, monotonic
+	SimTime    float64            `json:"simTime"`
+	Interval   float64            `json:"interval"` // s until the next frame is due
+	Run        uint64             `json:"run"`
+	Status     string             `json:"status"`
+	Engine     string             `json:"engine"`
+	Entities   []engineEntity     `json:"entities"`
+	Events     []simulation.Event `json:"events,omitempty"`
+}
+
+// engineEntity is one entity in engine axes and units. Rotation is a
+// quaternion as x, y, z, w, turning the engine's forward axis (Unity +Z,
+// Unreal +X) along the velocity, wings level.
+type engineEntity struct {
+	ID           string     `json:"id"`
+	Type         string     `json:"type"`
+	Position     [3]float64 `json:"position"`
+	Rotation     [4]float64 `json:"rotation"`
+	Velocity     [3]float64 `json:"velocity"`
+	Acceleration [3]float64 `json:"acceleration"`
+}
+
+// ParseEngine validates a requested game engine; empty selects none.
+func ParseEngine(engine string) (string, error) {
+	switch engine {
+	case "", EngineUnity, EngineUnreal:
+		return engine, nil
+	}
+	return "", fmt.Errorf("unknown engine %q, want %s or %s", engine, EngineUnity, EngineUnreal)
+}
+
+// newEngineFrame converts state for engine, stamped with the hub's
+// broadcast sequence number and the client's frame interval.
+func newEngineFrame(state simulation.SimulationState, engine string, seq uint64, interval time.Duration, now time.Time) engineFrame {
+	f := engineFrame{
+		Seq:        seq,
+		ServerTime: now.Sub(engineEpoch).Seconds(),
+		SimTime:    state.Time,
+		Interval:   interval.Seconds(),
+		Run:        state.Run,
+		Status:     state.Status,
+		Engine:     engine,
+		Entities:   make([]engineEntity, len(state.Entities)),
+		Events:     state.Events,
+	}
+	for i, e := range state.Entities {
+		f.Entities[i] = newEngineEntity(e, engine)
+	}
+	return f
+}
+
+func newEngineEntity(e *entities.Entity, engine string) engineEntity {
+	v := e.Velocity
+	level := math.Hypot(v.X, v.Z)
+	yaw, pitch := 0.0, 0.0 // at rest, face north
+	if level > 0 || v.Y != 0 {
+		yaw, pitch = math.Atan2(v.X, v.Z), math.Atan2(v.Y, level)
+	}
+	sy, cy := math.Sincos(yaw / 2)
+	sp, cp := math.Sincos(pitch / 2)
+	out := engineEntity{ID: e.ID, Type: string(e.Type)}
+	if engine == EngineUnreal {
+		// FQuat(FRotator(pitch, yaw, 0)).
+		out.Rotation = [4]float64{sp * sy, -sp * cy, cp * sy, cp * cy}
+	} else {
+		// Quaternion.Euler(-pitch, yaw, 0): Unity pitches nose down about +X.
+		out.Rotation = [4]float64{-cy * sp, sy * cp, sy * sp, cy * cp}
+	}
+	out.Position = engineAxes(e.Position, engine)
+	out.Velocity = engineAxes(e.Velocity, engine)
+	out.Acceleration = engineAxes(e.Acceleration, engine)
+	return out
+}
+
+// engineAxes converts a local vector to engine axes and units.
+func engineAxes(v vector.Vector3, engine string) [3]float64 {
+	if engine == EngineUnreal {
+		return [3]float64{v.Z * 100, v.X * 100, v.Y * 100}
+	}
+	return [3]float64{v.X, v.Y, v.Z}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// rotate turns v by the quaternion q, given as x, y, z, w.
+func rotate(q [4]float64, v [3]float64) [3]float64 {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	// v + 2w(u×v) + 2u×(u×v) for u = (x, y, z).
+	cx, cy, cz := y*v[2]-z*v[1], z*v[0]-x*v[2], x*v[1]-y*v[0]
+	return [3]float64{
+		v[0] + 2*w*cx + 2*(y*cz-z*cy),
+		v[1] + 2*w*cy + 2*(z*cx-x*cz),
+		v[2] + 2*w*cz + 2*(x*cy-y*cx),
+	}
+}
+
+func TestEngineRotation(t *testing.T) {
+	forward := map[string][3]float64{EngineUnity: {0, 0, 1}, EngineUnreal: {1, 0, 0}}
+	for _, v := range []vector.Vector3{{Z: 1}, {X: 300}, {X: -50, Y: 20, Z: -80}, {Y: -9}, {}} {
+		for engine, fwd := range forward {
+			e := newEngineEntity(&entities.Entity{Velocity: v}, engine)
+			want := e.Velocity
+			if n := math.Sqrt(want[0]*want[0] + want[1]*want[1] + want[2]*want[2]); n > 0 {
+				want = [3]float64{want[0] / n, want[1] / n, want[2] / n}
+			} else {
+				want = fwd // at rest, facing north
+			}
+			got := rotate(e.Rotation, fwd)
+			for i := range got {
+				if math.Abs(got[i]-want[i]) > 1e-9 {
+					t.Errorf("%s, velocity %v: nose points %v, want %v", engine, v, got, want)
+					break
+				}
+			}
+		}
+	}
+}
+
+func TestEngineStream(t *testing.T) {
+	sess := newSession("engine")
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	conn := dialHub(t, sess, "engine=unreal&rate=15")
+
+	var frames []engineFrame
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for len(frames) < 3 {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var f engineFrame
+		if err := json.Unmarshal(data, &f); err != nil {
+			t.Fatal(err)
+		}
+		frames = append(frames, f)
+	}
+	for i, f := range frames {
+		if f.Engine != EngineUnreal || len(f.Entities) == 0 || math.Abs(f.Interval-1.0/15) > 1e-9 {
+			t.Fatalf("frame %d: %+v", i, f)
+		}
+		if i > 0 && (f.Seq <= frames[i-1].Seq || f.ServerTime <= frames[i-1].ServerTime) {
+			t.Errorf("frame %d: seq %d at %gs follows seq %d at %gs", i, f.Seq, f.ServerTime, frames[i-1].Seq, frames[i-1].ServerTime)
+		}
+	}
+	// Unreal positions are centimetres with X north.
+	e := sess.State().Entities[0]
+	if got := frames[0].Entities[0].Position; got[0] != e.Position.Z*100 || got[2] != e.Position.Y*100 {
+		t.Errorf("position %v for local %v", got, e.Position)
+	}
+
+	for _, q := range []string{"engine=godot", "engine=unity&delta=1", "engine=unity&fields=time", "engine=unity&trails=5"} {
+		if _, err := parseClientOptions(httptest.NewRequest("GET", "/ws?"+q, nil)); err == nil {
+			t.Errorf("accepted %s", q)
+		}
+	}
+}
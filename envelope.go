@@ -0,0 +1,276 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/pkg/intercept"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Envelope search defaults and limits.
+const (
+	defaultEnvelopeRadials  = 24
+	defaultEnvelopeMaxRange = 40000.0
+	defaultEnvelopeSteps    = 20
+	defaultEnvelopeBreakG   = 5.0
+	defaultLaunchSpeed      = 30.0
+	envelopeRefinements     = 6      // bisection passes per boundary
+	envelopeMinSpeed        = 100.0  // m/s, a fly-out slowing below this has missed
+	envelopeMinPad          = 5000.0 // m, least room around a fly-out's geometry
+	MaxEnvelopeRadials      = 72
+	MaxEnvelopeSteps        = 50
+)
+
+// EnvelopeConfig describes the interceptor and target track an envelope is
+// computed for. Launch sites are placed on the ground around the target's
+// position at the moment of launch.
+type EnvelopeConfig struct {
+	// Interceptor profile.
+	Guidance    string               `json:"guidance"`
+	MaxAccel    float64              `json:"maxAccel,omitempty"`    // m/s^2, 0 keeps the missile default
+	LaunchSpeed float64              `json:"launchSpeed,omitempty"` // m/s off the rail, aimed at the target
+	Seeker      *scenario.SeekerSpec `json:"seeker,omitempty"`
+
+	// Target track at launch.
+	TargetPosition vector.Vector3 `json:"targetPosition"`
+	TargetVelocity vector.Vector3 `json:"targetVelocity"`
+
+	Radials  int     `json:"radials,omitempty"`  // bearings around the target
+	MaxRange float64 `json:"maxRange,omitempty"` // m, search limit
+	Steps    int     `json:"steps,omitempty"`    // coarse range samples per radial
+	BreakG   float64 `json:"breakG,omitempty"`   // target turn for the no-escape zone
+	MaxTime  float64 `json:"maxTime,omitempty"`  // s per fly-out, at most 120
+}
+
+// EnvelopeRadial is the launch envelope along one bearing from the target.
+// Ranges are 0 when no launch along the bearing intercepts.
+type EnvelopeRadial struct {
+	Bearing  float64 `json:"bearing"` // degrees clockwise from north (+Z)
+	Rmin     float64 `json:"rmin"`
+	Rmax     float64 `json:"rmax"`
+	NoEscape float64 `json:"noEscape"` // max range that intercepts even if the target breaks either way
+}
+
+// EnvelopeReport holds the radials and their outlines as ground polygons in
+// world coordinates, ready to draw on a map.
+type EnvelopeReport struct {
+	Config   EnvelopeConfig   `json:"config"`
+	Radials  []EnvelopeRadial `json:"radials"`
+	Rmax     []vector.Vector3 `json:"rmax"`
+	Rmin     []vector.Vector3 `json:"rmin"`
+	NoEscape []vector.Vector3 `json:"noEscape"`
+	FlyOuts  int              `json:"flyOuts"`
+	WallTime float64          `json:"wallTime"` // seconds
+}
+
+// ComputeEnvelope finds Rmin, Rmax and the no-escape range along each radial
+// by scanning launch range with fast headless fly-outs and bisecting each
+// boundary. Radials are searched in parallel.
+func ComputeEnvelope(cfg EnvelopeConfig) (EnvelopeReport, error) {
+	if cfg.Radials == 0 {
+		cfg.Radials = defaultEnvelopeRadials
+	}
+	if cfg.MaxRange == 0 {
+		cfg.MaxRange = defaultEnvelopeMaxRange
+	}
+	if cfg.Steps == 0 {
+		cfg.Steps = defaultEnvelopeSteps
+	}
+	if cfg.BreakG == 0 {
+		cfg.BreakG = defaultEnvelopeBreakG
+	}
+	if cfg.LaunchSpeed == 0 {
+		cfg.LaunchSpeed = defaultLaunchSpeed
+	}
+	if cfg.MaxTime <= 0 {
+		cfg.MaxTime = DefaultMaxTime
+	}
+	if cfg.MaxTime > DefaultMaxTime {
+		return EnvelopeReport{}, fmt.Errorf("maxTime must be at most %g s", DefaultMaxTime)
+	}
+	if cfg.Radials < 3 || cfg.Radials > MaxEnvelopeRadials {
+		return EnvelopeReport{}, fmt.Errorf("radials must be between 3 and %d", MaxEnvelopeRadials)
+	}
+	if cfg.Steps < 2 || cfg.Steps > MaxEnvelopeSteps {
+		return EnvelopeReport{}, fmt.Errorf("steps must be between 2 and %d", MaxEnvelopeSteps)
+	}
+	if cfg.MaxRange <= 0 || cfg.LaunchSpeed < 0 || cfg.BreakG < 0 {
+		return EnvelopeReport{}, fmt.Errorf("maxRange must be positive and launchSpeed, breakG not negative")
+	}
+	if cfg.TargetPosition.Y <= 0 {
+		return EnvelopeReport{}, fmt.Errorf("target must be airborne")
+	}
+	start := time.Now()
+
+	report := EnvelopeReport{Config: cfg, Radials: make([]EnvelopeRadial, cfg.Radials)}
+	flyOuts := make([]int, cfg.Radials)
+	forEach(cfg.Radials, 0, func(i int) error {
+		s := envelopeSearch{cfg: cfg, bearing: 360 * float64(i) / float64(cfg.Radials)}
+		report.Radials[i] = s.radial()
+		flyOuts[i] = s.flyOuts
+		return nil
+	})
+
+	for i, r := range report.Radials {
+		report.FlyOuts += flyOuts[i]
+		report.Rmax = append(report.Rmax, envelopePoint(cfg, r.Bearing, r.Rmax))
+		report.Rmin = append(report.Rmin, envelopePoint(cfg, r.Bearing, r.Rmin))
+		report.NoEscape = append(report.NoEscape, envelopePoint(cfg, r.Bearing, r.NoEscape))
+	}
+	report.WallTime = time.Since(start).Seconds()
+	return report, nil
+}
+
+// envelopePoint is the ground point at the given bearing and range from the target.
+func envelopePoint(cfg EnvelopeConfig, bearing, rng float64) vector.Vector3 {
+	b := bearing * math.Pi / 180
+	return vector.Vector3{
+		X: cfg.TargetPosition.X + rng*math.Sin(b),
+		Z: cfg.TargetPosition.Z + rng*math.Cos(b),
+	}
+}
+
+// envelopeSearch searches one radial.
+type envelopeSearch struct {
+	cfg     EnvelopeConfig
+	bearing float64
+	flyOuts int
+}
+
+func (e *envelopeSearch) radial() EnvelopeRadial {
+	r := EnvelopeRadial{Bearing: e.bearing}
+	step := e.cfg.MaxRange / float64(e.cfg.Steps)
+
+	// Coarse scan for the first and last ranges that intercept.
+	first, last := -1, -1
+	for i := 1; i <= e.cfg.Steps; i++ {
+		if e.hit(float64(i)*step, 0) {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return r
+	}
+	r.Rmin = e.boundary(float64(first-1)*step, float64(first)*step, 0)
+	r.Rmax = float64(last) * step
+	if last < e.cfg.Steps {
+		r.Rmax = e.boundary(float64(last+1)*step, r.Rmax, 0)
+	}
+
+	// The no-escape zone can only be inside the envelope: scan down from Rmax.
+	for i := last; i >= first; i-- {
+		rng := float64(i) * step
+		if e.hit(rng, e.cfg.BreakG) && e.hit(rng, -e.cfg.BreakG) {
+			r.NoEscape = rng
+			if i < last {
+				r.NoEscape = e.boundary(float64(i+1)*step, rng, e.cfg.BreakG)
+			}
+			break
+		}
+	}
+	return r
+}
+
+// boundary bisects between a range that misses and one that hits, returning
+// the hit side of the final bracket.
+func (e *envelopeSearch) boundary(miss, hit, breakG float64) float64 {
+	for n := 0; n < envelopeRefinements; n++ {
+		mid := (miss + hit) / 2
+		ok := e.hit(mid, breakG)
+		if ok && breakG != 0 {
+			ok = e.hit(mid, -breakG)
+		}
+		if ok {
+			hit = mid
+		} else {
+			miss = mid
+		}
+	}
+	return hit
+}
+
+// hit flies one interceptor from the given range and reports whether it
+// intercepts. A nonzero breakG makes the target turn at that load factor,
+// positive to the right, from the moment of launch.
+func (e *envelopeSearch) hit(rng, breakG float64) bool {
+	if rng <= 0 {
+		return false
+	}
+	e.flyOuts++
+	site := envelopePoint(e.cfg, e.bearing, rng)
+	aim := leadAim(site, e.cfg.TargetPosition, e.cfg.TargetVelocity, e.cfg.LaunchSpeed)
+	target := scenario.Entity{
+		ID:       "target-1",
+		Role:     scenario.RoleTarget,
+		Position: e.cfg.TargetPosition,
+		Velocity: e.cfg.TargetVelocity,
+	}
+	if breakG != 0 {
+		target.Maneuvers = []scenario.Maneuver{{Type: scenario.ManeuverTurn, Duration: e.cfg.MaxTime, G: breakG}}
+	}
+	sc := &scenario.Scenario{
+		Name: "envelope",
+		Entities: []scenario.Entity{target, {
+			ID:       "missile-1",
+			Role:     scenario.RoleInterceptor,
+			Position: site,
+			Velocity: aim.Mul(e.cfg.LaunchSpeed),
+			MaxAccel: e.cfg.MaxAccel,
+			Guidance: e.cfg.Guidance,
+			Seeker:   e.cfg.Seeker,
+		}},
+		Radar: &scenario.RadarSpec{Position: site.Add(vector.Vector3{Y: 10})},
+		// A fly-out that overshoots the geometry or bleeds off its speed
+		// has missed; end it there rather than at MaxTime.
+		Termination: scenario.Termination{
+			MaxTime:  e.cfg.MaxTime,
+			MinSpeed: envelopeMinSpeed,
+			Bounds:   envelopeBounds(site, e.cfg.TargetPosition, rng),
+		},
+	}
+	st, err := runHeadless(sc, 1, e.cfg.MaxTime)
+	return err == nil && st.Intercept
+}
+
+// leadAim returns the direction to launch from site at speed to meet a
+// target holding its velocity, or straight at the target when it cannot
+// be caught.
+func leadAim(site, target, velocity vector.Vector3, speed float64) vector.Vector3 {
+	r := target.Sub(site)
+	if t, ok := intercept.Time(r, velocity, vector.Vector3{}, speed); ok {
+		r = intercept.Point(r, velocity, vector.Vector3{}, t)
+	}
+	return r.Normalize()
+}
+
+// envelopeBounds is the box around the launch site and target, padded by the
+// launch range, or envelopeMinPad if larger, on every side.
+func envelopeBounds(site, target vector.Vector3, rng float64) *scenario.Bounds {
+	p := max(rng, envelopeMinPad)
+	pad := vector.Vector3{X: p, Y: p, Z: p}
+	return &scenario.Bounds{
+		Min: vector.Vector3{X: min(site.X, target.X), Y: min(site.Y, target.Y), Z: min(site.Z, target.Z)}.Sub(pad),
+		Max: vector.Vector3{X: max(site.X, target.X), Y: max(site.Y, target.Y), Z: max(site.Z, target.Z)}.Add(pad),
+	}
+}
+
+// runHeadless runs a scenario to completion with every per-frame extra
+// turned off.
+func runHeadless(sc *scenario.Scenario, seed uint64, maxTime float64) (SimulationState, error) {
+	sim := NewSimulator()
+	sim.SetLogger(logging.Discard)
+	sim.HistoryDuration = 0
+	sim.TrailDuration = 0
+	sim.Seed = seed
+	if err := sim.LoadScenario(sc); err != nil {
+		return SimulationState{}, err
+	}
+	return sim.RunToCompletion(maxTime), nil
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// APIError is the body of every error response.
+type APIError struct {
+	Code    string `json:"code"` // stable, machine-readable; see errorCodes
+	Message string `json:"message"`
+	Details any    `json:"details,omitempty"`
+}
+
+// errorCodes names the error for each status the API returns.
+var errorCodes = map[int]string{
+	http.StatusBadRequest:            "invalid_request",
+	http.StatusUnauthorized:          "unauthorized",
+	http.StatusForbidden:             "forbidden",
+	http.StatusNotFound:              "not_found",
+	http.StatusMethodNotAllowed:      "method_not_allowed",
+	http.StatusConflict:              "conflict",
+	http.StatusRequestEntityTooLarge: "too_large",
+	http.StatusUnprocessableEntity:   "validation_failed",
+	http.StatusTooManyRequests:       "rate_limited",
+	http.StatusInternalServerError:   "internal",
+	http.StatusServiceUnavailable:    "unavailable",
+}
+
+// writeError replies with a JSON APIError, the structured counterpart of
+// http.Error.
+func writeError(w http.ResponseWriter, message string, status int) {
+	writeErrorDetails(w, message, status, nil)
+}
+
+// writeErrorDetails is writeError with extra data for the client, such as
+// the accepted values of a rejected field.
+func writeErrorDetails(w http.ResponseWriter, message string, status int, details any) {
+	code, ok := errorCodes[status]
+	if !ok {
+		code = "error"
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(APIError{Code: code, Message: message, Details: details})
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestWriteError(t *testing.T) {
+	tests := []struct {
+		status  int
+		details any
+		code    string
+	}{
+		{http.StatusBadRequest, nil, "invalid_request"},
+		{http.StatusNotFound, nil, "not_found"},
+		{http.StatusMethodNotAllowed, nil, "method_not_allowed"},
+		{http.StatusConflict, []any{"a", "b"}, "conflict"},
+		{http.StatusTeapot, nil, "error"},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		writeErrorDetails(rec, "boom", tt.status, tt.details)
+		if rec.Code != tt.status || rec.Header().Get("Content-Type") != "application/json" {
+			t.Errorf("status %d: got %d %q", tt.status, rec.Code, rec.Header().Get("Content-Type"))
+		}
+		var got APIError
+		if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+			t.Fatalf("status %d: %v in %q", tt.status, err, rec.Body)
+		}
+		if got.Code != tt.code || got.Message != "boom" || (tt.details == nil) != (got.Details == nil) {
+			t.Errorf("status %d: body %+v, want code %q", tt.status, got, tt.code)
+		}
+	}
+}
+
+func TestHandlersReturnJSONErrors(t *testing.T) {
+	sessions = NewSessionManager()
+	finished := sessions.Create()
+	defer sessions.Delete(finished.ID)
+	finished.Sim.RunToCompletion(simulation.DefaultMaxTime)
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		target  string
+		status  int
+	}{
+		{"wrong method", handleStart, http.MethodGet, "/api/v1/start", http.StatusMethodNotAllowed},
+		{"unknown session", handleState, http.MethodGet, "/api/v1/state?session=nope", http.StatusNotFound},
+		{"bad body", handleGuidance, http.MethodPost, "/api/v1/guidance", http.StatusBadRequest},
+		{"start finished run", handleStart, http.MethodPost, "/api/v1/start?session=" + finished.ID, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(tt.method, tt.target, nil))
+			var got APIError
+			if rec.Code != tt.status || json.Unmarshal(rec.Body.Bytes(), &got) != nil || got.Code != errorCodes[tt.status] {
+				t.Errorf("got %d %q, want %d with code %q", rec.Code, rec.Body, tt.status, errorCodes[tt.status])
+			}
+		})
+	}
+}
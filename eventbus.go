@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"slices"
+	"time"
+
+	"missile-intercept-sim/internal/bus"
+)
+
+// busStreamer streams every session's state frames and events to an event
+// bus for archiving and offline analysis. Frames go to <prefix>.state and
+// events to <prefix>.events, keyed by session ID. A frame is only sent when
+// the state has moved on, so a stopped session costs nothing.
+type busStreamer struct {
+	url      string
+	prefix   string
+	interval time.Duration
+}
+
+func newBusStreamer(rawURL, prefix string, rate float64) (*busStreamer, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || !slices.Contains(bus.Schemes(), u.Scheme) {
+		return nil, fmt.Errorf("event bus URL must use one of %v, got %q", bus.Schemes(), rawURL)
+	}
+	if !(rate > 0 && rate <= maxClientRate) {
+		return nil, fmt.Errorf("event bus rate must be above 0 and at most %d", maxClientRate)
+	}
+	return &busStreamer{url: rawURL, prefix: prefix, interval: time.Duration(float64(time.Second) / rate)}, nil
+}
+
+// Run streams until ctx is done, reopening the bus with backoff whenever
+// publishing fails.
+func (s *busStreamer) Run(ctx context.Context) {
+	backoff := reconnectMinBackoff
+	for ctx.Err() == nil {
+		p, err := bus.Open(s.url)
+		if err == nil {
+			slog.Info("bus: streaming", "prefix", s.prefix)
+			backoff = reconnectMinBackoff
+			err = s.stream(ctx, p)
+			p.Close()
+		}
+		if err == nil {
+			return
+		}
+		slog.Warn("bus: retrying", "err", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, reconnectMaxBackoff)
+	}
+}
+
+// stream publishes on p until ctx is done or a publish fails.
+func (s *busStreamer) stream(ctx context.Context, p bus.Publisher) error {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	seen := make(eventCursor)
+	sent := make(map[*Session]ResumeToken)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := s.tick(p, seen, sent); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// tick publishes each session's new events and, if it changed since the
+// last one sent, its current frame.
+func (s *busStreamer) tick(p bus.Publisher, seen eventCursor, sent map[*Session]ResumeToken) error {
+	live := sessions.List()
+	for _, sess := range live {
+		for _, ev := range seen.next(sess) {
+			msg, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			if err := p.Publish(s.prefix+".events", sess.ID, msg); err != nil {
+				return err
+			}
+		}
+		state := sess.State()
+		tok := ResumeToken{state.Run, state.Time}
+		if last, ok := sent[sess]; ok && last == tok {
+			continue
+		}
+		msg, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := p.Publish(s.prefix+".state", sess.ID, msg); err != nil {
+			return err
+		}
+		sent[sess] = tok
+	}
+	seen.prune(live)
+	for sess := range sent {
+		if _, ok := seen[sess]; !ok {
+			delete(sent, sess)
+		}
+	}
+	return nil
+}
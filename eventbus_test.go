@@ -0,0 +1,64 @@
+package main
+
+import (
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+// busRecorder collects what the streamer publishes, by topic.
+type busRecorder map[string][]string
+
+func (r busRecorder) Publish(topic, key string, data []byte) error {
+	r[topic] = append(r[topic], key)
+	return nil
+}
+
+func (r busRecorder) Close() error { return nil }
+
+func TestBusStreamer(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	s, err := newBusStreamer("nats://localhost:4222", "sim", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := newBusStreamer("redis://localhost:6379", "sim", 10); err == nil {
+		t.Error("accepted a bus with no driver")
+	}
+	seen, sent := make(eventCursor), make(map[*Session]ResumeToken)
+
+	tests := []struct {
+		name          string
+		advance       int
+		frames, event bool
+	}{
+		{"first frame", 0, true, false},
+		{"unchanged", 0, false, false},
+		{"moved on", 1200, true, true},
+	}
+	for _, tt := range tests {
+		if tt.advance > 0 {
+			if _, err := sess.Sim.Advance(tt.advance); err != nil {
+				t.Fatal(err)
+			}
+		}
+		rec := busRecorder{}
+		if err := s.tick(rec, seen, sent); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(rec["sim.state"]) == 1; got != tt.frames {
+			t.Errorf("%s: frame sent = %v, want %v", tt.name, got, tt.frames)
+		}
+		if got := len(rec["sim.events"]) > 0; got != tt.event {
+			t.Errorf("%s: events sent = %v, want %v", tt.name, got, tt.event)
+		}
+		for _, key := range append(rec["sim.state"], rec["sim.events"]...) {
+			if key != sess.ID {
+				t.Errorf("%s: keyed %q, want the session ID", tt.name, key)
+			}
+		}
+	}
+}
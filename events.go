@@ -0,0 +1,94 @@
+package simulation
+
+import "context"
+
+// maxEvents bounds the in-memory event log; the oldest events are dropped first.
+const maxEvents = 1000
+
+// Event types.
+const (
+	EventLaunch      = "Launch"
+	EventPhase       = "Phase"    // run status changed
+	EventLock        = "Lock"     // seeker acquired its target
+	EventLockLost    = "LockLost" // seeker lost its target
+	EventRetarget    = "Retarget"
+	EventHandoff     = "Handoff" // threat passed to the next defense tier
+	EventIntercept   = "Intercept"
+	EventCrash       = "Crash"
+	EventOutOfBounds = "OutOfBounds"
+	EventSpent       = "Spent"    // interceptor fell below the minimum speed
+	EventImpact      = "Impact"   // target reached the ground
+	EventCommand     = "Command"  // a client changed the simulation
+	EventOverrun     = "Overrun"  // steps took longer than real time allows
+	EventDegraded    = "Degraded" // fidelity was reduced to keep up with real time
+	EventFault       = "Fault"    // the simulation panicked; Detail holds the stack
+)
+
+// Event is one entry in the simulation event log.
+type Event struct {
+	Seq      uint64  `json:"seq"`  // increases monotonically for the simulator's lifetime
+	Time     float64 `json:"time"` // simulation time
+	Type     string  `json:"type"`
+	EntityID string  `json:"entityId,omitempty"`
+	Message  string  `json:"message"`
+	// RequestID is the API request a Command event came from, matching the
+	// server's access log.
+	RequestID string `json:"requestId,omitempty"`
+	Detail    string `json:"detail,omitempty"` // the stack of a Fault
+}
+
+// logEventLocked appends an event stamped with the current simulation time.
+// Callers must hold s.mu.
+func (s *Simulator) logEventLocked(typ, entityID, msg string) {
+	s.appendEventLocked(Event{Type: typ, EntityID: entityID, Message: msg})
+}
+
+// appendEventLocked numbers and stamps ev, appends it to the log and hands
+// it to subscribers. Callers must hold s.mu.
+func (s *Simulator) appendEventLocked(ev Event) {
+	s.eventSeq++
+	ev.Seq = s.eventSeq
+	ev.Time = s.State.Time
+	s.events = append(s.events, ev)
+	if len(s.events) > maxEvents {
+		s.events = s.events[len(s.events)-maxEvents:]
+	}
+	s.publishEventLocked(ev)
+	if l, level := s.eventLogger(ev.Type); l.Enabled(context.Background(), level) {
+		args := []any{"event", ev.Type, "entity", ev.EntityID, "run", s.State.Run, "t", ev.Time}
+		if ev.Detail != "" {
+			args = append(args, "detail", ev.Detail)
+		}
+		l.Log(context.Background(), level, ev.Message, args...)
+	}
+}
+
+// LogCommand records that the API request requestID changed the simulation.
+func (s *Simulator) LogCommand(requestID, msg string) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.appendEventLocked(Event{Type: EventCommand, Message: msg, RequestID: requestID})
+}
+
+// setStatusLocked changes the run status, logging the phase change.
+// Callers must hold s.mu.
+func (s *Simulator) setStatusLocked(status string) {
+	if s.State.Status == status {
+		return
+	}
+	s.State.Status = status
+	s.logEventLocked(EventPhase, "", status)
+}
+
+// Events returns logged events with a sequence number greater than since,
+// oldest first. Pass 0 for the whole log.
+func (s *Simulator) Events(since uint64) []Event {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for i, ev := range s.events {
+		if ev.Seq > since {
+			return append([]Event(nil), s.events[i:]...)
+		}
+	}
+	return nil
+}
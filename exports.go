@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// handleExportCSV downloads the recording named by ?run= as CSV time
+// series, optionally only the entity named by ?entity=. Every export takes
+// ?rate= to resample the recording to that many frames per simulated
+// second.
+func handleExportCSV(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	name := r.URL.Query().Get("run")
+	rec, err := simulation.LoadRecording(recordingsDir, name)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if rec, err = resampled(r, rec); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/csv")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+rec.Name+`.csv"`)
+	if err := simulation.WriteCSV(w, rec, r.URL.Query().Get("entity")); err != nil {
+		slog.Warn("export", "format", "csv", "err", err)
+	}
+}
+
+// handleExportACMI downloads the recording named by ?run= as a Tacview ACMI
+// file, placing the local frame's origin at ?lat=, ?lon= and ?alt=.
+func handleExportACMI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	origin, err := parseOrigin(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rec, err := simulation.LoadRecording(recordingsDir, r.URL.Query().Get("run"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if rec, err = resampled(r, rec); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+rec.Name+`.txt.acmi"`)
+	if err := simulation.WriteACMI(w, rec, origin); err != nil {
+		slog.Warn("export", "format", "acmi", "err", err)
+	}
+}
+
+// maxResampleRate bounds ?rate=, in frames per simulated second, so a long
+// recording cannot be blown up beyond memory.
+const maxResampleRate = 1000
+
+// resampled returns rec resampled to ?rate= frames per simulated second,
+// or rec itself when no rate is given.
+func resampled(r *http.Request, rec *simulation.Recording) (*simulation.Recording, error) {
+	v := r.URL.Query().Get("rate")
+	if v == "" {
+		return rec, nil
+	}
+	rate, err := strconv.ParseFloat(v, 64)
+	if err != nil || !(rate > 0 && rate <= maxResampleRate) {
+		return nil, fmt.Errorf("rate must be above 0 and at most %d", maxResampleRate)
+	}
+	return rec.Resample(1 / rate), nil
+}
+
+// parseOrigin reads the geodetic origin of the local frame from ?lat=, ?lon=
+// and ?alt=, each defaulting to zero.
+func parseOrigin(r *http.Request) (geo.Origin, error) {
+	var o geo.Origin
+	q := r.URL.Query()
+	for _, p := range []struct {
+		name string
+		dst  *float64
+	}{{"lat", &o.Lat}, {"lon", &o.Lon}, {"alt", &o.Alt}} {
+		if v := q.Get(p.name); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				return o, fmt.Errorf("invalid %s %q", p.name, v)
+			}
+			*p.dst = f
+		}
+	}
+	if !o.Valid() {
+		return o, fmt.Errorf("origin %g,%g is out of range", o.Lat, o.Lon)
+	}
+	return o, nil
+}
+
+// handleExportGlobe downloads the recording named by ?run= for a globe
+// viewer: CZML for Cesium by default, or KML for Google Earth with
+// ?format=kml. The local frame's origin is placed as for ACMI.
+func handleExportGlobe(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	write, ext, mime := simulation.WriteCZML, ".czml", "application/json"
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "czml":
+	case "kml":
+		write, ext, mime = simulation.WriteKML, ".kml", "application/vnd.google-earth.kml+xml"
+	default:
+		writeError(w, "Unknown format "+strconv.Quote(format), http.StatusBadRequest)
+		return
+	}
+	origin, err := parseOrigin(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rec, err := simulation.LoadRecording(recordingsDir, r.URL.Query().Get("run"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if rec, err = resampled(r, rec); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", mime)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+rec.Name+ext+`"`)
+	if err := write(w, rec, origin); err != nil {
+		slog.Warn("export", "format", "globe", "err", err)
+	}
+}
+
+// handleExportGeoJSON downloads the recording named by ?run= as GeoJSON for
+// GIS tools: ground tracks, launch, intercept and impact points, and the
+// predicted debris impacts of intercepted targets. The local frame's origin
+// is placed as for ACMI.
+func handleExportGeoJSON(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	origin, err := parseOrigin(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rec, err := simulation.LoadRecording(recordingsDir, r.URL.Query().Get("run"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if rec, err = resampled(r, rec); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/geo+json")
+	w.Header().Set("Content-Disposition", `attachment; filename="`+rec.Name+`.geojson"`)
+	if err := simulation.WriteGeoJSON(w, rec, origin); err != nil {
+		slog.Warn("export", "format", "geojson", "err", err)
+	}
+}
+
+// handleExportFrames downloads the recording named by ?run= drawn as plan
+// and profile plots: a ZIP of one PNG per frame by default, or with
+// ?last=true a single PNG of the whole run. ?width= sets the image width
+// in pixels and ?every= draws only every nth frame.
+func handleExportFrames(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	var opts simulation.PlotOptions
+	for _, p := range []struct {
+		name string
+		dst  *int
+	}{{"width", &opts.Width}, {"every", &opts.Every}} {
+		if v := q.Get(p.name); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				writeError(w, fmt.Sprintf("invalid %s %q", p.name, v), http.StatusBadRequest)
+				return
+			}
+			*p.dst = n
+		}
+	}
+	opts.Last, _ = strconv.ParseBool(q.Get("last"))
+	if err := opts.Validate(); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rec, err := simulation.LoadRecording(recordingsDir, q.Get("run"))
+	if err != nil {
+		writeError(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	if rec, err = resampled(r, rec); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	write, ext, mime := simulation.WritePlotZip, ".zip", "application/zip"
+	if opts.Last {
+		write, ext, mime = simulation.WritePlotPNG, ".png", "image/png"
+	} else if images := opts.Images(len(rec.Frames)); images > simulation.MaxPlotFrames {
+		writeError(w, fmt.Sprintf("%d images is more than %d; raise every or lower rate", images, simulation.MaxPlotFrames), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", mime)
+	w.Header().Set("Content-Disposition", `attachment; filename="`+rec.Name+ext+`"`)
+	if err := write(w, rec, opts); err != nil {
+		slog.Warn("export", "format", "frames", "err", err)
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestParseOrigin(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    geo.Origin
+		wantErr bool
+	}{
+		{"", geo.Origin{}, false},
+		{"lat=36.2&lon=-115.03&alt=900", geo.Origin{Lat: 36.2, Lon: -115.03, Alt: 900}, false},
+		{"lat=north", geo.Origin{}, true},
+		{"lat=91", geo.Origin{}, true},
+		{"lon=NaN", geo.Origin{}, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/export/acmi?"+tt.query, nil)
+		got, err := parseOrigin(r)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%q: got %+v, want %+v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestResampled(t *testing.T) {
+	rec := &simulation.Recording{Dt: 0.01, Frames: []simulation.SimulationState{{Time: 0}, {Time: 1}, {Time: 2}}}
+	tests := []struct {
+		query   string
+		frames  int
+		wantErr bool
+	}{
+		{"", 3, false},
+		{"rate=10", 21, false},
+		{"rate=0.5", 2, false},
+		{"rate=0", 0, true},
+		{"rate=-1", 0, true},
+		{"rate=1e6", 0, true},
+		{"rate=fast", 0, true},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/api/v1/export/csv?"+tt.query, nil)
+		got, err := resampled(r, rec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.query, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && len(got.Frames) != tt.frames {
+			t.Errorf("%q: %d frames, want %d", tt.query, len(got.Frames), tt.frames)
+		}
+	}
+}
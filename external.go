@@ -0,0 +1,248 @@
+package simulation
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/guidance"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// GuidanceExternal is the guidance mode that asks an attached external
+// process for each acceleration command.
+const GuidanceExternal = "External"
+
+// DefaultExternalTimeout is how long a step waits for the external process
+// when no deadline is given.
+const DefaultExternalTimeout = 50 * time.Millisecond
+
+// maxExternalTimeout bounds the deadline, since the step blocks the
+// simulation lock for as long as it waits.
+const maxExternalTimeout = 5 * time.Second
+
+// GuidanceRequest is the datagram sent to the external process each step for
+// each interceptor in flight.
+type GuidanceRequest struct {
+	Seq     uint64           `json:"seq"`
+	Time    float64          `json:"time"`
+	Dt      float64          `json:"dt"`
+	Missile *entities.Entity `json:"missile"`
+	Target  *entities.Entity `json:"target"` // the seeker's estimate, not truth
+}
+
+// GuidanceReply is the datagram the external process answers with. Seq must
+// echo the request's so late replies to earlier steps are discarded.
+type GuidanceReply struct {
+	Seq          uint64         `json:"seq"`
+	Acceleration vector.Vector3 `json:"acceleration"`
+}
+
+// ExternalStatus describes the attached external guidance link.
+type ExternalStatus struct {
+	Addr     string  `json:"addr"`
+	Timeout  float64 `json:"timeoutMs"`
+	Requests uint64  `json:"requests"`
+	Replies  uint64  `json:"replies"`
+	Misses   uint64  `json:"misses"` // steps flown on the fallback law
+}
+
+// ExternalGuidance is a guidance law computed by another process over UDP,
+// for testing algorithms written outside Go against this physics engine. Each
+// call sends a GuidanceRequest and waits up to the timeout for the matching
+// GuidanceReply. A step that gets no answer in time flies ProNav instead, so
+// a slow or missing process degrades the engagement rather than stalling it.
+type ExternalGuidance struct {
+	conn     net.Conn
+	timeout  time.Duration
+	fallback guidance.GuidanceLaw
+	now      func() float64 // simulation time, read under the simulator's lock
+	seq      uint64
+	buf      []byte
+	requests atomic.Uint64
+	replies  atomic.Uint64
+	misses   atomic.Uint64
+}
+
+// DialExternalGuidance connects to an external guidance process listening
+// for datagrams at addr. A zero timeout means DefaultExternalTimeout.
+func DialExternalGuidance(addr string, timeout time.Duration) (*ExternalGuidance, error) {
+	if timeout == 0 {
+		timeout = DefaultExternalTimeout
+	}
+	if timeout < 0 || timeout > maxExternalTimeout {
+		return nil, errors.New("timeout must be between 0 and 5s")
+	}
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, err
+	}
+	return &ExternalGuidance{
+		conn:     conn,
+		timeout:  timeout,
+		fallback: guidance.GetFactory("ProNav"),
+		now:      func() float64 { return 0 },
+		buf:      make([]byte, 64*1024),
+	}, nil
+}
+
+// CalculateAcceleration asks the external process for the missile's command.
+func (g *ExternalGuidance) CalculateAcceleration(missile, target *entities.Entity, dt float64) vector.Vector3 {
+	if a, ok := g.ask(missile, target, dt); ok {
+		return a
+	}
+	g.misses.Add(1)
+	return g.fallback.CalculateAcceleration(missile, target, dt)
+}
+
+// ask runs one request/reply exchange, reporting false on timeout or error.
+func (g *ExternalGuidance) ask(missile, target *entities.Entity, dt float64) (vector.Vector3, bool) {
+	g.seq++
+	data, err := json.Marshal(GuidanceRequest{g.seq, g.now(), dt, missile, target})
+	if err != nil {
+		return vector.Vector3{}, false
+	}
+	g.requests.Add(1)
+	if _, err := g.conn.Write(data); err != nil {
+		return vector.Vector3{}, false
+	}
+	g.conn.SetReadDeadline(time.Now().Add(g.timeout))
+	for {
+		n, err := g.conn.Read(g.buf)
+		if err != nil {
+			return vector.Vector3{}, false
+		}
+		var reply GuidanceReply
+		if json.Unmarshal(g.buf[:n], &reply) != nil || reply.Seq != g.seq {
+			continue
+		}
+		g.replies.Add(1)
+		return reply.Acceleration, true
+	}
+}
+
+// Status reports the link's address and counters.
+func (g *ExternalGuidance) Status() ExternalStatus {
+	return ExternalStatus{
+		Addr:     g.conn.RemoteAddr().String(),
+		Timeout:  float64(g.timeout) / float64(time.Millisecond),
+		Requests: g.requests.Load(),
+		Replies:  g.replies.Load(),
+		Misses:   g.misses.Load(),
+	}
+}
+
+// Close releases the link's socket.
+func (g *ExternalGuidance) Close() error {
+	return g.conn.Close()
+}
+
+// CommandGuidance flies each interceptor on the last acceleration commanded
+// for it, for co-simulation masters that push commands alongside each step
+// rather than answering requests. An interceptor with no command flies
+// ProNav.
+type CommandGuidance struct {
+	addr     string
+	fallback guidance.GuidanceLaw
+	mu       sync.Mutex
+	commands map[string]vector.Vector3
+	requests atomic.Uint64
+	replies  atomic.Uint64
+	misses   atomic.Uint64
+}
+
+// NewCommandGuidance returns a law with no commands yet, fed from addr.
+func NewCommandGuidance(addr string) *CommandGuidance {
+	return &CommandGuidance{
+		addr:     addr,
+		fallback: guidance.GetFactory("ProNav"),
+		commands: make(map[string]vector.Vector3),
+	}
+}
+
+// SetCommands updates the commands for the interceptors named in cmds,
+// keeping the others' last ones.
+func (c *CommandGuidance) SetCommands(cmds map[string]vector.Vector3) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for id, a := range cmds {
+		c.commands[id] = a
+	}
+	c.replies.Add(uint64(len(cmds)))
+}
+
+// CalculateAcceleration returns the missile's last command.
+func (c *CommandGuidance) CalculateAcceleration(missile, target *entities.Entity, dt float64) vector.Vector3 {
+	c.requests.Add(1)
+	c.mu.Lock()
+	a, ok := c.commands[missile.ID]
+	c.mu.Unlock()
+	if ok {
+		return a
+	}
+	c.misses.Add(1)
+	return c.fallback.CalculateAcceleration(missile, target, dt)
+}
+
+// Status reports where commands come from and the counters.
+func (c *CommandGuidance) Status() ExternalStatus {
+	return ExternalStatus{
+		Addr:     c.addr,
+		Requests: c.requests.Load(),
+		Replies:  c.replies.Load(),
+		Misses:   c.misses.Load(),
+	}
+}
+
+// Close does nothing; the master owns the connection.
+func (c *CommandGuidance) Close() error { return nil }
+
+// ExternalLaw is a guidance law supplied from outside the simulator.
+type ExternalLaw interface {
+	guidance.GuidanceLaw
+	Status() ExternalStatus
+	Close() error
+}
+
+// guidanceLawLocked returns the law for a guidance mode. External falls back
+// to ProNav while no process is attached. Callers must hold s.mu.
+func (s *Simulator) guidanceLawLocked(mode string) guidance.GuidanceLaw {
+	if mode == GuidanceExternal && s.external != nil {
+		return s.external
+	}
+	return guidance.GetFactory(mode)
+}
+
+// SetExternalGuidance attaches an external guidance law, closing any
+// previous one, or detaches it when g is nil. Interceptors flying External
+// switch to the new law, or to ProNav on detach.
+func (s *Simulator) SetExternalGuidance(g ExternalLaw) {
+	s.lock()
+	defer s.mu.Unlock()
+	if s.external != nil {
+		s.external.Close()
+	}
+	s.external = g
+	if eg, ok := g.(*ExternalGuidance); ok {
+		eg.now = func() float64 { return s.State.Time }
+	}
+	for _, ic := range s.Interceptors {
+		if ic.GuidanceName == GuidanceExternal {
+			ic.GuidanceLaw = s.guidanceLawLocked(ic.GuidanceName)
+		}
+	}
+}
+
+// ExternalGuidanceStatus reports the attached external guidance link, if any.
+func (s *Simulator) ExternalGuidanceStatus() (ExternalStatus, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.external == nil {
+		return ExternalStatus{}, false
+	}
+	return s.external.Status(), true
+}
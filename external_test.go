@@ -0,0 +1,117 @@
+package simulation
+
+import (
+	"encoding/json"
+	"net"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// guidanceServer answers each request on a local UDP socket with whatever
+// respond returns, sending nothing for a nil reply.
+func guidanceServer(t *testing.T, respond func(GuidanceRequest) []GuidanceReply) string {
+	t.Helper()
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { pc.Close() })
+	go func() {
+		buf := make([]byte, 64*1024)
+		for {
+			n, addr, err := pc.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var req GuidanceRequest
+			if json.Unmarshal(buf[:n], &req) != nil {
+				continue
+			}
+			for _, reply := range respond(req) {
+				data, _ := json.Marshal(reply)
+				pc.WriteTo(data, addr)
+			}
+		}
+	}()
+	return pc.LocalAddr().String()
+}
+
+func TestExternalGuidance(t *testing.T) {
+	cmd := vector.Vector3{X: 1, Y: 2, Z: 3}
+	tests := []struct {
+		name     string
+		respond  func(GuidanceRequest) []GuidanceReply
+		wantCmd  bool
+		wantMiss uint64
+	}{
+		{"answers", func(req GuidanceRequest) []GuidanceReply {
+			return []GuidanceReply{{req.Seq, cmd}}
+		}, true, 0},
+		{"silent", func(GuidanceRequest) []GuidanceReply { return nil }, false, 1},
+		{"stale", func(req GuidanceRequest) []GuidanceReply {
+			return []GuidanceReply{{req.Seq - 1, cmd}}
+		}, false, 1},
+		{"late answer after stale", func(req GuidanceRequest) []GuidanceReply {
+			return []GuidanceReply{{req.Seq + 1, vector.Vector3{}}, {req.Seq, cmd}}
+		}, true, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := DialExternalGuidance(guidanceServer(t, tt.respond), 20*time.Millisecond)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer g.Close()
+			missile := &entities.Entity{ID: "m", Velocity: vector.Vector3{X: 300}}
+			target := &entities.Entity{ID: "t", Position: vector.Vector3{X: 2000, Y: 500}, Velocity: vector.Vector3{Z: 200}}
+			got := g.CalculateAcceleration(missile, target, 0.01)
+			if (got == cmd) != tt.wantCmd {
+				t.Errorf("command %+v, want external %v", got, tt.wantCmd)
+			}
+			if st := g.Status(); st.Requests != 1 || st.Misses != tt.wantMiss {
+				t.Errorf("status %+v, want 1 request and %d misses", st, tt.wantMiss)
+			}
+		})
+	}
+}
+
+func TestExternalGuidanceMode(t *testing.T) {
+	times := make(chan float64, 100)
+	addr := guidanceServer(t, func(req GuidanceRequest) []GuidanceReply {
+		times <- req.Time
+		return []GuidanceReply{{Seq: req.Seq}}
+	})
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	s.Seed = 42
+	s.Reset()
+	g, err := DialExternalGuidance(addr, time.Second)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetExternalGuidance(g)
+	defer s.SetExternalGuidance(nil)
+	s.SetGuidanceMode(GuidanceExternal)
+	if _, err := s.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+	st, ok := s.ExternalGuidanceStatus()
+	if !ok || st.Requests == 0 || st.Misses != 0 {
+		t.Fatalf("status %+v, attached %v", st, ok)
+	}
+	if first := <-times; first < 0 || first > s.GetState().Time {
+		t.Errorf("request stamped t=%v", first)
+	}
+
+	s.SetExternalGuidance(nil)
+	if _, ok := s.ExternalGuidanceStatus(); ok {
+		t.Error("link still attached after detach")
+	}
+	if _, err := s.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+}
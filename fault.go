@@ -0,0 +1,56 @@
+package simulation
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+)
+
+// StatusFaulted is the status of a run ended by a panic in its step, such
+// as a guidance law dividing by zero. The world is left as the step
+// abandoned it and no outcome report is made; Reset flies again.
+const StatusFaulted = "Faulted"
+
+// recoverStepLocked, deferred by stepLocked, faults the run if the step
+// panics, so one broken scenario costs its own run rather than the process.
+// Callers must hold s.mu.
+func (s *Simulator) recoverStepLocked() {
+	if p := recover(); p != nil {
+		s.faultLocked(p, debug.Stack())
+	}
+}
+
+// recoverLoop, deferred by the loop goroutines, faults the run if the loop
+// whose context is ctx panics outside a step, such as while publishing the
+// state. A loop that was already halted no longer owns the run, so its
+// panic is only logged.
+func (s *Simulator) recoverLoop(ctx context.Context) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	stack := debug.Stack()
+	s.lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		s.logs.server.Error("halted loop panicked", "panic", p, "stack", string(stack))
+		return
+	}
+	s.faultLocked(p, stack)
+}
+
+// faultLocked logs the panic p, with its stack, as a Fault event and ends
+// the run as Faulted if it had not already ended. Callers must hold s.mu.
+func (s *Simulator) faultLocked(p any, stack []byte) {
+	s.faults.Add(1)
+	s.appendEventLocked(Event{Type: EventFault, Message: fmt.Sprintf("Simulation panicked: %v", p), Detail: string(stack)})
+	if s.State.Lifecycle == LifecycleFinished {
+		return
+	}
+	s.State.Reason = ReasonFault
+	// A run only steps while running or paused, and both may finish.
+	s.transitionLocked("fault", LifecycleFinished)
+	s.setStatusLocked(StatusFaulted)
+	s.haltLocked()
+	s.finishRecordingLocked()
+}
@@ -0,0 +1,74 @@
+package simulation
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestFault(t *testing.T) {
+	tests := []struct {
+		name string
+		fly  func(s *Simulator)
+	}{
+		{"step", func(s *Simulator) { s.Advance(10) }},
+		{"run", func(s *Simulator) { s.RunToCompletion(DefaultMaxTime) }},
+		{"loop", func(s *Simulator) {
+			s.Start()
+			for deadline := time.Now().Add(time.Second); s.GetState().Lifecycle == LifecycleRunning && time.Now().Before(deadline); {
+				time.Sleep(loopInterval)
+			}
+			s.Stop() // waits for the loop to exit
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSimulator()
+			s.SetLogger(logging.Discard)
+			s.TimeScale = TimeScaleAFAP
+			bystander := NewSimulator()
+			bystander.SetLogger(logging.Discard)
+			s.AddRule("broken", RuleFunc(func(ctx *RuleContext) {
+				if ctx.State.Time > 0.05 {
+					var m map[string]int
+					m["boom"]++
+				}
+			}))
+
+			tt.fly(s)
+			st := s.GetState()
+			if st.Status != StatusFaulted || st.Reason != ReasonFault || st.Lifecycle != LifecycleFinished {
+				t.Fatalf("%s %s %s, want a faulted run", st.Status, st.Reason, st.Lifecycle)
+			}
+			var fault *Event
+			for _, ev := range s.Events(0) {
+				if ev.Type == EventFault {
+					fault = &ev
+				}
+			}
+			if fault == nil || !strings.Contains(fault.Message, "nil map") || !strings.Contains(fault.Detail, "fault_test.go") {
+				t.Fatalf("fault event %+v", fault)
+			}
+			if h := s.Health(); h.Faults != 1 || h.Running {
+				t.Errorf("health %+v, want one fault and no loop", h)
+			}
+			var te *TransitionError
+			if err := s.Start(); !errors.As(err, &te) {
+				t.Errorf("started a faulted run: %v", err)
+			}
+
+			// The faulted simulator resets, and others never noticed.
+			s.RemoveRule("broken")
+			s.Reset()
+			if _, err := s.Advance(10); err != nil {
+				t.Errorf("after reset: %v", err)
+			}
+			if _, err := bystander.Advance(10); err != nil || bystander.Health().Faults != 0 {
+				t.Errorf("bystander: %v", err)
+			}
+		})
+	}
+}
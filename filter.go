@@ -0,0 +1,142 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// StreamFilter narrows the state a client is sent, for lightweight clients
+// such as dashboards that only plot positions.
+type StreamFilter struct {
+	Entities []string // entity IDs to send, nil for all
+	Fields   []string // state fields to send, "entities.<field>" for entity fields; nil for all
+}
+
+// Valid filter field names, taken from the JSON encoding of the state.
+var (
+	stateFields  = jsonFields(reflect.TypeFor[simulation.SimulationState]())
+	entityFields = jsonFields(reflect.TypeFor[entities.Entity]())
+)
+
+// jsonFields returns the JSON names of struct type t's fields.
+func jsonFields(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := range t.NumField() {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if f.IsExported() && name != "-" && name != "" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// ParseFilter builds a filter from comma-separated entity IDs and field
+// names, rejecting fields the state does not have.
+func ParseFilter(ids, fields string) (StreamFilter, error) {
+	var f StreamFilter
+	f.Entities = splitList(ids)
+	for _, name := range splitList(fields) {
+		if field, ok := strings.CutPrefix(name, "entities."); ok {
+			if !entityFields[field] {
+				return f, fmt.Errorf("unknown entity field %q", field)
+			}
+		} else if !stateFields[name] {
+			return f, fmt.Errorf("unknown field %q", name)
+		}
+		f.Fields = append(f.Fields, name)
+	}
+	return f, nil
+}
+
+// splitList splits a comma-separated list, dropping empty items.
+func splitList(list string) []string {
+	var items []string
+	for _, item := range strings.Split(list, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// key identifies the filter so clients sharing one also share encodings.
+func (f StreamFilter) key() string {
+	return strings.Join(f.Entities, ",") + "|" + strings.Join(f.Fields, ",")
+}
+
+// selectEntities drops the entities, and their trails, that f leaves out.
+func (f StreamFilter) selectEntities(state simulation.SimulationState) simulation.SimulationState {
+	if f.Entities == nil {
+		return state
+	}
+	var kept []*entities.Entity
+	for _, e := range state.Entities {
+		if slices.Contains(f.Entities, e.ID) {
+			kept = append(kept, e)
+		}
+	}
+	state.Entities = kept
+	if state.Trails != nil {
+		trails := make(map[string][]simulation.TrailPoint)
+		for _, id := range f.Entities {
+			if t, ok := state.Trails[id]; ok {
+				trails[id] = t
+			}
+		}
+		state.Trails = trails
+	}
+	return state
+}
+
+// marshal encodes state as JSON with only f's fields. Entity IDs are always
+// kept so delta streams can tell entities apart.
+func (f StreamFilter) marshal(state simulation.SimulationState) ([]byte, error) {
+	full, err := json.Marshal(f.selectEntities(state))
+	if err != nil || f.Fields == nil {
+		return full, err
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(full, &fields); err != nil {
+		return nil, err
+	}
+	keep := make(map[string]bool)
+	entityKeep := map[string]bool{"id": true}
+	for _, name := range f.Fields {
+		if field, ok := strings.CutPrefix(name, "entities."); ok {
+			entityKeep[field] = true
+			keep["entities"] = true
+		} else {
+			keep[name] = true
+		}
+	}
+	for name := range fields {
+		if !keep[name] {
+			delete(fields, name)
+		}
+	}
+	// Naming only "entities" sends them whole; naming entity fields trims them.
+	if raw, ok := fields["entities"]; ok && len(entityKeep) > 1 {
+		var list []map[string]json.RawMessage
+		if err := json.Unmarshal(raw, &list); err != nil {
+			return nil, err
+		}
+		for _, e := range list {
+			for name := range e {
+				if !entityKeep[name] {
+					delete(e, name)
+				}
+			}
+		}
+		if fields["entities"], err = json.Marshal(list); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(fields)
+}
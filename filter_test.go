@@ -0,0 +1,94 @@
+package main
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestParseFilter(t *testing.T) {
+	tests := []struct {
+		ids, fields string
+		want        StreamFilter
+		err         bool
+	}{
+		{"", "", StreamFilter{}, false},
+		{" T1 ,,I1", "", StreamFilter{Entities: []string{"T1", "I1"}}, false},
+		{"", "time,entities.position", StreamFilter{Fields: []string{"time", "entities.position"}}, false},
+		{"", "bogus", StreamFilter{}, true},
+		{"", "entities.bogus", StreamFilter{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseFilter(tt.ids, tt.fields)
+		if (err != nil) != tt.err {
+			t.Errorf("ParseFilter(%q, %q) error = %v", tt.ids, tt.fields, err)
+			continue
+		}
+		if !tt.err && (!slices.Equal(got.Entities, tt.want.Entities) || !slices.Equal(got.Fields, tt.want.Fields)) {
+			t.Errorf("ParseFilter(%q, %q) = %+v, want %+v", tt.ids, tt.fields, got, tt.want)
+		}
+	}
+}
+
+func TestStreamFilterMarshal(t *testing.T) {
+	sim := simulation.NewSimulator()
+	sim.SetLogger(logging.Discard)
+	state := sim.GetState()
+	if len(state.Entities) < 2 {
+		t.Fatal("default scenario needs two entities")
+	}
+	first := state.Entities[0].ID
+	tests := []struct {
+		name         string
+		filter       StreamFilter
+		wantFields   []string
+		wantEntities int
+		entityFields []string // nil for whole entities
+	}{
+		{"positions only", StreamFilter{Fields: []string{"time", "entities.position"}}, []string{"entities", "time"}, len(state.Entities), []string{"id", "position"}},
+		{"one entity", StreamFilter{Entities: []string{first}}, nil, 1, nil},
+		{"whole entities", StreamFilter{Fields: []string{"entities"}}, []string{"entities"}, len(state.Entities), nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg, err := tt.filter.marshal(state)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got map[string]json.RawMessage
+			if err := json.Unmarshal(msg, &got); err != nil {
+				t.Fatal(err)
+			}
+			if tt.wantFields != nil {
+				var keys []string
+				for k := range got {
+					keys = append(keys, k)
+				}
+				slices.Sort(keys)
+				if !slices.Equal(keys, tt.wantFields) {
+					t.Errorf("fields %v, want %v", keys, tt.wantFields)
+				}
+			}
+			var list []map[string]json.RawMessage
+			if err := json.Unmarshal(got["entities"], &list); err != nil {
+				t.Fatal(err)
+			}
+			if len(list) != tt.wantEntities {
+				t.Fatalf("%d entities, want %d", len(list), tt.wantEntities)
+			}
+			if tt.entityFields != nil {
+				var keys []string
+				for k := range list[0] {
+					keys = append(keys, k)
+				}
+				slices.Sort(keys)
+				if !slices.Equal(keys, tt.entityFields) {
+					t.Errorf("entity fields %v, want %v", keys, tt.entityFields)
+				}
+			}
+		})
+	}
+}
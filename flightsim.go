@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/flightsim"
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// flightSimModels gives the FlightGear model each entity type is drawn with
+// over multiplayer; both ship with FlightGear's base package.
+var flightSimModels = map[string]string{
+	"Target":  "Aircraft/c172p/Models/c172p.xml",
+	"Missile": "Aircraft/ufo/Models/ufo.xml",
+}
+
+// defaultFlightGearMPPort is FlightGear's usual --multiplay=in port.
+const defaultFlightGearMPPort = 5000
+
+// flightSimBridge streams one session to a desktop flight simulator so an
+// engagement can be watched in 3D. The chased entity flies the simulator's
+// own aircraft, so its chase and cockpit views follow it; every other
+// entity appears as AI or multiplayer traffic.
+//
+// X-Plane takes VEHX packets on one port, aircraft 0 being the chased
+// entity. FlightGear takes the chased entity over its native FDM protocol
+// and the rest over multiplayer, on two ports.
+type flightSimBridge struct {
+	xplane   bool
+	own      net.Conn // X-Plane, or FlightGear's native FDM
+	traffic  net.Conn // FlightGear multiplayer, nil for X-Plane
+	session  string
+	chase    string // entity ID, or empty to chase the first engaged interceptor
+	origin   geo.Origin
+	interval time.Duration
+
+	last  string         // the entity chased last tick
+	slots map[string]int // X-Plane aircraft index of each entity but the chased one
+}
+
+// newFlightSimBridge parses target as xplane://host[:49000] or
+// flightgear://host:<fdm port>[?mp=<multiplayer port>].
+func newFlightSimBridge(target, session, chase string, origin geo.Origin, rate float64) (*flightSimBridge, error) {
+	if !(rate > 0 && rate <= maxClientRate) {
+		return nil, fmt.Errorf("flight sim rate must be above 0 and at most %d", maxClientRate)
+	}
+	u, err := url.Parse(target)
+	if err != nil || u.Hostname() == "" {
+		return nil, fmt.Errorf("flight sim must be xplane://host[:port] or flightgear://host:port, got %q", target)
+	}
+	b := &flightSimBridge{
+		session:  session,
+		chase:    chase,
+		origin:   origin,
+		interval: time.Duration(float64(time.Second) / rate),
+		slots:    make(map[string]int),
+	}
+	switch u.Scheme {
+	case "xplane":
+		b.xplane = true
+		addr := u.Host
+		if u.Port() == "" {
+			addr = net.JoinHostPort(u.Hostname(), "49000")
+		}
+		if b.own, err = net.Dial("udp", addr); err != nil {
+			return nil, err
+		}
+	case "flightgear":
+		if u.Port() == "" {
+			return nil, errors.New("flightgear:// needs the native FDM port")
+		}
+		mp := defaultFlightGearMPPort
+		if v := u.Query().Get("mp"); v != "" {
+			if mp, err = strconv.Atoi(v); err != nil || mp < 1 || mp > math.MaxUint16 {
+				return nil, fmt.Errorf("invalid multiplayer port %q", v)
+			}
+		}
+		if b.own, err = net.Dial("udp", u.Host); err != nil {
+			return nil, err
+		}
+		if b.traffic, err = net.Dial("udp", net.JoinHostPort(u.Hostname(), strconv.Itoa(mp))); err != nil {
+			b.own.Close()
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown flight sim %q, want xplane or flightgear", u.Scheme)
+	}
+	return b, nil
+}
+
+// Run streams poses until ctx is done.
+func (b *flightSimBridge) Run(ctx context.Context) {
+	defer b.close()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			sess, ok := sessions.Get(b.session)
+			if !ok {
+				continue
+			}
+			if err := b.tick(sess, now); err != nil {
+				slog.Error("flight sim", "err", err)
+			}
+		}
+	}
+}
+
+func (b *flightSimBridge) close() {
+	b.own.Close()
+	if b.traffic != nil {
+		b.traffic.Close()
+	}
+}
+
+// tick sends every entity's pose once. Send errors are reported but do not
+// stop the others.
+func (b *flightSimBridge) tick(sess *Session, now time.Time) error {
+	state := sess.State()
+	chased := b.chased(state.Entities, state.Engagements)
+	var errs []error
+	for _, e := range state.Entities {
+		p := b.pose(e)
+		var err error
+		switch {
+		case b.xplane && e.ID == chased:
+			_, err = b.own.Write(flightsim.VEHX(0, p))
+		case b.xplane:
+			_, err = b.own.Write(flightsim.VEHX(b.slot(e.ID), p))
+		case e.ID == chased:
+			_, err = b.own.Write(flightsim.NativeFDM(p, now))
+		default:
+			_, err = b.traffic.Write(flightsim.Multiplayer(flightSimCallsign(e.ID), flightSimModels[string(e.Type)], p, state.Time))
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
+
+// chased picks the entity to chase: the configured one, else the first
+// interceptor in flight, else whichever was chased last so the view stays
+// on an interceptor after its intercept, else the first entity.
+func (b *flightSimBridge) chased(all []*entities.Entity, engagements []simulation.EngagementStatus) string {
+	if b.chase != "" {
+		return b.chase
+	}
+	for _, eng := range engagements {
+		if eng.Status == "Flying" {
+			b.last = eng.MissileID
+			return b.last
+		}
+	}
+	for _, e := range all {
+		if e.ID == b.last {
+			return b.last
+		}
+	}
+	if len(all) > 0 {
+		b.last = all[0].ID
+	}
+	return b.last
+}
+
+// slot returns an entity's X-Plane AI aircraft index, numbering entities
+// from 1 in the order they are first seen.
+func (b *flightSimBridge) slot(id string) int {
+	n, ok := b.slots[id]
+	if !ok {
+		n = len(b.slots) + 1
+		b.slots[id] = n
+	}
+	return n
+}
+
+// pose places e on the globe with its nose along its velocity, wings level.
+func (b *flightSimBridge) pose(e *entities.Entity) flightsim.Pose {
+	p, v := e.Position, e.Velocity
+	lat, lon, alt := b.origin.Geodetic(p.X, p.Z, p.Y)
+	x, y, z := b.origin.ECEF(p.X, p.Z, p.Y)
+	level := math.Hypot(v.X, v.Z)
+	pose := flightsim.Pose{
+		Lat: lat, Lon: lon, Alt: alt,
+		North: v.Z, East: v.X, Down: -v.Y,
+		ECEF:  [3]float64{x, y, z},
+		Speed: math.Sqrt(level*level + v.Y*v.Y),
+	}
+	if pose.Speed > 0 {
+		pose.Heading = math.Mod(math.Atan2(v.X, v.Z)*180/math.Pi+360, 360)
+		pose.Pitch = math.Atan2(v.Y, level) * 180 / math.Pi
+	}
+	return pose
+}
+
+// callsignAbbreviations shorten the usual words in entity IDs.
+var callsignAbbreviations = strings.NewReplacer("interceptor", "int", "missile", "msl", "target", "tgt")
+
+// flightSimCallsign shortens an entity ID to a multiplayer callsign,
+// keeping its end, which usually numbers it.
+func flightSimCallsign(id string) string {
+	id = callsignAbbreviations.Replace(id)
+	if len(id) > flightsim.MaxCallsign {
+		id = id[len(id)-flightsim.MaxCallsign:]
+	}
+	return id
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestFlightSimBridge(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+
+	fdm, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fdm.Close()
+	mp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer mp.Close()
+
+	// received counts the packets waiting on c and returns the size of the last.
+	received := func(c net.PacketConn) (n, size int) {
+		buf := make([]byte, 1500)
+		for {
+			c.SetReadDeadline(time.Now().Add(200 * time.Millisecond))
+			m, _, err := c.ReadFrom(buf)
+			if err != nil {
+				return n, size
+			}
+			n, size = n+1, m
+		}
+	}
+	origin, _ := parseOriginFlag("36.2,-115.0,900")
+	target := "flightgear://" + fdm.LocalAddr().String() + "?mp=" + portOf(t, mp)
+	fg, err := newFlightSimBridge(target, defaultSessionID, "", origin, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer fg.close()
+	if err := fg.tick(sess, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	entities := len(sess.State().Entities)
+	if n, size := received(fdm); n != 1 || size != 408 {
+		t.Errorf("native FDM got %d packets of %d bytes, want one of 408", n, size)
+	}
+	if n, size := received(mp); n != entities-1 || size != 232 {
+		t.Errorf("multiplayer got %d packets of %d bytes, want %d of 232", n, size, entities-1)
+	}
+
+	xp, err := newFlightSimBridge("xplane://"+fdm.LocalAddr().String(), defaultSessionID, "target-1", origin, 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer xp.close()
+	if err := xp.tick(sess, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if n, size := received(fdm); n != entities || size != 45 {
+		t.Errorf("X-Plane got %d packets of %d bytes, want %d of 45", n, size, entities)
+	}
+	if len(xp.slots) != entities-1 || xp.slots["target-1"] != 0 {
+		t.Errorf("X-Plane slots %v leave the chased target on aircraft 0", xp.slots)
+	}
+
+	for _, bad := range []string{"xplane://", "flightgear://localhost", "flightgear://localhost:5500?mp=0", "tacview://localhost:42674"} {
+		if _, err := newFlightSimBridge(bad, defaultSessionID, "", origin, 30); err == nil {
+			t.Errorf("accepted %q", bad)
+		}
+	}
+}
+
+func TestFlightSimCallsign(t *testing.T) {
+	tests := []struct{ id, want string }{
+		{"target-1", "tgt-1"},
+		{"missile-12", "msl-12"},
+		{"interceptor-3", "int-3"},
+		{"battery-north-7", "north-7"},
+	}
+	for _, tt := range tests {
+		if got := flightSimCallsign(tt.id); got != tt.want {
+			t.Errorf("flightSimCallsign(%q) = %q, want %q", tt.id, got, tt.want)
+		}
+	}
+}
+
+func portOf(t *testing.T, c net.PacketConn) string {
+	t.Helper()
+	_, port, err := net.SplitHostPort(c.LocalAddr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return port
+}
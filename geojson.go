@@ -0,0 +1,173 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/internal/units"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// GeoJSON feature kinds, in each feature's "kind" property.
+const (
+	FeatureTrack     = "track"     // an entity's ground track
+	FeatureLaunch    = "launch"    // where an interceptor was launched
+	FeatureIntercept = "intercept" // where an interceptor killed its target
+	FeatureImpact    = "impact"    // where a target reached the ground
+	FeatureDebris    = "debris"    // predicted ground impact of an intercepted target's debris
+)
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Name     string           `json:"name,omitempty"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONGeometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+// WriteGeoJSON writes a recording as a GeoJSON FeatureCollection for GIS
+// tools, with the local frame placed at origin: each entity's ground track
+// as a LineString, and launch, intercept, impact and predicted debris impact
+// points. Debris falls from the target's state at intercept without drag
+// onto the local frame's ground plane, so its impact point is an estimate.
+func WriteGeoJSON(w io.Writer, rec *Recording, origin geo.Origin) error {
+	doc := geoJSONCollection{Type: "FeatureCollection", Name: globeTitle(rec), Features: []geoJSONFeature{}}
+	for _, tr := range tracks(rec) {
+		line := make([][2]float64, len(tr.Points))
+		for i, p := range tr.Points {
+			line[i] = geoJSONPosition(origin, p)
+		}
+		geom := geoJSONGeometry{Type: "LineString", Coordinates: line}
+		if len(line) == 1 {
+			geom = geoJSONGeometry{Type: "Point", Coordinates: line[0]}
+		}
+		doc.Features = append(doc.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geom,
+			Properties: map[string]any{
+				"kind":   FeatureTrack,
+				"id":     tr.ID,
+				"type":   tr.Type,
+				"start":  tr.Times[0],
+				"end":    tr.Times[len(tr.Times)-1],
+				"stroke": geoJSONColor(tr.Type),
+			},
+		})
+	}
+	for _, ev := range rec.Events {
+		switch ev.Type {
+		case EventLaunch, EventImpact:
+			e, _ := recordedEntity(rec, ev.EntityID, ev.Time)
+			if e == nil {
+				continue
+			}
+			kind := FeatureLaunch
+			if ev.Type == EventImpact {
+				kind = FeatureImpact
+			}
+			doc.Features = append(doc.Features, geoJSONPoint(origin, e.Position, map[string]any{
+				"kind": kind, "id": ev.EntityID, "time": ev.Time, "message": ev.Message,
+			}))
+		case EventIntercept:
+			missile, frame := recordedEntity(rec, ev.EntityID, ev.Time)
+			if missile == nil {
+				continue
+			}
+			props := map[string]any{"kind": FeatureIntercept, "id": ev.EntityID, "time": ev.Time, "message": ev.Message}
+			target := interceptedTarget(frame, ev.EntityID)
+			if target != nil {
+				props["target"] = target.ID
+			}
+			doc.Features = append(doc.Features, geoJSONPoint(origin, missile.Position, props))
+			if target == nil {
+				continue
+			}
+			p, fall := debrisImpact(target.Position, target.Velocity)
+			doc.Features = append(doc.Features, geoJSONPoint(origin, p, map[string]any{
+				"kind": FeatureDebris, "id": target.ID, "time": ev.Time + fall, "fallTime": fall,
+			}))
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(doc)
+}
+
+// recordedEntity returns entity id in the first frame at or after time t,
+// and that frame.
+func recordedEntity(rec *Recording, id string, t float64) (*entities.Entity, *SimulationState) {
+	for i := range rec.Frames {
+		st := &rec.Frames[i]
+		if st.Time < t {
+			continue
+		}
+		for _, e := range st.Entities {
+			if e.ID == id {
+				return e, st
+			}
+		}
+	}
+	return nil, nil
+}
+
+// interceptedTarget returns the target missileID was engaging in frame.
+func interceptedTarget(frame *SimulationState, missileID string) *entities.Entity {
+	for _, eg := range frame.Engagements {
+		if eg.MissileID != missileID {
+			continue
+		}
+		for _, e := range frame.Entities {
+			if e.ID == eg.TargetID {
+				return e
+			}
+		}
+	}
+	return nil
+}
+
+// debrisImpact predicts where a body at p moving at v lands on the ground
+// plane Y = 0 under gravity alone, and how long it takes to fall.
+func debrisImpact(p, v vector.Vector3) (vector.Vector3, float64) {
+	if p.Y <= 0 {
+		return vector.Vector3{X: p.X, Z: p.Z}, 0
+	}
+	t := (v.Y + math.Sqrt(v.Y*v.Y+2*units.G*p.Y)) / units.G
+	return vector.Vector3{X: p.X + v.X*t, Z: p.Z + v.Z*t}, t
+}
+
+func geoJSONPoint(origin geo.Origin, p vector.Vector3, props map[string]any) geoJSONFeature {
+	_, _, alt := geodetic(origin, p)
+	props["altitude"] = math.Round(alt*10) / 10
+	return geoJSONFeature{
+		Type:       "Feature",
+		Geometry:   geoJSONGeometry{Type: "Point", Coordinates: geoJSONPosition(origin, p)},
+		Properties: props,
+	}
+}
+
+// geoJSONPosition returns p's longitude and latitude, in that order as
+// GeoJSON wants, rounded to about a centimetre.
+func geoJSONPosition(origin geo.Origin, p vector.Vector3) [2]float64 {
+	lat, lon, _ := geodetic(origin, p)
+	return [2]float64{math.Round(lon*1e7) / 1e7, math.Round(lat*1e7) / 1e7}
+}
+
+// geoJSONColor returns the entity type's globe color as a CSS hex color,
+// for the simplestyle "stroke" property many GIS viewers honour.
+func geoJSONColor(typ string) string {
+	c := globeColor(typ)
+	return fmt.Sprintf("#%02x%02x%02x", c[0], c[1], c[2])
+}
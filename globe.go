@@ -0,0 +1,171 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// globeColors gives the RGBA color each entity type is drawn in on a globe.
+var globeColors = map[string][4]int{
+	"Target":  {230, 57, 70, 255},
+	"Missile": {69, 123, 157, 255},
+}
+
+var defaultGlobeColor = [4]int{160, 160, 160, 255}
+
+// track is one entity's trajectory through a recording, in the local frame.
+type track struct {
+	ID, Type string
+	Times    []float64
+	Points   []vector.Vector3
+}
+
+// tracks splits a recording's frames into per-entity trajectories, in the
+// order the entities first appear.
+func tracks(rec *Recording) []*track {
+	var list []*track
+	byID := make(map[string]*track)
+	for _, st := range rec.Frames {
+		for _, e := range st.Entities {
+			tr, ok := byID[e.ID]
+			if !ok {
+				tr = &track{ID: e.ID, Type: string(e.Type)}
+				byID[e.ID] = tr
+				list = append(list, tr)
+			}
+			tr.Times = append(tr.Times, st.Time)
+			tr.Points = append(tr.Points, e.Position)
+		}
+	}
+	return list
+}
+
+// at returns the track's position at or just after time t.
+func (tr *track) at(t float64) vector.Vector3 {
+	for i, ti := range tr.Times {
+		if ti >= t {
+			return tr.Points[i]
+		}
+	}
+	return tr.Points[len(tr.Points)-1]
+}
+
+// geodetic converts a local Y-up position to latitude, longitude and altitude.
+func geodetic(origin geo.Origin, p vector.Vector3) (lat, lon, alt float64) {
+	return origin.Geodetic(p.X, p.Z, p.Y)
+}
+
+// czmlPacket is one object in a CZML document. Only the properties the
+// export uses are declared.
+type czmlPacket struct {
+	ID           string         `json:"id"`
+	Name         string         `json:"name,omitempty"`
+	Version      string         `json:"version,omitempty"`
+	Clock        *czmlClock     `json:"clock,omitempty"`
+	Availability string         `json:"availability,omitempty"`
+	Description  string         `json:"description,omitempty"`
+	Position     *czmlPosition  `json:"position,omitempty"`
+	Point        map[string]any `json:"point,omitempty"`
+	Path         map[string]any `json:"path,omitempty"`
+	Label        map[string]any `json:"label,omitempty"`
+}
+
+type czmlClock struct {
+	Interval    string  `json:"interval"`
+	CurrentTime string  `json:"currentTime"`
+	Multiplier  float64 `json:"multiplier"`
+	Range       string  `json:"range"`
+}
+
+type czmlPosition struct {
+	Epoch         string    `json:"epoch,omitempty"`
+	Cartesian     []float64 `json:"cartesian"` // earth-fixed metres, time-tagged when Epoch is set
+	Interpolation string    `json:"interpolationAlgorithm,omitempty"`
+}
+
+// WriteCZML writes a recording as a time-dynamic CZML document for Cesium,
+// with the local frame placed at origin. Each entity becomes a point with a
+// trailing path, and each event a labelled marker from when it happened.
+func WriteCZML(w io.Writer, rec *Recording, origin geo.Origin) error {
+	stamp := rec.timestamp
+	span := stamp(0) + "/" + stamp(rec.Duration())
+	packets := []czmlPacket{{
+		ID:      "document",
+		Name:    globeTitle(rec),
+		Version: "1.0",
+		Clock:   &czmlClock{Interval: span, CurrentTime: stamp(0), Multiplier: 1, Range: "CLAMPED"},
+	}}
+	all := tracks(rec)
+	for _, tr := range all {
+		pos := &czmlPosition{Epoch: stamp(0), Interpolation: "LAGRANGE"}
+		for i, p := range tr.Points {
+			x, y, z := origin.ECEF(p.X, p.Z, p.Y)
+			pos.Cartesian = append(pos.Cartesian, tr.Times[i], x, y, z)
+		}
+		color := map[string]any{"rgba": globeColor(tr.Type)}
+		packets = append(packets, czmlPacket{
+			ID:           tr.ID,
+			Name:         tr.ID,
+			Availability: stamp(tr.Times[0]) + "/" + stamp(tr.Times[len(tr.Times)-1]),
+			Description:  tr.Type,
+			Position:     pos,
+			Point:        map[string]any{"pixelSize": 8, "color": color},
+			Path: map[string]any{
+				"width":     2,
+				"leadTime":  0,
+				"trailTime": rec.Duration(),
+				"material":  map[string]any{"solidColor": map[string]any{"color": color}},
+			},
+		})
+	}
+	byID := make(map[string]*track, len(all))
+	for _, tr := range all {
+		byID[tr.ID] = tr
+	}
+	for i, ev := range rec.Events {
+		tr, ok := byID[ev.EntityID]
+		if !ok {
+			continue
+		}
+		p := tr.at(ev.Time)
+		x, y, z := origin.ECEF(p.X, p.Z, p.Y)
+		packets = append(packets, czmlPacket{
+			ID:           fmt.Sprintf("event-%d", i),
+			Name:         ev.Type,
+			Availability: stamp(ev.Time) + "/" + stamp(rec.Duration()),
+			Description:  ev.Message,
+			Position:     &czmlPosition{Cartesian: []float64{x, y, z}},
+			Point:        map[string]any{"pixelSize": 5, "color": map[string]any{"rgba": [4]int{255, 255, 255, 255}}},
+			Label:        map[string]any{"text": ev.Type, "font": "12px sans-serif", "pixelOffset": map[string]any{"cartesian2": []int{0, -16}}},
+		})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", " ")
+	return enc.Encode(packets)
+}
+
+// timestamp returns the wall-clock time of simulation time t, counting the
+// recording's creation as time zero.
+func (r *Recording) timestamp(t float64) string {
+	return r.Created.UTC().Add(time.Duration(t * float64(time.Second))).Format(time.RFC3339Nano)
+}
+
+// globeTitle names the document after the recording's scenario.
+func globeTitle(rec *Recording) string {
+	if rec.Manifest != nil && rec.Manifest.Scenario != "" {
+		return rec.Manifest.Scenario
+	}
+	return rec.Name
+}
+
+func globeColor(typ string) [4]int {
+	if c, ok := globeColors[typ]; ok {
+		return c
+	}
+	return defaultGlobeColor
+}
@@ -0,0 +1,137 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"math"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/geo"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestWriteCZML(t *testing.T) {
+	rec := recordRun(t, 30)
+	var buf bytes.Buffer
+	if err := WriteCZML(&buf, rec, geo.Origin{Lat: 36, Lon: -115}); err != nil {
+		t.Fatal(err)
+	}
+	var packets []czmlPacket
+	if err := json.Unmarshal(buf.Bytes(), &packets); err != nil {
+		t.Fatal(err)
+	}
+	if len(packets) < 3 || packets[0].ID != "document" || packets[0].Clock == nil {
+		t.Fatalf("want a document packet then entities, got %d packets", len(packets))
+	}
+	var sawEvent bool
+	for _, p := range packets[1:] {
+		if strings.HasPrefix(p.ID, "event-") {
+			sawEvent = true
+			continue
+		}
+		c := p.Position.Cartesian
+		if len(c) == 0 || len(c)%4 != 0 {
+			t.Fatalf("%s: %d cartesian values, want time-tagged xyz", p.ID, len(c))
+		}
+		// Every sample sits within a few tens of km of the origin.
+		ox, oy, oz := geo.Origin{Lat: 36, Lon: -115}.ECEF(0, 0, 0)
+		for i := 0; i < len(c); i += 4 {
+			if d := math.Sqrt(sq(c[i+1]-ox) + sq(c[i+2]-oy) + sq(c[i+3]-oz)); d > 50e3 {
+				t.Fatalf("%s: sample at t=%g is %.0fm from the origin", p.ID, c[i], d)
+			}
+		}
+	}
+	if !sawEvent {
+		t.Error("no event markers")
+	}
+}
+
+func TestWriteKML(t *testing.T) {
+	rec := recordRun(t, 30)
+	var buf bytes.Buffer
+	if err := WriteKML(&buf, rec, geo.Origin{Lat: 36, Lon: -115}); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Placemarks []struct {
+			Name  string   `xml:"name"`
+			When  []string `xml:"Track>when"`
+			Coord []string `xml:"Track>coord"`
+		} `xml:"Document>Placemark"`
+	}
+	if err := xml.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	var tracks int
+	for _, p := range doc.Placemarks {
+		if len(p.When) != len(p.Coord) {
+			t.Errorf("%s: %d times but %d coordinates", p.Name, len(p.When), len(p.Coord))
+		}
+		if len(p.When) > 0 {
+			tracks++
+		}
+	}
+	if tracks != 2 {
+		t.Errorf("got %d tracks, want 2", tracks)
+	}
+	if len(doc.Placemarks) == tracks {
+		t.Error("no event placemarks")
+	}
+}
+
+func sq(x float64) float64 { return x * x }
+
+func TestWriteGeoJSON(t *testing.T) {
+	rec := recordRun(t, 30)
+	var buf bytes.Buffer
+	if err := WriteGeoJSON(&buf, rec, geo.Origin{Lat: 36, Lon: -115}); err != nil {
+		t.Fatal(err)
+	}
+	var doc struct {
+		Type     string `json:"type"`
+		Features []struct {
+			Geometry struct {
+				Type        string          `json:"type"`
+				Coordinates json.RawMessage `json:"coordinates"`
+			} `json:"geometry"`
+			Properties map[string]any `json:"properties"`
+		} `json:"features"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	kinds := make(map[string]int)
+	for _, f := range doc.Features {
+		kind, _ := f.Properties["kind"].(string)
+		kinds[kind]++
+		if kind == FeatureDebris {
+			var p [2]float64
+			if err := json.Unmarshal(f.Geometry.Coordinates, &p); err != nil || math.Abs(p[0]+115) > 1 || math.Abs(p[1]-36) > 1 {
+				t.Errorf("debris lands at %v, %v", p, err)
+			}
+		}
+	}
+	if doc.Type != "FeatureCollection" || kinds[FeatureTrack] != 2 || kinds[FeatureLaunch] != 1 || kinds[FeatureIntercept] != 1 || kinds[FeatureDebris] != 1 {
+		t.Errorf("got features %v", kinds)
+	}
+}
+
+func TestDebrisImpact(t *testing.T) {
+	tests := []struct {
+		p, v     vector.Vector3
+		want     vector.Vector3
+		wantFall float64
+	}{
+		{vector.Vector3{Y: 4.905}, vector.Vector3{X: 100}, vector.Vector3{X: 100}, 1},
+		{vector.Vector3{X: 5, Y: 0}, vector.Vector3{Z: 100, Y: -50}, vector.Vector3{X: 5}, 0},
+		{vector.Vector3{Y: 0.0001}, vector.Vector3{Y: 9.81, Z: 10}, vector.Vector3{Z: 20}, 2},
+	}
+	for _, tt := range tests {
+		got, fall := debrisImpact(tt.p, tt.v)
+		if got.Distance(tt.want) > 0.01 || math.Abs(fall-tt.wantFall) > 0.001 {
+			t.Errorf("debrisImpact(%v, %v) = %v after %gs, want %v after %gs", tt.p, tt.v, got, fall, tt.want, tt.wantFall)
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// maxStreamRate caps the update rate a StreamState caller can ask for, Hz.
+const maxStreamRate = 60
+
+// grpcAPI implements the Simulator service in proto/simulator.proto.
+type grpcAPI struct{}
+
+// stopGRPC lets in-flight calls finish, cutting off any still running,
+// such as open state streams, when ctx is done.
+func stopGRPC(ctx context.Context, srv *grpc.Server) {
+	done := make(chan struct{})
+	go func() {
+		srv.GracefulStop()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		srv.Stop()
+	}
+}
+
+func newGRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	srv := grpc.NewServer(opts...)
+	srv.RegisterService(&simulatorServiceDesc, grpcAPI{})
+	return srv
+}
+
+// grpcRequest is the common shape of every request Struct.
+type grpcRequest struct {
+	Command
+	Session string  `json:"session"`
+	Rate    float64 `json:"rate"` // StreamState only, Hz
+}
+
+// decodeRequest converts a request Struct and resolves its session.
+func decodeRequest(in *structpb.Struct) (grpcRequest, *Session, error) {
+	var req grpcRequest
+	raw, err := in.MarshalJSON()
+	if err == nil {
+		err = json.Unmarshal(raw, &req)
+	}
+	if err != nil {
+		return req, nil, status.Error(codes.InvalidArgument, "invalid request")
+	}
+	if req.Session == "" {
+		req.Session = defaultSessionID
+	}
+	sess, ok := sessions.Get(req.Session)
+	if !ok {
+		return req, nil, status.Error(codes.NotFound, "unknown session")
+	}
+	return req, sess, nil
+}
+
+// toStruct converts v through its JSON encoding.
+func toStruct(v any) (*structpb.Struct, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	out := &structpb.Struct{}
+	if err := out.UnmarshalJSON(raw); err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return out, nil
+}
+
+func (grpcAPI) GetState(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	_, sess, err := decodeRequest(in)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(sess.State())
+}
+
+func (grpcAPI) StreamState(in *structpb.Struct, stream grpc.ServerStream) error {
+	req, sess, err := decodeRequest(in)
+	if err != nil {
+		return err
+	}
+	interval := broadcastInterval
+	if req.Rate < 0 || req.Rate > maxStreamRate {
+		return status.Errorf(codes.InvalidArgument, "rate must be between 0 and %d", maxStreamRate)
+	}
+	if req.Rate > 0 {
+		interval = time.Duration(float64(time.Second) / req.Rate)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		msg, err := toStruct(sess.State())
+		if err != nil {
+			return err
+		}
+		if err := stream.SendMsg(msg); err != nil {
+			return err
+		}
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// control runs a request as the named command.
+func (grpcAPI) control(typ string, in *structpb.Struct) (*Session, error) {
+	req, sess, err := decodeRequest(in)
+	if err != nil {
+		return nil, err
+	}
+	req.Command.Type = typ
+	if err := sess.CheckControl(req.Lease, time.Now()); err != nil {
+		return nil, status.Error(codes.Aborted, err.Error())
+	}
+	if err := runCommand(sess, req.Command); err != nil {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	return sess, nil
+}
+
+// controlMethod adapts a command with no reply to a unary handler.
+func controlMethod(name, typ string) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: name,
+		Handler: unaryHandler(name, func(ctx context.Context, api grpcAPI, in *structpb.Struct) (any, error) {
+			if _, err := api.control(typ, in); err != nil {
+				return nil, err
+			}
+			return &emptypb.Empty{}, nil
+		}),
+	}
+}
+
+// unaryHandler decodes the request Struct and runs fn through any interceptor.
+func unaryHandler(name string, fn func(context.Context, grpcAPI, *structpb.Struct) (any, error)) func(any, context.Context, func(any) error, grpc.UnaryServerInterceptor) (any, error) {
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		in := &structpb.Struct{}
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return fn(ctx, srv.(grpcAPI), in)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/missileintercept.v1.Simulator/" + name}
+		return interceptor(ctx, in, info, func(ctx context.Context, req any) (any, error) {
+			return fn(ctx, srv.(grpcAPI), req.(*structpb.Struct))
+		})
+	}
+}
+
+// simulatorServiceDesc registers grpcAPI under the service name and method
+// names of proto/simulator.proto.
+var simulatorServiceDesc = grpc.ServiceDesc{
+	ServiceName: "missileintercept.v1.Simulator",
+	HandlerType: (*any)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetState",
+			Handler: unaryHandler("GetState", func(ctx context.Context, api grpcAPI, in *structpb.Struct) (any, error) {
+				return api.GetState(ctx, in)
+			}),
+		},
+		controlMethod("Start", "start"),
+		controlMethod("Stop", "stop"),
+		controlMethod("SetGuidance", "guidance"),
+		controlMethod("Launch", "launch"),
+		controlMethod("SetTimeScale", "timescale"),
+		{
+			MethodName: "Step",
+			Handler: unaryHandler("Step", func(ctx context.Context, api grpcAPI, in *structpb.Struct) (any, error) {
+				sess, err := api.control("step", in)
+				if err != nil {
+					return nil, err
+				}
+				return toStruct(sess.Sim.GetState())
+			}),
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamState",
+			ServerStreams: true,
+			Handler: func(srv any, stream grpc.ServerStream) error {
+				in := &structpb.Struct{}
+				if err := stream.RecvMsg(in); err != nil {
+					return err
+				}
+				return srv.(grpcAPI).StreamState(in, stream)
+			},
+		},
+	},
+	Metadata: "proto/simulator.proto",
+}
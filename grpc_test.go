@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func dialGRPC(t *testing.T) *grpc.ClientConn {
+	t.Helper()
+	sessions = NewSessionManager()
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newGRPCServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+	conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCControl(t *testing.T) {
+	conn := dialGRPC(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	tests := []struct {
+		method string
+		req    map[string]any
+		reply  any
+		code   codes.Code
+	}{
+		{"Step", map[string]any{"count": 3}, &structpb.Struct{}, codes.OK},
+		{"Step", map[string]any{"count": -1}, &structpb.Struct{}, codes.FailedPrecondition},
+		{"GetState", map[string]any{"session": "nope"}, &structpb.Struct{}, codes.NotFound},
+		{"Launch", map[string]any{"interceptor": "nope"}, &emptypb.Empty{}, codes.FailedPrecondition},
+		{"SetTimeScale", map[string]any{"scale": 2}, &emptypb.Empty{}, codes.OK},
+		{"Stop", nil, &emptypb.Empty{}, codes.OK},
+	}
+	for _, tt := range tests {
+		req, err := structpb.NewStruct(tt.req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = conn.Invoke(ctx, "/missileintercept.v1.Simulator/"+tt.method, req, tt.reply)
+		if got := status.Code(err); got != tt.code {
+			t.Errorf("%s %v: code %v (%v), want %v", tt.method, tt.req, got, err, tt.code)
+		}
+	}
+
+	state := &structpb.Struct{}
+	if err := conn.Invoke(ctx, "/missileintercept.v1.Simulator/GetState", &structpb.Struct{}, state); err != nil {
+		t.Fatal(err)
+	}
+	if got := state.Fields["time"].GetNumberValue(); got <= 0 {
+		t.Errorf("state time after Step = %g, want > 0", got)
+	}
+}
+
+func TestGRPCStreamState(t *testing.T) {
+	conn := dialGRPC(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	desc := &grpc.StreamDesc{StreamName: "StreamState", ServerStreams: true}
+	stream, err := conn.NewStream(ctx, desc, "/missileintercept.v1.Simulator/StreamState")
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := structpb.NewStruct(map[string]any{"rate": 50})
+	if err := stream.SendMsg(req); err != nil {
+		t.Fatal(err)
+	}
+	stream.CloseSend()
+	for i := 0; i < 3; i++ {
+		msg := &structpb.Struct{}
+		if err := stream.RecvMsg(msg); err != nil {
+			t.Fatal(err)
+		}
+		if _, ok := msg.Fields["status"]; !ok {
+			t.Fatalf("frame %d has no status: %v", i, msg)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestHandleGuidance(t *testing.T) {
+	sessions = NewSessionManager()
+	sess := sessions.Create()
+	defer sessions.Delete(sess.ID)
+
+	tests := []struct {
+		name string
+		body string
+		code int
+		want string // the session's mode afterwards
+	}{
+		{"known", `{"mode":"LeadPursuit"}`, http.StatusOK, "LeadPursuit"},
+		{"external", `{"mode":"External"}`, http.StatusOK, simulation.GuidanceExternal},
+		{"unknown", `{"mode":"Magic"}`, http.StatusBadRequest, simulation.GuidanceExternal},
+		{"missing", `{}`, http.StatusBadRequest, simulation.GuidanceExternal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handleGuidance(rec, httptest.NewRequest(http.MethodPost, "/api/guidance?session="+sess.ID, strings.NewReader(tt.body)))
+			if rec.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if got := sess.Sim.GetState().Engagements[0].Guidance; got != tt.want {
+				t.Errorf("flying %s, want %s", got, tt.want)
+			}
+			if tt.code != http.StatusBadRequest {
+				return
+			}
+			var apiErr struct {
+				Details []string `json:"details"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&apiErr); err != nil || !slices.Equal(apiErr.Details, simulation.ValidGuidanceModes()) {
+				t.Errorf("details %v, want the valid modes (%v)", apiErr.Details, err)
+			}
+		})
+	}
+}
+
+func TestHandleGuidanceModes(t *testing.T) {
+	rec := httptest.NewRecorder()
+	handleGuidanceModes(rec, httptest.NewRequest(http.MethodGet, "/api/guidance/modes", nil))
+	var got []simulation.GuidanceLawInfo
+	if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, law := range got {
+		names = append(names, law.Name)
+	}
+	if rec.Code != http.StatusOK || !slices.Equal(names, simulation.ValidGuidanceModes()) {
+		t.Errorf("status %d, modes %v", rec.Code, names)
+	}
+}
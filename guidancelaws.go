@@ -0,0 +1,100 @@
+package simulation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+// GuidanceParam is a setting of the interceptor that shapes its guidance
+// law, taken from the scenario entity field of the same name.
+type GuidanceParam struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Default     float64 `json:"default"`
+	Unit        string  `json:"unit,omitempty"`
+}
+
+// GuidanceLawInfo describes a guidance mode for clients choosing one.
+type GuidanceLawInfo struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	Parameters  []GuidanceParam `json:"parameters"`
+	External    bool            `json:"external,omitempty"` // commands come from an attached process
+}
+
+// gainParam is the command multiplier every law honours.
+var gainParam = GuidanceParam{Name: "gain", Description: "multiplies the commanded acceleration", Default: 1}
+
+// guidanceLaws describes the laws in GuidanceModes, then External.
+var guidanceLaws = map[string]GuidanceLawInfo{
+	"ProNav": {
+		Description: "Proportional navigation: turns at a multiple N of the line-of-sight rate, driving the rate to zero so the missile flies a collision course.",
+		Parameters:  []GuidanceParam{{Name: "gain", Description: "scales the navigation constant N", Default: 1}},
+	},
+	"PurePursuit": {
+		Description: "Pure pursuit: points straight at where the target is now. Simple and robust, but it ends in a tail chase with high demands near the end.",
+		Parameters:  []GuidanceParam{gainParam},
+	},
+	"LeadPursuit": {
+		Description: "Lead pursuit: points ahead of the target along its velocity, cutting the corner pure pursuit takes.",
+		Parameters:  []GuidanceParam{gainParam},
+	},
+	GuidanceExternal: {
+		Description: "External: each command comes from the process attached at /api/guidance/external or by a co-simulation master; ProNav flies while none is attached or it misses a deadline.",
+		Parameters:  []GuidanceParam{gainParam},
+		External:    true,
+	},
+}
+
+// GuidanceLaws describes every guidance mode SetGuidanceMode accepts: the
+// laws in GuidanceModes, then External.
+func GuidanceLaws() []GuidanceLawInfo {
+	var out []GuidanceLawInfo
+	for _, name := range ValidGuidanceModes() {
+		info := guidanceLaws[name]
+		info.Name = name
+		info.Parameters = slices.Clone(info.Parameters)
+		out = append(out, info)
+	}
+	return out
+}
+
+// ValidGuidanceModes lists the modes SetGuidanceMode accepts.
+func ValidGuidanceModes() []string {
+	return append(slices.Clone(GuidanceModes), GuidanceExternal)
+}
+
+// UnknownGuidanceError is a guidance mode the simulator cannot fly.
+type UnknownGuidanceError struct {
+	Mode  string
+	Valid []string
+}
+
+func (e *UnknownGuidanceError) Error() string {
+	return fmt.Sprintf("unknown guidance mode %q (want one of %s)", e.Mode, strings.Join(e.Valid, ", "))
+}
+
+// CheckGuidanceMode returns an *UnknownGuidanceError unless mode is one of
+// ValidGuidanceModes or empty, which leaves the law to the scenario.
+func CheckGuidanceMode(mode string) error {
+	if valid := ValidGuidanceModes(); mode != "" && !slices.Contains(valid, mode) {
+		return &UnknownGuidanceError{Mode: mode, Valid: valid}
+	}
+	return nil
+}
+
+// CheckScenarioGuidance returns the *UnknownGuidanceError of the first
+// entity in sc naming a guidance mode the simulator cannot fly, with the
+// entity's path. sc.Validate checks the rest of the document but does not
+// know the modes.
+func CheckScenarioGuidance(sc *scenario.Scenario) error {
+	for i, e := range sc.Entities {
+		if err := CheckGuidanceMode(e.Guidance); err != nil {
+			return fmt.Errorf("entities[%d].guidance: %w", i, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package simulation
+
+import (
+	"errors"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+)
+
+func TestSetGuidanceMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{"PurePursuit", false},
+		{"LeadPursuit", false},
+		{"ProNav", false},
+		{GuidanceExternal, false},
+		{"pronav", true},
+		{"", true},
+		{"Magic", true},
+	}
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			before := s.GuidanceName
+			err := s.SetGuidanceMode(tt.mode)
+			var unknown *UnknownGuidanceError
+			if errors.As(err, &unknown) != tt.wantErr {
+				t.Fatalf("err %v, want unknown mode %v", err, tt.wantErr)
+			}
+			want := tt.mode
+			if tt.wantErr {
+				want = before
+				if len(unknown.Valid) != len(GuidanceLaws()) {
+					t.Errorf("valid modes %v", unknown.Valid)
+				}
+			}
+			if s.GuidanceName != want || s.Interceptors[0].GuidanceName != want {
+				t.Errorf("flying %s (%s), want %s", s.GuidanceName, s.Interceptors[0].GuidanceName, want)
+			}
+		})
+	}
+}
+
+func TestGuidanceLaws(t *testing.T) {
+	laws := GuidanceLaws()
+	for i, name := range ValidGuidanceModes() {
+		if laws[i].Name != name || laws[i].Description == "" || len(laws[i].Parameters) == 0 {
+			t.Errorf("law %d: %+v, want %s described", i, laws[i], name)
+		}
+		if laws[i].External != (name == GuidanceExternal) {
+			t.Errorf("%s external = %v", name, laws[i].External)
+		}
+	}
+	laws[0].Parameters[0].Default = 99
+	if GuidanceLaws()[0].Parameters[0].Default == 99 {
+		t.Error("GuidanceLaws shares its parameters with callers")
+	}
+}
+
+func TestCheckGuidanceMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{"", false},
+		{"LeadPursuit", false},
+		{GuidanceExternal, false},
+		{"pronav", true},
+	}
+	for _, tt := range tests {
+		err := CheckGuidanceMode(tt.mode)
+		var unknown *UnknownGuidanceError
+		if errors.As(err, &unknown) != tt.wantErr {
+			t.Errorf("%q: err %v, want unknown mode %v", tt.mode, err, tt.wantErr)
+		}
+	}
+}
+
+func TestUnknownGuidanceRejected(t *testing.T) {
+	wishful := func() *scenario.Scenario {
+		sc := scenario.Default()
+		sc.Entities[1].Guidance = "Wishful"
+		return sc
+	}
+	tests := []struct {
+		name string
+		run  func(s *Simulator) error
+	}{
+		{"batch", func(*Simulator) error {
+			_, err := RunBatch(scenario.Default(), BatchConfig{Runs: 1, Guidance: "Wishful"})
+			return err
+		}},
+		{"batch scenario", func(*Simulator) error {
+			_, err := RunBatch(wishful(), BatchConfig{Runs: 1})
+			return err
+		}},
+		{"rerun", func(s *Simulator) error { return s.Rerun(Overrides{Guidance: "Wishful"}) }},
+		{"scenario", func(s *Simulator) error { return s.LoadScenario(wishful()) }},
+		{"headless", func(*Simulator) error {
+			_, err := RunScenario(scenario.Default(), RunConfig{Guidance: "Wishful"})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		s := NewSimulator()
+		s.SetLogger(logging.Discard)
+		err := tt.run(s)
+		var unknown *UnknownGuidanceError
+		if !errors.As(err, &unknown) || unknown.Mode != "Wishful" {
+			t.Errorf("%s: err %v, want unknown mode Wishful", tt.name, err)
+			continue
+		}
+		if s.State.Lifecycle != LifecycleIdle || s.GuidanceName != "ProNav" || s.GetScenario().Entities[1].Guidance == "Wishful" {
+			t.Errorf("%s: rejected guidance changed the simulator", tt.name)
+		}
+	}
+}
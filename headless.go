@@ -0,0 +1,54 @@
+package simulation
+
+import (
+	"fmt"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+)
+
+// RunConfig configures a single headless run.
+type RunConfig struct {
+	Seed     uint64     // 0 draws one
+	Guidance string     // law for every interceptor; empty keeps the scenario's
+	MaxTime  float64    // s of simulated time before the run is stopped as a timeout; 0 for the batch default
+	Log      StepLogger // gets the initial state and the state after every step; nil for none
+}
+
+// RunScenario flies sc once, headless and as fast as it will go, and
+// returns its outcome report. It is RunBatch for a single run, without the
+// jitter, for scripts that want the report and trajectory of one run.
+func RunScenario(sc *scenario.Scenario, cfg RunConfig) (*OutcomeReport, error) {
+	if err := CheckGuidanceMode(cfg.Guidance); err != nil {
+		return nil, err
+	}
+	if cfg.MaxTime < 0 {
+		return nil, fmt.Errorf("max time must not be negative")
+	}
+	if cfg.MaxTime == 0 {
+		cfg.MaxTime = DefaultMaxTime
+	}
+	sim := NewSimulator()
+	sim.SetLogger(logging.Discard)
+	sim.HistoryDuration = 0
+	sim.TrailDuration = 0
+	sim.Seed = cfg.Seed
+	if err := sim.LoadScenario(sc); err != nil {
+		return nil, err
+	}
+	if cfg.Guidance != "" {
+		if err := sim.SetGuidanceMode(cfg.Guidance); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.Log != nil {
+		st := sim.GetState()
+		if err := cfg.Log.LogStep(&st); err != nil {
+			return nil, err
+		}
+	}
+	if _, err := sim.runLogged(cfg.MaxTime, cfg.Log); err != nil {
+		return nil, err
+	}
+	return sim.Result(), nil
+}
@@ -0,0 +1,56 @@
+package simulation
+
+import (
+	"bytes"
+	"encoding/csv"
+	"testing"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+func TestRunScenario(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     RunConfig
+		wantErr bool
+	}{
+		{"defaults", RunConfig{Seed: 42}, false},
+		{"guidance", RunConfig{Seed: 42, Guidance: "PurePursuit", MaxTime: 5}, false},
+		{"unknown guidance", RunConfig{Guidance: "Wishful"}, true},
+		{"negative max time", RunConfig{MaxTime: -1}, true},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		log, err := NewCSVLog(&buf, "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.cfg.Log = log
+		rep, err := RunScenario(scenario.Default(), tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if rep == nil {
+			t.Fatalf("%s: no outcome report", tt.name)
+		}
+		if tt.cfg.MaxTime > 0 && rep.Time > tt.cfg.MaxTime+0.1 {
+			t.Errorf("%s: ran to %g s past the %g s limit", tt.name, rep.Time, tt.cfg.MaxTime)
+		}
+		if err := log.Flush(); err != nil {
+			t.Fatal(err)
+		}
+		rows, err := csv.NewReader(&buf).ReadAll()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(rows) < 3 || rows[1][0] != "0" {
+			t.Errorf("%s: CSV has %d rows, first at t=%v; want the initial state and every step", tt.name, len(rows), rows[1][0])
+		}
+		if last := rows[len(rows)-1][0]; last != num(rep.Time) {
+			t.Errorf("%s: CSV ends at t=%s, run at %g s", tt.name, last, rep.Time)
+		}
+	}
+}
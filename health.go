@@ -0,0 +1,64 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+// shuttingDown is set once the server starts draining, failing readiness so
+// orchestrators stop routing to it.
+var shuttingDown atomic.Bool
+
+// sessionHealth is one session's entry in a health report.
+type sessionHealth struct {
+	ID string `json:"id"`
+	simulation.LoopHealth
+	Clients int `json:"clients"`
+}
+
+// healthReport is the body of /healthz and /readyz.
+type healthReport struct {
+	Status   string          `json:"status"` // ok, stalled or shutting down
+	Sessions []sessionHealth `json:"sessions"`
+}
+
+// checkHealth reports on every session's loop. It reads no simulator state
+// under its lock, so a wedged loop cannot hang the check itself.
+func checkHealth() healthReport {
+	report := healthReport{Status: "ok", Sessions: []sessionHealth{}}
+	for _, sess := range sessions.List() {
+		h := sessionHealth{ID: sess.ID, LoopHealth: sess.Sim.Health(), Clients: sess.Hub.Clients()}
+		if h.Stalled {
+			report.Status = "stalled"
+		}
+		report.Sessions = append(report.Sessions, h)
+	}
+	return report
+}
+
+// handleHealthz is the liveness probe: it fails when a simulation loop has
+// stopped stepping, which only a restart will fix.
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, checkHealth())
+}
+
+// handleReadyz is the readiness probe: it also fails while shutting down.
+func handleReadyz(w http.ResponseWriter, r *http.Request) {
+	report := checkHealth()
+	if shuttingDown.Load() {
+		report.Status = "shutting down"
+	}
+	writeHealth(w, report)
+}
+
+func writeHealth(w http.ResponseWriter, report healthReport) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if report.Status != "ok" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestHealthProbes(t *testing.T) {
+	sessions = NewSessionManager()
+	defer shuttingDown.Store(false)
+	tests := []struct {
+		name     string
+		handler  http.HandlerFunc
+		draining bool
+		want     int
+		status   string
+	}{
+		{"live", handleHealthz, false, http.StatusOK, "ok"},
+		{"ready", handleReadyz, false, http.StatusOK, "ok"},
+		{"live while draining", handleHealthz, true, http.StatusOK, "ok"},
+		{"not ready while draining", handleReadyz, true, http.StatusServiceUnavailable, "shutting down"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shuttingDown.Store(tt.draining)
+			rec := httptest.NewRecorder()
+			tt.handler(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			var got healthReport
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatal(err)
+			}
+			if rec.Code != tt.want || got.Status != tt.status || len(got.Sessions) != 1 {
+				t.Errorf("got %d %+v, want %d %q", rec.Code, got, tt.want, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+)
+
+// DefaultHistoryDuration is how much simulated time the state history keeps
+// unless configured otherwise.
+const DefaultHistoryDuration = 120.0
+
+// maxHistoryDuration bounds the history so a client can't exhaust memory.
+const maxHistoryDuration = 3600.0
+
+// resetHistoryLocked empties the history and stores the current state as
+// its first frame. Callers must hold s.mu.
+func (s *Simulator) resetHistoryLocked() {
+	s.history = frameStore{limit: s.historyRetentionLocked()}
+	s.historyFrameLocked()
+}
+
+// historyRetentionLocked returns the limits the history is kept within.
+func (s *Simulator) historyRetentionLocked() Retention {
+	return Retention{Duration: s.HistoryDuration, Frames: s.HistoryFrames, Bytes: s.HistoryBytes}
+}
+
+// historyFrameLocked stores a copy of the current state in the history.
+func (s *Simulator) historyFrameLocked() {
+	if s.HistoryDuration > 0 {
+		s.history.push(cloneState(s.State))
+	}
+}
+
+// SetHistoryDuration changes how many seconds of simulated time the history
+// keeps; 0 disables it. The history restarts from the current frame.
+func (s *Simulator) SetHistoryDuration(d float64) error {
+	s.lock()
+	defer s.mu.Unlock()
+	r := s.historyRetentionLocked()
+	r.Duration = d
+	return s.setHistoryRetentionLocked(r)
+}
+
+// SetHistoryRetention changes every limit on the history at once. Its
+// Duration must be set; 0 disables the history. The history restarts from
+// the current frame.
+func (s *Simulator) SetHistoryRetention(r Retention) error {
+	s.lock()
+	defer s.mu.Unlock()
+	return s.setHistoryRetentionLocked(r)
+}
+
+func (s *Simulator) setHistoryRetentionLocked(r Retention) error {
+	if r.Duration < 0 || r.Duration > maxHistoryDuration {
+		return fmt.Errorf("history duration must be between 0 and %g seconds", maxHistoryDuration)
+	}
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	s.HistoryDuration, s.HistoryFrames, s.HistoryBytes = r.Duration, r.Frames, r.Bytes
+	s.resetHistoryLocked()
+	return nil
+}
+
+// History returns the stored states with simulation time in [from, to].
+func (s *Simulator) History(from, to float64) []SimulationState {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.history.between(from, to)
+}
+
+// Resume returns the stored states after time t of the given run, oldest
+// first, for a client that lost its connection after receiving the frame at
+// t. It reports false if the run has since been reset or restored, or the
+// history no longer holds every step since t.
+func (s *Simulator) Resume(run uint64, t float64) ([]SimulationState, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if run != s.State.Run || t > s.State.Time {
+		return nil, false
+	}
+	if from, ok := s.history.fullFrom(); !ok || from > t {
+		return nil, false
+	}
+	return s.history.between(math.Nextafter(t, math.Inf(1)), s.State.Time), true
+}
@@ -0,0 +1,72 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFrameStore(t *testing.T) {
+	frameSize := stateSize(&SimulationState{})
+	tests := []struct {
+		name     string
+		limit    Retention
+		pushed   int // frames pushed at t = 0, 1, 2, ...
+		from, to float64
+		want     []float64
+		fullFrom float64 // every step from here on is kept
+	}{
+		{"unlimited", Retention{}, 5, math.Inf(-1), math.Inf(1), []float64{0, 1, 2, 3, 4}, 0},
+		{"partly filled", Retention{Frames: 4}, 2, math.Inf(-1), math.Inf(1), []float64{0, 1}, 0},
+		{"exactly full", Retention{Frames: 3}, 3, math.Inf(-1), math.Inf(1), []float64{0, 1, 2}, 0},
+		{"too few to thin", Retention{Frames: 3}, 5, math.Inf(-1), math.Inf(1), []float64{2, 3, 4}, 2},
+		{"thinned", Retention{Frames: 6}, 7, math.Inf(-1), math.Inf(1), []float64{0, 2, 3, 4, 5, 6}, 3},
+		{"thinned twice", Retention{Frames: 6}, 9, math.Inf(-1), math.Inf(1), []float64{0, 4, 5, 6, 7, 8}, 5},
+		{"duration", Retention{Duration: 2}, 5, math.Inf(-1), math.Inf(1), []float64{2, 3, 4}, 2},
+		{"bytes", Retention{Bytes: 3 * frameSize}, 5, math.Inf(-1), math.Inf(1), []float64{2, 3, 4}, 2},
+		{"window inclusive", Retention{Frames: 3}, 5, 3, 4, []float64{3, 4}, 2},
+		{"window inside", Retention{Frames: 3}, 5, 2.5, 3.5, []float64{3}, 2},
+		{"window before", Retention{Frames: 3}, 5, 0, 1, nil, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := frameStore{limit: tt.limit}
+			for i := 0; i < tt.pushed; i++ {
+				fs.push(SimulationState{Time: float64(i)})
+			}
+			got := fs.between(tt.from, tt.to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d frames, want %v", len(got), tt.want)
+			}
+			for i, st := range got {
+				if st.Time != tt.want[i] {
+					t.Errorf("frame %d at t=%g, want %g", i, st.Time, tt.want[i])
+				}
+			}
+			if from, ok := fs.fullFrom(); !ok || from != tt.fullFrom {
+				t.Errorf("full rate from %g (%v), want %g", from, ok, tt.fullFrom)
+			}
+			if want := int64(len(fs.frames)) * frameSize; fs.bytes != want {
+				t.Errorf("accounts for %d bytes, want %d", fs.bytes, want)
+			}
+		})
+	}
+}
+
+func TestRetentionValidate(t *testing.T) {
+	tests := []struct {
+		r       Retention
+		wantErr bool
+	}{
+		{Retention{}, false},
+		{Retention{Duration: 60, Frames: 100, Bytes: 1 << 20}, false},
+		{Retention{Duration: -1}, true},
+		{Retention{Frames: -1}, true},
+		{Retention{Frames: 1}, true},
+		{Retention{Bytes: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.r.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v: Validate() = %v, want error %v", tt.r, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,620 @@
+package main
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"missile-intercept-sim/internal/simulation"
+
+	"github.com/gorilla/websocket"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// Broadcast pacing and per-client limits.
+const (
+	broadcastInterval = 33 * time.Millisecond // ~30Hz update for UI
+	clientSendBuffer  = 16                    // frames queued per client before it counts as slow
+	maxPooledBuffer   = 4 << 20               // encode buffers that grew past this go to the collector
+	clientWriteWait   = 10 * time.Second
+	clientPongWait    = 30 * time.Second // silence after which a client counts as dead
+	clientPingPeriod  = 10 * time.Second // must be shorter than clientPongWait
+	maxCommandSize    = 4 << 10          // bytes per client message
+
+	// compressionLevel trades ratio for speed on compressed streams; state
+	// frames are repetitive enough that the fastest level does most of the work.
+	compressionLevel = flate.BestSpeed
+)
+
+// Hub fans the state of one session out to its WebSocket and server-sent
+// event clients. Each tick
+// the state is serialized once, or once per distinct trail window, into a
+// pooled buffer, and the same prepared message is queued to every client. A client whose queue is full is
+// evicted rather than allowed to hold up the rest.
+type Hub struct {
+	sess *Session
+
+	mu        sync.Mutex
+	clients   map[*hubClient]struct{}
+	count     atomic.Int32  // len(clients), readable without mu
+	lastEvent uint64        // newest event already broadcast
+	seq       uint64        // broadcasts so far, numbering engine frames
+	quit      chan struct{} // closes the broadcast loop; nil while idle
+}
+
+// Stream encodings a client can ask for with ?format=.
+const (
+	FormatJSON    = "json"
+	FormatMsgpack = "msgpack" // binary frames with the same field names as the JSON
+)
+
+// maxClientRate is the highest update rate a client can ask for, Hz: the
+// broadcast rate itself.
+const maxClientRate = 30
+
+// ClientOptions are the stream settings of one WebSocket client.
+type ClientOptions struct {
+	Trails   float64 // s of trails, 0 for none
+	Format   string  // FormatJSON or FormatMsgpack
+	Delta    bool    // keyframes with only the changes in between
+	Control  bool    // may send commands; observers can only ask for keyframes
+	Rate     float64 // updates per second, 0 for every broadcast
+	Filter   StreamFilter
+	Resume   *ResumeToken // last frame received before reconnecting, nil for a new client
+	Lease    string       // control lease commands are sent under
+	Compress bool         // deflate frames, if the client negotiated permessage-deflate
+	Engine   string       // EngineUnity or EngineUnreal for game-engine frames, empty for the state
+	Compact  bool         // vectors as [x, y, z] float32 arrays rather than objects
+	// RequestID is the connection's request, which its commands are logged under.
+	RequestID string
+}
+
+// hubClient is one registered connection.
+type hubClient struct {
+	ClientOptions
+	conn     *websocket.Conn // nil for a server-sent event stream
+	addr     string
+	delta    *deltaTracker // nil for full frames every tick
+	send     chan outgoing
+	interval time.Duration      // between frames, 0 for every broadcast
+	next     time.Time          // when the next frame is due
+	pending  []simulation.Event // events from broadcasts the client skipped
+	resumed  bool               // missed frames were replayed from Resume
+}
+
+// outgoing is a message queued to a client. State frames carry the resume
+// token of the state they were built from.
+type outgoing struct {
+	data     []byte
+	prepared *websocket.PreparedMessage // frame shared by every WebSocket client, nil to send data
+	token    string                     // empty for other messages
+	event    string                     // names messages other than frames on event streams
+}
+
+// stateMsg wraps a frame built from state.
+func stateMsg(data []byte, state simulation.SimulationState) outgoing {
+	return outgoing{data: data, token: ResumeToken{state.Run, state.Time}.String()}
+}
+
+// due reports whether c takes a frame at now, scheduling the one after. The
+// schedule advances by whole intervals so the average rate is the one asked
+// for, not rounded down to a multiple of the broadcast interval.
+func (c *hubClient) due(now time.Time) bool {
+	if c.interval == 0 {
+		return true
+	}
+	if now.Before(c.next) {
+		return false
+	}
+	if now.Sub(c.next) > c.interval {
+		c.next = now // first frame, or fell behind
+	}
+	c.next = c.next.Add(c.interval)
+	return true
+}
+
+// viewKey identifies what a client sees of a tick's state.
+type viewKey struct {
+	trails   float64
+	filter   string
+	engine   string
+	interval time.Duration // engine frames only, which carry it
+	compact  bool
+}
+
+// frameKey identifies one distinct encoding of a tick's state.
+type frameKey struct {
+	viewKey
+	format string
+}
+
+// messageType is the WebSocket frame type the client's format is sent as.
+func (c *hubClient) messageType() int {
+	if c.Format == FormatMsgpack {
+		return websocket.BinaryMessage
+	}
+	return websocket.TextMessage
+}
+
+// write sends msg on c's WebSocket, as prepared if it was.
+func (c *hubClient) write(msg outgoing) error {
+	c.conn.SetWriteDeadline(time.Now().Add(clientWriteWait))
+	if msg.prepared != nil {
+		return c.conn.WritePreparedMessage(msg.prepared)
+	}
+	return c.conn.WriteMessage(c.messageType(), msg.data)
+}
+
+// ParseFormat validates a requested stream encoding; empty selects JSON.
+func ParseFormat(format string) (string, error) {
+	switch format {
+	case "", FormatJSON:
+		return FormatJSON, nil
+	case FormatMsgpack:
+		return format, nil
+	}
+	return "", fmt.Errorf("unknown format %q", format)
+}
+
+func newHub(sess *Session) *Hub {
+	return &Hub{sess: sess, clients: make(map[*hubClient]struct{})}
+}
+
+func newHubClient(opts ClientOptions, conn *websocket.Conn, addr string) *hubClient {
+	c := &hubClient{ClientOptions: opts, conn: conn, addr: addr, send: make(chan outgoing, clientSendBuffer)}
+	if opts.Rate > 0 {
+		c.interval = time.Duration(float64(time.Second) / opts.Rate)
+	}
+	if opts.Delta {
+		c.delta = &deltaTracker{}
+	}
+	return c
+}
+
+// Serve registers conn and pumps frames to it until the client disconnects,
+// falls too far behind or the hub is closed.
+func (h *Hub) Serve(conn *websocket.Conn, opts ClientOptions) {
+	c := newHubClient(opts, conn, conn.RemoteAddr().String())
+	defer recovered("ws client "+c.addr, func() { h.unregister(c) })
+	conn.EnableWriteCompression(opts.Compress)
+	if opts.Compress {
+		conn.SetCompressionLevel(compressionLevel)
+	}
+	if opts.Resume != nil {
+		msgs, err := h.resume(c)
+		for _, msg := range msgs {
+			if err != nil {
+				break
+			}
+			err = c.write(msg)
+		}
+		if err != nil {
+			slog.Warn("ws resume", "err", err)
+			return
+		}
+	}
+	if err := h.register(c); err != nil {
+		slog.Warn("ws", "err", err)
+		return
+	}
+
+	// The reader runs the client's commands and watches for it going away.
+	// Pongs to the writer's pings keep the read deadline moving; a client
+	// that stops answering is dropped.
+	conn.SetReadLimit(maxCommandSize)
+	conn.SetReadDeadline(time.Now().Add(clientPongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(clientPongWait))
+	})
+	go func() {
+		defer recovered("ws reader "+c.addr, func() { h.unregister(c) })
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				h.unregister(c)
+				return
+			}
+			conn.SetReadDeadline(time.Now().Add(clientPongWait))
+			var cmd Command
+			ack := CommandAck{OK: true}
+			if err := json.Unmarshal(data, &cmd); err != nil {
+				ack.OK, ack.Error = false, "invalid command"
+			} else if err := h.execute(c, cmd); err != nil {
+				ack.OK, ack.Error = false, err.Error()
+			}
+			ack.Ack, ack.Type = cmd.ID, cmd.Type
+			h.reply(c, ack)
+		}
+	}()
+
+	ping := time.NewTicker(clientPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			if err := c.write(msg); err != nil {
+				slog.Debug("ws write", "err", err)
+				h.unregister(c)
+				return
+			}
+		case <-ping.C:
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(clientWriteWait)); err != nil {
+				h.unregister(c)
+				return
+			}
+		}
+	}
+}
+
+// register adds c, queueing a first frame that carries the event log so far,
+// or for a resumed client the events it missed, and starts the broadcast
+// loop for the first client.
+func (h *Hub) register(c *hubClient) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.sess.State()
+	if !state.Replay {
+		events := h.sess.Sim.Events(0)
+		if h.quit == nil {
+			if n := len(events); n > 0 {
+				h.lastEvent = events[n-1].Seq
+			}
+		} else {
+			// Later events arrive with the next broadcast.
+			for len(events) > 0 && events[len(events)-1].Seq > h.lastEvent {
+				events = events[:len(events)-1]
+			}
+		}
+		if c.resumed {
+			for len(events) > 0 && events[0].Time <= c.Resume.Time {
+				events = events[1:]
+			}
+		}
+		state.Events = events
+	}
+	c.due(time.Now())
+	tf := newTickFrames()
+	defer tf.release()
+	msg, err := h.frameLocked(c, state, c.Trails, tf)
+	if err != nil {
+		return err
+	}
+	c.send <- msg
+	h.clients[c] = struct{}{}
+	h.count.Add(1)
+	if h.quit == nil {
+		h.quit = make(chan struct{})
+		go h.run(h.quit)
+	}
+	return nil
+}
+
+// unregister removes c and closes its queue. The broadcast loop stops with
+// the last client. It is safe to call more than once.
+func (h *Hub) unregister(c *hubClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeLocked(c)
+}
+
+func (h *Hub) removeLocked(c *hubClient) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	h.count.Add(-1)
+	close(c.send)
+	if len(h.clients) == 0 && h.quit != nil {
+		close(h.quit)
+		h.quit = nil
+	}
+}
+
+// reply queues ack on c's stream, in the client's format.
+func (h *Hub) reply(c *hubClient, ack CommandAck) {
+	msg, err := marshal(ack, c.Format)
+	if err != nil {
+		slog.Error("ws ack", "err", err)
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.queueLocked(c, outgoing{data: msg, event: "ack"})
+}
+
+// announce queues v to every client between state frames, encoded once per
+// format. event names it on event streams.
+func (h *Hub) announce(event string, v any) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	encoded := make(map[string][]byte)
+	for c := range h.clients {
+		msg, ok := encoded[c.Format]
+		if !ok {
+			var err error
+			if msg, err = marshal(v, c.Format); err != nil {
+				slog.Error("ws announce", "err", err)
+				return
+			}
+			encoded[c.Format] = msg
+		}
+		h.queueLocked(c, outgoing{data: msg, event: event})
+	}
+}
+
+// queueLocked hands msg to c's writer, evicting the client if its queue is
+// full. Callers must hold h.mu.
+func (h *Hub) queueLocked(c *hubClient, msg outgoing) {
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	select {
+	case c.send <- msg:
+	default:
+		slog.Warn("ws: evicting slow client", "client", c.addr)
+		h.removeLocked(c)
+		if c.conn != nil {
+			c.conn.Close()
+		}
+	}
+}
+
+// requestKeyframe makes c's next delta frame a keyframe.
+func (h *Hub) requestKeyframe(c *hubClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if c.delta != nil {
+		c.delta.needKey = true
+	}
+}
+
+// Close disconnects every client, telling each the server is going away.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	bye := websocket.FormatCloseMessage(websocket.CloseGoingAway, "session closed")
+	for c := range h.clients {
+		h.removeLocked(c)
+		if c.conn != nil {
+			c.conn.WriteControl(websocket.CloseMessage, bye, time.Now().Add(time.Second))
+			c.conn.Close()
+		}
+	}
+}
+
+// Clients returns the number of connected clients. It does not wait for a
+// broadcast in progress.
+func (h *Hub) Clients() int {
+	return int(h.count.Load())
+}
+
+func (h *Hub) run(quit chan struct{}) {
+	ticker := time.NewTicker(broadcastInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			return
+		case <-ticker.C:
+			h.broadcast()
+		}
+	}
+}
+
+// broadcast prepares this tick's frames and queues them to every client.
+// A panic costs the clients this tick, not the broadcast loop.
+func (h *Hub) broadcast() {
+	defer recovered("broadcast "+h.sess.ID, nil)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	state := h.sess.State()
+	if !state.Replay {
+		state.Events = h.sess.Sim.Events(h.lastEvent)
+		if n := len(state.Events); n > 0 {
+			h.lastEvent = state.Events[n-1].Seq
+		}
+	}
+	h.seq++
+	frames := newTickFrames()
+	defer frames.release()
+	now := time.Now()
+	for c := range h.clients {
+		if !c.due(now) {
+			c.pending = append(c.pending, state.Events...)
+			continue
+		}
+		msg, err := h.frameLocked(c, state, c.Trails, frames)
+		if err != nil {
+			slog.Error("ws encode", "err", err)
+			return
+		}
+		h.queueLocked(c, msg)
+	}
+}
+
+// encodeBuffers holds the buffers frames are encoded into. The bytes are
+// copied once, into the prepared message or event stream data that outlives
+// the tick, and the buffer goes back for the next one.
+var encodeBuffers = sync.Pool{New: func() any { return new(bytes.Buffer) }}
+
+// frame is one encoding of a tick's state, shared by every client that
+// sees it.
+type frame struct {
+	buf      *bytes.Buffer              // pooled until the tick is released
+	prepared *websocket.PreparedMessage // built for the first WebSocket client
+	data     []byte                     // copied for the first event stream client
+}
+
+// message returns f queued for c, a frame of state. WebSocket clients share
+// one prepared message, which frames, and if negotiated compresses, the
+// payload once for all of them.
+func (f *frame) message(c *hubClient, state simulation.SimulationState) (outgoing, error) {
+	msg := stateMsg(nil, state)
+	if c.conn == nil {
+		if f.data == nil {
+			f.data = bytes.Clone(f.buf.Bytes())
+		}
+		msg.data = f.data
+		return msg, nil
+	}
+	if f.prepared == nil {
+		pm, err := websocket.NewPreparedMessage(c.messageType(), f.buf.Bytes())
+		if err != nil {
+			return outgoing{}, err
+		}
+		f.prepared = pm
+	}
+	msg.prepared = f.prepared
+	return msg, nil
+}
+
+// tickFrames caches one tick's encodings: full frames by view and format,
+// and split states for delta clients by view.
+type tickFrames struct {
+	full    map[frameKey]*frame
+	sources map[viewKey]*deltaSource
+}
+
+func newTickFrames() *tickFrames {
+	return &tickFrames{full: make(map[frameKey]*frame), sources: make(map[viewKey]*deltaSource)}
+}
+
+// release returns the tick's encode buffers to the pool. Messages already
+// built from them hold copies.
+func (tf *tickFrames) release() {
+	for _, f := range tf.full {
+		if f.buf.Cap() <= maxPooledBuffer {
+			f.buf.Reset()
+			encodeBuffers.Put(f.buf)
+		}
+		f.buf = nil
+	}
+}
+
+// frameLocked builds c's message for state with trails seconds of trails,
+// reusing whatever another client on the same tick already encoded. Callers
+// must hold h.mu.
+func (h *Hub) frameLocked(c *hubClient, state simulation.SimulationState, trails float64, tf *tickFrames) (outgoing, error) {
+	if len(c.pending) > 0 {
+		// Events from the broadcasts c skipped make its frame its own.
+		state.Events = append(c.pending, state.Events...)
+		c.pending = nil
+		tf = newTickFrames()
+		defer tf.release()
+	}
+	view := viewKey{trails: trails, filter: c.Filter.key(), compact: c.Compact}
+	if c.Engine != "" {
+		view = viewKey{filter: c.Filter.key(), engine: c.Engine, interval: c.interval}
+	}
+	if c.delta != nil {
+		src, ok := tf.sources[view]
+		if !ok {
+			full, err := c.Filter.marshal(h.withTrails(state, trails))
+			if err != nil {
+				return outgoing{}, err
+			}
+			if src, err = splitState(full); err != nil {
+				return outgoing{}, err
+			}
+			tf.sources[view] = src
+		}
+		data, err := c.delta.frame(src)
+		if err != nil {
+			return outgoing{}, err
+		}
+		return stateMsg(data, state), nil
+	}
+	key := frameKey{view, c.Format}
+	f, ok := tf.full[key]
+	if !ok {
+		f = &frame{buf: encodeBuffers.Get().(*bytes.Buffer)}
+		tf.full[key] = f
+		var err error
+		if c.Engine != "" {
+			interval := c.interval
+			if interval == 0 {
+				interval = broadcastInterval
+			}
+			ef := newEngineFrame(c.Filter.selectEntities(state), c.Engine, h.seq, interval, time.Now())
+			err = marshalTo(f.buf, ef, c.Format)
+		} else {
+			err = h.encode(f.buf, state, trails, c.Format, c.Filter, c.Compact)
+		}
+		if err != nil {
+			delete(tf.full, key)
+			return outgoing{}, err
+		}
+	}
+	return f.message(c, state)
+}
+
+// withTrails attaches the last trails seconds of entity trails to state.
+func (h *Hub) withTrails(state simulation.SimulationState, trails float64) simulation.SimulationState {
+	if trails > 0 && !state.Replay {
+		state.Trails = h.sess.Sim.Trails(trails)
+	}
+	return state
+}
+
+// encode serializes state into buf with trails attached, filtered and in
+// format, with compact vectors if asked.
+func (h *Hub) encode(buf *bytes.Buffer, state simulation.SimulationState, trails float64, format string, filter StreamFilter, compact bool) error {
+	state = h.withTrails(state, trails)
+	if filter.Fields == nil && !compact {
+		return marshalTo(buf, filter.selectEntities(state), format)
+	}
+	msg, err := filter.marshal(state)
+	if err != nil {
+		return err
+	}
+	if compact {
+		if msg, err = compactEncode(msg, format); err != nil {
+			return err
+		}
+		buf.Write(msg)
+		return nil
+	}
+	if format == FormatJSON {
+		buf.Write(msg)
+		return nil
+	}
+	var v any
+	if err := json.Unmarshal(msg, &v); err != nil {
+		return err
+	}
+	return marshalTo(buf, v, format)
+}
+
+// marshal encodes v in the given stream format.
+func marshal(v any, format string) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := marshalTo(&buf, v, format); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// marshalTo appends v to buf in the given stream format.
+func marshalTo(buf *bytes.Buffer, v any, format string) error {
+	if format == FormatMsgpack {
+		enc := msgpack.GetEncoder()
+		defer msgpack.PutEncoder(enc)
+		enc.Reset(buf)
+		enc.SetCustomStructTag("json") // keep the JSON field names
+		return enc.Encode(v)
+	}
+	// Encode as json.Marshal does, less the newline an Encoder ends with.
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+	buf.Truncate(buf.Len() - 1)
+	return nil
+}
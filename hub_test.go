@@ -0,0 +1,228 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/simulation"
+
+	"github.com/gorilla/websocket"
+)
+
+// dialHub serves sess's hub on a test server and connects one client to it.
+func dialHub(t *testing.T, sess *Session, query string) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		opts, err := parseClientOptions(r)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		opts.Control = r.URL.Query().Get("observer") == ""
+		sess.Hub.Serve(c, opts)
+	}))
+	t.Cleanup(srv.Close)
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/?"+query, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// nextAck reads messages until the acknowledgement of id arrives.
+func nextAck(t *testing.T, conn *websocket.Conn, id string) CommandAck {
+	t.Helper()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ack CommandAck
+		if json.Unmarshal(data, &ack) == nil && ack.Ack == id {
+			return ack
+		}
+	}
+}
+
+func TestHubCommands(t *testing.T) {
+	sess := newSession("test")
+	defer sess.Hub.Close()
+	conn := dialHub(t, sess, "")
+
+	tests := []struct {
+		cmd  string
+		ok   bool
+		errs string
+	}{
+		{`{"id":"1","type":"step","count":5}`, true, ""},
+		{`{"id":"2","type":"step","count":-1}`, false, "count must be"},
+		{`{"id":"3","type":"timescale","scale":-2}`, false, ""},
+		{`{"id":"4","type":"launch","interceptor":"nope"}`, false, "unknown interceptor"},
+		{`{"id":"5","type":"warp"}`, false, "unknown command"},
+		{`{"id":"6","type":"keyframe"}`, true, ""},
+		{`{"id":"7","type":"stop"}`, true, ""},
+	}
+	for _, tt := range tests {
+		var cmd Command
+		if err := json.Unmarshal([]byte(tt.cmd), &cmd); err != nil {
+			t.Fatal(err)
+		}
+		if err := conn.WriteMessage(websocket.TextMessage, []byte(tt.cmd)); err != nil {
+			t.Fatal(err)
+		}
+		ack := nextAck(t, conn, cmd.ID)
+		if ack.OK != tt.ok || ack.Type != cmd.Type || !strings.Contains(ack.Error, tt.errs) {
+			t.Errorf("%s: ack %+v, want ok %v error containing %q", tt.cmd, ack, tt.ok, tt.errs)
+		}
+	}
+	if got := sess.Sim.GetState().Time; got <= 0 {
+		t.Errorf("step command did not advance the run, time %g", got)
+	}
+}
+
+func TestClientDue(t *testing.T) {
+	c := &hubClient{interval: 200 * time.Millisecond}
+	start := time.Unix(0, 0)
+	sent := 0
+	// Five seconds of broadcasts at the hub's rate should yield 5Hz.
+	for now := start; now.Before(start.Add(5 * time.Second)); now = now.Add(broadcastInterval) {
+		if c.due(now) {
+			sent++
+		}
+	}
+	if sent < 24 || sent > 26 {
+		t.Errorf("sent %d frames in 5s at 5Hz", sent)
+	}
+	if every := (&hubClient{}).due(start); !every {
+		t.Error("client without a rate skipped a broadcast")
+	}
+}
+
+func TestSlowClientKeepsEvents(t *testing.T) {
+	sess := newSession("test")
+	c := &hubClient{ClientOptions: ClientOptions{Format: FormatJSON}}
+	c.pending = []simulation.Event{{Seq: 1}, {Seq: 2}}
+	state := sess.State()
+	state.Events = []simulation.Event{{Seq: 3}}
+	msg, err := sess.Hub.frameLocked(c, state, 0, newTickFrames())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got simulation.SimulationState
+	if err := json.Unmarshal(msg.data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Events) != 3 || c.pending != nil {
+		t.Errorf("frame carries %d events, pending %d; want all 3 sent", len(got.Events), len(c.pending))
+	}
+}
+
+func TestFilteredDeltaStream(t *testing.T) {
+	sess := newSession("test")
+	defer sess.Hub.Close()
+	conn := dialHub(t, sess, "delta=1&rate=5&fields=time,entities.position")
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	var f DeltaFrame
+	if err := conn.ReadJSON(&f); err != nil {
+		t.Fatal(err)
+	}
+	var state map[string]json.RawMessage
+	if err := json.Unmarshal(f.State, &state); err != nil {
+		t.Fatal(err)
+	}
+	if !f.Keyframe || len(state) != 2 || state["time"] == nil || state["entities"] == nil {
+		t.Errorf("first frame %s, want a keyframe with only time and entities", f.State)
+	}
+}
+
+func TestCompressedStream(t *testing.T) {
+	sess := newSession("test")
+	defer sess.Hub.Close()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		opts, _ := parseClientOptions(r)
+		sess.Hub.Serve(c, opts)
+	}))
+	defer srv.Close()
+	url := "ws" + strings.TrimPrefix(srv.URL, "http")
+	tests := []struct {
+		name  string
+		offer bool // client offers permessage-deflate
+		query string
+	}{
+		{"plain", false, ""},
+		{"negotiated only", true, ""},
+		{"compressed", true, "?compress=1"},
+		{"asked without offering", false, "?compress=1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dialer := websocket.Dialer{EnableCompression: tt.offer}
+			conn, resp, err := dialer.Dial(url+tt.query, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer conn.Close()
+			negotiated := strings.Contains(resp.Header.Get("Sec-Websocket-Extensions"), "permessage-deflate")
+			if negotiated != tt.offer {
+				t.Errorf("negotiated = %v, want %v", negotiated, tt.offer)
+			}
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			var st simulation.SimulationState
+			if err := conn.ReadJSON(&st); err != nil {
+				t.Fatal(err)
+			}
+			if len(st.Entities) == 0 {
+				t.Error("frame has no entities")
+			}
+		})
+	}
+}
+
+func TestFramesOutliveTick(t *testing.T) {
+	sess := newSession("test")
+	a := &hubClient{ClientOptions: ClientOptions{Format: FormatJSON}}
+	b := &hubClient{ClientOptions: ClientOptions{Format: FormatJSON}}
+	state := sess.State()
+
+	tf := newTickFrames()
+	first, err := sess.Hub.frameLocked(a, state, 0, tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	shared, err := sess.Hub.frameLocked(b, state, 0, tf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if &first.data[0] != &shared.data[0] {
+		t.Error("clients on one tick got separate encodings")
+	}
+	want := string(first.data)
+	tf.release()
+
+	// The next tick reuses the released buffer.
+	state.Time += 1
+	tf = newTickFrames()
+	defer tf.release()
+	if _, err := sess.Hub.frameLocked(a, state, 0, tf); err != nil {
+		t.Fatal(err)
+	}
+	if string(first.data) != want {
+		t.Error("a queued frame changed when its buffer was reused")
+	}
+}
@@ -0,0 +1,64 @@
+// Package bus streams messages to an event bus through pluggable drivers.
+// Drivers register a URL scheme; Open picks one by the URL it is given.
+package bus
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"sync"
+)
+
+// Publisher sends messages to a bus. Topics are dot-separated names; the
+// key groups related messages, such as those of one session, and drivers
+// map it onto the bus's own notion (a subject token, a partition key).
+type Publisher interface {
+	Publish(topic, key string, data []byte) error
+	Close() error
+}
+
+// Driver opens a publisher for a bus URL.
+type Driver func(u *url.URL) (Publisher, error)
+
+var (
+	mu      sync.RWMutex
+	drivers = make(map[string]Driver)
+)
+
+// Register makes a driver available under a URL scheme. It panics if the
+// scheme is taken.
+func Register(scheme string, d Driver) {
+	mu.Lock()
+	defer mu.Unlock()
+	if _, dup := drivers[scheme]; dup {
+		panic("bus: driver registered twice for " + scheme)
+	}
+	drivers[scheme] = d
+}
+
+// Schemes returns the registered URL schemes, sorted.
+func Schemes() []string {
+	mu.RLock()
+	defer mu.RUnlock()
+	list := make([]string, 0, len(drivers))
+	for s := range drivers {
+		list = append(list, s)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Open connects to the bus at rawURL with the driver for its scheme.
+func Open(rawURL string) (Publisher, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("bus: %w", err)
+	}
+	mu.RLock()
+	d, ok := drivers[u.Scheme]
+	mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("bus: no driver for scheme %q; have %v", u.Scheme, Schemes())
+	}
+	return d(u)
+}
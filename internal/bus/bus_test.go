@@ -0,0 +1,123 @@
+package bus
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestOpenUnknownScheme(t *testing.T) {
+	if _, err := Open("amqp://localhost:5672"); err == nil {
+		t.Error("opened a URL with no driver")
+	}
+}
+
+// fakeNATS plays a NATS server on conn, sending reply to CONNECT, and
+// returns the protocol lines it receives after that.
+func fakeNATS(t *testing.T, conn net.Conn, reply string) <-chan string {
+	lines := make(chan string, 16)
+	go func() {
+		defer close(lines)
+		conn.Write([]byte(`INFO {"server_id":"test","max_payload":64}` + "\r\n"))
+		r := bufio.NewReader(conn)
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimSpace(line)
+			if line == "PING" {
+				conn.Write([]byte(reply + "\r\n"))
+				continue
+			}
+			lines <- line
+		}
+	}()
+	return lines
+}
+
+func TestNATS(t *testing.T) {
+	client, server := net.Pipe()
+	lines := fakeNATS(t, server, "PONG")
+	c, err := newNATS(client, url.UserPassword("sim", "pw"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	connect := <-lines
+	var opts map[string]any
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(connect, "CONNECT ")), &opts); err != nil || opts["user"] != "sim" || opts["pass"] != "pw" {
+		t.Fatalf("CONNECT %q", connect)
+	}
+
+	tests := []struct {
+		topic, key, data string
+		want             []string
+		ok               bool
+	}{
+		{"sim.state", "default", `{"time":1}`, []string{"PUB sim.state.default 10", `{"time":1}`}, true},
+		{"sim.events", "", `{}`, []string{"PUB sim.events 2", `{}`}, true},
+		{"sim.state", "default", strings.Repeat("x", 65), nil, false}, // over max_payload
+	}
+	for _, tt := range tests {
+		err := c.Publish(tt.topic, tt.key, []byte(tt.data))
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.topic, err)
+		}
+		for _, want := range tt.want {
+			if got := <-lines; got != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		}
+	}
+}
+
+func TestNATSRefused(t *testing.T) {
+	client, server := net.Pipe()
+	fakeNATS(t, server, "-ERR 'Authorization Violation'")
+	if _, err := newNATS(client, url.User("bad-token")); err == nil {
+		t.Fatal("connected despite -ERR")
+	}
+}
+
+func TestKafkaREST(t *testing.T) {
+	type produced struct {
+		path, contentType, body string
+	}
+	got := make(chan produced, 1)
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- produced{r.URL.Path, r.Header.Get("Content-Type"), string(body)}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	p, err := Open("kafka://" + strings.TrimPrefix(srv.URL, "http://") + "/proxy/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := p.Publish("sim.events", "default", []byte(`{"type":"Intercept"}`)); err != nil {
+		t.Fatal(err)
+	}
+	req := <-got
+	if req.path != "/proxy/topics/sim.events" || req.contentType != "application/vnd.kafka.json.v2+json" {
+		t.Errorf("produced to %s as %s", req.path, req.contentType)
+	}
+	if want := `{"records":[{"key":"default","value":{"type":"Intercept"}}]}`; req.body != want {
+		t.Errorf("body %s, want %s", req.body, want)
+	}
+
+	status = http.StatusNotFound
+	if err := p.Publish("missing", "", []byte(`{}`)); err == nil {
+		t.Error("no error from a failed produce")
+	}
+	<-got
+}
@@ -0,0 +1,75 @@
+package bus
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// kafkaTimeout bounds one produce request.
+const kafkaTimeout = 10 * time.Second
+
+func init() {
+	Register("kafka", openKafka)
+	Register("kafka+https", openKafka)
+}
+
+// kafkaREST produces to Kafka through a Kafka REST Proxy (v2 API), which
+// keeps the server free of a native Kafka client. Each message goes to its
+// topic with its key as the record key, so one session's messages stay in
+// order on one partition. Messages must be JSON, which the proxy embeds as
+// record values.
+type kafkaREST struct {
+	base   string // proxy URL without a trailing slash
+	client *http.Client
+}
+
+// openKafka opens kafka://host:port for a proxy served over HTTP, or
+// kafka+https://host:port over HTTPS. A path prefix is kept.
+func openKafka(u *url.URL) (Publisher, error) {
+	if u.Host == "" {
+		return nil, fmt.Errorf("bus: kafka URL %q has no host", u.Redacted())
+	}
+	proxy := *u
+	proxy.Scheme = "http"
+	if u.Scheme == "kafka+https" {
+		proxy.Scheme = "https"
+	}
+	return &kafkaREST{
+		base:   strings.TrimSuffix(proxy.String(), "/"),
+		client: &http.Client{Timeout: kafkaTimeout},
+	}, nil
+}
+
+func (k *kafkaREST) Publish(topic, key string, data []byte) error {
+	type record struct {
+		Key   string          `json:"key,omitempty"`
+		Value json.RawMessage `json:"value"`
+	}
+	body, err := json.Marshal(struct {
+		Records []record `json:"records"`
+	}{[]record{{key, data}}})
+	if err != nil {
+		return err
+	}
+	resp, err := k.client.Post(k.base+"/topics/"+url.PathEscape(topic), "application/vnd.kafka.json.v2+json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("kafka: producing to %s: %s: %s", topic, resp.Status, bytes.TrimSpace(msg))
+	}
+	return nil
+}
+
+func (k *kafkaREST) Close() error {
+	k.client.CloseIdleConnections()
+	return nil
+}
@@ -0,0 +1,163 @@
+package bus
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// natsTimeout bounds dialing and the connect handshake.
+const natsTimeout = 10 * time.Second
+
+func init() {
+	Register("nats", openNATS)
+}
+
+// natsConn publishes to a NATS server with the core text protocol. A
+// message's subject is its topic followed by its key.
+type natsConn struct {
+	conn       net.Conn
+	maxPayload int
+
+	mu     sync.Mutex // serializes writes
+	w      *bufio.Writer
+	closed chan struct{}
+	once   sync.Once
+	err    error
+}
+
+// openNATS connects to nats://[user:pass@]host:port, or nats://token@host:port.
+func openNATS(u *url.URL) (Publisher, error) {
+	conn, err := net.DialTimeout("tcp", u.Host, natsTimeout)
+	if err != nil {
+		return nil, err
+	}
+	c, err := newNATS(conn, u.User)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+func newNATS(conn net.Conn, user *url.Userinfo) (*natsConn, error) {
+	conn.SetDeadline(time.Now().Add(natsTimeout))
+	r := bufio.NewReader(conn)
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, fmt.Errorf("nats: reading INFO: %w", err)
+	}
+	info, ok := strings.CutPrefix(strings.TrimSpace(line), "INFO ")
+	if !ok {
+		return nil, fmt.Errorf("nats: expected INFO, got %q", line)
+	}
+	var server struct {
+		MaxPayload int `json:"max_payload"`
+	}
+	if err := json.Unmarshal([]byte(info), &server); err != nil {
+		return nil, fmt.Errorf("nats: INFO: %w", err)
+	}
+
+	opts := map[string]any{"verbose": false, "pedantic": false, "name": "missile-intercept-sim", "lang": "go", "version": "1"}
+	if user != nil {
+		if pass, ok := user.Password(); ok {
+			opts["user"], opts["pass"] = user.Username(), pass
+		} else {
+			opts["auth_token"] = user.Username()
+		}
+	}
+	connect, err := json.Marshal(opts)
+	if err != nil {
+		return nil, err
+	}
+	c := &natsConn{conn: conn, maxPayload: server.MaxPayload, w: bufio.NewWriter(conn), closed: make(chan struct{})}
+	// The PING makes the server answer, with an error if it refused us.
+	fmt.Fprintf(c.w, "CONNECT %s\r\nPING\r\n", connect)
+	if err := c.w.Flush(); err != nil {
+		return nil, err
+	}
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, fmt.Errorf("nats: connecting: %w", err)
+		}
+		line = strings.TrimSpace(line)
+		if line == "PONG" {
+			break
+		}
+		if strings.HasPrefix(line, "-ERR") {
+			return nil, fmt.Errorf("nats: %s", line)
+		}
+	}
+	conn.SetDeadline(time.Time{})
+	go c.read(r)
+	return c, nil
+}
+
+func (c *natsConn) Publish(topic, key string, data []byte) error {
+	if c.maxPayload > 0 && len(data) > c.maxPayload {
+		return fmt.Errorf("nats: %d byte message exceeds the server's %d byte limit", len(data), c.maxPayload)
+	}
+	subject := topic
+	if key != "" {
+		subject += "." + key
+	}
+	return c.write(func(w *bufio.Writer) {
+		fmt.Fprintf(w, "PUB %s %d\r\n", subject, len(data))
+		w.Write(data)
+		w.WriteString("\r\n")
+	})
+}
+
+func (c *natsConn) Close() error {
+	c.shutdown(errors.New("nats: connection closed"))
+	return nil
+}
+
+func (c *natsConn) write(f func(*bufio.Writer)) error {
+	select {
+	case <-c.closed:
+		return c.err
+	default:
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	f(c.w)
+	if err := c.w.Flush(); err != nil {
+		c.shutdown(err)
+		return err
+	}
+	return nil
+}
+
+// read answers the server's pings and watches for errors and disconnects.
+func (c *natsConn) read(r *bufio.Reader) {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			c.shutdown(err)
+			return
+		}
+		switch line = strings.TrimSpace(line); {
+		case line == "PING":
+			c.write(func(w *bufio.Writer) { w.WriteString("PONG\r\n") })
+		case strings.HasPrefix(line, "-ERR"):
+			c.shutdown(fmt.Errorf("nats: %s", line))
+			return
+		}
+	}
+}
+
+func (c *natsConn) shutdown(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.closed)
+		c.conn.Close()
+	})
+}
@@ -0,0 +1,243 @@
+// Package dis encodes the IEEE 1278.1 Distributed Interactive Simulation
+// PDUs the simulator emits: Entity State, Fire and Detonation, in protocol
+// version 7. All coordinates are earth-centred, earth-fixed.
+package dis
+
+import (
+	"encoding/binary"
+	"math"
+	"time"
+)
+
+// ProtocolVersion is IEEE 1278.1-2012.
+const ProtocolVersion = 7
+
+// PDU types and their families.
+const (
+	typeEntityState = 1
+	typeFire        = 2
+	typeDetonation  = 3
+
+	familyEntityInformation = 1
+	familyWarfare           = 2
+)
+
+// Force IDs.
+const (
+	ForceOther    = 0
+	ForceFriendly = 1
+	ForceOpposing = 2
+	ForceNeutral  = 3
+)
+
+// Detonation results.
+const (
+	ResultOther           = 0
+	ResultEntityImpact    = 1
+	ResultEntityProximate = 2
+	ResultGroundImpact    = 3
+	ResultNone            = 6 // dud, or the weapon never detonated
+)
+
+// Dead reckoning algorithm: rate of position with constant acceleration,
+// fixed orientation, in world coordinates.
+const drmFVW = 5
+
+// EntityID identifies an entity, or with Entity holding an event number,
+// an event, in an exercise.
+type EntityID struct {
+	Site, Application, Entity uint16
+}
+
+// EntityType is the seven-part DIS entity type enumeration.
+type EntityType struct {
+	Kind, Domain                           uint8
+	Country                                uint16
+	Category, Subcategory, Specific, Extra uint8
+}
+
+// Vector is an ECEF position in metres or a velocity or acceleration in
+// metres per second (squared).
+type Vector [3]float64
+
+// Orientation holds the Euler angles psi, theta and phi of an entity's body
+// axes relative to the ECEF axes, radians.
+type Orientation struct {
+	Psi, Theta, Phi float64
+}
+
+// OrientationFromAxes returns the Euler angles of a body whose forward
+// (x) and right (y) axes point along the given ECEF unit vectors.
+func OrientationFromAxes(forward, right Vector) Orientation {
+	psi := math.Atan2(forward[1], forward[0])
+	theta := math.Atan2(-forward[2], math.Hypot(forward[0], forward[1]))
+	// The right axis of the body before roll, and its down axis.
+	sinPsi, cosPsi := math.Sincos(psi)
+	sinTheta, cosTheta := math.Sincos(theta)
+	y0 := Vector{-sinPsi, cosPsi, 0}
+	z0 := Vector{cosPsi * sinTheta, sinPsi * sinTheta, cosTheta}
+	phi := math.Atan2(dot(right, z0), dot(right, y0))
+	return Orientation{psi, theta, phi}
+}
+
+func dot(a, b Vector) float64 {
+	return a[0]*b[0] + a[1]*b[1] + a[2]*b[2]
+}
+
+// EntityState is an Entity State PDU.
+type EntityState struct {
+	Exercise     uint8
+	Time         time.Time
+	ID           EntityID
+	Force        uint8
+	Type         EntityType
+	Location     Vector
+	Velocity     Vector
+	Acceleration Vector
+	Orientation  Orientation
+	Appearance   uint32
+	Marking      string // up to 11 ASCII characters
+}
+
+// EventPDU holds what Fire and Detonation PDUs have in common.
+type EventPDU struct {
+	Exercise uint8
+	Time     time.Time
+	Shooter  EntityID
+	Target   EntityID // zero if unknown
+	Munition EntityID // the weapon's own entity
+	Event    EntityID // Entity holds the event number
+	Location Vector
+	Velocity Vector
+	Warhead  EntityType // the munition's type
+	Quantity uint16
+}
+
+// Fire is a Fire PDU, sent when a weapon is launched.
+type Fire struct {
+	EventPDU
+	Range float64 // m, 0 if unknown
+}
+
+// Detonation is a Detonation PDU, sent when a weapon detonates or is
+// otherwise done.
+type Detonation struct {
+	EventPDU
+	Result uint8
+}
+
+// Marshal encodes the PDU.
+func (p *EntityState) Marshal() []byte {
+	b := header(nil, p.Exercise, typeEntityState, familyEntityInformation, p.Time)
+	b = appendID(b, p.ID)
+	b = append(b, p.Force, 0) // no variable parameters
+	b = appendType(b, p.Type)
+	b = appendType(b, p.Type) // alternative type
+	b = appendVector32(b, p.Velocity)
+	b = appendVector64(b, p.Location)
+	b = appendFloat32(b, p.Orientation.Psi)
+	b = appendFloat32(b, p.Orientation.Theta)
+	b = appendFloat32(b, p.Orientation.Phi)
+	b = binary.BigEndian.AppendUint32(b, p.Appearance)
+	b = append(b, drmFVW)
+	b = append(b, make([]byte, 15)...)
+	b = appendVector32(b, p.Acceleration)
+	b = appendVector32(b, Vector{}) // angular velocity
+	b = append(b, 1)                // ASCII marking
+	var marking [11]byte
+	copy(marking[:], p.Marking)
+	b = append(b, marking[:]...)
+	b = binary.BigEndian.AppendUint32(b, 0) // capabilities
+	return setLength(b)
+}
+
+// Marshal encodes the PDU.
+func (p *Fire) Marshal() []byte {
+	b := header(nil, p.Exercise, typeFire, familyWarfare, p.Time)
+	b = appendID(b, p.Shooter)
+	b = appendID(b, p.Target)
+	b = appendID(b, p.Munition)
+	b = appendID(b, p.Event)
+	b = binary.BigEndian.AppendUint32(b, 0) // fire mission index
+	b = appendVector64(b, p.Location)
+	b = appendBurst(b, p.Warhead, p.Quantity)
+	b = appendVector32(b, p.Velocity)
+	b = appendFloat32(b, p.Range)
+	return setLength(b)
+}
+
+// Marshal encodes the PDU.
+func (p *Detonation) Marshal() []byte {
+	b := header(nil, p.Exercise, typeDetonation, familyWarfare, p.Time)
+	b = appendID(b, p.Shooter)
+	b = appendID(b, p.Target)
+	b = appendID(b, p.Munition)
+	b = appendID(b, p.Event)
+	b = appendVector32(b, p.Velocity)
+	b = appendVector64(b, p.Location)
+	b = appendBurst(b, p.Warhead, p.Quantity)
+	b = appendVector32(b, Vector{})  // location relative to the target
+	b = append(b, p.Result, 0, 0, 0) // no variable parameters, padding
+	return setLength(b)
+}
+
+// header appends a PDU header with the length left to setLength.
+func header(b []byte, exercise, pduType, family uint8, t time.Time) []byte {
+	b = append(b, ProtocolVersion, exercise, pduType, family)
+	b = binary.BigEndian.AppendUint32(b, Timestamp(t))
+	return append(b, 0, 0, 0, 0) // length, PDU status, padding
+}
+
+func setLength(b []byte) []byte {
+	binary.BigEndian.PutUint16(b[8:], uint16(len(b)))
+	return b
+}
+
+// Timestamp encodes t as a relative DIS timestamp: units of 3600/2^31 s
+// past the hour, with the low bit clear.
+func Timestamp(t time.Time) uint32 {
+	past := t.Sub(t.Truncate(time.Hour)).Seconds()
+	units := uint32(past / 3600 * (1 << 31))
+	return units << 1
+}
+
+func appendID(b []byte, id EntityID) []byte {
+	b = binary.BigEndian.AppendUint16(b, id.Site)
+	b = binary.BigEndian.AppendUint16(b, id.Application)
+	return binary.BigEndian.AppendUint16(b, id.Entity)
+}
+
+func appendType(b []byte, t EntityType) []byte {
+	b = append(b, t.Kind, t.Domain)
+	b = binary.BigEndian.AppendUint16(b, t.Country)
+	return append(b, t.Category, t.Subcategory, t.Specific, t.Extra)
+}
+
+// appendBurst appends a munition descriptor with a high-explosive warhead
+// and proximity fuse.
+func appendBurst(b []byte, munition EntityType, quantity uint16) []byte {
+	const warheadHE, fuseProximity = 1000, 5000
+	b = appendType(b, munition)
+	b = binary.BigEndian.AppendUint16(b, warheadHE)
+	b = binary.BigEndian.AppendUint16(b, fuseProximity)
+	b = binary.BigEndian.AppendUint16(b, quantity)
+	return binary.BigEndian.AppendUint16(b, 0) // rate
+}
+
+func appendFloat32(b []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint32(b, math.Float32bits(float32(f)))
+}
+
+func appendVector32(b []byte, v Vector) []byte {
+	for _, f := range v {
+		b = appendFloat32(b, f)
+	}
+	return b
+}
+
+func appendVector64(b []byte, v Vector) []byte {
+	for _, f := range v {
+		b = binary.BigEndian.AppendUint64(b, math.Float64bits(f))
+	}
+	return b
+}
@@ -0,0 +1,82 @@
+package dis
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestPDULayout(t *testing.T) {
+	at := time.Date(2026, 10, 16, 14, 30, 0, 0, time.UTC) // half past the hour
+	tests := []struct {
+		name    string
+		pdu     []byte
+		length  int
+		pduType uint8
+		family  uint8
+	}{
+		{"entity state", (&EntityState{Exercise: 3, Time: at, Marking: "missile-1-with-a-long-name"}).Marshal(), 144, typeEntityState, familyEntityInformation},
+		{"fire", (&Fire{EventPDU: EventPDU{Exercise: 3, Time: at}}).Marshal(), 96, typeFire, familyWarfare},
+		{"detonation", (&Detonation{EventPDU: EventPDU{Exercise: 3, Time: at}}).Marshal(), 104, typeDetonation, familyWarfare},
+	}
+	for _, tt := range tests {
+		b := tt.pdu
+		if len(b) != tt.length || int(binary.BigEndian.Uint16(b[8:])) != tt.length {
+			t.Errorf("%s: %d bytes, length field %d, want %d", tt.name, len(b), binary.BigEndian.Uint16(b[8:]), tt.length)
+		}
+		if b[0] != ProtocolVersion || b[1] != 3 || b[2] != tt.pduType || b[3] != tt.family {
+			t.Errorf("%s: header % x", tt.name, b[:4])
+		}
+		if ts := binary.BigEndian.Uint32(b[4:]); ts != 1<<31 {
+			t.Errorf("%s: timestamp %#x, want half an hour", tt.name, ts)
+		}
+	}
+}
+
+func TestEntityStateFields(t *testing.T) {
+	p := EntityState{
+		ID:       EntityID{1, 2, 3},
+		Force:    ForceFriendly,
+		Type:     EntityType{Kind: 2, Domain: 1, Country: 225, Category: 1},
+		Location: Vector{6378137, 0, 0},
+		Velocity: Vector{0, 300, 0},
+		Marking:  "M1",
+	}
+	b := p.Marshal()
+	if id := [3]uint16{binary.BigEndian.Uint16(b[12:]), binary.BigEndian.Uint16(b[14:]), binary.BigEndian.Uint16(b[16:])}; id != [3]uint16{1, 2, 3} {
+		t.Errorf("entity ID %v", id)
+	}
+	if b[18] != ForceFriendly || b[20] != 2 || binary.BigEndian.Uint16(b[22:]) != 225 {
+		t.Errorf("force and type % x", b[18:28])
+	}
+	if vy := math.Float32frombits(binary.BigEndian.Uint32(b[40:])); vy != 300 {
+		t.Errorf("velocity y %g", vy)
+	}
+	if x := math.Float64frombits(binary.BigEndian.Uint64(b[48:])); x != 6378137 {
+		t.Errorf("location x %g", x)
+	}
+	if string(b[129:131]) != "M1" || b[128] != 1 {
+		t.Errorf("marking % x", b[128:140])
+	}
+}
+
+func TestOrientationFromAxes(t *testing.T) {
+	const deg = math.Pi / 180
+	tests := []struct {
+		name           string
+		forward, right Vector
+		want           Orientation
+	}{
+		{"along x, level", Vector{1, 0, 0}, Vector{0, 1, 0}, Orientation{0, 0, 0}},
+		{"along y", Vector{0, 1, 0}, Vector{-1, 0, 0}, Orientation{90 * deg, 0, 0}},
+		{"pitched down the z axis", Vector{0, 0, -1}, Vector{0, 1, 0}, Orientation{0, 90 * deg, 0}},
+		{"rolled right", Vector{1, 0, 0}, Vector{0, 0, 1}, Orientation{0, 0, 90 * deg}},
+	}
+	for _, tt := range tests {
+		got := OrientationFromAxes(tt.forward, tt.right)
+		if math.Abs(got.Psi-tt.want.Psi) > 1e-9 || math.Abs(got.Theta-tt.want.Theta) > 1e-9 || math.Abs(got.Phi-tt.want.Phi) > 1e-9 {
+			t.Errorf("%s: got %+v, want %+v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,194 @@
+// Package flightsim encodes the UDP packets that place vehicles in desktop
+// flight simulators: FlightGear's native FDM and multiplayer protocols, and
+// X-Plane's VEHX. The native FDM and VEHX vehicle 0 drive the simulator's
+// own aircraft, which its chase view follows; multiplayer and the other
+// VEHX vehicles show everything else.
+package flightsim
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"time"
+
+	"missile-intercept-sim/internal/units"
+)
+
+// Pose is where a vehicle is and how it is moving.
+type Pose struct {
+	Lat, Lon, Alt        float64    // degrees, metres above the ellipsoid
+	Heading, Pitch, Roll float64    // degrees, heading true
+	North, East, Down    float64    // velocity, m/s
+	ECEF                 [3]float64 // the position, earth-centred earth-fixed metres
+	Speed                float64    // m/s along the nose
+}
+
+// FDMVersion is the version of FlightGear's FGNetFDM structure encoded by
+// NativeFDM.
+const FDMVersion = 24
+
+// fgNetFDM is FlightGear's net_fdm.hxx structure, version 24, with four
+// engines, four tanks and three wheels. Every field is big endian.
+type fgNetFDM struct {
+	Version, Padding                               uint32
+	Longitude, Latitude, Altitude                  float64 // radians, radians, metres
+	AGL, Phi, Theta, Psi, Alpha, Beta              float32 // metres, radians
+	PhiDot, ThetaDot, PsiDot                       float32
+	VCAS, ClimbRate                                float32 // knots, ft/s
+	VNorth, VEast, VDown                           float32 // ft/s
+	VBodyU, VBodyV, VBodyW                         float32 // ft/s
+	AXPilot, AYPilot, AZPilot                      float32
+	StallWarning, SlipDeg                          float32
+	NumEngines                                     uint32
+	EngState                                       [4]uint32
+	RPM, FuelFlow, FuelPx, EGT, CHT, MPOSI, TIT    [4]float32
+	OilTemp, OilPx                                 [4]float32
+	NumTanks                                       uint32
+	FuelQuantity                                   [4]float32
+	NumWheels                                      uint32
+	WOW                                            [3]uint32
+	GearPos, GearSteer, GearCompression            [3]float32
+	CurTime                                        uint32
+	Warp                                           int32
+	Visibility                                     float32
+	Elevator, ElevatorTrimTab, LeftFlap, RightFlap float32
+	LeftAileron, RightAileron, Rudder, NoseWheel   float32
+	Speedbrake, Spoilers                           float32
+}
+
+// NativeFDM encodes p for FlightGear started with
+// --native-fdm=socket,in,<hz>,,<port>,udp --fdm=null, which then flies its
+// own aircraft exactly as told.
+func NativeFDM(p Pose, now time.Time) []byte {
+	f := fgNetFDM{
+		Version:    FDMVersion,
+		Longitude:  units.Radians(p.Lon),
+		Latitude:   units.Radians(p.Lat),
+		Altitude:   p.Alt,
+		Phi:        float32(units.Radians(p.Roll)),
+		Theta:      float32(units.Radians(p.Pitch)),
+		Psi:        float32(units.Radians(p.Heading)),
+		VCAS:       float32(units.ToKnots(p.Speed)),
+		ClimbRate:  float32(-p.Down * units.FeetPerMetre),
+		VNorth:     float32(p.North * units.FeetPerMetre),
+		VEast:      float32(p.East * units.FeetPerMetre),
+		VDown:      float32(p.Down * units.FeetPerMetre),
+		VBodyU:     float32(p.Speed * units.FeetPerMetre),
+		CurTime:    uint32(now.Unix()),
+		Visibility: 30000,
+	}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, &f)
+	return buf.Bytes()
+}
+
+// FlightGear multiplayer protocol constants.
+const (
+	mpMagic      = 0x46474653 // "FGFS"
+	mpVersion    = 0x00010001
+	mpPositionID = 7
+	// MaxCallsign is the longest callsign multiplayer carries.
+	MaxCallsign = 7
+	mpModelLen  = 96
+)
+
+type mpHeader struct {
+	Magic, Version, MsgID, MsgLen uint32
+	RequestedRange, ReplyPort     uint32
+	Callsign                      [8]byte
+}
+
+type mpPosition struct {
+	Model                     [mpModelLen]byte
+	Time, Lag                 float64
+	Position                  [3]float64 // ECEF metres
+	Orientation               [3]float32 // ECEF to body, angle times axis
+	LinearVel, AngularVel     [3]float32 // body frame
+	LinearAccel, AngularAccel [3]float32
+	Pad                       uint32
+}
+
+// Multiplayer encodes p as a FlightGear multiplayer position message for the
+// aircraft callsign, drawn with model, a path such as
+// "Aircraft/ufo/Models/ufo.xml". simTime must increase between messages for
+// FlightGear to interpolate. Send it to FlightGear's --multiplay=in port.
+func Multiplayer(callsign, model string, p Pose, simTime float64) []byte {
+	h := mpHeader{
+		Magic:          mpMagic,
+		Version:        mpVersion,
+		MsgID:          mpPositionID,
+		MsgLen:         uint32(binary.Size(mpHeader{}) + binary.Size(mpPosition{})),
+		RequestedRange: 100,
+	}
+	copy(h.Callsign[:MaxCallsign], callsign)
+	m := mpPosition{Time: simTime, Position: p.ECEF}
+	copy(m.Model[:mpModelLen-1], model)
+	q := bodyQuat(p)
+	m.Orientation = q.angleAxis()
+	m.LinearVel = [3]float32{float32(p.Speed), 0, 0}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.BigEndian, &h)
+	binary.Write(&buf, binary.BigEndian, &m)
+	return buf.Bytes()
+}
+
+// quat is a rotation quaternion in SimGear's convention.
+type quat struct{ w, x, y, z float64 }
+
+func (a quat) mul(b quat) quat {
+	return quat{
+		w: a.w*b.w - a.x*b.x - a.y*b.y - a.z*b.z,
+		x: a.w*b.x + a.x*b.w + a.y*b.z - a.z*b.y,
+		y: a.w*b.y - a.x*b.z + a.y*b.w + a.z*b.x,
+		z: a.w*b.z + a.x*b.y - a.y*b.x + a.z*b.w,
+	}
+}
+
+// angleAxis returns the rotation as its axis scaled by its angle.
+func (q quat) angleAxis() [3]float32 {
+	if q.w < 0 {
+		q = quat{-q.w, -q.x, -q.y, -q.z}
+	}
+	s := math.Sqrt(q.x*q.x + q.y*q.y + q.z*q.z)
+	if s == 0 {
+		return [3]float32{}
+	}
+	angle := 2 * math.Atan2(s, q.w)
+	return [3]float32{float32(q.x / s * angle), float32(q.y / s * angle), float32(q.z / s * angle)}
+}
+
+// bodyQuat rotates ECEF axes to p's body axes: to the local north-east-down
+// frame at p's latitude and longitude, then by its heading, pitch and roll.
+func bodyQuat(p Pose) quat {
+	lon, lat := units.Radians(p.Lon), units.Radians(p.Lat)
+	sz, cz := math.Sincos(lon / 2)
+	sy, cy := math.Sincos(-math.Pi/4 - lat/2)
+	horizon := quat{w: cz * cy, x: -sz * sy, y: cz * sy, z: sz * cy}
+
+	sz, cz = math.Sincos(units.Radians(p.Heading) / 2)
+	sy, cy = math.Sincos(units.Radians(p.Pitch) / 2)
+	sx, cx := math.Sincos(units.Radians(p.Roll) / 2)
+	body := quat{
+		w: cx*cy*cz + sx*sy*sz,
+		x: sx*cy*cz - cx*sy*sz,
+		y: cx*sy*cz + sx*cy*sz,
+		z: cx*cy*sz - sx*sy*cz,
+	}
+	return horizon.mul(body)
+}
+
+// VEHX encodes p as an X-Plane VEHX packet placing aircraft index, 0 being
+// the user's own, which the chase view follows. X-Plane takes it on its
+// receive port, 49000 by default.
+func VEHX(index int, p Pose) []byte {
+	b := make([]byte, 0, 45)
+	b = append(b, "VEHX\x00"...)
+	b = binary.LittleEndian.AppendUint32(b, uint32(int32(index)))
+	for _, v := range []float64{p.Lat, p.Lon, p.Alt} {
+		b = binary.LittleEndian.AppendUint64(b, math.Float64bits(v))
+	}
+	for _, v := range []float64{p.Heading, p.Pitch, p.Roll} {
+		b = binary.LittleEndian.AppendUint32(b, math.Float32bits(float32(v)))
+	}
+	return b
+}
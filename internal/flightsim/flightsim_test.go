@@ -0,0 +1,79 @@
+package flightsim
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/units"
+)
+
+// rotate applies the rotation q to v.
+func rotate(q quat, v [3]float64) [3]float64 {
+	r := q.mul(quat{0, v[0], v[1], v[2]}).mul(quat{q.w, -q.x, -q.y, -q.z})
+	return [3]float64{r.x, r.y, r.z}
+}
+
+func TestBodyQuat(t *testing.T) {
+	tests := []struct {
+		name string
+		p    Pose
+		nose [3]float64 // ECEF unit vector
+	}{
+		{"north at the origin", Pose{}, [3]float64{0, 0, 1}},
+		{"east at the origin", Pose{Heading: 90}, [3]float64{0, 1, 0}},
+		{"straight up", Pose{Pitch: 90}, [3]float64{1, 0, 0}},
+		{"north at 90E", Pose{Lon: 90}, [3]float64{0, 0, 1}},
+		{"east at 90E", Pose{Lon: 90, Heading: 90}, [3]float64{-1, 0, 0}},
+		{"south at the pole", Pose{Lat: 90, Heading: 180}, [3]float64{1, 0, 0}},
+	}
+	for _, tt := range tests {
+		// Rebuild the rotation from the angle-axis multiplayer sends.
+		aa := bodyQuat(tt.p).angleAxis()
+		angle := math.Sqrt(float64(aa[0]*aa[0] + aa[1]*aa[1] + aa[2]*aa[2]))
+		q := quat{w: 1}
+		if angle > 0 {
+			s, c := math.Sincos(angle / 2)
+			q = quat{c, float64(aa[0]) / angle * s, float64(aa[1]) / angle * s, float64(aa[2]) / angle * s}
+		}
+		got := rotate(q, [3]float64{1, 0, 0})
+		for i := range got {
+			if math.Abs(got[i]-tt.nose[i]) > 1e-5 {
+				t.Errorf("%s: nose points %v, want %v", tt.name, got, tt.nose)
+				break
+			}
+		}
+	}
+}
+
+func TestPackets(t *testing.T) {
+	p := Pose{Lat: 36.2, Lon: -115, Alt: 900, Heading: 45, Pitch: 10, North: 100, East: 100, Down: -20, Speed: 143}
+
+	fdm := NativeFDM(p, time.Unix(1000, 0))
+	if len(fdm) != 408 || binary.BigEndian.Uint32(fdm) != FDMVersion {
+		t.Errorf("native FDM is %d bytes, version %d; want 408 bytes of version %d", len(fdm), binary.BigEndian.Uint32(fdm), FDMVersion)
+	}
+	if lat := math.Float64frombits(binary.BigEndian.Uint64(fdm[16:])); math.Abs(lat-units.Radians(36.2)) > 1e-12 {
+		t.Errorf("native FDM latitude %g", lat)
+	}
+
+	mp := Multiplayer("int-1", "Aircraft/ufo/Models/ufo.xml", p, 3.5)
+	if len(mp) != 232 || binary.BigEndian.Uint32(mp) != mpMagic || binary.BigEndian.Uint32(mp[12:]) != 232 {
+		t.Errorf("multiplayer message is %d bytes: % x", len(mp), mp[:16])
+	}
+	if string(mp[24:29]) != "int-1" || mp[29] != 0 || string(mp[32:36]) != "Airc" {
+		t.Errorf("multiplayer callsign %q, model %q", mp[24:32], mp[32:40])
+	}
+	if long := Multiplayer("a-very-long-callsign", "", p, 0); long[31] != 0 {
+		t.Error("callsign is not NUL terminated")
+	}
+
+	vehx := VEHX(2, p)
+	if len(vehx) != 45 || string(vehx[:5]) != "VEHX\x00" || binary.LittleEndian.Uint32(vehx[5:]) != 2 {
+		t.Errorf("VEHX % x", vehx)
+	}
+	if psi := math.Float32frombits(binary.LittleEndian.Uint32(vehx[33:])); psi != 45 {
+		t.Errorf("VEHX heading %g", psi)
+	}
+}
@@ -0,0 +1,167 @@
+// Package frames converts positions and vectors between the coordinate
+// frames the simulator meets: its local frame, east-north-up (ENU),
+// north-east-down (NED), earth-centred earth-fixed (ECEF) and WGS-84
+// geodetic latitude, longitude and altitude.
+//
+// The local frame is ENU with its axes reordered: X east, Y up, Z north.
+package frames
+
+import (
+	"math"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// The WGS-84 ellipsoid.
+const (
+	SemiMajor  = 6378137.0         // m
+	Flattening = 1 / 298.257223563 // (a - b) / a
+	SemiMinor  = SemiMajor * (1 - Flattening)
+
+	e2  = Flattening * (2 - Flattening)              // first eccentricity squared
+	ep2 = e2 / ((1 - Flattening) * (1 - Flattening)) // second eccentricity squared
+)
+
+// Geodetic is a WGS-84 position.
+type Geodetic struct {
+	Lat float64 `json:"lat"` // degrees
+	Lon float64 `json:"lon"` // degrees
+	Alt float64 `json:"alt"` // m above the ellipsoid
+}
+
+// ECEF returns g in earth-centred, earth-fixed metres: X through latitude
+// and longitude 0, Z through the north pole.
+func (g Geodetic) ECEF() vector.Vector3 {
+	sinLat, cosLat := math.Sincos(g.Lat * math.Pi / 180)
+	sinLon, cosLon := math.Sincos(g.Lon * math.Pi / 180)
+	n := SemiMajor / math.Sqrt(1-e2*sinLat*sinLat)
+	return vector.Vector3{
+		X: (n + g.Alt) * cosLat * cosLon,
+		Y: (n + g.Alt) * cosLat * sinLon,
+		Z: (n*(1-e2) + g.Alt) * sinLat,
+	}
+}
+
+// GeodeticFromECEF returns the WGS-84 position of ECEF point p, in closed
+// form by Heikkinen's method: exact to well under a millimetre everywhere
+// but within tens of kilometres of the earth's centre, poles included.
+func GeodeticFromECEF(p vector.Vector3) Geodetic {
+	a, b := SemiMajor, SemiMinor
+	r := math.Hypot(p.X, p.Y)
+	z2 := p.Z * p.Z
+	f := 54 * b * b * z2
+	g := r*r + (1-e2)*z2 - e2*(a*a-b*b)
+	c := e2 * e2 * f * r * r / (g * g * g)
+	s := math.Cbrt(1 + c + math.Sqrt(c*c+2*c))
+	k := s + 1 + 1/s
+	pp := f / (3 * k * k * g * g)
+	q := math.Sqrt(1 + 2*e2*e2*pp)
+	r0 := -pp*e2*r/(1+q) + math.Sqrt(math.Max(0, a*a/2*(1+1/q)-pp*(1-e2)*z2/(q*(1+q))-pp*r*r/2))
+	d := r - e2*r0
+	u := math.Hypot(d, p.Z)
+	v := math.Sqrt(d*d + (1-e2)*z2)
+	z0 := b * b * p.Z / (a * v)
+	return Geodetic{
+		Lat: math.Atan2(p.Z+ep2*z0, r) * 180 / math.Pi,
+		Lon: math.Atan2(p.Y, p.X) * 180 / math.Pi,
+		Alt: u * (1 - b*b/(a*v)),
+	}
+}
+
+// LocalToENU reorders a local-frame vector to east, north, up.
+func LocalToENU(v vector.Vector3) vector.Vector3 {
+	return vector.Vector3{X: v.X, Y: v.Z, Z: v.Y}
+}
+
+// ENUToLocal reorders an east, north, up vector to the local frame.
+func ENUToLocal(v vector.Vector3) vector.Vector3 {
+	return vector.Vector3{X: v.X, Y: v.Z, Z: v.Y}
+}
+
+// ENUToNED turns an east, north, up vector into north, east, down.
+func ENUToNED(v vector.Vector3) vector.Vector3 {
+	return vector.Vector3{X: v.Y, Y: v.X, Z: -v.Z}
+}
+
+// NEDToENU turns a north, east, down vector into east, north, up.
+func NEDToENU(v vector.Vector3) vector.Vector3 {
+	return vector.Vector3{X: v.Y, Y: v.X, Z: -v.Z}
+}
+
+// LocalToNED turns a local-frame vector into north, east, down.
+func LocalToNED(v vector.Vector3) vector.Vector3 {
+	return vector.Vector3{X: v.Z, Y: v.X, Z: -v.Y}
+}
+
+// NEDToLocal turns a north, east, down vector into the local frame.
+func NEDToLocal(v vector.Vector3) vector.Vector3 {
+	return vector.Vector3{X: v.Y, Y: -v.Z, Z: v.X}
+}
+
+// Tangent is the ENU frame tangent to the ellipsoid at an origin, which is
+// how the local frame sits on the globe.
+type Tangent struct {
+	Origin Geodetic
+	ecef   vector.Vector3 // the origin
+	enu    vector.Matrix3 // columns east, north and up in ECEF axes
+}
+
+// NewTangent returns the tangent frame at origin.
+func NewTangent(origin Geodetic) Tangent {
+	sinLat, cosLat := math.Sincos(origin.Lat * math.Pi / 180)
+	sinLon, cosLon := math.Sincos(origin.Lon * math.Pi / 180)
+	return Tangent{
+		Origin: origin,
+		ecef:   origin.ECEF(),
+		enu: vector.FromAxes(
+			vector.Vector3{X: -sinLon, Y: cosLon},
+			vector.Vector3{X: -sinLat * cosLon, Y: -sinLat * sinLon, Z: cosLat},
+			vector.Vector3{X: cosLat * cosLon, Y: cosLat * sinLon, Z: sinLat},
+		),
+	}
+}
+
+// RotateToECEF turns an ENU vector at the origin, such as a velocity, into
+// ECEF axes.
+func (t Tangent) RotateToECEF(enu vector.Vector3) vector.Vector3 {
+	return t.enu.MulVec(enu)
+}
+
+// RotateFromECEF turns an ECEF vector into ENU axes at the origin.
+func (t Tangent) RotateFromECEF(v vector.Vector3) vector.Vector3 {
+	return t.enu.MulVecT(v)
+}
+
+// ENUToECEF converts an ENU offset in metres from the origin to an ECEF
+// position.
+func (t Tangent) ENUToECEF(enu vector.Vector3) vector.Vector3 {
+	return t.ecef.Add(t.enu.MulVec(enu))
+}
+
+// ECEFToENU converts an ECEF position to its ENU offset from the origin.
+func (t Tangent) ECEFToENU(p vector.Vector3) vector.Vector3 {
+	return t.enu.MulVecT(p.Sub(t.ecef))
+}
+
+// ENUToGeodetic converts an ENU offset from the origin to a WGS-84
+// position.
+func (t Tangent) ENUToGeodetic(enu vector.Vector3) Geodetic {
+	return GeodeticFromECEF(t.ENUToECEF(enu))
+}
+
+// GeodeticToENU converts a WGS-84 position to its ENU offset from the
+// origin. Far from the origin the offset's up component falls away with
+// the earth's curvature.
+func (t Tangent) GeodeticToENU(g Geodetic) vector.Vector3 {
+	return t.ECEFToENU(g.ECEF())
+}
+
+// LocalToGeodetic converts a local-frame position to a WGS-84 position.
+func (t Tangent) LocalToGeodetic(local vector.Vector3) Geodetic {
+	return t.ENUToGeodetic(LocalToENU(local))
+}
+
+// GeodeticToLocal converts a WGS-84 position to the local frame.
+func (t Tangent) GeodeticToLocal(g Geodetic) vector.Vector3 {
+	return ENUToLocal(t.GeodeticToENU(g))
+}
@@ -0,0 +1,114 @@
+package frames
+
+import (
+	"math"
+	"testing"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestGeodeticECEF(t *testing.T) {
+	tests := []struct {
+		name string
+		g    Geodetic
+		p    vector.Vector3
+		tol  float64 // m, and 1e-7° of latitude or longitude per metre
+	}{
+		{"equator, prime meridian", Geodetic{0, 0, 0}, vector.Vector3{X: SemiMajor}, 1e-6},
+		{"equator, 90E", Geodetic{0, 90, 0}, vector.Vector3{Y: SemiMajor}, 1e-6},
+		{"north pole", Geodetic{90, 0, 0}, vector.Vector3{Z: 6356752.314245179}, 1e-6},
+		{"south pole, 100 m up", Geodetic{-90, 0, 100}, vector.Vector3{Z: -6356852.314245179}, 1e-6},
+		{"equator, 45E, 1 km up", Geodetic{0, 45, 999.9564}, vector.Vector3{X: 4510731, Y: 4510731}, 1e-3},
+		{"equator, 180", Geodetic{0, 180, -50}, vector.Vector3{X: -SemiMajor + 50}, 1e-6},
+	}
+	for _, tt := range tests {
+		if got := tt.g.ECEF(); got.Distance(tt.p) > tt.tol {
+			t.Errorf("%s: ECEF %.4f, want %.4f", tt.name, got, tt.p)
+		}
+		got := GeodeticFromECEF(tt.p)
+		if math.Abs(got.Lat-tt.g.Lat) > 1e-7*tt.tol || math.Abs(got.Alt-tt.g.Alt) > tt.tol ||
+			(math.Abs(tt.g.Lat) != 90 && math.Abs(math.Remainder(got.Lon-tt.g.Lon, 360)) > 1e-7*tt.tol) {
+			t.Errorf("%s: geodetic %+v, want %+v", tt.name, got, tt.g)
+		}
+	}
+}
+
+func TestGeodeticRoundTrip(t *testing.T) {
+	for lat := -90.0; lat <= 90; lat += 7.5 {
+		for lon := -180.0; lon < 180; lon += 37 {
+			for _, alt := range []float64{-400, 0, 8848, 35786e3} {
+				g := Geodetic{lat, lon, alt}
+				got := GeodeticFromECEF(g.ECEF())
+				if math.Abs(got.Lat-lat) > 1e-9 || math.Abs(got.Alt-alt) > 1e-4 ||
+					(math.Abs(lat) != 90 && math.Abs(got.Lon-lon) > 1e-9) {
+					t.Errorf("%+v came back as %+v", g, got)
+				}
+			}
+		}
+	}
+}
+
+func TestAxes(t *testing.T) {
+	local := vector.Vector3{X: 1, Y: 2, Z: 3} // east 1, up 2, north 3
+	tests := []struct {
+		name string
+		got  vector.Vector3
+		want vector.Vector3
+	}{
+		{"local to ENU", LocalToENU(local), vector.Vector3{X: 1, Y: 3, Z: 2}},
+		{"local to NED", LocalToNED(local), vector.Vector3{X: 3, Y: 1, Z: -2}},
+		{"ENU to NED", ENUToNED(vector.Vector3{X: 1, Y: 3, Z: 2}), vector.Vector3{X: 3, Y: 1, Z: -2}},
+		{"NED to ENU", NEDToENU(vector.Vector3{X: 3, Y: 1, Z: -2}), vector.Vector3{X: 1, Y: 3, Z: 2}},
+		{"ENU to local", ENUToLocal(LocalToENU(local)), local},
+		{"NED to local", NEDToLocal(LocalToNED(local)), local},
+	}
+	for _, tt := range tests {
+		if tt.got != tt.want {
+			t.Errorf("%s: %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestTangent(t *testing.T) {
+	tests := []struct {
+		name   string
+		origin Geodetic
+		enu    vector.Vector3
+		ecef   vector.Vector3 // the direction enu turns to
+	}{
+		{"up at 0,0 is +X", Geodetic{}, vector.Vector3{Z: 1}, vector.Vector3{X: 1}},
+		{"east at 0,0 is +Y", Geodetic{}, vector.Vector3{X: 1}, vector.Vector3{Y: 1}},
+		{"north at 0,0 is +Z", Geodetic{}, vector.Vector3{Y: 1}, vector.Vector3{Z: 1}},
+		{"up at the north pole is +Z", Geodetic{Lat: 90}, vector.Vector3{Z: 1}, vector.Vector3{Z: 1}},
+		{"east at 0,90E is -X", Geodetic{Lon: 90}, vector.Vector3{X: 1}, vector.Vector3{X: -1}},
+	}
+	for _, tt := range tests {
+		tan := NewTangent(tt.origin)
+		if got := tan.RotateToECEF(tt.enu); got.Distance(tt.ecef) > 1e-12 {
+			t.Errorf("%s: rotated to %v", tt.name, got)
+		}
+		if got := tan.RotateFromECEF(tt.ecef); got.Distance(tt.enu) > 1e-12 {
+			t.Errorf("%s: rotated back to %v", tt.name, got)
+		}
+	}
+
+	tan := NewTangent(Geodetic{51.4779, -0.0015, 45}) // Greenwich
+	if got := tan.ENUToECEF(vector.Vector3{}); got.Distance(tan.Origin.ECEF()) > 1e-9 {
+		t.Errorf("origin at %v", got)
+	}
+	above := Geodetic{tan.Origin.Lat, tan.Origin.Lon, tan.Origin.Alt + 500}
+	if got := tan.GeodeticToENU(above); got.Distance(vector.Vector3{Z: 500}) > 1e-6 {
+		t.Errorf("500 m up is %v", got)
+	}
+	local := vector.Vector3{X: 12e3, Y: 3e3, Z: -40e3}
+	if got := tan.GeodeticToLocal(tan.LocalToGeodetic(local)); got.Distance(local) > 1e-6 {
+		t.Errorf("local %v came back as %v", local, got)
+	}
+
+	// One arc-minute of latitude at the equator is about 1842.9 m along the
+	// ellipsoid.
+	eq := NewTangent(Geodetic{})
+	if g := eq.ENUToGeodetic(vector.Vector3{Y: 1842.9}); math.Abs(g.Lat-1.0/60) > 1e-4 || math.Abs(g.Alt-0.27) > 0.01 {
+		t.Errorf("1842.9 m north is %+v", g)
+	}
+}
@@ -0,0 +1,47 @@
+// Package geo places the simulator's flat local frame on the WGS84
+// ellipsoid, for exports to tools that work in latitude and longitude.
+package geo
+
+import (
+	"missile-intercept-sim/internal/frames"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Origin is the geodetic point the local frame's origin sits at. The local
+// frame is east-north-up about it, tangent to the ellipsoid.
+type Origin struct {
+	Lat float64 `json:"lat"` // degrees
+	Lon float64 `json:"lon"` // degrees
+	Alt float64 `json:"alt"` // m above the ellipsoid
+}
+
+// Valid reports whether the origin is a point on the globe.
+func (o Origin) Valid() bool {
+	return o.Lat >= -90 && o.Lat <= 90 && o.Lon >= -180 && o.Lon <= 180
+}
+
+// tangent returns the east-north-up frame at o.
+func (o Origin) tangent() frames.Tangent {
+	return frames.NewTangent(frames.Geodetic{Lat: o.Lat, Lon: o.Lon, Alt: o.Alt})
+}
+
+// ECEF converts an east/north/up offset in metres from o to earth-centred,
+// earth-fixed coordinates.
+func (o Origin) ECEF(east, north, up float64) (x, y, z float64) {
+	p := o.tangent().ENUToECEF(vector.Vector3{X: east, Y: north, Z: up})
+	return p.X, p.Y, p.Z
+}
+
+// Rotate turns an east/north/up vector at o, such as a velocity, into
+// earth-centred, earth-fixed axes.
+func (o Origin) Rotate(east, north, up float64) (x, y, z float64) {
+	v := o.tangent().RotateToECEF(vector.Vector3{X: east, Y: north, Z: up})
+	return v.X, v.Y, v.Z
+}
+
+// Geodetic converts an east/north/up offset in metres from o to latitude
+// and longitude in degrees and altitude in metres above the ellipsoid.
+func (o Origin) Geodetic(east, north, up float64) (lat, lon, alt float64) {
+	g := o.tangent().ENUToGeodetic(vector.Vector3{X: east, Y: north, Z: up})
+	return g.Lat, g.Lon, g.Alt
+}
@@ -0,0 +1,49 @@
+package geo
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGeodetic(t *testing.T) {
+	// One arc-minute of latitude is about 1852 m, of longitude that times
+	// the cosine of the latitude.
+	tests := []struct {
+		name               string
+		origin             Origin
+		east, north, up    float64
+		lat, lon, alt, tol float64
+	}{
+		{"origin", Origin{51.5, -0.1, 20}, 0, 0, 0, 51.5, -0.1, 20, 1e-9},
+		{"climb", Origin{10, 20, 0}, 0, 0, 5000, 10, 20, 5000, 1e-6},
+		{"north at the equator", Origin{0, 0, 0}, 0, 1842.9, 0, 1.0 / 60, 0, 0.27, 1e-4},
+		{"east at 60N", Origin{60, 0, 0}, 930.2, 0, 0, 60, 1.0 / 60, 0.07, 1e-3},
+	}
+	for _, tt := range tests {
+		lat, lon, alt := tt.origin.Geodetic(tt.east, tt.north, tt.up)
+		if math.Abs(lat-tt.lat) > tt.tol || math.Abs(lon-tt.lon) > tt.tol || math.Abs(alt-tt.alt) > 1 {
+			t.Errorf("%s: got %.7f, %.7f, %.2f; want %.7f, %.7f, %.2f", tt.name, lat, lon, alt, tt.lat, tt.lon, tt.alt)
+		}
+	}
+}
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		name            string
+		origin          Origin
+		east, north, up float64
+		x, y, z         float64
+	}{
+		{"up at 0,0 is +x", Origin{}, 0, 0, 1, 1, 0, 0},
+		{"east at 0,0 is +y", Origin{}, 1, 0, 0, 0, 1, 0},
+		{"north at 0,0 is +z", Origin{}, 0, 1, 0, 0, 0, 1},
+		{"up at the north pole is +z", Origin{Lat: 90}, 0, 0, 1, 0, 0, 1},
+		{"east at 0,90E is -x", Origin{Lon: 90}, 1, 0, 0, -1, 0, 0},
+	}
+	for _, tt := range tests {
+		x, y, z := tt.origin.Rotate(tt.east, tt.north, tt.up)
+		if math.Abs(x-tt.x) > 1e-12 || math.Abs(y-tt.y) > 1e-12 || math.Abs(z-tt.z) > 1e-12 {
+			t.Errorf("%s: got %.3f, %.3f, %.3f", tt.name, x, y, z)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+// Package logging is the server's structured log: log/slog with a level per
+// module, so one part can be turned up to debug without flooding the rest.
+//
+// A logger belongs to a module once it carries the ModuleKey attribute,
+// as For gives it. Records from loggers without one belong to Server. The
+// levels are process-wide and can be changed while the server runs.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+)
+
+// ModuleKey is the attribute naming the module a record comes from.
+const ModuleKey = "module"
+
+// Modules.
+const (
+	Guidance  = "guidance"  // guidance commands, launches, seeker lock and retargeting
+	Physics   = "physics"   // intercepts, crashes, impacts and other end states
+	Recording = "recording" // recordings and the disk writer
+	Server    = "server"    // HTTP, streams, bridges and everything untagged
+)
+
+// Modules lists every module.
+var Modules = []string{Guidance, Physics, Recording, Server}
+
+var levels = func() map[string]*slog.LevelVar {
+	m := make(map[string]*slog.LevelVar, len(Modules))
+	for _, name := range Modules {
+		m[name] = new(slog.LevelVar) // Info
+	}
+	return m
+}()
+
+// SetLevel sets the lowest level module logs at.
+func SetLevel(module string, level slog.Level) error {
+	lv, ok := levels[module]
+	if !ok {
+		return fmt.Errorf("unknown module %q (want one of %s)", module, strings.Join(Modules, ", "))
+	}
+	lv.Set(level)
+	return nil
+}
+
+// Levels returns each module's level.
+func Levels() map[string]slog.Level {
+	m := make(map[string]slog.Level, len(levels))
+	for name, lv := range levels {
+		m[name] = lv.Level()
+	}
+	return m
+}
+
+// ParseLevels applies a level spec: a level for every module, such as
+// "debug", or comma-separated module=level pairs, such as
+// "guidance=debug,server=warn". Nothing is changed if the spec is invalid.
+func ParseLevels(spec string) error {
+	set := make(map[string]slog.Level)
+	for _, part := range strings.Split(spec, ",") {
+		if part = strings.TrimSpace(part); part == "" {
+			continue
+		}
+		module, name, ok := strings.Cut(part, "=")
+		if !ok {
+			module, name = "", part
+		}
+		var level slog.Level
+		if err := level.UnmarshalText([]byte(name)); err != nil {
+			return fmt.Errorf("invalid level %q", name)
+		}
+		if module == "" {
+			for _, m := range Modules {
+				set[m] = level
+			}
+			continue
+		}
+		if !slices.Contains(Modules, module) {
+			return fmt.Errorf("unknown module %q (want one of %s)", module, strings.Join(Modules, ", "))
+		}
+		set[module] = level
+	}
+	for module, level := range set {
+		levels[module].Set(level)
+	}
+	return nil
+}
+
+// New returns a logger writing to w as text, or as JSON lines, that drops
+// each record below its module's level.
+func New(w io.Writer, json bool) *slog.Logger {
+	// The module levels do the filtering, so the output takes everything.
+	opts := &slog.HandlerOptions{Level: slog.LevelDebug}
+	var next slog.Handler = slog.NewTextHandler(w, opts)
+	if json {
+		next = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(&handler{next: next, level: levels[Server]})
+}
+
+// For returns l, or the default logger if nil, tagged with module.
+func For(l *slog.Logger, module string) *slog.Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return l.With(ModuleKey, module)
+}
+
+// Discard is a logger that drops everything, for headless runs.
+var Discard = slog.New(slog.DiscardHandler)
+
+// handler filters records by the level of the module its logger is
+// tagged with.
+type handler struct {
+	next  slog.Handler
+	level *slog.LevelVar
+}
+
+func (h *handler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level.Level()
+}
+
+func (h *handler) Handle(ctx context.Context, r slog.Record) error {
+	return h.next.Handle(ctx, r)
+}
+
+func (h *handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	c := *h
+	for _, a := range attrs {
+		if a.Key != ModuleKey {
+			continue
+		}
+		if lv, ok := levels[a.Value.String()]; ok {
+			c.level = lv
+		}
+	}
+	c.next = h.next.WithAttrs(attrs)
+	return &c
+}
+
+func (h *handler) WithGroup(name string) slog.Handler {
+	c := *h
+	c.next = h.next.WithGroup(name)
+	return &c
+}
@@ -0,0 +1,72 @@
+package logging
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    map[string]slog.Level
+		wantErr bool
+	}{
+		{"", map[string]slog.Level{Guidance: slog.LevelInfo, Physics: slog.LevelInfo, Recording: slog.LevelInfo, Server: slog.LevelInfo}, false},
+		{"debug", map[string]slog.Level{Guidance: slog.LevelDebug, Physics: slog.LevelDebug, Recording: slog.LevelDebug, Server: slog.LevelDebug}, false},
+		{"warn, guidance=debug", map[string]slog.Level{Guidance: slog.LevelDebug, Physics: slog.LevelWarn, Recording: slog.LevelWarn, Server: slog.LevelWarn}, false},
+		{"physics=error", map[string]slog.Level{Guidance: slog.LevelInfo, Physics: slog.LevelError, Recording: slog.LevelInfo, Server: slog.LevelInfo}, false},
+		{"loud", nil, true},
+		{"radar=debug", nil, true},
+		{"guidance=debug,physics=chatty", nil, true},
+	}
+	for _, tt := range tests {
+		ParseLevels("info")
+		err := ParseLevels(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%q: err = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if tt.wantErr {
+			tt.want = map[string]slog.Level{Guidance: slog.LevelInfo, Physics: slog.LevelInfo, Recording: slog.LevelInfo, Server: slog.LevelInfo}
+		}
+		for module, level := range Levels() {
+			if level != tt.want[module] {
+				t.Errorf("%q: %s at %v, want %v", tt.spec, module, level, tt.want[module])
+			}
+		}
+	}
+	ParseLevels("info")
+}
+
+func TestModuleLevels(t *testing.T) {
+	defer ParseLevels("info")
+	var buf bytes.Buffer
+	l := New(&buf, false)
+	if err := SetLevel(Guidance, slog.LevelDebug); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLevel(Server, slog.LevelWarn); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetLevel("radar", slog.LevelDebug); err == nil {
+		t.Error("set the level of an unknown module")
+	}
+	For(l, Guidance).Debug("command")
+	For(l, Physics).Debug("hidden")
+	For(l, Physics).Info("intercept")
+	l.Info("untagged is server")
+	l.With("session", "a").Warn("warned")
+	out := buf.String()
+	for _, want := range []string{"msg=command module=guidance", "msg=intercept module=physics", "msg=warned session=a"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("log lacks %q:\n%s", want, out)
+		}
+	}
+	for _, unwanted := range []string{"hidden", "untagged"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("log has %q below its module's level:\n%s", unwanted, out)
+		}
+	}
+}
@@ -0,0 +1,265 @@
+// Package mqtt is a minimal MQTT 3.1.1 client that publishes at QoS 0, all
+// the telemetry publisher needs. It does not subscribe.
+package mqtt
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Control packet types, shifted into the high nibble of the fixed header.
+const (
+	packetConnect    = 1 << 4
+	packetConnack    = 2 << 4
+	packetPublish    = 3 << 4
+	packetPingreq    = 12 << 4
+	packetDisconnect = 14 << 4
+)
+
+// maxRemaining is the largest remaining length the protocol can encode.
+const maxRemaining = 268435455
+
+// ErrClosed is returned by Publish once the connection is gone.
+var ErrClosed = errors.New("mqtt: connection closed")
+
+// Options configure a connection.
+type Options struct {
+	ClientID  string
+	Username  string
+	Password  string
+	KeepAlive time.Duration // 0 disables keepalive pings
+	TLS       *tls.Config   // nil for plain TCP
+	Timeout   time.Duration // for dialing and the CONNACK, default 10s
+}
+
+// Client is a connection to a broker. It is safe for concurrent use.
+type Client struct {
+	conn net.Conn
+
+	mu     sync.Mutex // serializes writes
+	w      *bufio.Writer
+	closed chan struct{}
+	once   sync.Once
+	err    error // why the connection closed
+}
+
+// Dial connects to the broker at addr ("host:port") and completes the MQTT
+// handshake.
+func Dial(addr string, opts Options) (*Client, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	d := &net.Dialer{Timeout: opts.Timeout}
+	var conn net.Conn
+	var err error
+	if opts.TLS != nil {
+		conn, err = tls.DialWithDialer(d, "tcp", addr, opts.TLS)
+	} else {
+		conn, err = d.Dial("tcp", addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewClient(conn, opts)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewClient performs the MQTT handshake over an established connection.
+func NewClient(conn net.Conn, opts Options) (*Client, error) {
+	if opts.Timeout == 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	c := &Client{conn: conn, w: bufio.NewWriter(conn), closed: make(chan struct{})}
+
+	var flags byte = 0x02 // clean session
+	var payload []byte
+	payload = appendString(payload, opts.ClientID)
+	if opts.Username != "" {
+		flags |= 0x80
+		payload = appendString(payload, opts.Username)
+		if opts.Password != "" {
+			flags |= 0x40
+			payload = appendString(payload, opts.Password)
+		}
+	}
+	body := appendString(nil, "MQTT")
+	body = append(body, 4, flags) // protocol level 4 is 3.1.1
+	body = binary.BigEndian.AppendUint16(body, uint16(opts.KeepAlive/time.Second))
+	body = append(body, payload...)
+
+	conn.SetDeadline(time.Now().Add(opts.Timeout))
+	if err := c.write(packetConnect, body); err != nil {
+		return nil, err
+	}
+	r := bufio.NewReader(conn)
+	typ, ack, err := readPacket(r)
+	if err != nil {
+		return nil, fmt.Errorf("mqtt: reading CONNACK: %w", err)
+	}
+	if typ&0xf0 != packetConnack || len(ack) != 2 {
+		return nil, fmt.Errorf("mqtt: expected CONNACK, got packet type %d", typ>>4)
+	}
+	if ack[1] != 0 {
+		return nil, fmt.Errorf("mqtt: connection refused, return code %d", ack[1])
+	}
+	conn.SetDeadline(time.Time{})
+
+	go c.read(r)
+	if opts.KeepAlive > 0 {
+		go c.ping(opts.KeepAlive)
+	}
+	return c, nil
+}
+
+// Publish sends payload to topic at QoS 0. A retained message is kept by
+// the broker for subscribers that arrive later.
+func (c *Client) Publish(topic string, payload []byte, retain bool) error {
+	if len(topic) > 0xffff {
+		return errors.New("mqtt: topic too long")
+	}
+	var flags byte
+	if retain {
+		flags = 1
+	}
+	body := appendString(nil, topic)
+	body = append(body, payload...)
+	return c.write(packetPublish|flags, body)
+}
+
+// Close disconnects cleanly.
+func (c *Client) Close() error {
+	c.write(packetDisconnect, nil)
+	c.shutdown(ErrClosed)
+	return nil
+}
+
+// Done is closed when the connection is lost or closed; Err then says why.
+func (c *Client) Done() <-chan struct{} {
+	return c.closed
+}
+
+// Err returns why the connection closed, nil while it is open.
+func (c *Client) Err() error {
+	select {
+	case <-c.closed:
+		return c.err
+	default:
+		return nil
+	}
+}
+
+func (c *Client) shutdown(err error) {
+	c.once.Do(func() {
+		c.err = err
+		close(c.closed)
+		c.conn.Close()
+	})
+}
+
+func (c *Client) write(header byte, body []byte) error {
+	select {
+	case <-c.closed:
+		return ErrClosed
+	default:
+	}
+	if len(body) > maxRemaining {
+		return errors.New("mqtt: packet too large")
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.w.WriteByte(header)
+	c.w.Write(appendLength(nil, len(body)))
+	c.w.Write(body)
+	if err := c.w.Flush(); err != nil {
+		c.shutdown(err)
+		return err
+	}
+	return nil
+}
+
+// read drains what the broker sends, which for a publisher is only ping
+// responses, noticing when the connection drops.
+func (c *Client) read(r *bufio.Reader) {
+	for {
+		if _, _, err := readPacket(r); err != nil {
+			c.shutdown(err)
+			return
+		}
+	}
+}
+
+func (c *Client) ping(every time.Duration) {
+	t := time.NewTicker(every / 2)
+	defer t.Stop()
+	for {
+		select {
+		case <-c.closed:
+			return
+		case <-t.C:
+			if c.write(packetPingreq, nil) != nil {
+				return
+			}
+		}
+	}
+}
+
+// readPacket reads one packet, returning its first byte, the type and
+// flags, and its body.
+func readPacket(r *bufio.Reader) (byte, []byte, error) {
+	typ, err := r.ReadByte()
+	if err != nil {
+		return 0, nil, err
+	}
+	n, mult := 0, 1
+	for i := 0; ; i++ {
+		b, err := r.ReadByte()
+		if err != nil {
+			return 0, nil, err
+		}
+		n += int(b&0x7f) * mult
+		if b&0x80 == 0 {
+			break
+		}
+		if i == 3 {
+			return 0, nil, errors.New("mqtt: malformed remaining length")
+		}
+		mult *= 128
+	}
+	body := make([]byte, n)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return 0, nil, err
+	}
+	return typ, body, nil
+}
+
+// appendLength appends n in the protocol's variable-length encoding.
+func appendLength(b []byte, n int) []byte {
+	for {
+		digit := byte(n % 128)
+		n /= 128
+		if n > 0 {
+			digit |= 0x80
+		}
+		b = append(b, digit)
+		if n == 0 {
+			return b
+		}
+	}
+}
+
+// appendString appends s with its two-byte length prefix.
+func appendString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint16(b, uint16(len(s)))
+	return append(b, s...)
+}
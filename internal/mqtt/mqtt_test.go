@@ -0,0 +1,97 @@
+package mqtt
+
+import (
+	"bufio"
+	"bytes"
+	"net"
+	"testing"
+)
+
+// fakeBroker accepts one client on conn, answering CONNECT with code, and
+// returns the packets it receives after that.
+func fakeBroker(t *testing.T, conn net.Conn, code byte) <-chan []byte {
+	t.Helper()
+	packets := make(chan []byte, 8)
+	go func() {
+		defer close(packets)
+		r := bufio.NewReader(conn)
+		typ, body, err := readPacket(r)
+		if err != nil || typ != packetConnect || !bytes.HasPrefix(body, []byte("\x00\x04MQTT\x04")) {
+			t.Errorf("bad CONNECT %x %q: %v", typ, body, err)
+			return
+		}
+		conn.Write([]byte{packetConnack, 2, 0, code})
+		for {
+			typ, body, err := readPacket(r)
+			if err != nil {
+				return
+			}
+			packets <- append([]byte{typ}, body...)
+		}
+	}()
+	return packets
+}
+
+func TestPublish(t *testing.T) {
+	client, server := net.Pipe()
+	packets := fakeBroker(t, server, 0)
+	c, err := NewClient(client, Options{ClientID: "sim"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		topic   string
+		payload string
+		retain  bool
+	}{
+		{"sim/default/state", `{"time":1}`, true},
+		{"sim/default/events", string(bytes.Repeat([]byte("x"), 300)), false}, // two-byte length
+	}
+	for _, tt := range tests {
+		if err := c.Publish(tt.topic, []byte(tt.payload), tt.retain); err != nil {
+			t.Fatal(err)
+		}
+		got := <-packets
+		want := []byte{packetPublish}
+		if tt.retain {
+			want[0] |= 1
+		}
+		want = append(appendString(want, tt.topic), tt.payload...)
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s: got %q, want %q", tt.topic, got, want)
+		}
+	}
+	c.Close()
+	if got := <-packets; got[0] != packetDisconnect {
+		t.Errorf("got packet %x, want DISCONNECT", got[0])
+	}
+	if err := c.Publish("sim/x", nil, false); err != ErrClosed {
+		t.Errorf("publish after close: %v", err)
+	}
+}
+
+func TestConnectRefused(t *testing.T) {
+	client, server := net.Pipe()
+	fakeBroker(t, server, 5) // not authorized
+	if _, err := NewClient(client, Options{ClientID: "sim", Username: "u", Password: "p"}); err == nil {
+		t.Fatal("connected despite refusal")
+	}
+}
+
+func TestAppendLength(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []byte
+	}{
+		{0, []byte{0}},
+		{127, []byte{0x7f}},
+		{128, []byte{0x80, 0x01}},
+		{16383, []byte{0xff, 0x7f}},
+		{2097152, []byte{0x80, 0x80, 0x80, 0x01}},
+	}
+	for _, tt := range tests {
+		if got := appendLength(nil, tt.n); !bytes.Equal(got, tt.want) {
+			t.Errorf("appendLength(%d) = %x, want %x", tt.n, got, tt.want)
+		}
+	}
+}
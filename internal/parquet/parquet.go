@@ -0,0 +1,429 @@
+// Package parquet writes flat tables as Apache Parquet files, enough for
+// analysis tools such as pandas and DuckDB to read simulation telemetry
+// without a custom parser. Columns are required INT64, DOUBLE or UTF-8
+// strings, PLAIN encoded, optionally gzip compressed, one page per column
+// chunk.
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"sort"
+)
+
+// Type is a column's physical type.
+type Type int32
+
+// Column types, numbered as in the Parquet format.
+const (
+	Int64  Type = 2
+	Double Type = 5
+	String Type = 6 // BYTE_ARRAY annotated UTF8
+)
+
+// Codec is a page compression codec.
+type Codec int32
+
+// Codecs, numbered as in the Parquet format.
+const (
+	Uncompressed Codec = 0
+	Gzip         Codec = 2
+)
+
+// DefaultRowGroupRows is how many rows a Writer buffers per row group when
+// Options leaves it zero.
+const DefaultRowGroupRows = 1 << 17
+
+var magic = []byte("PAR1")
+
+// Column names and types one column of a file. Parquet has no field for
+// physical units, so the units of columns that set one go in the footer's
+// key-value metadata under "units", as a JSON object from column name to
+// unit.
+type Column struct {
+	Name string
+	Type Type
+	Unit string
+}
+
+// Options tune a Writer.
+type Options struct {
+	Codec        Codec
+	RowGroupRows int               // rows per row group, DefaultRowGroupRows if 0
+	Metadata     map[string]string // written to the footer's key-value metadata
+}
+
+// Writer writes rows to a Parquet file. Rows are buffered a row group at a
+// time; Close writes the last group and the footer.
+type Writer struct {
+	w       io.Writer
+	columns []Column
+	opts    Options
+	offset  int64
+	rows    int64 // in the file
+	groups  []rowGroup
+	buffers []bytes.Buffer // the current row group, PLAIN encoded per column
+	pending int            // rows in the current row group
+	scratch [8]byte
+}
+
+type rowGroup struct {
+	rows   int64
+	size   int64
+	chunks []chunk
+}
+
+type chunk struct {
+	offset       int64
+	uncompressed int64
+	compressed   int64
+	values       int64
+}
+
+// NewWriter starts a file with the given columns on w.
+func NewWriter(w io.Writer, columns []Column, opts Options) (*Writer, error) {
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("parquet: no columns")
+	}
+	for _, c := range columns {
+		if c.Type != Int64 && c.Type != Double && c.Type != String {
+			return nil, fmt.Errorf("parquet: column %s has unsupported type %d", c.Name, c.Type)
+		}
+	}
+	if opts.Codec != Uncompressed && opts.Codec != Gzip {
+		return nil, fmt.Errorf("parquet: unsupported codec %d", opts.Codec)
+	}
+	if opts.RowGroupRows <= 0 {
+		opts.RowGroupRows = DefaultRowGroupRows
+	}
+	// The writer adds to the metadata, so it keeps its own copy.
+	opts.Metadata = maps.Clone(opts.Metadata)
+	units := make(map[string]string)
+	for _, c := range columns {
+		if c.Unit != "" {
+			units[c.Name] = c.Unit
+		}
+	}
+	if len(units) > 0 {
+		b, err := json.Marshal(units)
+		if err != nil {
+			return nil, err
+		}
+		if opts.Metadata == nil {
+			opts.Metadata = make(map[string]string)
+		}
+		opts.Metadata["units"] = string(b)
+	}
+	if _, err := w.Write(magic); err != nil {
+		return nil, err
+	}
+	return &Writer{
+		w:       w,
+		columns: columns,
+		opts:    opts,
+		offset:  int64(len(magic)),
+		buffers: make([]bytes.Buffer, len(columns)),
+	}, nil
+}
+
+// Append adds a row: one int64, float64 or string per column, in order.
+func (w *Writer) Append(row ...any) error {
+	if len(row) != len(w.columns) {
+		return fmt.Errorf("parquet: row has %d values for %d columns", len(row), len(w.columns))
+	}
+	for i, v := range row {
+		c := w.columns[i]
+		if !typeMatches(c.Type, v) {
+			return fmt.Errorf("parquet: column %s cannot hold %T", c.Name, v)
+		}
+	}
+	for i, v := range row {
+		b := &w.buffers[i]
+		switch v := v.(type) {
+		case int64:
+			binary.LittleEndian.PutUint64(w.scratch[:], uint64(v))
+			b.Write(w.scratch[:])
+		case float64:
+			binary.LittleEndian.PutUint64(w.scratch[:], math.Float64bits(v))
+			b.Write(w.scratch[:])
+		case string:
+			binary.LittleEndian.PutUint32(w.scratch[:4], uint32(len(v)))
+			b.Write(w.scratch[:4])
+			b.WriteString(v)
+		}
+	}
+	w.pending++
+	if w.pending >= w.opts.RowGroupRows {
+		return w.flush()
+	}
+	return nil
+}
+
+func typeMatches(t Type, v any) bool {
+	switch v.(type) {
+	case int64:
+		return t == Int64
+	case float64:
+		return t == Double
+	case string:
+		return t == String
+	}
+	return false
+}
+
+// flush writes the buffered rows as a row group.
+func (w *Writer) flush() error {
+	if w.pending == 0 {
+		return nil
+	}
+	g := rowGroup{rows: int64(w.pending)}
+	for i := range w.columns {
+		raw := w.buffers[i].Bytes()
+		data := raw
+		if w.opts.Codec == Gzip {
+			var z bytes.Buffer
+			zw := gzip.NewWriter(&z)
+			zw.Write(raw)
+			if err := zw.Close(); err != nil {
+				return err
+			}
+			data = z.Bytes()
+		}
+		header := pageHeader(len(raw), len(data), w.pending)
+		c := chunk{
+			offset:       w.offset,
+			uncompressed: int64(len(header) + len(raw)),
+			compressed:   int64(len(header) + len(data)),
+			values:       int64(w.pending),
+		}
+		if err := w.write(header); err != nil {
+			return err
+		}
+		if err := w.write(data); err != nil {
+			return err
+		}
+		g.size += c.uncompressed
+		g.chunks = append(g.chunks, c)
+		w.buffers[i].Reset()
+	}
+	w.groups = append(w.groups, g)
+	w.rows += g.rows
+	w.pending = 0
+	return nil
+}
+
+func (w *Writer) write(p []byte) error {
+	n, err := w.w.Write(p)
+	w.offset += int64(n)
+	return err
+}
+
+// SetMetadata sets a footer key-value pair, such as a summary only known
+// once every row is written.
+func (w *Writer) SetMetadata(key, value string) {
+	if w.opts.Metadata == nil {
+		w.opts.Metadata = make(map[string]string)
+	}
+	w.opts.Metadata[key] = value
+}
+
+// Close writes the remaining rows and the footer. It does not close the
+// underlying writer.
+func (w *Writer) Close() error {
+	if err := w.flush(); err != nil {
+		return err
+	}
+	meta := w.footer()
+	var size [4]byte
+	binary.LittleEndian.PutUint32(size[:], uint32(len(meta)))
+	for _, p := range [][]byte{meta, size[:], magic} {
+		if err := w.write(p); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Thrift compact protocol field types.
+const (
+	tI32    = 5
+	tI64    = 6
+	tBinary = 8
+	tList   = 9
+	tStruct = 12
+)
+
+// Parquet enum values used in the metadata.
+const (
+	repetitionRequired = 0
+	convertedUTF8      = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	pageData           = 0
+)
+
+// pageHeader encodes the header of a PLAIN data page holding n required
+// values, which need no repetition or definition levels.
+func pageHeader(uncompressed, compressed, n int) []byte {
+	var e encoder
+	e.i32(1, pageData)
+	e.i32(2, int32(uncompressed))
+	e.i32(3, int32(compressed))
+	e.begin(5)
+	e.i32(1, int32(n))
+	e.i32(2, encodingPlain)
+	e.i32(3, encodingRLE)
+	e.i32(4, encodingRLE)
+	e.end()
+	e.stop()
+	return e.buf
+}
+
+// footer encodes the FileMetaData.
+func (w *Writer) footer() []byte {
+	var e encoder
+	e.i32(1, 1) // format version
+	e.list(2, tStruct, len(w.columns)+1)
+	// The root of the schema, then one leaf per column.
+	e.struct_()
+	e.binary(4, "schema")
+	e.i32(5, int32(len(w.columns)))
+	e.end()
+	for _, c := range w.columns {
+		e.struct_()
+		e.i32(1, int32(c.Type))
+		e.i32(3, repetitionRequired)
+		e.binary(4, c.Name)
+		if c.Type == String {
+			e.i32(6, convertedUTF8)
+		}
+		e.end()
+	}
+	e.i64(3, w.rows)
+	e.list(4, tStruct, len(w.groups))
+	for _, g := range w.groups {
+		e.struct_()
+		e.list(1, tStruct, len(g.chunks))
+		for i, c := range g.chunks {
+			col := w.columns[i]
+			e.struct_()
+			e.i64(2, c.offset)
+			e.begin(3)
+			e.i32(1, int32(col.Type))
+			e.list(2, tI32, 2)
+			e.varint(zigzag(encodingPlain))
+			e.varint(zigzag(encodingRLE))
+			e.list(3, tBinary, 1)
+			e.varint(uint64(len(col.Name)))
+			e.buf = append(e.buf, col.Name...)
+			e.i32(4, int32(w.opts.Codec))
+			e.i64(5, c.values)
+			e.i64(6, c.uncompressed)
+			e.i64(7, c.compressed)
+			e.i64(9, c.offset)
+			e.end()
+			e.end()
+		}
+		e.i64(2, g.size)
+		e.i64(3, g.rows)
+		e.end()
+	}
+	if len(w.opts.Metadata) > 0 {
+		keys := make([]string, 0, len(w.opts.Metadata))
+		for k := range w.opts.Metadata {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.list(5, tStruct, len(keys))
+		for _, k := range keys {
+			e.struct_()
+			e.binary(1, k)
+			e.binary(2, w.opts.Metadata[k])
+			e.end()
+		}
+	}
+	e.binary(6, "missile-intercept-sim")
+	e.stop()
+	return e.buf
+}
+
+// encoder writes the Thrift compact protocol. last tracks the previous
+// field ID of each open struct, as field headers are delta encoded.
+type encoder struct {
+	buf  []byte
+	last []int16
+	id   int16
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (e *encoder) varint(v uint64) {
+	e.buf = binary.AppendUvarint(e.buf, v)
+}
+
+func (e *encoder) field(id int16, typ byte) {
+	if d := id - e.id; d > 0 && d <= 15 {
+		e.buf = append(e.buf, byte(d)<<4|typ)
+	} else {
+		e.buf = append(e.buf, typ)
+		e.varint(zigzag(int64(id)))
+	}
+	e.id = id
+}
+
+func (e *encoder) i32(id int16, v int32) {
+	e.field(id, tI32)
+	e.varint(zigzag(int64(v)))
+}
+
+func (e *encoder) i64(id int16, v int64) {
+	e.field(id, tI64)
+	e.varint(zigzag(v))
+}
+
+func (e *encoder) binary(id int16, s string) {
+	e.field(id, tBinary)
+	e.varint(uint64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// list starts a list field of n elements; the caller writes them.
+func (e *encoder) list(id int16, elem byte, n int) {
+	e.field(id, tList)
+	if n < 15 {
+		e.buf = append(e.buf, byte(n)<<4|elem)
+		return
+	}
+	e.buf = append(e.buf, 0xf0|elem)
+	e.varint(uint64(n))
+}
+
+// begin opens a struct field; struct_ opens a struct list element.
+func (e *encoder) begin(id int16) {
+	e.field(id, tStruct)
+	e.struct_()
+}
+
+func (e *encoder) struct_() {
+	e.last = append(e.last, e.id)
+	e.id = 0
+}
+
+// end closes the innermost struct.
+func (e *encoder) end() {
+	e.stop()
+	e.id = e.last[len(e.last)-1]
+	e.last = e.last[:len(e.last)-1]
+}
+
+func (e *encoder) stop() {
+	e.buf = append(e.buf, 0)
+}
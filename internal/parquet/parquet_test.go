@@ -0,0 +1,211 @@
+package parquet
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"testing"
+)
+
+// decoder reads the Thrift compact protocol into maps of field ID to value,
+// enough to check the metadata a Writer produces.
+type decoder struct {
+	b   []byte
+	err bool
+}
+
+func (d *decoder) byte_() byte {
+	if len(d.b) == 0 {
+		d.err = true
+		return 0
+	}
+	c := d.b[0]
+	d.b = d.b[1:]
+	return c
+}
+
+func (d *decoder) varint() uint64 {
+	v, n := binary.Uvarint(d.b)
+	if n <= 0 {
+		d.err = true
+		return 0
+	}
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *decoder) int() int64 {
+	v := d.varint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *decoder) value(typ byte) any {
+	switch typ {
+	case tI32, tI64:
+		return d.int()
+	case tBinary:
+		n := int(d.varint())
+		if n > len(d.b) {
+			d.err = true
+			return ""
+		}
+		s := string(d.b[:n])
+		d.b = d.b[n:]
+		return s
+	case tList:
+		h := d.byte_()
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(d.varint())
+		}
+		list := make([]any, n)
+		for i := range list {
+			list[i] = d.value(h & 0x0f)
+		}
+		return list
+	case tStruct:
+		fields := make(map[int16]any)
+		var id int16
+		for !d.err {
+			h := d.byte_()
+			if h == 0 {
+				break
+			}
+			if delta := int16(h >> 4); delta != 0 {
+				id += delta
+			} else {
+				id = int16(d.int())
+			}
+			fields[id] = d.value(h & 0x0f)
+		}
+		return fields
+	}
+	d.err = true
+	return nil
+}
+
+func (d *decoder) struct_() map[int16]any {
+	return d.value(tStruct).(map[int16]any)
+}
+
+// readColumn decodes every value of column i from a file written by Writer.
+func readColumn(t *testing.T, file []byte, meta map[int16]any, i int) []any {
+	t.Helper()
+	typ := Type(meta[2].([]any)[i+1].(map[int16]any)[1].(int64))
+	var values []any
+	for _, g := range meta[4].([]any) {
+		cm := g.(map[int16]any)[1].([]any)[i].(map[int16]any)[3].(map[int16]any)
+		d := &decoder{b: file[cm[9].(int64):]}
+		ph := d.struct_()
+		page := d.b[:ph[3].(int64)]
+		if Codec(cm[4].(int64)) == Gzip {
+			zr, err := gzip.NewReader(bytes.NewReader(page))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if page, err = io.ReadAll(zr); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if int64(len(page)) != ph[2].(int64) {
+			t.Fatalf("page is %d bytes, header says %d", len(page), ph[2])
+		}
+		for range ph[5].(map[int16]any)[1].(int64) {
+			switch typ {
+			case Int64:
+				values = append(values, int64(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			case Double:
+				values = append(values, math.Float64frombits(binary.LittleEndian.Uint64(page)))
+				page = page[8:]
+			case String:
+				n := binary.LittleEndian.Uint32(page)
+				values = append(values, string(page[4:4+n]))
+				page = page[4+n:]
+			}
+		}
+	}
+	return values
+}
+
+func TestWriter(t *testing.T) {
+	columns := []Column{{"step", Int64, ""}, {"time", Double, "s"}, {"entity", String, ""}}
+	tests := []struct {
+		name   string
+		opts   Options
+		rows   int
+		groups int
+	}{
+		{"empty", Options{}, 0, 0},
+		{"one group", Options{}, 10, 1},
+		{"split groups", Options{RowGroupRows: 4}, 10, 3},
+		{"gzip", Options{Codec: Gzip, RowGroupRows: 7, Metadata: map[string]string{"seed": "42", "run": "3"}}, 20, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			w, err := NewWriter(&buf, columns, tt.opts)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i := range tt.rows {
+				if err := w.Append(int64(i), float64(i)/4, []string{"target", "interceptor-1"}[i%2]); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := w.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			file := buf.Bytes()
+			if !bytes.HasPrefix(file, magic) || !bytes.HasSuffix(file, magic) {
+				t.Fatal("missing PAR1 magic")
+			}
+			size := binary.LittleEndian.Uint32(file[len(file)-8:])
+			d := &decoder{b: file[len(file)-8-int(size) : len(file)-8]}
+			meta := d.struct_()
+			if d.err || len(d.b) != 0 {
+				t.Fatalf("footer does not decode cleanly, %d bytes left", len(d.b))
+			}
+			if meta[3].(int64) != int64(tt.rows) || len(meta[4].([]any)) != tt.groups {
+				t.Errorf("footer has %v rows in %d groups", meta[3], len(meta[4].([]any)))
+			}
+			schema := meta[2].([]any)
+			if len(schema) != 4 || schema[0].(map[int16]any)[5].(int64) != 3 || schema[3].(map[int16]any)[4] != "entity" {
+				t.Errorf("schema %v", schema)
+			}
+			// The caller's metadata, sorted, and the units.
+			kv, _ := meta[5].([]any)
+			if len(kv) != len(tt.opts.Metadata)+1 || kv[len(kv)-1].(map[int16]any)[2] != `{"time":"s"}` ||
+				(len(kv) > 1 && kv[0].(map[int16]any)[1] != "run") {
+				t.Errorf("key-value metadata %v", meta[5])
+			}
+			steps, times, names := readColumn(t, file, meta, 0), readColumn(t, file, meta, 1), readColumn(t, file, meta, 2)
+			if len(steps) != tt.rows || len(times) != tt.rows || len(names) != tt.rows {
+				t.Fatalf("read %d, %d and %d values, want %d", len(steps), len(times), len(names), tt.rows)
+			}
+			for i := range tt.rows {
+				if steps[i] != int64(i) || times[i] != float64(i)/4 || names[i] != []string{"target", "interceptor-1"}[i%2] {
+					t.Fatalf("row %d reads back as %v %v %v", i, steps[i], times[i], names[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWriterRejects(t *testing.T) {
+	if _, err := NewWriter(io.Discard, []Column{{Name: "flag", Type: Type(0)}}, Options{}); err == nil {
+		t.Error("accepted a BOOLEAN column")
+	}
+	if _, err := NewWriter(io.Discard, []Column{{Name: "x", Type: Double}}, Options{Codec: 1}); err == nil {
+		t.Error("accepted snappy")
+	}
+	w, _ := NewWriter(io.Discard, []Column{{Name: "x", Type: Double}, {Name: "n", Type: Int64}}, Options{})
+	for _, row := range [][]any{{1.0}, {1.0, 2.0}, {"x", int64(1)}} {
+		if err := w.Append(row...); err == nil {
+			t.Errorf("appended %v", row)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+// Package runstore keeps finished runs in a SQL database, SQLite by default
+// or Postgres, so they outlive the server and can be compared over time.
+package runstore
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/lib/pq"
+	_ "github.com/mattn/go-sqlite3"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+// ErrNotFound is returned for a run ID the store does not hold.
+var ErrNotFound = errors.New("run not found")
+
+// maxCompareRuns bounds how many runs one comparison reads.
+const maxCompareRuns = 100000
+
+// Run is one finished run as stored.
+type Run struct {
+	ID      int64                        `json:"id"`
+	Session string                       `json:"session"`
+	Report  simulation.OutcomeReport     `json:"report"`
+	Events  []simulation.Event           `json:"events,omitempty"`
+	Frames  []simulation.SimulationState `json:"frames,omitempty"` // only if the run was recorded and trajectories are kept
+}
+
+// Summary is the indexed part of a stored run, for listing.
+type Summary struct {
+	ID           int64     `json:"id"`
+	Session      string    `json:"session"`
+	Scenario     string    `json:"scenario"`
+	Seed         uint64    `json:"seed"`
+	Version      string    `json:"version"`
+	Guidance     string    `json:"guidance"` // the first interceptor's law
+	Result       string    `json:"result"`
+	Reason       string    `json:"reason"`
+	Time         float64   `json:"time"`
+	MissDistance float64   `json:"missDistance"`
+	Finished     time.Time `json:"finished"`
+	Trajectory   bool      `json:"trajectory"`
+}
+
+// Filter selects stored runs. Zero fields match everything.
+type Filter struct {
+	Session  string
+	Scenario string
+	Guidance string
+	Result   string
+	Since    time.Time
+	Until    time.Time
+	Before   int64 // only runs with a lower ID, for paging backwards
+	Limit    int
+}
+
+// Group is the aggregate of the runs sharing one key in a comparison.
+type Group struct {
+	Key          string    `json:"key"`
+	Runs         int       `json:"runs"`
+	Intercepts   int       `json:"intercepts"`
+	Pk           float64   `json:"pk"`
+	MeanMiss     float64   `json:"meanMiss"` // m, over every run
+	MeanTime     float64   `json:"meanTime"` // s of simulated time
+	First        time.Time `json:"first"`
+	Last         time.Time `json:"last"`
+	firstID      int64
+	missDistance float64
+	simTime      float64
+}
+
+// CompareKeys are the run attributes a comparison can group by.
+var CompareKeys = []string{"day", "week", "month", "scenario", "guidance", "version", "session"}
+
+// Store is a run database.
+type Store struct {
+	db      *sql.DB
+	dialect string // sqlite3 or postgres
+}
+
+// Open connects to the database named by dsn: postgres://... for Postgres,
+// otherwise a SQLite file path, optionally prefixed sqlite:. The schema is
+// created if missing.
+func Open(dsn string) (*Store, error) {
+	driver, source := "sqlite3", strings.TrimPrefix(strings.TrimPrefix(dsn, "sqlite://"), "sqlite:")
+	if strings.HasPrefix(dsn, "postgres://") || strings.HasPrefix(dsn, "postgresql://") {
+		driver, source = "postgres", dsn
+	} else {
+		// One writer at a time, waiting out a busy database rather than failing.
+		source = "file:" + source + "?_foreign_keys=on&_busy_timeout=5000"
+	}
+	db, err := sql.Open(driver, source)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{db: db, dialect: driver}
+	if driver == "sqlite3" {
+		db.SetMaxOpenConns(1)
+	}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// migrate creates the tables the store needs.
+func (s *Store) migrate() error {
+	id, stamp := "INTEGER PRIMARY KEY AUTOINCREMENT", "TIMESTAMP"
+	if s.dialect == "postgres" {
+		id, stamp = "BIGSERIAL PRIMARY KEY", "TIMESTAMPTZ"
+	}
+	for _, stmt := range []string{
+		`CREATE TABLE IF NOT EXISTS runs (
+			id ` + id + `,
+			session TEXT NOT NULL,
+			scenario TEXT NOT NULL,
+			seed TEXT NOT NULL,
+			version TEXT NOT NULL,
+			guidance TEXT NOT NULL,
+			result TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			sim_time DOUBLE PRECISION NOT NULL,
+			miss_distance DOUBLE PRECISION NOT NULL,
+			finished ` + stamp + ` NOT NULL,
+			report TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS runs_scenario ON runs (scenario, finished)`,
+		`CREATE INDEX IF NOT EXISTS runs_finished ON runs (finished)`,
+		`CREATE TABLE IF NOT EXISTS run_events (
+			run_id BIGINT NOT NULL REFERENCES runs (id) ON DELETE CASCADE,
+			seq BIGINT NOT NULL,
+			time DOUBLE PRECISION NOT NULL,
+			type TEXT NOT NULL,
+			entity TEXT NOT NULL,
+			message TEXT NOT NULL,
+			request_id TEXT NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS run_events_run ON run_events (run_id, seq)`,
+		`CREATE TABLE IF NOT EXISTS run_trajectories (
+			run_id BIGINT PRIMARY KEY REFERENCES runs (id) ON DELETE CASCADE,
+			frames TEXT NOT NULL
+		)`,
+	} {
+		if _, err := s.db.Exec(stmt); err != nil {
+			return fmt.Errorf("creating schema: %w", err)
+		}
+	}
+	return nil
+}
+
+// Save stores a run and returns its ID.
+func (s *Store) Save(ctx context.Context, run Run) (int64, error) {
+	rep := run.Report
+	report, err := json.Marshal(rep)
+	if err != nil {
+		return 0, err
+	}
+	var guidance string
+	if len(rep.Engagements) > 0 {
+		guidance = rep.Engagements[0].Guidance
+	}
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	var id int64
+	err = tx.QueryRowContext(ctx, `INSERT INTO runs
+		(session, scenario, seed, version, guidance, result, reason, sim_time, miss_distance, finished, report)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11) RETURNING id`,
+		run.Session, rep.Scenario, strconv.FormatUint(rep.Seed, 10), rep.Manifest.Version, guidance,
+		rep.Result, rep.Reason, rep.Time, rep.MissDistance, rep.Finished.UTC(), string(report),
+	).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if len(run.Events) > 0 {
+		stmt, err := tx.PrepareContext(ctx, `INSERT INTO run_events
+			(run_id, seq, time, type, entity, message, request_id) VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+		if err != nil {
+			return 0, err
+		}
+		defer stmt.Close()
+		for _, ev := range run.Events {
+			if _, err := stmt.ExecContext(ctx, id, int64(ev.Seq), ev.Time, ev.Type, ev.EntityID, ev.Message, ev.RequestID); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if len(run.Frames) > 0 {
+		frames, err := json.Marshal(run.Frames)
+		if err != nil {
+			return 0, err
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO run_trajectories (run_id, frames) VALUES ($1, $2)`, id, string(frames)); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit()
+}
+
+// List returns the runs matching f, newest first.
+func (s *Store) List(ctx context.Context, f Filter) ([]Summary, error) {
+	var where []string
+	var args []any
+	add := func(cond string, v any) {
+		args = append(args, v)
+		where = append(where, fmt.Sprintf(cond, len(args)))
+	}
+	if f.Session != "" {
+		add("session = $%d", f.Session)
+	}
+	if f.Scenario != "" {
+		add("scenario = $%d", f.Scenario)
+	}
+	if f.Guidance != "" {
+		add("guidance = $%d", f.Guidance)
+	}
+	if f.Result != "" {
+		add("result = $%d", f.Result)
+	}
+	if !f.Since.IsZero() {
+		add("finished >= $%d", f.Since.UTC())
+	}
+	if !f.Until.IsZero() {
+		add("finished < $%d", f.Until.UTC())
+	}
+	if f.Before > 0 {
+		add("id < $%d", f.Before)
+	}
+	query := `SELECT id, session, scenario, seed, version, guidance, result, reason, sim_time, miss_distance, finished,
+		EXISTS (SELECT 1 FROM run_trajectories t WHERE t.run_id = runs.id) FROM runs`
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	query += " ORDER BY id DESC"
+	if f.Limit > 0 {
+		query += " LIMIT " + strconv.Itoa(f.Limit)
+	}
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	runs := []Summary{}
+	for rows.Next() {
+		var r Summary
+		var seed string
+		if err := rows.Scan(&r.ID, &r.Session, &r.Scenario, &seed, &r.Version, &r.Guidance, &r.Result, &r.Reason,
+			&r.Time, &r.MissDistance, &r.Finished, &r.Trajectory); err != nil {
+			return nil, err
+		}
+		r.Seed, _ = strconv.ParseUint(seed, 10, 64)
+		runs = append(runs, r)
+	}
+	return runs, rows.Err()
+}
+
+// Get returns a stored run with its events, and its trajectory if frames is
+// set and one was kept.
+func (s *Store) Get(ctx context.Context, id int64, frames bool) (*Run, error) {
+	run := &Run{ID: id}
+	var report string
+	err := s.db.QueryRowContext(ctx, `SELECT session, report FROM runs WHERE id = $1`, id).Scan(&run.Session, &report)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(report), &run.Report); err != nil {
+		return nil, err
+	}
+	rows, err := s.db.QueryContext(ctx, `SELECT seq, time, type, entity, message, request_id
+		FROM run_events WHERE run_id = $1 ORDER BY seq`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var ev simulation.Event
+		var seq int64
+		if err := rows.Scan(&seq, &ev.Time, &ev.Type, &ev.EntityID, &ev.Message, &ev.RequestID); err != nil {
+			return nil, err
+		}
+		ev.Seq = uint64(seq)
+		run.Events = append(run.Events, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if frames {
+		var data string
+		err := s.db.QueryRowContext(ctx, `SELECT frames FROM run_trajectories WHERE run_id = $1`, id).Scan(&data)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		if data != "" {
+			if err := json.Unmarshal([]byte(data), &run.Frames); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return run, nil
+}
+
+// Delete removes a stored run.
+func (s *Store) Delete(ctx context.Context, id int64) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM runs WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Compare groups the runs matching f by one of CompareKeys and aggregates
+// each group, ordered by the group's earliest run. Time keys bucket by the
+// UTC day, ISO week or month a run finished in.
+func (s *Store) Compare(ctx context.Context, f Filter, by string) ([]Group, error) {
+	key, ok := compareKey(by)
+	if !ok {
+		return nil, fmt.Errorf("cannot compare by %q; use one of %s", by, strings.Join(CompareKeys, ", "))
+	}
+	f.Limit, f.Before = maxCompareRuns, 0
+	runs, err := s.List(ctx, f)
+	if err != nil {
+		return nil, err
+	}
+	groups := make(map[string]*Group)
+	for _, r := range runs {
+		k := key(r)
+		g := groups[k]
+		if g == nil {
+			g = &Group{Key: k, First: r.Finished, Last: r.Finished, firstID: r.ID}
+			groups[k] = g
+		}
+		g.Runs++
+		if r.Result == "Intercepted" {
+			g.Intercepts++
+		}
+		g.missDistance += r.MissDistance
+		g.simTime += r.Time
+		if r.Finished.Before(g.First) {
+			g.First = r.Finished
+		}
+		if r.Finished.After(g.Last) {
+			g.Last = r.Finished
+		}
+		g.firstID = min(g.firstID, r.ID)
+	}
+	out := make([]Group, 0, len(groups))
+	for _, g := range groups {
+		n := float64(g.Runs)
+		g.Pk = float64(g.Intercepts) / n
+		g.MeanMiss = g.missDistance / n
+		g.MeanTime = g.simTime / n
+		out = append(out, *g)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].firstID < out[j].firstID })
+	return out, nil
+}
+
+// compareKey returns the function mapping a run to its group under by.
+func compareKey(by string) (func(Summary) string, bool) {
+	switch by {
+	case "day":
+		return func(r Summary) string { return r.Finished.UTC().Format("2006-01-02") }, true
+	case "week":
+		return func(r Summary) string {
+			y, w := r.Finished.UTC().ISOWeek()
+			return fmt.Sprintf("%d-W%02d", y, w)
+		}, true
+	case "month":
+		return func(r Summary) string { return r.Finished.UTC().Format("2006-01") }, true
+	case "scenario":
+		return func(r Summary) string { return r.Scenario }, true
+	case "guidance":
+		return func(r Summary) string { return r.Guidance }, true
+	case "version":
+		return func(r Summary) string { return r.Version }, true
+	case "session":
+		return func(r Summary) string { return r.Session }, true
+	}
+	return nil, false
+}
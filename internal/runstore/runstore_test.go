@@ -0,0 +1,127 @@
+package runstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+func testRun(session, guidance, result string, miss float64, finished time.Time) Run {
+	return Run{
+		Session: session,
+		Report: simulation.OutcomeReport{
+			Scenario:     "crossing",
+			Seed:         1<<63 + 5, // above int64, stored as text
+			Result:       result,
+			MissDistance: miss,
+			Time:         10,
+			Finished:     finished,
+			Engagements:  []simulation.EngagementReport{{MissileID: "interceptor-1", Guidance: guidance}},
+		},
+		Events: []simulation.Event{
+			{Seq: 1, Type: simulation.EventLaunch, EntityID: "interceptor-1", Message: "launched"},
+			{Seq: 2, Time: 10, Type: simulation.EventPhase, Message: result},
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "runs.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	day := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	runs := []Run{
+		testRun("default", "ProNav", "Intercepted", 2, day),
+		testRun("default", "ProNav", "Leaked", 80, day.Add(time.Hour)),
+		testRun("other", "PurePursuit", "Intercepted", 4, day.Add(24*time.Hour)),
+	}
+	runs[0].Frames = []simulation.SimulationState{{Time: 0}, {Time: 0.01}}
+	var ids []int64
+	for _, r := range runs {
+		id, err := s.Save(ctx, r)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, id)
+	}
+
+	tests := []struct {
+		name string
+		f    Filter
+		want []int64
+	}{
+		{"all", Filter{}, []int64{ids[2], ids[1], ids[0]}},
+		{"session", Filter{Session: "default"}, []int64{ids[1], ids[0]}},
+		{"guidance and result", Filter{Guidance: "ProNav", Result: "Leaked"}, []int64{ids[1]}},
+		{"since", Filter{Since: day.Add(time.Minute)}, []int64{ids[2], ids[1]}},
+		{"until", Filter{Until: day.Add(time.Minute)}, []int64{ids[0]}},
+		{"page", Filter{Before: ids[2], Limit: 1}, []int64{ids[1]}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.List(ctx, tt.f)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var gotIDs []int64
+			for _, r := range got {
+				gotIDs = append(gotIDs, r.ID)
+			}
+			if len(gotIDs) != len(tt.want) {
+				t.Fatalf("got runs %v, want %v", gotIDs, tt.want)
+			}
+			for i := range gotIDs {
+				if gotIDs[i] != tt.want[i] {
+					t.Fatalf("got runs %v, want %v", gotIDs, tt.want)
+				}
+			}
+		})
+	}
+
+	all, _ := s.List(ctx, Filter{})
+	if r := all[2]; r.Seed != runs[0].Report.Seed || !r.Trajectory || !r.Finished.Equal(day) || r.Guidance != "ProNav" {
+		t.Errorf("summary %+v does not match the saved run", r)
+	}
+	run, err := s.Get(ctx, ids[0], true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(run.Events) != 2 || run.Events[1].Message != "Intercepted" || len(run.Frames) != 2 || run.Report.MissDistance != 2 {
+		t.Errorf("got run %+v", run)
+	}
+	if run, _ := s.Get(ctx, ids[0], false); run.Frames != nil {
+		t.Error("frames returned without asking")
+	}
+
+	groups, err := s.Compare(ctx, Filter{}, "guidance")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(groups) != 2 || groups[0].Key != "ProNav" || groups[0].Runs != 2 || groups[0].Pk != 0.5 || groups[0].MeanMiss != 41 {
+		t.Errorf("by guidance: %+v", groups)
+	}
+	if groups, _ := s.Compare(ctx, Filter{}, "day"); len(groups) != 2 || groups[0].Key != "2026-03-01" || groups[1].Runs != 1 {
+		t.Errorf("by day: %+v", groups)
+	}
+	if _, err := s.Compare(ctx, Filter{}, "colour"); err == nil {
+		t.Error("compared by an unknown key")
+	}
+
+	if err := s.Delete(ctx, ids[0]); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, ids[0], true); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleted run: %v", err)
+	}
+	if err := s.Delete(ctx, ids[0]); !errors.Is(err, ErrNotFound) {
+		t.Errorf("deleting twice: %v", err)
+	}
+}
@@ -0,0 +1,319 @@
+package scenario
+
+import (
+	"missile-intercept-sim/internal/sensors"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Default is the original built-in engagement: a target flying level
+// across the launcher's front and a single ProNav interceptor.
+func Default() *Scenario {
+	return &Scenario{
+		Name:        "default",
+		Description: "Level target crossing at 2000m, single ProNav interceptor launched from the origin.",
+		Entities: []Entity{
+			{
+				ID:       "target-1",
+				Role:     RoleTarget,
+				Position: vector.Vector3{X: 5000, Y: 2000, Z: 5000},
+				Velocity: vector.Vector3{X: -200, Y: 0, Z: -100}, // Moving West and South
+			},
+			{
+				ID:       "missile-1",
+				Role:     RoleInterceptor,
+				Position: vector.Vector3{X: 0, Y: 0, Z: 0},
+				// Initial boost: upwards (Y+) and slightly towards target
+				Velocity: vector.Vector3{X: 10, Y: 10, Z: 10},
+				Guidance: "ProNav",
+			},
+		},
+		Radar: &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}},
+	}
+}
+
+// HeadOn is a fast, high target flying straight at the launcher.
+func HeadOn() *Scenario {
+	return &Scenario{
+		Name:        "head-on",
+		Description: "Mach 1.8 target inbound at 5000m straight down the launcher's boresight.",
+		Entities: []Entity{
+			{
+				ID:       "target-1",
+				Role:     RoleTarget,
+				Position: vector.Vector3{X: 0, Y: 5000, Z: 20000},
+				Velocity: vector.Vector3{X: 0, Y: 0, Z: -600},
+			},
+			{
+				ID:       "missile-1",
+				Role:     RoleInterceptor,
+				Position: vector.Vector3{X: 0, Y: 0, Z: 0},
+				Velocity: vector.Vector3{X: 0, Y: 100, Z: 100},
+				Guidance: "ProNav",
+			},
+		},
+		Radar:       &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}},
+		Termination: Termination{MaxTime: 90},
+	}
+}
+
+// Crossing is a target passing broadside, the hardest geometry for pursuit laws.
+func Crossing() *Scenario {
+	return &Scenario{
+		Name:        "crossing",
+		Description: "Target crossing left to right at 3000m with a high line-of-sight rate.",
+		Entities: []Entity{
+			{
+				ID:       "target-1",
+				Role:     RoleTarget,
+				Position: vector.Vector3{X: -8000, Y: 3000, Z: 8000},
+				Velocity: vector.Vector3{X: 300, Y: 0, Z: 0},
+			},
+			{
+				ID:       "missile-1",
+				Role:     RoleInterceptor,
+				Position: vector.Vector3{X: 0, Y: 0, Z: 0},
+				Velocity: vector.Vector3{X: 0, Y: 60, Z: 40},
+				Guidance: "ProNav",
+			},
+		},
+		Radar:       &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}},
+		Termination: Termination{MaxTime: 90},
+	}
+}
+
+// PopUp hides a low-flying target behind a ridge until it climbs into view.
+func PopUp() *Scenario {
+	return &Scenario{
+		Name:        "pop-up",
+		Description: "Target flies nap-of-the-earth behind a ridge, then pops up to attack; terrain masks the sensors until it does.",
+		Entities: []Entity{
+			{
+				ID:       "target-1",
+				Role:     RoleTarget,
+				Position: vector.Vector3{X: 9000, Y: 120, Z: 9000},
+				Velocity: vector.Vector3{X: -180, Y: 0, Z: -180},
+				Maneuvers: []Maneuver{
+					{Type: ManeuverClimb, Start: 8, Duration: 5, G: 3},
+				},
+			},
+			{
+				ID:       "missile-1",
+				Role:     RoleInterceptor,
+				Position: vector.Vector3{X: 0, Y: 0, Z: 0},
+				Velocity: vector.Vector3{X: 40, Y: 250, Z: 40},
+				Guidance: "ProNav",
+			},
+		},
+		Radar: &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}},
+		Environment: Environment{
+			Terrain: &TerrainSpec{
+				Hills: []sensors.Hill{
+					{X: 4500, Z: 4500, Height: 500, Radius: 800},
+				},
+			},
+		},
+		Termination: Termination{MaxTime: 90},
+	}
+}
+
+// BallisticReentry is an unpowered warhead falling from high altitude.
+func BallisticReentry() *Scenario {
+	return &Scenario{
+		Name:        "ballistic-reentry",
+		Description: "Ballistic reentry vehicle descending steeply from 30km; the interceptor must climb to meet it.",
+		Entities: []Entity{
+			{
+				ID:        "rv-1",
+				Role:      RoleTarget,
+				Position:  vector.Vector3{X: 15000, Y: 30000, Z: 15000},
+				Velocity:  vector.Vector3{X: -500, Y: -1200, Z: -500},
+				Ballistic: true,
+			},
+			{
+				ID:       "missile-1",
+				Role:     RoleInterceptor,
+				Position: vector.Vector3{X: 0, Y: 0, Z: 0},
+				Velocity: vector.Vector3{X: 40, Y: 400, Z: 40},
+				Guidance: "ProNav",
+				Seeker:   &SeekerSpec{MaxRange: 60000},
+			},
+		},
+		Radar:       &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}, MaxRange: 120000},
+		Termination: Termination{MaxTime: 60},
+	}
+}
+
+// Raid is a four-ship formation met by one interceptor per target.
+func Raid() *Scenario {
+	sc := &Scenario{
+		Name:        "raid",
+		Description: "Four-target raid in loose formation, one weaving; one interceptor assigned per target.",
+		Radar:       &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}},
+		Termination: Termination{MaxTime: 120},
+	}
+	offsets := []vector.Vector3{
+		{X: 0, Y: 0, Z: 0},
+		{X: 600, Y: 200, Z: 400},
+		{X: -600, Y: -200, Z: 400},
+		{X: 0, Y: 400, Z: 900},
+	}
+	lead := vector.Vector3{X: 6000, Y: 3000, Z: 12000}
+	for i, off := range offsets {
+		id := string(rune('1' + i))
+		target := Entity{
+			ID:       "target-" + id,
+			Role:     RoleTarget,
+			Position: lead.Add(off),
+			Velocity: vector.Vector3{X: -120, Y: 0, Z: -250},
+		}
+		if i == 3 {
+			target.Maneuvers = []Maneuver{{Type: ManeuverWeave, Start: 5, Duration: 60, G: 4, Period: 8}}
+		}
+		sc.Entities = append(sc.Entities, target, Entity{
+			ID:       "missile-" + id,
+			Role:     RoleInterceptor,
+			Position: vector.Vector3{X: float64(i-2) * 50, Y: 0, Z: 0},
+			Velocity: vector.Vector3{X: 10, Y: 30, Z: 20},
+			Guidance: "ProNav",
+			TargetID: "target-" + id,
+		})
+	}
+	return sc
+}
+
+// Training varies the default engagement on every reset so repeated runs
+// don't memorize one geometry.
+func Training() *Scenario {
+	sc := Default()
+	sc.Name = "training"
+	sc.Description = "Single inbound target whose heading (±20°), speed (200-300 m/s) and altitude (1500-4000 m) are drawn from the run seed."
+	sc.Entities[0].Random = &Randomization{
+		Heading:     20,
+		SpeedMin:    200,
+		SpeedMax:    300,
+		AltitudeMin: 1500,
+		AltitudeMax: 4000,
+	}
+	sc.Termination.MaxTime = 90
+	return sc
+}
+
+// AreaDefense is a six-round battery under launch doctrine facing a
+// three-target stream.
+func AreaDefense() *Scenario {
+	sc := &Scenario{
+		Name:        "area-defense",
+		Description: "Three targets inbound in trail against a six-round battery that fires on its own, shoot-look-shoot, once tracks close inside 12km.",
+		Radar:       &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}},
+		Doctrine: &Doctrine{
+			Policy:      PolicyShootLookShoot,
+			Salvo:       1,
+			LaunchRange: 12000,
+			LookTime:    2,
+		},
+		Termination: Termination{
+			MaxTime: 150,
+			Bounds: &Bounds{
+				Min: vector.Vector3{X: -10000, Y: -100, Z: -5000},
+				Max: vector.Vector3{X: 10000, Y: 8000, Z: 40000},
+			},
+		},
+	}
+	for i := 0; i < 3; i++ {
+		sc.Entities = append(sc.Entities, Entity{
+			ID:       "target-" + string(rune('1'+i)),
+			Role:     RoleTarget,
+			Position: vector.Vector3{X: 2000 - float64(i)*2000, Y: 2500, Z: 25000 + float64(i)*4000},
+			Velocity: vector.Vector3{X: 0, Y: 0, Z: -250},
+		})
+	}
+	for i := 0; i < 6; i++ {
+		sc.Entities = append(sc.Entities, Entity{
+			ID:       "missile-" + string(rune('1'+i)),
+			Role:     RoleInterceptor,
+			Position: vector.Vector3{X: float64(i-3) * 20, Y: 0, Z: 0},
+			Velocity: vector.Vector3{X: 0, Y: 30, Z: 0},
+			Guidance: "ProNav",
+		})
+	}
+	return sc
+}
+
+// LayeredDefense pits an upper-tier exo interceptor, a medium-range SAM
+// battery and point defense against a reentry vehicle and a low cruiser.
+func LayeredDefense() *Scenario {
+	sc := &Scenario{
+		Name:        "layered-defense",
+		Description: "Reentry vehicle and low-level cruiser against three defense tiers: exo interceptors above 20km, a SAM battery, then point defense for leakers.",
+		Radar:       &RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}, MaxRange: 120000},
+		Doctrine: &Doctrine{
+			Policy:   PolicyShootLookShoot,
+			LookTime: 1,
+			Tiers: []Tier{
+				{Name: "upper", MaxRange: 80000, MinAltitude: 20000},
+				{Name: "middle", MinRange: 1000, MaxRange: 25000, MaxAltitude: 25000, Shots: 2},
+				{Name: "point", MaxRange: 5000, Salvo: 2},
+			},
+		},
+		Termination: Termination{MaxTime: 120},
+		Entities: []Entity{
+			{
+				ID:        "rv-1",
+				Role:      RoleTarget,
+				Position:  vector.Vector3{X: 20000, Y: 50000, Z: 20000},
+				Velocity:  vector.Vector3{X: -400, Y: -1200, Z: -400},
+				Ballistic: true,
+			},
+			{
+				ID:       "cruiser-1",
+				Role:     RoleTarget,
+				Position: vector.Vector3{X: 3000, Y: 800, Z: 30000},
+				Velocity: vector.Vector3{X: 0, Y: 0, Z: -280},
+			},
+		},
+	}
+	// Each tier fires from its own site.
+	add := func(prefix, tier string, n int, site, vel vector.Vector3, accel float64, seeker *SeekerSpec) {
+		for i := 0; i < n; i++ {
+			sc.Entities = append(sc.Entities, Entity{
+				ID:       prefix + "-" + string(rune('1'+i)),
+				Role:     RoleInterceptor,
+				Position: site,
+				Velocity: vel,
+				MaxAccel: accel,
+				Guidance: "ProNav",
+				Tier:     tier,
+				Seeker:   seeker,
+			})
+		}
+	}
+	add("exo", "upper", 2, vector.Vector3{X: -2000}, vector.Vector3{Y: 800}, 150, &SeekerSpec{MaxRange: 60000})
+	add("sam", "middle", 4, vector.Vector3{X: 500, Z: 500}, vector.Vector3{X: 30, Y: 60}, 0, nil)
+	add("pd", "point", 4, vector.Vector3{}, vector.Vector3{Y: 100}, 500, nil)
+	return sc
+}
+
+// Builtins returns fresh copies of the scenarios shipped with the simulator.
+func Builtins() []*Scenario {
+	return []*Scenario{
+		Default(),
+		HeadOn(),
+		Crossing(),
+		PopUp(),
+		BallisticReentry(),
+		Raid(),
+		Training(),
+		AreaDefense(),
+		LayeredDefense(),
+	}
+}
+
+// Builtin looks up a shipped scenario by name.
+func Builtin(name string) (*Scenario, bool) {
+	for _, sc := range Builtins() {
+		if sc.Name == name {
+			return sc, true
+		}
+	}
+	return nil, false
+}
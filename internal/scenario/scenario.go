@@ -0,0 +1,556 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"strings"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/sensors"
+	"missile-intercept-sim/internal/stochastic"
+	"missile-intercept-sim/internal/units"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Entity roles.
+const (
+	RoleTarget      = "target"
+	RoleInterceptor = "interceptor"
+)
+
+// Scenario describes everything needed to initialize a run.
+// Y-UP System: X=East, Y=Alt, Z=North
+type Scenario struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description,omitempty"`
+	Seed        uint64          `json:"seed,omitempty"` // 0 draws a fresh seed per run
+	Entities    []Entity        `json:"entities"`
+	Radar       *RadarSpec      `json:"radar,omitempty"`
+	Asset       *vector.Vector3 `json:"asset,omitempty"` // defended point threats are ranked against, defaults to the radar site
+	Environment Environment     `json:"environment"`
+	Termination Termination     `json:"termination"`
+	Doctrine    *Doctrine       `json:"doctrine,omitempty"`
+}
+
+// Entity is the initial condition of one target or interceptor.
+type Entity struct {
+	ID       string         `json:"id"`
+	Role     string         `json:"role"` // target, interceptor
+	Position vector.Vector3 `json:"position"`
+	Velocity vector.Vector3 `json:"velocity"`
+	MaxAccel float64        `json:"maxAccel,omitempty"` // m/s^2, 0 keeps the entity default
+	Random   *Randomization `json:"random,omitempty"`   // initial conditions drawn afresh on every reset
+
+	// Interceptors only.
+	Guidance   string      `json:"guidance,omitempty"`
+	Gain       float64     `json:"gain,omitempty"`       // guidance command multiplier, scales N for ProNav; 0 means 1
+	LaunchTime float64     `json:"launchTime,omitempty"` // s, held on the launcher until then
+	TargetID   string      `json:"targetId,omitempty"`   // defaults to the highest-priority target
+	Tier       string      `json:"tier,omitempty"`       // doctrine tier the interceptor belongs to
+	Seeker     *SeekerSpec `json:"seeker,omitempty"`
+
+	// Targets only.
+	Maneuvers []Maneuver `json:"maneuvers,omitempty"`
+	Ballistic bool       `json:"ballistic,omitempty"` // falls under gravity instead of holding altitude
+}
+
+// Randomization bounds the initial conditions drawn for an entity. Zero
+// fields keep the value as written.
+type Randomization struct {
+	Heading     float64 `json:"heading,omitempty"`     // ± degrees about the written heading
+	SpeedMin    float64 `json:"speedMin,omitempty"`    // m/s
+	SpeedMax    float64 `json:"speedMax,omitempty"`    // m/s
+	AltitudeMin float64 `json:"altitudeMin,omitempty"` // m
+	AltitudeMax float64 `json:"altitudeMax,omitempty"` // m
+
+	// Gaussian jitter applied after the draws above.
+	PositionSigma float64 `json:"positionSigma,omitempty"` // m, 1-sigma per axis
+	VelocitySigma float64 `json:"velocitySigma,omitempty"` // m/s, 1-sigma per axis
+}
+
+// apply draws e's initial conditions from r. Jitter never puts an entity
+// below the terrain, and level-flying targets stay level.
+func (r *Randomization) apply(e *Entity, rng *rand.Rand, terrain sensors.Terrain) {
+	if r.Heading > 0 {
+		a := stochastic.Uniform(rng, -r.Heading, r.Heading) * math.Pi / 180
+		sin, cos := math.Sincos(a)
+		v := e.Velocity
+		// Positive angles turn right (clockwise seen from above).
+		e.Velocity.X = v.X*cos + v.Z*sin
+		e.Velocity.Z = v.Z*cos - v.X*sin
+	}
+	if r.SpeedMax > 0 {
+		speed := stochastic.Uniform(rng, r.SpeedMin, r.SpeedMax)
+		e.Velocity = e.Velocity.Normalize().Mul(speed)
+	}
+	if r.AltitudeMax > 0 {
+		e.Position.Y = stochastic.Uniform(rng, r.AltitudeMin, r.AltitudeMax)
+	}
+	if r.PositionSigma > 0 {
+		nominal := e.Position
+		e.Position = nominal.Add(stochastic.GaussianVector(rng, r.PositionSigma))
+		if e.Position.Y < terrain.Elevation(e.Position.X, e.Position.Z) {
+			e.Position.Y = nominal.Y
+		}
+	}
+	if r.VelocitySigma > 0 {
+		e.Velocity = e.Velocity.Add(stochastic.GaussianVector(rng, r.VelocitySigma))
+		if e.Role == RoleTarget && !e.Ballistic {
+			e.Velocity.Y = 0
+		}
+	}
+}
+
+// Randomized returns a copy of the scenario with every randomized entity's
+// initial conditions drawn from rng. Entities without a Randomization draw
+// nothing, so scenarios that don't use it leave rng untouched.
+func (s *Scenario) Randomized(rng *rand.Rand) *Scenario {
+	cp := s.Clone()
+	terrain := cp.Environment.Terrain.Model()
+	for i := range cp.Entities {
+		if r := cp.Entities[i].Random; r != nil {
+			r.apply(&cp.Entities[i], rng, terrain)
+		}
+	}
+	return cp
+}
+
+// RadarSpec configures the ground surveillance radar.
+type RadarSpec struct {
+	Position   vector.Vector3 `json:"position"`
+	MaxRange   float64        `json:"maxRange,omitempty"`
+	NotchWidth float64        `json:"notchWidth,omitempty"`
+	UpdateRate float64        `json:"updateRate,omitempty"`
+	Noise      float64        `json:"noise,omitempty"`
+}
+
+// SeekerSpec overrides an interceptor's default seeker. Zero fields keep the default.
+type SeekerSpec struct {
+	Band        sensors.Band `json:"band,omitempty"`
+	MaxRange    float64      `json:"maxRange,omitempty"`
+	GimbalLimit float64      `json:"gimbalLimit,omitempty"`
+	NotchWidth  float64      `json:"notchWidth,omitempty"`
+	UpdateRate  float64      `json:"updateRate,omitempty"`
+	Noise       float64      `json:"noise,omitempty"`
+}
+
+// Environment holds terrain and weather.
+type Environment struct {
+	Terrain *TerrainSpec    `json:"terrain,omitempty"`
+	Weather sensors.Weather `json:"weather"`
+}
+
+// TerrainSpec describes a ground plane with optional hills.
+type TerrainSpec struct {
+	Base  float64        `json:"base"`
+	Hills []sensors.Hill `json:"hills,omitempty"`
+}
+
+// Model builds the terrain model used by the simulator.
+func (t *TerrainSpec) Model() sensors.Terrain {
+	if t == nil {
+		return sensors.FlatTerrain{}
+	}
+	if len(t.Hills) == 0 {
+		return sensors.FlatTerrain{Altitude: t.Base}
+	}
+	return sensors.HillTerrain{Base: t.Base, Hills: t.Hills}
+}
+
+// Fire-control policies.
+const (
+	PolicyShootLookShoot = "shoot-look-shoot" // wait for a salvo to resolve before firing again
+	PolicyShootShoot     = "shoot-shoot"      // fire the next salvo LookTime after the last, without waiting
+)
+
+// Doctrine makes interceptors wait on the launcher until the fire-control
+// rules commit them against a radar track. Without one, each interceptor
+// launches at its LaunchTime.
+type Doctrine struct {
+	Policy      string  `json:"policy,omitempty"`      // defaults to shoot-look-shoot
+	Salvo       int     `json:"salvo,omitempty"`       // interceptors per salvo, default 1
+	LaunchRange float64 `json:"launchRange,omitempty"` // m, engage tracks this close to a launcher; unused with tiers
+	LookTime    float64 `json:"lookTime,omitempty"`    // s, assessment delay before the next salvo
+	HoldFire    bool    `json:"holdFire,omitempty"`
+	Tiers       []Tier  `json:"tiers,omitempty"` // layered defense, outermost first
+}
+
+// Tier is one layer of a layered defense: the interceptors tagged with its
+// name and the envelope they engage in. A threat that survives a tier's
+// shots is handed to the next tier down.
+type Tier struct {
+	Name        string  `json:"name"`
+	MinRange    float64 `json:"minRange,omitempty"`    // m from the launcher
+	MaxRange    float64 `json:"maxRange"`              // m from the launcher
+	MinAltitude float64 `json:"minAltitude,omitempty"` // m
+	MaxAltitude float64 `json:"maxAltitude,omitempty"` // m, 0 is unlimited
+	Salvo       int     `json:"salvo,omitempty"`       // overrides the doctrine salvo
+	Shots       int     `json:"shots,omitempty"`       // salvos per threat before handing it on, default 1
+}
+
+// Contains reports whether a track at pos, rng metres from a launcher, is
+// inside the tier's envelope.
+func (t *Tier) Contains(pos vector.Vector3, rng float64) bool {
+	if rng < t.MinRange || rng > t.MaxRange || pos.Y < t.MinAltitude {
+		return false
+	}
+	return t.MaxAltitude == 0 || pos.Y <= t.MaxAltitude
+}
+
+// Termination controls when a run ends.
+type Termination struct {
+	InterceptRadius float64 `json:"interceptRadius,omitempty"` // m
+	MaxTime         float64 `json:"maxTime,omitempty"`         // s, 0 runs until another condition ends it
+	Bounds          *Bounds `json:"bounds,omitempty"`          // interceptors leaving the box are lost
+	MinSpeed        float64 `json:"minSpeed,omitempty"`        // m/s, an interceptor slowing below this after reaching it is spent
+}
+
+// Bounds is an axis-aligned box in world coordinates.
+type Bounds struct {
+	Min vector.Vector3 `json:"min"`
+	Max vector.Vector3 `json:"max"`
+}
+
+// Contains reports whether p lies inside the box.
+func (b *Bounds) Contains(p vector.Vector3) bool {
+	return p.X >= b.Min.X && p.X <= b.Max.X &&
+		p.Y >= b.Min.Y && p.Y <= b.Max.Y &&
+		p.Z >= b.Min.Z && p.Z <= b.Max.Z
+}
+
+// DefaultInterceptRadius applies when the scenario does not set one.
+const DefaultInterceptRadius = 5.0
+
+// Maneuver types.
+const (
+	ManeuverTurn  = "turn"  // level turn at G; positive turns right
+	ManeuverClimb = "climb" // vertical pull at G; negative dives
+	ManeuverWeave = "weave" // sinusoidal level turn with amplitude G and Period
+)
+
+// Maneuver is a scripted target acceleration over a time window.
+type Maneuver struct {
+	Type     string  `json:"type"`
+	Start    float64 `json:"start"`    // s
+	Duration float64 `json:"duration"` // s
+	G        float64 `json:"g"`        // load factor
+	Period   float64 `json:"period,omitempty"`
+}
+
+// Active reports whether the maneuver applies at simulation time t.
+func (m Maneuver) Active(t float64) bool {
+	return t >= m.Start && t < m.Start+m.Duration
+}
+
+// Acceleration returns the maneuver's acceleration for an entity at time t.
+func (m Maneuver) Acceleration(e *entities.Entity, t float64) vector.Vector3 {
+	if !m.Active(t) {
+		return vector.Vector3{}
+	}
+	up := vector.Vector3{Y: 1}
+	switch m.Type {
+	case ManeuverTurn, ManeuverWeave:
+		heading := vector.Vector3{X: e.Velocity.X, Z: e.Velocity.Z}.Normalize()
+		right := up.Cross(heading)
+		g := m.G
+		if m.Type == ManeuverWeave && m.Period > 0 {
+			g *= math.Sin(2 * math.Pi * (t - m.Start) / m.Period)
+		}
+		return right.Mul(units.FromG(g))
+	case ManeuverClimb:
+		return up.Mul(units.FromG(m.G))
+	}
+	return vector.Vector3{}
+}
+
+// Targets returns the target entities in declaration order.
+func (s *Scenario) Targets() []Entity {
+	return s.byRole(RoleTarget)
+}
+
+// Interceptors returns the interceptor entities in declaration order.
+func (s *Scenario) Interceptors() []Entity {
+	return s.byRole(RoleInterceptor)
+}
+
+func (s *Scenario) byRole(role string) []Entity {
+	var out []Entity
+	for _, e := range s.Entities {
+		if e.Role == role {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// FieldError is one problem found in a scenario document. Path locates it
+// in the JSON, e.g. entities[2].random; decode errors carry the line and
+// column instead when the field is not known.
+type FieldError struct {
+	Path    string `json:"path,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Column  int    `json:"column,omitempty"`
+	Message string `json:"message"`
+}
+
+func (e FieldError) String() string {
+	switch {
+	case e.Path != "":
+		return e.Path + ": " + e.Message
+	case e.Line > 0:
+		return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return e.Message
+}
+
+// ValidationError lists every problem found in a scenario.
+type ValidationError []FieldError
+
+func (v ValidationError) Error() string {
+	msgs := make([]string, len(v))
+	for i, e := range v {
+		msgs[i] = e.String()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// add records a problem at path; format and args make the message.
+func (v *ValidationError) add(path, format string, args ...any) {
+	*v = append(*v, FieldError{Path: path, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the scenario for structural errors. It reports every
+// problem it finds as a ValidationError.
+func (s *Scenario) Validate() error {
+	var errs ValidationError
+	if s.Name == "" {
+		errs.add("name", "is required")
+	}
+	ids := make(map[string]string)
+	terrain := s.Environment.Terrain.Model()
+	for i, e := range s.Entities {
+		at := fmt.Sprintf("entities[%d]", i)
+		if e.ID == "" {
+			errs.add(at+".id", "is required")
+		} else if _, dup := ids[e.ID]; dup {
+			errs.add(at+".id", "duplicate id %q", e.ID)
+		}
+		if e.Role != RoleTarget && e.Role != RoleInterceptor {
+			errs.add(at+".role", "must be %q or %q", RoleTarget, RoleInterceptor)
+		}
+		if e.ID != "" {
+			if _, dup := ids[e.ID]; !dup {
+				ids[e.ID] = e.Role
+			}
+		}
+		if e.Gain < 0 || e.LaunchTime < 0 {
+			errs.add(at, "gain and launchTime must not be negative")
+		}
+		if sk := e.Seeker; sk != nil {
+			switch sk.Band {
+			case "", sensors.BandRF, sensors.BandIR:
+			default:
+				errs.add(at+".seeker.band", "unknown band %q", sk.Band)
+			}
+		}
+		if r := e.Random; r != nil {
+			if r.Heading < 0 || r.Heading > 180 {
+				errs.add(at+".random.heading", "must be between 0 and 180")
+			}
+			if r.SpeedMin < 0 || r.SpeedMax < r.SpeedMin || r.AltitudeMin < 0 || r.AltitudeMax < r.AltitudeMin {
+				errs.add(at+".random", "ranges must be non-negative with min at most max")
+			}
+			if r.PositionSigma < 0 || r.VelocitySigma < 0 {
+				errs.add(at+".random", "jitter must not be negative")
+			}
+			if r.SpeedMax > 0 && e.Velocity == (vector.Vector3{}) {
+				errs.add(at+".random", "speed needs a nonzero velocity to scale")
+			}
+			if r.AltitudeMax > 0 {
+				if ground := terrain.Elevation(e.Position.X, e.Position.Z); r.AltitudeMin <= ground {
+					errs.add(at+".random.altitudeMin", "%.0f is not above the terrain at %.0f", r.AltitudeMin, ground)
+				}
+				if b := s.Termination.Bounds; b != nil && (r.AltitudeMin < b.Min.Y || r.AltitudeMax > b.Max.Y) {
+					errs.add(at+".random", "altitude band must lie within termination bounds")
+				}
+			}
+		}
+		if e.Ballistic && len(e.Maneuvers) > 0 {
+			errs.add(at, "ballistic targets cannot maneuver")
+		}
+		for j, m := range e.Maneuvers {
+			mat := fmt.Sprintf("%s.maneuvers[%d]", at, j)
+			switch m.Type {
+			case ManeuverTurn, ManeuverClimb, ManeuverWeave:
+			default:
+				errs.add(mat+".type", "unknown type %q", m.Type)
+			}
+			if m.Duration <= 0 {
+				errs.add(mat+".duration", "must be positive")
+			}
+		}
+	}
+	if len(s.Targets()) == 0 {
+		errs.add("entities", "scenario needs at least one target")
+	}
+	if len(s.Interceptors()) == 0 {
+		errs.add("entities", "scenario needs at least one interceptor")
+	}
+	for i, e := range s.Entities {
+		if e.TargetID != "" && ids[e.TargetID] != RoleTarget {
+			errs.add(fmt.Sprintf("entities[%d].targetId", i), "%q is not a target", e.TargetID)
+		}
+	}
+	if s.Termination.InterceptRadius < 0 || s.Termination.MaxTime < 0 || s.Termination.MinSpeed < 0 {
+		errs.add("termination", "termination values must not be negative")
+	}
+	tiers := make(map[string]bool)
+	if d := s.Doctrine; d != nil {
+		switch d.Policy {
+		case "", PolicyShootLookShoot, PolicyShootShoot:
+		default:
+			errs.add("doctrine.policy", "unknown policy %q", d.Policy)
+		}
+		if d.Salvo < 0 || d.LookTime < 0 || d.LaunchRange < 0 {
+			errs.add("doctrine", "salvo, lookTime and launchRange must not be negative")
+		}
+		if len(d.Tiers) == 0 && d.LaunchRange == 0 {
+			errs.add("doctrine.launchRange", "is required without tiers")
+		}
+		for i, t := range d.Tiers {
+			at := fmt.Sprintf("doctrine.tiers[%d]", i)
+			if t.Name == "" || tiers[t.Name] {
+				errs.add(at+".name", "name must be set and unique")
+			}
+			tiers[t.Name] = true
+			if t.MinRange < 0 || t.MaxRange <= t.MinRange {
+				errs.add(at, "maxRange must exceed minRange")
+			}
+			if t.MinAltitude < 0 || (t.MaxAltitude != 0 && t.MaxAltitude <= t.MinAltitude) {
+				errs.add(at, "maxAltitude must exceed minAltitude")
+			}
+			if t.Salvo < 0 || t.Shots < 0 {
+				errs.add(at, "salvo and shots must not be negative")
+			}
+		}
+	}
+	for i, e := range s.Entities {
+		at := fmt.Sprintf("entities[%d].tier", i)
+		switch {
+		case e.Tier != "" && len(tiers) == 0:
+			errs.add(at, "is set but the doctrine has no tiers")
+		case e.Role == RoleInterceptor && len(tiers) > 0 && !tiers[e.Tier]:
+			errs.add(at, "tier %q is not a doctrine tier", e.Tier)
+		}
+	}
+	if b := s.Termination.Bounds; b != nil {
+		if b.Min.X >= b.Max.X || b.Min.Y >= b.Max.Y || b.Min.Z >= b.Max.Z {
+			errs.add("termination.bounds", "min must be below max on every axis")
+		}
+		for i, e := range s.Entities {
+			if !b.Contains(e.Position) {
+				errs.add(fmt.Sprintf("entities[%d].position", i), "is outside the termination bounds")
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// Clone returns a deep copy of the scenario.
+func (s *Scenario) Clone() *Scenario {
+	cp := *s
+	cp.Entities = make([]Entity, len(s.Entities))
+	for i, e := range s.Entities {
+		if e.Seeker != nil {
+			sk := *e.Seeker
+			e.Seeker = &sk
+		}
+		e.Maneuvers = append([]Maneuver(nil), e.Maneuvers...)
+		if e.Random != nil {
+			r := *e.Random
+			e.Random = &r
+		}
+		cp.Entities[i] = e
+	}
+	if s.Radar != nil {
+		r := *s.Radar
+		cp.Radar = &r
+	}
+	if s.Environment.Terrain != nil {
+		t := *s.Environment.Terrain
+		t.Hills = append([]sensors.Hill(nil), t.Hills...)
+		cp.Environment.Terrain = &t
+	}
+	cp.Environment.Weather.Clouds = append([]sensors.CloudLayer(nil), s.Environment.Weather.Clouds...)
+	if s.Termination.Bounds != nil {
+		b := *s.Termination.Bounds
+		cp.Termination.Bounds = &b
+	}
+	if s.Asset != nil {
+		a := *s.Asset
+		cp.Asset = &a
+	}
+	if s.Doctrine != nil {
+		d := *s.Doctrine
+		d.Tiers = append([]Tier(nil), d.Tiers...)
+		cp.Doctrine = &d
+	}
+	return &cp
+}
+
+// Decode reads and validates a JSON scenario. Problems with the document
+// come back as a ValidationError locating each one.
+func Decode(r io.Reader) (*Scenario, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("read scenario: %w", err)
+	}
+	var sc Scenario
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sc); err != nil {
+		return nil, decodeError(data, dec.InputOffset(), err)
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// decodeError locates a JSON decoding error in data. offset is where the
+// decoder stopped, used when the error does not say.
+func decodeError(data []byte, offset int64, err error) ValidationError {
+	fe := FieldError{Message: strings.TrimPrefix(err.Error(), "json: ")}
+	var syntax *json.SyntaxError
+	var typ *json.UnmarshalTypeError
+	switch {
+	case errors.As(err, &syntax):
+		offset = syntax.Offset
+	case errors.As(err, &typ):
+		offset = typ.Offset
+		fe.Path = typ.Field
+		fe.Message = fmt.Sprintf("expected %s, got %s", typ.Type, typ.Value)
+	case errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		offset = int64(len(data))
+		fe.Message = "unexpected end of document"
+	}
+	fe.Line, fe.Column = position(data, offset)
+	return ValidationError{fe}
+}
+
+// position returns the 1-based line and column of the last byte before
+// offset, which is where the decoder reports a problem.
+func position(data []byte, offset int64) (line, col int) {
+	before := data[:min(max(offset, 0), int64(len(data)))]
+	line = bytes.Count(before, []byte("\n")) + 1
+	col = len(before) - (bytes.LastIndexByte(before, '\n') + 1)
+	return line, col
+}
@@ -0,0 +1,68 @@
+package scenario
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestDecodeLocatesErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+		want []FieldError
+	}{
+		{
+			"syntax",
+			"{\n  \"name\": \"x\",\n  \"entities\": [,]\n}",
+			[]FieldError{{Line: 3, Column: 16}},
+		},
+		{
+			"wrong type",
+			"{\n  \"name\": 7\n}",
+			[]FieldError{{Path: "name", Line: 2, Column: 11}},
+		},
+		{
+			"truncated",
+			"{\n  \"name\": \"x\"",
+			[]FieldError{{Line: 2, Column: 13}},
+		},
+		{
+			"every validation problem",
+			`{"name": "", "entities": [{"id": "T", "role": "target", "random": {"heading": 200}}, {"id": "T", "role": "decoy"}]}`,
+			[]FieldError{
+				{Path: "name"},
+				{Path: "entities[0].random.heading"},
+				{Path: "entities[1].id"},
+				{Path: "entities[1].role"},
+				{Path: "entities"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decode(strings.NewReader(tt.doc))
+			var got ValidationError
+			if !errors.As(err, &got) {
+				t.Fatalf("error %v is not a ValidationError", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d problems, want %d: %v", len(got), len(tt.want), got)
+			}
+			for i, w := range tt.want {
+				g := got[i]
+				if g.Path != w.Path || g.Line != w.Line || g.Column != w.Column || g.Message == "" {
+					t.Errorf("problem %d = %+v, want %+v", i, g, w)
+				}
+			}
+		})
+	}
+}
+
+func TestBuiltinsValidate(t *testing.T) {
+	for _, sc := range Builtins() {
+		if err := sc.Validate(); err != nil {
+			t.Errorf("%s: %v", sc.Name, err)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package scenario
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+const storeExt = ".json"
+
+// validName restricts stored scenario names to something safe as a file name.
+var validName = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,63}$`)
+
+// StoredInfo describes a scenario in a store directory.
+type StoredInfo struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	Size        int64     `json:"size"`
+	Modified    time.Time `json:"modified"`
+}
+
+// CheckName reports whether name can be used to store a scenario.
+func CheckName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid scenario name %q: use up to 64 letters, digits, - and _", name)
+	}
+	return nil
+}
+
+// Save validates sc and writes it to dir as <name>.json, replacing any
+// scenario stored under that name. The write is atomic, so readers never
+// see half a file.
+func Save(dir, name string, sc *Scenario) error {
+	if err := CheckName(name); err != nil {
+		return err
+	}
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(sc, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), filepath.Join(dir, name+storeExt))
+}
+
+// Load reads and validates the scenario stored in dir under name.
+func Load(dir, name string) (*Scenario, error) {
+	data, err := Raw(dir, name)
+	if err != nil {
+		return nil, err
+	}
+	sc, err := Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("stored scenario %s: %w", name, err)
+	}
+	return sc, nil
+}
+
+// Raw returns the stored document as written, for download.
+func Raw(dir, name string) ([]byte, error) {
+	if err := CheckName(name); err != nil {
+		return nil, err
+	}
+	return os.ReadFile(filepath.Join(dir, name+storeExt))
+}
+
+// Remove deletes the scenario stored under name.
+func Remove(dir, name string) error {
+	if err := CheckName(name); err != nil {
+		return err
+	}
+	return os.Remove(filepath.Join(dir, name+storeExt))
+}
+
+// List returns the scenarios stored in dir, by name.
+func List(dir string) ([]StoredInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []StoredInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := []StoredInfo{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), storeExt)
+		if e.IsDir() || !ok || CheckName(name) != nil {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		item := StoredInfo{Name: name, Size: info.Size(), Modified: info.ModTime()}
+		if data, err := os.ReadFile(filepath.Join(dir, e.Name())); err == nil {
+			var head struct {
+				Description string `json:"description"`
+			}
+			if json.Unmarshal(data, &head) == nil {
+				item.Description = head.Description
+			}
+		}
+		list = append(list, item)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
@@ -0,0 +1,243 @@
+package sensors
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/stochastic"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Environment bundles the world conditions sensors are evaluated against.
+type Environment struct {
+	Terrain Terrain
+	Weather Weather
+	Rand    *rand.Rand // per-run source for measurement noise; nil disables noise
+}
+
+// Track is the last observation a sensor holds on a target.
+type Track struct {
+	TargetID  string         `json:"targetId"`
+	Position  vector.Vector3 `json:"position"`
+	Velocity  vector.Vector3 `json:"velocity"`
+	RangeRate float64        `json:"rangeRate"` // m/s, negative when closing
+	Time      float64        `json:"time"`
+}
+
+// Predict extrapolates the track to time t assuming constant velocity.
+func (tr Track) Predict(t float64) (vector.Vector3, vector.Vector3) {
+	return tr.Position.AddScaled(tr.Velocity, t-tr.Time), tr.Velocity
+}
+
+// Status is the per-sensor summary published in the simulation state.
+type Status struct {
+	ID        string  `json:"id"`
+	Kind      string  `json:"kind"` // Radar, Seeker
+	TargetID  string  `json:"targetId"`
+	Detected  bool    `json:"detected"`
+	Masked    bool    `json:"masked"`
+	Notched   bool    `json:"notched"`
+	Gimbaled  bool    `json:"gimbaled"` // seeker look angle exceeded its gimbal limit
+	Obscured  bool    `json:"obscured"` // cloud cover blocked an IR sensor
+	Range     float64 `json:"range"`
+	RangeRate float64 `json:"rangeRate"`
+	MaxRange  float64 `json:"maxRange"`  // detection range after weather
+	LookAngle float64 `json:"lookAngle"` // degrees off boresight, seekers only
+	Time      float64 `json:"time"`      // simulation time of the measurement
+}
+
+// sensorSpec is the part of a sensor's configuration shared by every kind.
+type sensorSpec struct {
+	kind       string
+	id         string
+	band       Band
+	maxRange   float64
+	notchWidth float64 // <= 0 disables the notch
+}
+
+// measure runs the checks shared by every sensor: weather-attenuated range,
+// terrain masking, cloud obscuration and the Doppler notch.
+func measure(env Environment, spec sensorSpec, from, fromVel vector.Vector3, target *entities.Entity, t float64) Status {
+	st := Status{ID: spec.id, Kind: spec.kind, TargetID: target.ID, Time: t}
+	los := target.Position.Sub(from)
+	st.Range = los.Magnitude()
+	if st.Range > 0 {
+		st.RangeRate = los.Dot(target.Velocity.Sub(fromVel)) / st.Range
+	}
+	st.MaxRange = env.Weather.EffectiveRange(spec.band, spec.maxRange)
+	if st.Range > st.MaxRange {
+		return st
+	}
+	if !LineOfSight(env.Terrain, from, target.Position) {
+		st.Masked = true
+		return st
+	}
+	if env.Weather.Obscured(spec.band, from, target.Position) {
+		st.Obscured = true
+		return st
+	}
+	// A beaming target has almost no Doppler shift relative to clutter
+	// and falls into the clutter filter.
+	if spec.notchWidth > 0 && math.Abs(st.RangeRate) < spec.notchWidth {
+		st.Notched = true
+		return st
+	}
+	st.Detected = true
+	return st
+}
+
+// trackFrom builds a track from a successful detection, adding Gaussian
+// position noise with the given 1-sigma in meters.
+func trackFrom(env Environment, st Status, target *entities.Entity, noise float64) *Track {
+	pos := target.Position
+	if env.Rand != nil && noise > 0 {
+		pos = pos.Add(stochastic.GaussianVector(env.Rand, noise))
+	}
+	return &Track{
+		TargetID:  target.ID,
+		Position:  pos,
+		Velocity:  target.Velocity,
+		RangeRate: st.RangeRate,
+		Time:      st.Time,
+	}
+}
+
+// Radar is a fixed ground-based surveillance radar.
+type Radar struct {
+	ID         string            `json:"id"`
+	Position   vector.Vector3    `json:"position"`
+	MaxRange   float64           `json:"maxRange"`   // clear-air detection range
+	NotchWidth float64           `json:"notchWidth"` // m/s, 0 disables the Doppler notch
+	UpdateRate float64           `json:"updateRate"` // Hz, 0 updates every physics step
+	Noise      float64           `json:"noise"`      // m, 1-sigma position error
+	Tracks     map[string]*Track `json:"tracks"`     // by target ID
+	statuses   []Status
+}
+
+// NewRadar creates a radar site with default performance.
+func NewRadar(id string, pos vector.Vector3) *Radar {
+	return &Radar{
+		ID:         id,
+		Position:   pos,
+		MaxRange:   60000,
+		UpdateRate: 10,
+		Tracks:     make(map[string]*Track),
+	}
+}
+
+// Update attempts a detection of every target at simulation time t.
+// A notched target is dropped from track entirely.
+func (r *Radar) Update(env Environment, targets []*entities.Entity, t float64) {
+	spec := sensorSpec{kind: "Radar", id: r.ID, band: BandRF, maxRange: r.MaxRange, notchWidth: r.NotchWidth}
+	r.statuses = r.statuses[:0]
+	for _, target := range targets {
+		st := measure(env, spec, r.Position, vector.Vector3{}, target, t)
+		switch {
+		case st.Detected:
+			r.Tracks[target.ID] = trackFrom(env, st, target, r.Noise)
+		case st.Notched:
+			delete(r.Tracks, target.ID)
+		}
+		r.statuses = append(r.statuses, st)
+	}
+}
+
+// Drop removes a target from track, e.g. once it has been destroyed.
+func (r *Radar) Drop(targetID string) {
+	delete(r.Tracks, targetID)
+}
+
+// Statuses returns the per-target results of the last update.
+func (r *Radar) Statuses() []Status {
+	return r.statuses
+}
+
+// Clone returns an independent copy of the radar and its tracks.
+func (r *Radar) Clone() *Radar {
+	cp := *r
+	cp.Tracks = make(map[string]*Track, len(r.Tracks))
+	for id, tr := range r.Tracks {
+		trCopy := *tr
+		cp.Tracks[id] = &trCopy
+	}
+	cp.statuses = append([]Status(nil), r.statuses...)
+	return &cp
+}
+
+// Seeker is the interceptor's onboard homing sensor.
+type Seeker struct {
+	ID          string  `json:"id"`
+	Band        Band    `json:"band"`
+	MaxRange    float64 `json:"maxRange"`    // clear-air detection range
+	GimbalLimit float64 `json:"gimbalLimit"` // degrees off boresight, 0 disables the limit
+	NotchWidth  float64 `json:"notchWidth"`  // m/s, 0 disables the Doppler notch
+	UpdateRate  float64 `json:"updateRate"`  // Hz, 0 updates every physics step
+	Noise       float64 `json:"noise"`       // m, 1-sigma position error
+	Track       *Track  `json:"track,omitempty"`
+	status      Status
+	perceived   entities.Entity // reused by Perceived so guidance doesn't allocate every step
+}
+
+// NewSeeker creates a seeker with default performance.
+func NewSeeker(id string) *Seeker {
+	return &Seeker{
+		ID:          id,
+		Band:        BandRF,
+		MaxRange:    30000,
+		GimbalLimit: 60,
+		UpdateRate:  100,
+	}
+}
+
+// Update attempts a detection of the target from the missile's position.
+// Range rate is measured relative to the missile's own motion. Without an
+// attitude model the velocity vector stands in for the body axis, so a target
+// outside the gimbal limit breaks lock and the track is dropped.
+func (s *Seeker) Update(env Environment, missile, target *entities.Entity, t float64) {
+	spec := sensorSpec{kind: "Seeker", id: s.ID, band: s.Band, maxRange: s.MaxRange, notchWidth: s.NotchWidth}
+	s.status = measure(env, spec, missile.Position, missile.Velocity, target, t)
+	if speed := missile.Velocity.Magnitude(); speed > 0 && s.status.Range > 0 {
+		los := target.Position.Sub(missile.Position)
+		s.status.LookAngle = missile.Velocity.AngleTo(los) * 180 / math.Pi
+		if s.GimbalLimit > 0 && s.status.LookAngle > s.GimbalLimit && s.status.Detected {
+			s.status.Detected = false
+			s.status.Gimbaled = true
+			s.Track = nil
+			return
+		}
+	}
+	switch {
+	case s.status.Detected:
+		s.Track = trackFrom(env, s.status, target, s.Noise)
+	case s.status.Notched:
+		s.Track = nil
+	}
+}
+
+// Status returns the result of the last update.
+func (s *Seeker) Status() Status {
+	return s.status
+}
+
+// Clone returns an independent copy of the seeker.
+func (s *Seeker) Clone() *Seeker {
+	cp := *s
+	if s.Track != nil {
+		tr := *s.Track
+		cp.Track = &tr
+	}
+	return &cp
+}
+
+// Perceived returns a copy of the target as the seeker believes it to be at time t,
+// or nil if the seeker has never acquired it. The copy belongs to the seeker
+// and is only valid until the next call.
+func (s *Seeker) Perceived(target *entities.Entity, t float64) *entities.Entity {
+	if s.Track == nil {
+		return nil
+	}
+	s.perceived = *target
+	s.perceived.Position, s.perceived.Velocity = s.Track.Predict(t)
+	return &s.perceived
+}
@@ -0,0 +1,77 @@
+package sensors
+
+import (
+	"math"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Terrain provides ground elevation in the simulation frame.
+// Y-UP System: X=East, Y=Alt, Z=North
+type Terrain interface {
+	Elevation(x, z float64) float64
+}
+
+// FlatTerrain is a level ground plane.
+type FlatTerrain struct {
+	Altitude float64 `json:"altitude"`
+}
+
+// Elevation returns the constant ground altitude.
+func (f FlatTerrain) Elevation(x, z float64) float64 {
+	return f.Altitude
+}
+
+// Hill is a Gaussian-shaped terrain feature.
+type Hill struct {
+	X      float64 `json:"x"`
+	Z      float64 `json:"z"`
+	Height float64 `json:"height"`
+	Radius float64 `json:"radius"` // 1-sigma footprint in meters
+}
+
+// HillTerrain is a flat base with Gaussian hills superimposed.
+type HillTerrain struct {
+	Base  float64 `json:"base"`
+	Hills []Hill  `json:"hills"`
+}
+
+// Elevation sums the contribution of every hill at the given point.
+func (h HillTerrain) Elevation(x, z float64) float64 {
+	elev := h.Base
+	for _, hill := range h.Hills {
+		if hill.Radius <= 0 {
+			continue
+		}
+		dx := x - hill.X
+		dz := z - hill.Z
+		elev += hill.Height * math.Exp(-(dx*dx+dz*dz)/(2*hill.Radius*hill.Radius))
+	}
+	return elev
+}
+
+// losSampleSpacing is the distance between terrain samples along a ray.
+const losSampleSpacing = 25.0
+
+// LineOfSight reports whether the straight segment from a to b clears the terrain.
+// A nil terrain never blocks.
+func LineOfSight(t Terrain, a, b vector.Vector3) bool {
+	if t == nil {
+		return true
+	}
+	dist := a.Distance(b)
+	n := int(math.Ceil(dist / losSampleSpacing))
+	if n < 1 {
+		n = 1
+	}
+	delta := b.Sub(a).Div(float64(n))
+	// Skip the endpoints: sensors and targets sitting on the ground
+	// should not mask themselves.
+	for i := 1; i < n; i++ {
+		p := a.Add(delta.Mul(float64(i)))
+		if p.Y < t.Elevation(p.X, p.Z) {
+			return false
+		}
+	}
+	return true
+}
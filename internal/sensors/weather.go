@@ -0,0 +1,100 @@
+package sensors
+
+import (
+	"math"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Band is the part of the spectrum a sensor operates in.
+type Band string
+
+const (
+	BandRF Band = "RF" // radar
+	BandIR Band = "IR" // infrared
+)
+
+// CloudLayer is a horizontal cloud deck between two altitudes.
+type CloudLayer struct {
+	Base float64 `json:"base"` // m
+	Top  float64 `json:"top"`  // m
+}
+
+// Weather describes atmospheric conditions affecting sensor performance.
+// The zero value is clear air.
+type Weather struct {
+	RainRate float64      `json:"rainRate"` // mm/h
+	Humidity float64      `json:"humidity"` // relative humidity, 0..1
+	Clouds   []CloudLayer `json:"clouds"`
+}
+
+// Rain attenuation coefficients for X-band, gamma = k * R^alpha in dB/km.
+const (
+	rainK     = 0.0101
+	rainAlpha = 1.276
+)
+
+// rangeIterations is the number of bisection steps used to solve for the
+// attenuated detection range.
+const rangeIterations = 40
+
+// EffectiveRange scales a clear-air detection range for the given band. Clear
+// air returns maxRange unchanged.
+func (w Weather) EffectiveRange(band Band, maxRange float64) float64 {
+	switch band {
+	case BandIR:
+		// Extinction in 1/km beyond clear air, whose own loss is already in
+		// the rated range: water vapour dominates, rain adds scattering.
+		// Signal falls as exp(-sigma*R)/R^2, so R = R0 * exp(-sigma*R/2).
+		sigma := 0.4*clamp01(w.Humidity) + 0.1*math.Pow(math.Max(w.RainRate, 0), 0.6)
+		if sigma <= 0 {
+			return maxRange
+		}
+		return solveRange(maxRange, func(r float64) float64 {
+			return math.Exp(-sigma * r / 1000 / 2)
+		})
+	default:
+		if w.RainRate <= 0 {
+			return maxRange
+		}
+		// Two-way path loss against the R^4 radar equation.
+		gamma := rainK * math.Pow(w.RainRate, rainAlpha)
+		return solveRange(maxRange, func(r float64) float64 {
+			return math.Pow(10, -2*gamma*r/1000/40)
+		})
+	}
+}
+
+// solveRange finds r in [0, maxRange] with r = maxRange * factor(r), where
+// factor decreases monotonically with range.
+func solveRange(maxRange float64, factor func(r float64) float64) float64 {
+	lo, hi := 0.0, maxRange
+	for i := 0; i < rangeIterations; i++ {
+		mid := (lo + hi) / 2
+		if mid < maxRange*factor(mid) {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	return lo
+}
+
+// Obscured reports whether the segment from a to b passes through a cloud
+// layer. Clouds are opaque to IR but transparent to radar.
+func (w Weather) Obscured(band Band, a, b vector.Vector3) bool {
+	if band != BandIR {
+		return false
+	}
+	lo, hi := math.Min(a.Y, b.Y), math.Max(a.Y, b.Y)
+	for _, c := range w.Clouds {
+		if hi >= c.Base && lo <= c.Top {
+			return true
+		}
+	}
+	return false
+}
+
+func clamp01(x float64) float64 {
+	return math.Max(0, math.Min(1, x))
+}
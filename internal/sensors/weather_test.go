@@ -0,0 +1,61 @@
+package sensors
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolveRange(t *testing.T) {
+	const maxRange = 30000.0
+	tests := []struct {
+		name   string
+		factor func(r float64) float64
+		want   float64 // 0 checks the fixed point instead
+	}{
+		{"no loss", func(float64) float64 { return 1 }, maxRange},
+		{"constant loss", func(float64) float64 { return 0.5 }, maxRange / 2},
+		{"total loss", func(float64) float64 { return 0 }, 0},
+		{"exponential loss", func(r float64) float64 { return math.Exp(-r / 20000) }, 0},
+		{"decibel loss", func(r float64) float64 { return math.Pow(10, -r/1000/10) }, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := solveRange(maxRange, tt.factor)
+			if got < 0 || got > maxRange {
+				t.Fatalf("solveRange = %g, outside [0, %g]", got, maxRange)
+			}
+			want := tt.want
+			if want == 0 {
+				want = maxRange * tt.factor(got)
+			}
+			if math.Abs(got-want) > 1e-3 {
+				t.Errorf("solveRange = %g, want %g", got, want)
+			}
+		})
+	}
+}
+
+func TestEffectiveRange(t *testing.T) {
+	const maxRange = 30000.0
+	tests := []struct {
+		name    string
+		weather Weather
+		band    Band
+		reduced bool
+	}{
+		{"clear air IR", Weather{}, BandIR, false},
+		{"clear air RF", Weather{}, BandRF, false},
+		{"humid IR", Weather{Humidity: 0.5}, BandIR, true},
+		{"humid RF", Weather{Humidity: 0.5}, BandRF, false},
+		{"rain IR", Weather{RainRate: 10}, BandIR, true},
+		{"rain RF", Weather{RainRate: 10}, BandRF, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.weather.EffectiveRange(tt.band, maxRange)
+			if reduced := got < maxRange-1e-6; reduced != tt.reduced || got <= 0 {
+				t.Errorf("EffectiveRange = %g of %g, reduced %v, want reduced %v", got, maxRange, reduced, tt.reduced)
+			}
+		})
+	}
+}
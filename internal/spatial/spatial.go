@@ -0,0 +1,139 @@
+// Package spatial answers proximity queries over many moving points without
+// comparing every pair. A Grid buckets points into cubes of a fixed size, so
+// a search visits only the cells it overlaps; rebuilt every step it turns the
+// n×m distance checks of a crowded engagement into roughly n + m.
+package spatial
+
+import (
+	"math"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Grid is a uniform grid over space. Points are numbered in the order they
+// are inserted; queries return those numbers.
+type Grid struct {
+	size   float64 // cell edge, m
+	cells  map[cell][]int
+	points []vector.Vector3
+}
+
+type cell struct{ x, y, z int64 }
+
+// NewGrid creates an empty grid with cells size metres on a side. Queries are
+// cheapest with the size about the radius searched for.
+func NewGrid(size float64) *Grid {
+	if !(size > 0) || math.IsInf(size, 0) {
+		size = 1
+	}
+	return &Grid{size: size, cells: make(map[cell][]int)}
+}
+
+// Size returns the cell edge in metres.
+func (g *Grid) Size() float64 {
+	return g.size
+}
+
+// Reset empties the grid, keeping its storage for the next step's points.
+func (g *Grid) Reset() {
+	if len(g.cells) > 4*len(g.points)+16 {
+		// Cells left behind by points that moved on; drop them.
+		clear(g.cells)
+	} else {
+		for k, v := range g.cells {
+			g.cells[k] = v[:0]
+		}
+	}
+	g.points = g.points[:0]
+}
+
+// Len returns the number of points inserted since the last Reset.
+func (g *Grid) Len() int {
+	return len(g.points)
+}
+
+// Insert adds p and returns its number. Non-finite points are numbered but
+// never found.
+func (g *Grid) Insert(p vector.Vector3) int {
+	i := len(g.points)
+	g.points = append(g.points, p)
+	if p.IsFinite() {
+		k := g.cellOf(p)
+		g.cells[k] = append(g.cells[k], i)
+	}
+	return i
+}
+
+// Point returns point i.
+func (g *Grid) Point(i int) vector.Vector3 {
+	return g.points[i]
+}
+
+// Within appends to dst the number of every point no farther than r from p,
+// in no particular order, and returns the extended slice.
+func (g *Grid) Within(p vector.Vector3, r float64, dst []int) []int {
+	if !(r >= 0) || !p.IsFinite() {
+		return dst
+	}
+	r2 := r * r
+	lo, hi := g.cellOf(p.Sub(vector.Vector3{X: r, Y: r, Z: r})), g.cellOf(p.Add(vector.Vector3{X: r, Y: r, Z: r}))
+	span := float64(hi.x-lo.x+1) * float64(hi.y-lo.y+1) * float64(hi.z-lo.z+1)
+	if span > float64(len(g.cells)) {
+		// A search wider than the occupied cells: walk those instead.
+		for k, idx := range g.cells {
+			if k.x < lo.x || k.x > hi.x || k.y < lo.y || k.y > hi.y || k.z < lo.z || k.z > hi.z {
+				continue
+			}
+			dst = g.appendWithin(dst, idx, p, r2)
+		}
+		return dst
+	}
+	for x := lo.x; x <= hi.x; x++ {
+		for y := lo.y; y <= hi.y; y++ {
+			for z := lo.z; z <= hi.z; z++ {
+				dst = g.appendWithin(dst, g.cells[cell{x, y, z}], p, r2)
+			}
+		}
+	}
+	return dst
+}
+
+// Nearest returns the number of the point closest to p and its distance, or
+// -1 if the grid holds no finite point.
+func (g *Grid) Nearest(p vector.Vector3) (int, float64) {
+	best, bestD2 := -1, math.Inf(1)
+	if !p.IsFinite() {
+		return best, bestD2
+	}
+	// Widen the search until it finds something: every point nearer than
+	// the nearest found is inside the same radius, so is among them.
+	var idx []int
+	for r := g.size; len(g.points) > 0 && r < g.size*(1<<52); r *= 2 {
+		if idx = g.Within(p, r, idx[:0]); len(idx) > 0 {
+			break
+		}
+	}
+	for _, i := range idx {
+		if d2 := g.points[i].DistanceSquared(p); d2 < bestD2 {
+			best, bestD2 = i, d2
+		}
+	}
+	return best, math.Sqrt(bestD2)
+}
+
+func (g *Grid) appendWithin(dst, idx []int, p vector.Vector3, r2 float64) []int {
+	for _, i := range idx {
+		if g.points[i].DistanceSquared(p) <= r2 {
+			dst = append(dst, i)
+		}
+	}
+	return dst
+}
+
+func (g *Grid) cellOf(p vector.Vector3) cell {
+	return cell{
+		int64(math.Floor(p.X / g.size)),
+		int64(math.Floor(p.Y / g.size)),
+		int64(math.Floor(p.Z / g.size)),
+	}
+}
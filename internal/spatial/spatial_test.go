@@ -0,0 +1,109 @@
+package spatial
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+func randomPoints(rng *rand.Rand, n int, extent float64) []vector.Vector3 {
+	pts := make([]vector.Vector3, n)
+	for i := range pts {
+		pts[i] = vector.Vector3{
+			X: (rng.Float64() - 0.5) * extent,
+			Y: (rng.Float64() - 0.5) * extent,
+			Z: (rng.Float64() - 0.5) * extent,
+		}
+	}
+	return pts
+}
+
+func TestWithinMatchesBruteForce(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	tests := []struct {
+		name       string
+		size, r    float64
+		n          int
+		extent     float64
+		wantLoaded bool // the query should find something
+	}{
+		{"cells the radius", 50, 50, 2000, 2000, true},
+		{"radius many cells", 10, 400, 500, 2000, true},
+		{"radius wider than the world", 10, 1e6, 100, 2000, true},
+		{"zero radius", 10, 0, 100, 2000, false},
+	}
+	for _, tt := range tests {
+		pts := randomPoints(rng, tt.n, tt.extent)
+		g := NewGrid(tt.size)
+		for _, p := range pts {
+			g.Insert(p)
+		}
+		found := false
+		for _, q := range randomPoints(rng, 50, tt.extent) {
+			got := g.Within(q, tt.r, nil)
+			var want []int
+			for i, p := range pts {
+				if p.Distance(q) <= tt.r {
+					want = append(want, i)
+				}
+			}
+			slices.Sort(got)
+			if !slices.Equal(got, want) {
+				t.Fatalf("%s: within %g of %v got %v, want %v", tt.name, tt.r, q, got, want)
+			}
+			found = found || len(want) > 0
+		}
+		if found != tt.wantLoaded {
+			t.Errorf("%s: queries found points %v, want %v", tt.name, found, tt.wantLoaded)
+		}
+	}
+}
+
+func TestNearest(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+	pts := randomPoints(rng, 300, 10000)
+	g := NewGrid(20)
+	for _, p := range pts {
+		g.Insert(p)
+	}
+	for _, q := range randomPoints(rng, 100, 30000) {
+		want, wantD := -1, math.Inf(1)
+		for i, p := range pts {
+			if d := p.Distance(q); d < wantD {
+				want, wantD = i, d
+			}
+		}
+		if got, d := g.Nearest(q); got != want || math.Abs(d-wantD) > 1e-9 {
+			t.Fatalf("nearest to %v: %d at %g, want %d at %g", q, got, d, want, wantD)
+		}
+	}
+
+	g.Reset()
+	if i, _ := g.Nearest(vector.Vector3{}); i != -1 || g.Len() != 0 {
+		t.Errorf("empty grid: nearest %d, %d points", i, g.Len())
+	}
+	g.Insert(vector.Vector3{X: math.NaN()})
+	if got := g.Within(vector.Vector3{}, math.Inf(1), nil); len(got) != 0 {
+		t.Errorf("found a NaN point: %v", got)
+	}
+}
+
+func BenchmarkWithin(b *testing.B) {
+	rng := rand.New(rand.NewPCG(5, 6))
+	pts := randomPoints(rng, 1000, 50000)
+	g := NewGrid(20)
+	var dst []int
+	b.ReportAllocs()
+	for b.Loop() {
+		g.Reset()
+		for _, p := range pts {
+			g.Insert(p)
+		}
+		for _, p := range pts {
+			dst = g.Within(p, 20, dst[:0])
+		}
+	}
+}
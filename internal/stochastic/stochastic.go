@@ -0,0 +1,159 @@
+// Package stochastic draws the random quantities of Monte Carlo runs:
+// Gaussian and uniform scalars and vectors, directions, headings and
+// time-correlated noise. Every sampler takes its source explicitly, so a
+// run seeded once replays bit-identically however its draws are shared
+// out.
+package stochastic
+
+import (
+	"math"
+	"math/rand/v2"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// New returns a source seeded the way the simulator seeds its runs.
+func New(seed uint64) *rand.Rand {
+	return rand.New(rand.NewPCG(seed, seed))
+}
+
+// Uniform draws from [lo, hi).
+func Uniform(rng *rand.Rand, lo, hi float64) float64 {
+	return lo + (hi-lo)*rng.Float64()
+}
+
+// Gaussian draws from the normal distribution N(mean, sigma²).
+func Gaussian(rng *rand.Rand, mean, sigma float64) float64 {
+	return mean + sigma*rng.NormFloat64()
+}
+
+// GaussianVector draws a vector with independent N(0, sigma²) components,
+// X first.
+func GaussianVector(rng *rand.Rand, sigma float64) vector.Vector3 {
+	return vector.Vector3{
+		X: rng.NormFloat64() * sigma,
+		Y: rng.NormFloat64() * sigma,
+		Z: rng.NormFloat64() * sigma,
+	}
+}
+
+// CorrelatedVector draws a vector from the normal distribution with the
+// given mean and covariance, which must be positive semi-definite; only
+// its lower triangle is read. It reports false, drawing nothing, for a
+// covariance it cannot factor.
+func CorrelatedVector(rng *rand.Rand, mean vector.Vector3, cov vector.Matrix3) (vector.Vector3, bool) {
+	l, ok := cholesky(cov)
+	if !ok {
+		return vector.Vector3{}, false
+	}
+	return mean.Add(l.MulVec(GaussianVector(rng, 1))), true
+}
+
+// cholesky returns the lower-triangular L with L Lᵀ = m. Zero pivots, from
+// axes with no variance, leave their column zero.
+func cholesky(m vector.Matrix3) (vector.Matrix3, bool) {
+	var l vector.Matrix3
+	for j := range 3 {
+		d := m[j][j]
+		for k := range j {
+			d -= l[j][k] * l[j][k]
+		}
+		// Tolerate rounding a hair below zero on singular matrices.
+		if d < -1e-12*max(1, m[j][j]) || math.IsNaN(d) {
+			return vector.Matrix3{}, false
+		}
+		if d <= 0 {
+			continue
+		}
+		l[j][j] = math.Sqrt(d)
+		for i := j + 1; i < 3; i++ {
+			s := m[i][j]
+			for k := range j {
+				s -= l[i][k] * l[j][k]
+			}
+			l[i][j] = s / l[j][j]
+		}
+	}
+	return l, true
+}
+
+// UnitVector draws a direction uniformly over the sphere.
+func UnitVector(rng *rand.Rand) vector.Vector3 {
+	for {
+		// A standard normal vector points every way alike.
+		v := GaussianVector(rng, 1)
+		if n := v.Magnitude(); n > 1e-9 {
+			return v.Div(n)
+		}
+	}
+}
+
+// VonMises draws an angle in radians, within π of mu, from the von Mises
+// distribution: the circle's analogue of the normal, concentrated about mu
+// by kappa, roughly 1/σ² for small spreads. Kappa 0 draws uniformly around
+// the circle. It uses the rejection method of Best and Fisher (1979).
+func VonMises(rng *rand.Rand, mu, kappa float64) float64 {
+	if kappa <= 1e-9 {
+		return math.Remainder(mu+Uniform(rng, -math.Pi, math.Pi), 2*math.Pi)
+	}
+	tau := 1 + math.Sqrt(1+4*kappa*kappa)
+	rho := (tau - math.Sqrt(2*tau)) / (2 * kappa)
+	r := (1 + rho*rho) / (2 * rho)
+	for {
+		z := math.Cos(math.Pi * rng.Float64())
+		f := (1 + r*z) / (r + z)
+		c := kappa * (r - f)
+		u := rng.Float64()
+		if c*(2-c) > u || math.Log(c/u)+1 >= c {
+			theta := math.Acos(max(-1, min(1, f)))
+			if rng.Float64() < 0.5 {
+				theta = -theta
+			}
+			return math.Remainder(mu+theta, 2*math.Pi)
+		}
+	}
+}
+
+// GaussMarkov is first-order Gauss-Markov noise: Gaussian with standard
+// deviation Sigma, correlated over time constant Tau seconds, as sensor
+// biases that wander and gusts that persist. The zero value is ready to
+// use and starts at a draw from its steady state.
+type GaussMarkov struct {
+	Sigma float64
+	Tau   float64 // s; 0 gives white noise
+
+	value   float64
+	started bool
+}
+
+// Next advances the noise by dt seconds and returns its new value. The
+// update is exact for any dt, not an Euler step.
+func (g *GaussMarkov) Next(rng *rand.Rand, dt float64) float64 {
+	if !g.started || g.Tau <= 0 {
+		g.value, g.started = rng.NormFloat64()*g.Sigma, true
+		return g.value
+	}
+	phi := math.Exp(-dt / g.Tau)
+	g.value = phi*g.value + g.Sigma*math.Sqrt(1-phi*phi)*rng.NormFloat64()
+	return g.value
+}
+
+// Value returns the noise's current value, 0 before the first Next.
+func (g *GaussMarkov) Value() float64 { return g.value }
+
+// GaussMarkovVector is GaussMarkov noise on each axis independently.
+type GaussMarkovVector struct {
+	X, Y, Z GaussMarkov
+}
+
+// NewGaussMarkovVector returns vector noise with the same sigma and time
+// constant on every axis.
+func NewGaussMarkovVector(sigma, tau float64) *GaussMarkovVector {
+	g := GaussMarkov{Sigma: sigma, Tau: tau}
+	return &GaussMarkovVector{g, g, g}
+}
+
+// Next advances every axis by dt seconds, X first, and returns the noise.
+func (g *GaussMarkovVector) Next(rng *rand.Rand, dt float64) vector.Vector3 {
+	return vector.Vector3{X: g.X.Next(rng, dt), Y: g.Y.Next(rng, dt), Z: g.Z.Next(rng, dt)}
+}
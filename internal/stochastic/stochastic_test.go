@@ -0,0 +1,186 @@
+package stochastic
+
+import (
+	"math"
+	"testing"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+const draws = 200000
+
+// moments returns the mean and variance of n draws of f.
+func moments(n int, f func() float64) (mean, variance float64) {
+	var sum, sq float64
+	for range n {
+		x := f()
+		sum += x
+		sq += x * x
+	}
+	mean = sum / float64(n)
+	return mean, sq/float64(n) - mean*mean
+}
+
+func TestScalars(t *testing.T) {
+	rng := New(1)
+	tests := []struct {
+		name           string
+		f              func() float64
+		mean, variance float64
+		lo, hi         float64
+	}{
+		{"uniform", func() float64 { return Uniform(rng, -2, 6) }, 2, 64.0 / 12, -2, 6},
+		{"gaussian", func() float64 { return Gaussian(rng, 10, 3) }, 10, 9, math.Inf(-1), math.Inf(1)},
+		{"von Mises, flat", func() float64 { return VonMises(rng, 1, 0) }, 0, math.Pi * math.Pi / 3, -math.Pi, math.Pi},
+		{"von Mises, concentrated", func() float64 { return VonMises(rng, 0.5, 400) }, 0.5, 1.0 / 400, -math.Pi, math.Pi},
+	}
+	for _, tt := range tests {
+		lo, hi := math.Inf(1), math.Inf(-1)
+		mean, variance := moments(draws, func() float64 {
+			x := tt.f()
+			lo, hi = min(lo, x), max(hi, x)
+			return x
+		})
+		if math.Abs(mean-tt.mean) > 0.02*max(1, math.Sqrt(tt.variance)) || math.Abs(variance-tt.variance) > 0.02*tt.variance {
+			t.Errorf("%s: mean %.4f, variance %.4f; want %.4f, %.4f", tt.name, mean, variance, tt.mean, tt.variance)
+		}
+		if lo < tt.lo || hi > tt.hi {
+			t.Errorf("%s: draws span [%g, %g], outside [%g, %g]", tt.name, lo, hi, tt.lo, tt.hi)
+		}
+	}
+}
+
+func TestVonMisesResultant(t *testing.T) {
+	// The mean resultant length of von Mises draws is I1(κ)/I0(κ).
+	rng := New(2)
+	tests := []struct {
+		kappa, mu, want float64
+	}{
+		{0.5, 0, 0.242676},
+		{2, 3, 0.697775},
+		{10, -2, 0.948853},
+	}
+	for _, tt := range tests {
+		var c, s float64
+		for range draws {
+			sin, cos := math.Sincos(VonMises(rng, tt.mu, tt.kappa))
+			c, s = c+cos, s+sin
+		}
+		r := math.Hypot(c, s) / draws
+		if mu := math.Atan2(s, c); math.Abs(r-tt.want) > 0.005 || math.Abs(math.Remainder(mu-tt.mu, 2*math.Pi)) > 0.03 {
+			t.Errorf("κ %g: resultant %.4f about %.3f, want %.4f about %g", tt.kappa, r, mu, tt.want, tt.mu)
+		}
+	}
+}
+
+func TestUnitVector(t *testing.T) {
+	rng := New(3)
+	var sum vector.Vector3
+	var up int
+	for range draws {
+		v := UnitVector(rng)
+		if math.Abs(v.Magnitude()-1) > 1e-12 {
+			t.Fatalf("%v is not a unit vector", v)
+		}
+		sum = sum.Add(v)
+		if v.Y > 0.5 {
+			up++
+		}
+	}
+	// Uniform over the sphere: no mean direction, and a quarter of the
+	// area above 30° elevation.
+	if sum.Magnitude()/draws > 0.01 || math.Abs(float64(up)/draws-0.25) > 0.005 {
+		t.Errorf("mean %v, %d of %d above y = 0.5", sum.Div(draws), up, draws)
+	}
+}
+
+func TestCorrelatedVector(t *testing.T) {
+	rng := New(4)
+	mean := vector.Vector3{X: 1, Y: -2, Z: 3}
+	cov := vector.Matrix3{{4, 1.2, 0}, {1.2, 1, -0.3}, {0, -0.3, 0.25}}
+	var sum vector.Vector3
+	var sq vector.Matrix3
+	for range draws {
+		v, ok := CorrelatedVector(rng, mean, cov)
+		if !ok {
+			t.Fatal("covariance rejected")
+		}
+		d := v.Sub(mean)
+		sum = sum.Add(d)
+		sq = sq.Add(vector.Outer(d, d))
+	}
+	got := sq.Scale(1.0 / draws)
+	for i := range 3 {
+		for j := range 3 {
+			if math.Abs(got[i][j]-cov[i][j]) > 0.03 {
+				t.Errorf("covariance %v, want %v", got, cov)
+				return
+			}
+		}
+	}
+	if sum.Magnitude()/draws > 0.01 {
+		t.Errorf("mean off by %v", sum.Div(draws))
+	}
+
+	tests := []struct {
+		name string
+		cov  vector.Matrix3
+		ok   bool
+	}{
+		{"zero", vector.Matrix3{}, true},
+		{"singular", vector.Outer(vector.Vector3{X: 1, Y: 2}, vector.Vector3{X: 1, Y: 2}), true},
+		{"negative", vector.Diagonal(vector.Vector3{X: 1, Y: -1, Z: 1}), false},
+		{"indefinite", vector.Matrix3{{1, 2, 0}, {2, 1, 0}, {0, 0, 1}}, false},
+	}
+	for _, tt := range tests {
+		v, ok := CorrelatedVector(rng, mean, tt.cov)
+		if ok != tt.ok || (ok && !v.IsFinite()) {
+			t.Errorf("%s: drew %v, ok %v", tt.name, v, ok)
+		}
+	}
+	if v, _ := CorrelatedVector(rng, mean, vector.Matrix3{}); v != mean {
+		t.Errorf("zero covariance drew %v", v)
+	}
+}
+
+func TestGaussMarkov(t *testing.T) {
+	rng := New(5)
+	tests := []struct {
+		name           string
+		sigma, tau, dt float64
+		correlation    float64
+	}{
+		{"slow", 2, 10, 0.1, math.Exp(-0.01)},
+		{"fast", 0.5, 0.05, 0.1, math.Exp(-2)},
+		{"white", 1, 0, 0.1, 0},
+	}
+	for _, tt := range tests {
+		g := GaussMarkov{Sigma: tt.sigma, Tau: tt.tau}
+		prev := g.Next(rng, tt.dt)
+		var sum, sq, lag float64
+		for range draws {
+			x := g.Next(rng, tt.dt)
+			sum, sq, lag = sum+x, sq+x*x, lag+x*prev
+			prev = x
+		}
+		variance := sq / draws
+		// The slow process spans only 2000 time constants, so its sample
+		// variance settles less tightly than white noise's.
+		if math.Abs(variance/(tt.sigma*tt.sigma)-1) > 0.06 {
+			t.Errorf("%s: variance %.4f, want %.4f", tt.name, variance, tt.sigma*tt.sigma)
+		}
+		if c := lag / sq; math.Abs(c-tt.correlation) > 0.02 {
+			t.Errorf("%s: lag-one correlation %.4f, want %.4f", tt.name, c, tt.correlation)
+		}
+		if g.Value() != prev {
+			t.Errorf("%s: value %g, last draw %g", tt.name, g.Value(), prev)
+		}
+	}
+
+	v := NewGaussMarkovVector(1, 5)
+	a := v.Next(New(6), 0.1)
+	b := NewGaussMarkovVector(1, 5).Next(New(6), 0.1)
+	if a != b || a.X == a.Y {
+		t.Errorf("vector noise %v and %v from one seed", a, b)
+	}
+}
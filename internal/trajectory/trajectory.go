@@ -0,0 +1,170 @@
+// Package trajectory interpolates sampled trajectories between their
+// samples, to resample recordings to any rate and to find closest approach
+// more finely than the simulation step.
+//
+// Positions follow the cubic Hermite spline through each sample's position
+// and velocity, so interpolated velocities are continuous and agree with
+// the samples. Accelerations are interpolated linearly: the spline's own
+// second derivative jumps at every sample.
+package trajectory
+
+import (
+	"math"
+	"sort"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Sample is one point on a trajectory.
+type Sample struct {
+	T            float64 // s
+	Position     vector.Vector3
+	Velocity     vector.Vector3
+	Acceleration vector.Vector3
+}
+
+// Track is a trajectory sampled at strictly increasing times.
+type Track []Sample
+
+// Start returns the time of the first sample.
+func (tr Track) Start() float64 { return tr[0].T }
+
+// End returns the time of the last sample.
+func (tr Track) End() float64 { return tr[len(tr)-1].T }
+
+// At returns the track at time t, or false if t is outside the track.
+func (tr Track) At(t float64) (Sample, bool) {
+	if len(tr) == 0 || t < tr.Start() || t > tr.End() {
+		return Sample{}, false
+	}
+	i := sort.Search(len(tr), func(i int) bool { return tr[i].T >= t })
+	if tr[i].T == t {
+		return tr[i], true
+	}
+	return hermite(tr[i-1], tr[i], t), true
+}
+
+// hermite interpolates between a and b at a.T < t < b.T.
+func hermite(a, b Sample, t float64) Sample {
+	h := b.T - a.T
+	s := (t - a.T) / h
+	s2, s3 := s*s, s*s*s
+	// The Hermite basis and its derivatives.
+	h00, h10, h01, h11 := 2*s3-3*s2+1, s3-2*s2+s, -2*s3+3*s2, s3-s2
+	d00, d10, d01, d11 := 6*s2-6*s, 3*s2-4*s+1, -6*s2+6*s, 3*s2-2*s
+	return Sample{
+		T: t,
+		Position: a.Position.Mul(h00).Add(a.Velocity.Mul(h10 * h)).
+			Add(b.Position.Mul(h01)).Add(b.Velocity.Mul(h11 * h)),
+		Velocity: a.Position.Mul(d00 / h).Add(a.Velocity.Mul(d10)).
+			Add(b.Position.Mul(d01 / h)).Add(b.Velocity.Mul(d11)),
+		Acceleration: a.Acceleration.Lerp(b.Acceleration, s),
+	}
+}
+
+// Times returns the times from start to end, inclusive, dt apart, counted
+// from start so they do not drift.
+func Times(start, end, dt float64) []float64 {
+	if dt <= 0 || end < start {
+		return nil
+	}
+	n := int(math.Floor((end-start)/dt+1e-9)) + 1
+	times := make([]float64, n)
+	for i := range times {
+		times[i] = start + float64(i)*dt
+	}
+	return times
+}
+
+// Resample returns the track every dt seconds from its first sample.
+func (tr Track) Resample(dt float64) Track {
+	if len(tr) == 0 {
+		return nil
+	}
+	times := Times(tr.Start(), tr.End(), dt)
+	out := make(Track, 0, len(times))
+	for _, t := range times {
+		s, _ := tr.At(t)
+		out = append(out, s)
+	}
+	return out
+}
+
+// closestIterations of golden-section search narrow each bracket to under
+// a millionth of a step.
+const closestIterations = 40
+
+// ClosestApproach returns the time and distance at which a and b come
+// closest while both exist, or false if they never overlap in time.
+func ClosestApproach(a, b Track) (t, dist float64, ok bool) {
+	if len(a) == 0 || len(b) == 0 {
+		return 0, 0, false
+	}
+	start, end := max(a.Start(), b.Start()), min(a.End(), b.End())
+	if start > end {
+		return 0, 0, false
+	}
+	gap := func(t float64) float64 {
+		sa, _ := a.At(t)
+		sb, _ := b.At(t)
+		return sa.Position.Distance(sb.Position)
+	}
+	// Both tracks are cubic between consecutive sample times of either, so
+	// within each such interval the distance has at most a few minima;
+	// bracket the lowest on a coarse grid, then refine it.
+	knots := []float64{start}
+	for _, tr := range []Track{a, b} {
+		for _, s := range tr {
+			if s.T > start && s.T < end {
+				knots = append(knots, s.T)
+			}
+		}
+	}
+	knots = append(knots, end)
+	sort.Float64s(knots)
+	t, dist = start, gap(start)
+	for i := 1; i < len(knots); i++ {
+		lo, hi := knots[i-1], knots[i]
+		if hi == lo {
+			continue
+		}
+		const grid = 4
+		best, bestT := math.Inf(1), lo
+		for j := 0; j <= grid; j++ {
+			tt := lo + (hi-lo)*float64(j)/grid
+			if d := gap(tt); d < best {
+				best, bestT = d, tt
+			}
+		}
+		step := (hi - lo) / grid
+		if tt, d := goldenMin(gap, max(lo, bestT-step), min(hi, bestT+step)); d < best {
+			best, bestT = d, tt
+		}
+		if best < dist {
+			t, dist = bestT, best
+		}
+	}
+	return t, dist, true
+}
+
+// goldenMin returns the minimum of f on [lo, hi], assuming one there.
+func goldenMin(f func(float64) float64, lo, hi float64) (float64, float64) {
+	const r = 0.6180339887498949 // (√5 - 1) / 2
+	x1, x2 := hi-r*(hi-lo), lo+r*(hi-lo)
+	f1, f2 := f(x1), f(x2)
+	for range closestIterations {
+		if f1 < f2 {
+			hi, x2, f2 = x2, x1, f1
+			x1 = hi - r*(hi-lo)
+			f1 = f(x1)
+		} else {
+			lo, x1, f1 = x1, x2, f2
+			x2 = lo + r*(hi-lo)
+			f2 = f(x2)
+		}
+	}
+	if f1 < f2 {
+		return x1, f1
+	}
+	return x2, f2
+}
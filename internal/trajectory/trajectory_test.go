@@ -0,0 +1,111 @@
+package trajectory
+
+import (
+	"math"
+	"testing"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// ballistic samples a body thrown from p with velocity v under gravity g
+// every dt seconds up to end.
+func ballistic(p, v, g vector.Vector3, dt, end float64) Track {
+	var tr Track
+	for _, t := range Times(0, end, dt) {
+		tr = append(tr, Sample{
+			T:            t,
+			Position:     p.Add(v.Mul(t)).Add(g.Mul(t * t / 2)),
+			Velocity:     v.Add(g.Mul(t)),
+			Acceleration: g,
+		})
+	}
+	return tr
+}
+
+func TestAt(t *testing.T) {
+	// A cubic spline through positions and velocities is exact for any
+	// trajectory of degree three or less, however coarse the samples.
+	g := vector.Vector3{Y: -9.81}
+	p, v := vector.Vector3{X: 10, Y: 1000}, vector.Vector3{X: 300, Y: 50, Z: -20}
+	tr := ballistic(p, v, g, 2, 20)
+	tests := []struct {
+		t  float64
+		ok bool
+	}{
+		{0, true}, {0.3, true}, {7.77, true}, {10, true}, {19.999, true}, {20, true},
+		{-0.1, false}, {20.1, false},
+	}
+	for _, tt := range tests {
+		s, ok := tr.At(tt.t)
+		if ok != tt.ok {
+			t.Errorf("at %g: ok %v", tt.t, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		wantP := p.Add(v.Mul(tt.t)).Add(g.Mul(tt.t * tt.t / 2))
+		wantV := v.Add(g.Mul(tt.t))
+		if s.Position.Distance(wantP) > 1e-9 || s.Velocity.Distance(wantV) > 1e-9 || s.Acceleration != g {
+			t.Errorf("at %g: %+v, want position %v velocity %v", tt.t, s, wantP, wantV)
+		}
+	}
+	if _, ok := Track(nil).At(0); ok {
+		t.Error("empty track has a sample")
+	}
+}
+
+func TestResample(t *testing.T) {
+	tr := ballistic(vector.Vector3{}, vector.Vector3{X: 100}, vector.Vector3{}, 0.1, 3)
+	tests := []struct {
+		dt   float64
+		n    int
+		last float64
+	}{
+		{1, 4, 3},
+		{0.25, 13, 3},
+		{0.7, 5, 2.8},
+		{0.01, 301, 3},
+		{5, 1, 0},
+	}
+	for _, tt := range tests {
+		out := tr.Resample(tt.dt)
+		if len(out) != tt.n || math.Abs(out.End()-tt.last) > 1e-9 {
+			t.Errorf("every %g s: %d samples ending at %g, want %d ending at %g", tt.dt, len(out), out.End(), tt.n, tt.last)
+			continue
+		}
+		if x := out[len(out)-1].Position.X; math.Abs(x-100*tt.last) > 1e-9 {
+			t.Errorf("every %g s: ends at x %g", tt.dt, x)
+		}
+	}
+	if Times(0, 1, 0) != nil || Times(1, 0, 0.1) != nil {
+		t.Error("Times accepts an empty range")
+	}
+}
+
+func TestClosestApproach(t *testing.T) {
+	// Two bodies crossing at right angles, sampled far more coarsely than
+	// their closest approach: a at the origin heading east at 100 m/s, b
+	// 1000 m north and 5 m up heading south at 100 m/s. They pass closest
+	// at t = 5, 5 m apart, between the samples at 4 and 6.
+	a := ballistic(vector.Vector3{X: -500}, vector.Vector3{X: 100}, vector.Vector3{}, 2, 10)
+	b := ballistic(vector.Vector3{Y: 5, Z: 500}, vector.Vector3{Z: -100}, vector.Vector3{}, 2, 10)
+	tests := []struct {
+		name    string
+		a, b    Track
+		t, dist float64
+		ok      bool
+	}{
+		{"crossing", a, b, 5, 5, true},
+		{"offset samples", a, ballistic(vector.Vector3{Y: 5, Z: 500}, vector.Vector3{Z: -100}, vector.Vector3{}, 0.7, 10), 5, 5, true},
+		{"receding", a[3:], b[3:], 6, math.Hypot(math.Hypot(100, 100), 5), true},
+		{"disjoint", a[:2], b[3:], 0, 0, false},
+		{"empty", nil, b, 0, 0, false},
+	}
+	for _, tt := range tests {
+		tc, dist, ok := ClosestApproach(tt.a, tt.b)
+		if ok != tt.ok || math.Abs(tc-tt.t) > 1e-6 || math.Abs(dist-tt.dist) > 1e-6 {
+			t.Errorf("%s: %g m at %g s (%v), want %g m at %g s", tt.name, dist, tc, ok, tt.dist, tt.t)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Package units converts between the SI units the simulator computes in and
+// the units pilots, simulators and frontends expect, and names units for
+// the telemetry schemas that carry them.
+package units
+
+import "math"
+
+// Unit names the unit of a telemetry field, as written in schemas.
+type Unit string
+
+// Units telemetry is reported in.
+const (
+	None             Unit = ""
+	Metre            Unit = "m"
+	Second           Unit = "s"
+	Kilogram         Unit = "kg"
+	MetresPerSecond  Unit = "m/s"
+	MetresPerSecond2 Unit = "m/s^2"
+	Degree           Unit = "deg"
+	DegreesPerSecond Unit = "deg/s"
+	Radian           Unit = "rad"
+	RadiansPerSecond Unit = "rad/s"
+	StandardGravity  Unit = "g"
+	Decibel          Unit = "dB"
+	Knot             Unit = "kn"
+	Mach             Unit = "Mach"
+)
+
+// G is the acceleration of gravity the simulation flies in, m/s², and the
+// size of one g of load.
+const G = 9.81
+
+// Length and speed conversions.
+const (
+	FeetPerMetre = 3.28083989501312
+	KnotsPerMS   = 1.94384449244060 // knots per m/s
+)
+
+// Degrees converts radians to degrees.
+func Degrees(rad float64) float64 { return rad * 180 / math.Pi }
+
+// Radians converts degrees to radians.
+func Radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+// ToG converts an acceleration in m/s² to g.
+func ToG(a float64) float64 { return a / G }
+
+// FromG converts an acceleration in g to m/s².
+func FromG(g float64) float64 { return g * G }
+
+// ToKnots converts a speed in m/s to knots.
+func ToKnots(v float64) float64 { return v * KnotsPerMS }
+
+// FromKnots converts a speed in knots to m/s.
+func FromKnots(kn float64) float64 { return kn / KnotsPerMS }
+
+// The 1976 US Standard Atmosphere, up to 32 km.
+const (
+	seaLevelTemperature = 288.15    // K
+	lapseRate           = 0.0065    // K/m, troposphere
+	tropopause          = 11000.0   // m
+	stratosphere        = 20000.0   // m, where temperature starts rising again
+	stratosphereLapse   = -0.001    // K/m above 20 km
+	tropopauseTemp      = 216.65    // K
+	airGamma            = 1.4       // ratio of specific heats
+	airGasConstant      = 287.05287 // J/(kg K)
+	maxAtmosphere       = 32000.0   // m, the top of the model
+)
+
+// SpeedOfSound returns the speed of sound in m/s at alt metres in the
+// standard atmosphere: 340.3 at sea level, 295.1 from 11 to 20 km. The
+// altitude is taken as geopotential, within 0.2% of geometric below 32 km;
+// altitudes outside 0 to 32 km are held to those limits.
+func SpeedOfSound(alt float64) float64 {
+	alt = min(max(alt, 0), maxAtmosphere)
+	t := tropopauseTemp
+	switch {
+	case alt < tropopause:
+		t = seaLevelTemperature - lapseRate*alt
+	case alt > stratosphere:
+		t = tropopauseTemp - stratosphereLapse*(alt-stratosphere)
+	}
+	return math.Sqrt(airGamma * airGasConstant * t)
+}
+
+// ToMach converts a speed in m/s at alt metres to a Mach number.
+func ToMach(v, alt float64) float64 { return v / SpeedOfSound(alt) }
+
+// FromMach converts a Mach number at alt metres to a speed in m/s.
+func FromMach(mach, alt float64) float64 { return mach * SpeedOfSound(alt) }
@@ -0,0 +1,51 @@
+package units
+
+import (
+	"math"
+	"testing"
+)
+
+func TestConversions(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want float64
+	}{
+		{"half turn in degrees", Degrees(math.Pi), 180},
+		{"right angle in radians", Radians(90), math.Pi / 2},
+		{"9 g", FromG(9), 88.29},
+		{"one g", ToG(G), 1},
+		{"100 m/s in knots", ToKnots(100), 194.38444924406},
+		{"one knot", FromKnots(1), 1852.0 / 3600},
+		{"a mile in feet", 1609.344 * FeetPerMetre, 5280},
+	}
+	for _, tt := range tests {
+		if math.Abs(tt.got-tt.want) > 1e-9 {
+			t.Errorf("%s: %.12g, want %.12g", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestSpeedOfSound(t *testing.T) {
+	// Reference values from the ICAO standard atmosphere tables, by
+	// geopotential altitude.
+	tests := []struct {
+		alt, want float64
+	}{
+		{0, 340.294},
+		{5000, 320.529},
+		{11000, 295.070},
+		{15000, 295.070},
+		{25000, 298.455},
+		{32000, 303.131},
+		{-500, 340.294},
+		{50000, 303.131},
+	}
+	for _, tt := range tests {
+		if got := SpeedOfSound(tt.alt); math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("at %g m: %.3f m/s, want %.3f", tt.alt, got, tt.want)
+		}
+	}
+	if got := ToMach(FromMach(1.8, 5000), 5000); math.Abs(got-1.8) > 1e-12 {
+		t.Errorf("Mach 1.8 came back as %g", got)
+	}
+}
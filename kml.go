@@ -0,0 +1,61 @@
+package simulation
+
+import (
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"missile-intercept-sim/internal/geo"
+)
+
+// WriteKML writes a recording as KML for Google Earth, with the local frame
+// placed at origin. Each entity becomes a time-stamped gx:Track, which
+// Google Earth's time slider plays back, and each event a placemark.
+func WriteKML(w io.Writer, rec *Recording, origin geo.Origin) error {
+	out := bufio.NewWriter(w)
+	stamp := rec.timestamp
+	fmt.Fprint(out, xml.Header)
+	fmt.Fprintln(out, `<kml xmlns="http://www.opengis.net/kml/2.2" xmlns:gx="http://www.google.com/kml/ext/2.2">`)
+	fmt.Fprintf(out, "<Document>\n<name>%s</name>\n", kmlText(globeTitle(rec)))
+	all := tracks(rec)
+	for _, tr := range all {
+		c := globeColor(tr.Type)
+		// KML colors are aabbggrr.
+		fmt.Fprintf(out, "<Placemark>\n<name>%s</name>\n<description>%s</description>\n", kmlText(tr.ID), kmlText(tr.Type))
+		fmt.Fprintf(out, "<Style><LineStyle><color>%02x%02x%02x%02x</color><width>2</width></LineStyle></Style>\n", c[3], c[2], c[1], c[0])
+		fmt.Fprintln(out, "<gx:Track>\n<altitudeMode>absolute</altitudeMode>")
+		for _, t := range tr.Times {
+			fmt.Fprintf(out, "<when>%s</when>\n", stamp(t))
+		}
+		for _, p := range tr.Points {
+			lat, lon, alt := geodetic(origin, p)
+			fmt.Fprintf(out, "<gx:coord>%.7f %.7f %.1f</gx:coord>\n", lon, lat, alt)
+		}
+		fmt.Fprintln(out, "</gx:Track>\n</Placemark>")
+	}
+	byID := make(map[string]*track, len(all))
+	for _, tr := range all {
+		byID[tr.ID] = tr
+	}
+	for _, ev := range rec.Events {
+		tr, ok := byID[ev.EntityID]
+		if !ok {
+			continue
+		}
+		lat, lon, alt := geodetic(origin, tr.at(ev.Time))
+		fmt.Fprintf(out, "<Placemark>\n<name>%s</name>\n<description>%s</description>\n", kmlText(ev.Type), kmlText(ev.Message))
+		fmt.Fprintf(out, "<TimeStamp><when>%s</when></TimeStamp>\n", stamp(ev.Time))
+		fmt.Fprintf(out, "<Point><altitudeMode>absolute</altitudeMode><coordinates>%.7f,%.7f,%.1f</coordinates></Point>\n</Placemark>\n", lon, lat, alt)
+	}
+	fmt.Fprintln(out, "</Document>\n</kml>")
+	return out.Flush()
+}
+
+// kmlText escapes s for use as XML character data.
+func kmlText(s string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
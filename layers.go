@@ -0,0 +1,51 @@
+package simulation
+
+import (
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// tierLocked returns the doctrine tier that should engage th at pos, or nil
+// when no tier can fire at it yet. A tier that has fired all its shots at a
+// threat hands it to the next; tiers a threat has been handed past are
+// never revisited. Callers must hold s.mu.
+func (s *Simulator) tierLocked(th *Threat, pos vector.Vector3) *scenario.Tier {
+	tiers := s.Doctrine.Tiers
+	for i := th.Layer; i < len(tiers); i++ {
+		t := &tiers[i]
+		if i == th.Layer && th.LayerShots >= tierShots(t) {
+			s.handoffLocked(th, i+1)
+			continue
+		}
+		ic := s.nearestReadyLocked(pos, t.Name, t.MinRange, t.MaxRange)
+		if ic == nil || !t.Contains(pos, ic.Missile.Position.Distance(pos)) {
+			continue
+		}
+		if i > th.Layer {
+			s.handoffLocked(th, i)
+		}
+		return t
+	}
+	return nil
+}
+
+// tierShots is the number of salvos a tier fires at one threat.
+func tierShots(t *scenario.Tier) int {
+	if t.Shots == 0 {
+		return 1
+	}
+	return t.Shots
+}
+
+// handoffLocked passes th to tier next, or marks it as through every layer
+// when next is past the last tier. Callers must hold s.mu.
+func (s *Simulator) handoffLocked(th *Threat, next int) {
+	tiers := s.Doctrine.Tiers
+	from := tiers[th.Layer].Name
+	th.Layer, th.LayerShots = next, 0
+	if next < len(tiers) {
+		s.logEventLocked(EventHandoff, th.Entity.ID, "Handed from "+from+" to "+tiers[next].Name+" tier")
+	} else {
+		s.logEventLocked(EventHandoff, th.Entity.ID, "Leaked through the "+from+" tier, no layers left")
+	}
+}
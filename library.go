@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// scenariosDir is where uploaded scenarios are stored.
+var scenariosDir = "scenarios"
+
+// writeScenarioError replies to a rejected scenario document, listing each
+// problem and where it is.
+func writeScenarioError(w http.ResponseWriter, err error) {
+	var invalid scenario.ValidationError
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &invalid):
+		writeErrorDetails(w, "Invalid scenario", http.StatusUnprocessableEntity, invalid)
+	case errors.As(err, &tooLarge):
+		writeError(w, "Scenario too large", http.StatusRequestEntityTooLarge)
+	default:
+		writeError(w, err.Error(), http.StatusBadRequest)
+	}
+}
+
+// loadScenario resolves a scenario name: a built-in, or one from the library.
+func loadScenario(name string) (*scenario.Scenario, bool) {
+	if sc, ok := scenario.Builtin(name); ok {
+		return sc, true
+	}
+	sc, err := scenario.Load(scenariosDir, name)
+	return sc, err == nil
+}
+
+// handleLibrary manages stored scenarios by ?name=: GET lists them, or with
+// a name downloads one; PUT uploads a validated document under the name;
+// DELETE removes it.
+func handleLibrary(w http.ResponseWriter, r *http.Request) {
+	name := r.URL.Query().Get("name")
+	if r.Method != http.MethodGet || name != "" {
+		if err := scenario.CheckName(name); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	switch r.Method {
+	case http.MethodGet:
+		if name == "" {
+			list, err := scenario.List(scenariosDir)
+			if err != nil {
+				writeError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(list)
+			return
+		}
+		data, err := scenario.Raw(scenariosDir, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			writeError(w, "Unknown scenario", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", `attachment; filename="`+name+`.json"`)
+		w.Write(data)
+	case http.MethodPut:
+		if _, ok := scenario.Builtin(name); ok {
+			writeError(w, "A built-in scenario has that name", http.StatusConflict)
+			return
+		}
+		sc, err := scenario.Decode(r.Body)
+		if err != nil {
+			writeScenarioError(w, err)
+			return
+		}
+		if err := simulation.CheckScenarioGuidance(sc); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		if err := scenario.Save(scenariosDir, name, sc); err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		w.Write([]byte("Scenario stored"))
+	case http.MethodDelete:
+		err := scenario.Remove(scenariosDir, name)
+		if errors.Is(err, fs.ErrNotExist) {
+			writeError(w, "Unknown scenario", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Scenario deleted"))
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleValidateScenario checks a scenario document without loading or
+// storing it: errors that stop it loading fail the request, and warnings
+// about its physics come back with a valid one.
+func handleValidateScenario(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	diags, err := simulation.ValidateScenario(r.Body)
+	if err != nil {
+		writeScenarioError(w, err)
+		return
+	}
+	if simulation.HasErrors(diags) {
+		writeErrorDetails(w, "Invalid scenario", http.StatusUnprocessableEntity, diags)
+		return
+	}
+	type ValidateResponse struct {
+		Valid       bool                    `json:"valid"`
+		Diagnostics []simulation.Diagnostic `json:"diagnostics"` // warnings only
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ValidateResponse{Valid: true, Diagnostics: diags})
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+func TestLibrary(t *testing.T) {
+	saved := scenariosDir
+	defer func() { scenariosDir = saved }()
+	scenariosDir = t.TempDir()
+
+	doc, err := json.Marshal(scenario.HeadOn())
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"upload", http.MethodPut, "/api/v1/library?name=team-a", string(doc), http.StatusCreated},
+		{"invalid document", http.MethodPut, "/api/v1/library?name=team-b", `{"name": ""}`, http.StatusUnprocessableEntity},
+		{"bad name", http.MethodPut, "/api/v1/library?name=../x", string(doc), http.StatusBadRequest},
+		{"builtin name", http.MethodPut, "/api/v1/library?name=default", string(doc), http.StatusConflict},
+		{"download", http.MethodGet, "/api/v1/library?name=team-a", "", http.StatusOK},
+		{"missing", http.MethodGet, "/api/v1/library?name=team-b", "", http.StatusNotFound},
+		{"validate only", http.MethodPost, "/api/v1/scenarios/validate", string(doc), http.StatusOK},
+		{"validate singular", http.MethodPost, "/api/v1/scenario/validate", string(doc), http.StatusOK},
+		{"validate invalid", http.MethodPost, "/api/v1/scenario/validate", `{"name": ""}`, http.StatusUnprocessableEntity},
+		{"delete", http.MethodDelete, "/api/v1/library?name=team-a", "", http.StatusOK},
+		{"deleted", http.MethodGet, "/api/v1/library?name=team-a", "", http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := handleLibrary
+			if strings.Contains(tt.target, "validate") {
+				h = handleValidateScenario
+			}
+			rec := httptest.NewRecorder()
+			h(rec, httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body)))
+			if rec.Code != tt.want {
+				t.Fatalf("got %d %s, want %d", rec.Code, rec.Body, tt.want)
+			}
+			if tt.name == "download" {
+				got, err := scenario.Decode(rec.Body)
+				if err != nil || got.Name != scenario.HeadOn().Name {
+					t.Errorf("downloaded %v, %v", got, err)
+				}
+			}
+		})
+	}
+}
+
+func TestInvalidScenarioDetails(t *testing.T) {
+	rec := httptest.NewRecorder()
+	body := `{"name": "x", "entities": [{"id": "T1", "role": "target"}]}`
+	handleValidateScenario(rec, httptest.NewRequest(http.MethodPost, "/api/v1/scenarios/validate", strings.NewReader(body)))
+	var got struct {
+		Code    string                `json:"code"`
+		Details []scenario.FieldError `json:"details"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Code != "validation_failed" || len(got.Details) != 1 || got.Details[0].Path != "entities" {
+		t.Errorf("got %+v", got)
+	}
+}
+
+func TestLoadStoredScenario(t *testing.T) {
+	saved := scenariosDir
+	defer func() { scenariosDir = saved }()
+	scenariosDir = t.TempDir()
+	sc := scenario.Crossing()
+	sc.Name = "stored crossing"
+	if err := scenario.Save(scenariosDir, "crossing-2", sc); err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := loadScenario("crossing-2"); !ok || got.Name != sc.Name {
+		t.Errorf("loadScenario = %v, %v", got, ok)
+	}
+	if _, ok := loadScenario("nope"); ok {
+		t.Error("loaded a scenario that was never stored")
+	}
+}
@@ -0,0 +1,83 @@
+package simulation
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Lifecycle is the stage a run has reached:
+//
+//	Idle ──start──▶ Running ──stop──▶ Paused ──start──▶ Running
+//	  └───step───────────────────────▶ Paused
+//	Running or Paused ──the run ends──▶ Finished
+//
+// Reset, LoadScenario, Rerun and Restore begin again from Idle (Restore
+// from Paused or Finished, as the snapshot was). Asking for the stage the
+// run is already in does nothing; asking for one it cannot reach from
+// there fails with a *TransitionError.
+type Lifecycle string
+
+// Lifecycle stages.
+const (
+	LifecycleIdle     Lifecycle = "Idle"     // reset and not yet stepped
+	LifecycleRunning  Lifecycle = "Running"  // the loop, or RunToCompletion, is stepping it
+	LifecyclePaused   Lifecycle = "Paused"   // stepped part way, then stopped
+	LifecycleFinished Lifecycle = "Finished" // ended; reset it to fly again
+)
+
+// lifecycleMoves lists the stages each stage may move on to.
+var lifecycleMoves = map[Lifecycle][]Lifecycle{
+	LifecycleIdle:     {LifecycleRunning, LifecyclePaused},
+	LifecycleRunning:  {LifecyclePaused, LifecycleFinished},
+	LifecyclePaused:   {LifecycleRunning, LifecycleFinished},
+	LifecycleFinished: nil,
+}
+
+// TransitionError is an operation the run's lifecycle stage does not
+// allow, such as starting a finished run or stepping a running one.
+type TransitionError struct {
+	Op   string    // start, step or run
+	From Lifecycle // the stage the run was in
+}
+
+func (e *TransitionError) Error() string {
+	switch e.From {
+	case LifecycleRunning:
+		return fmt.Sprintf("cannot %s: simulation is running; stop it first", e.Op)
+	case LifecycleFinished:
+		return fmt.Sprintf("cannot %s: the run has finished; reset it first", e.Op)
+	}
+	return fmt.Sprintf("cannot %s: simulation is %s", e.Op, strings.ToLower(string(e.From)))
+}
+
+// transitionLocked moves the run to stage to on behalf of op, keeping the
+// status in step. Callers must hold s.mu.
+func (s *Simulator) transitionLocked(op string, to Lifecycle) error {
+	from := s.State.Lifecycle
+	if from == to {
+		return nil
+	}
+	if !slices.Contains(lifecycleMoves[from], to) {
+		return &TransitionError{Op: op, From: from}
+	}
+	s.State.Lifecycle = to
+	switch to {
+	case LifecycleRunning:
+		s.setStatusLocked("Running")
+	case LifecyclePaused:
+		s.setStatusLocked("Stopped")
+	}
+	return nil
+}
+
+// steppableLocked reports, as a *TransitionError, why the run cannot be
+// stepped by hand: the loop is running it or it has finished. Callers must
+// hold s.mu.
+func (s *Simulator) steppableLocked(op string) error {
+	switch s.State.Lifecycle {
+	case LifecycleRunning, LifecycleFinished:
+		return &TransitionError{Op: op, From: s.State.Lifecycle}
+	}
+	return nil
+}
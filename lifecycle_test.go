@@ -0,0 +1,106 @@
+package simulation
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestLifecycle(t *testing.T) {
+	type op struct {
+		name    string
+		do      func(s *Simulator) error
+		want    Lifecycle
+		wantErr bool // a *TransitionError
+	}
+	start := func(s *Simulator) error { return s.Start() }
+	stop := func(s *Simulator) error { s.Stop(); return nil }
+	reset := func(s *Simulator) error { s.Reset(); return nil }
+	step := func(s *Simulator) error { _, err := s.Advance(1); return err }
+	finish := func(s *Simulator) error { s.RunToCompletion(DefaultMaxTime); return nil }
+
+	tests := []struct {
+		name string
+		ops  []op
+	}{
+		{"start and stop", []op{
+			{"start", start, LifecycleRunning, false},
+			{"start again", start, LifecycleRunning, false},
+			{"step while running", step, LifecycleRunning, true},
+			{"stop", stop, LifecyclePaused, false},
+			{"stop again", stop, LifecyclePaused, false},
+			{"resume", start, LifecycleRunning, false},
+		}},
+		{"step from idle", []op{
+			{"stop idle", stop, LifecycleIdle, false},
+			{"step", step, LifecyclePaused, false},
+			{"start", start, LifecycleRunning, false},
+		}},
+		{"finished", []op{
+			{"run out", finish, LifecycleFinished, false},
+			{"start", start, LifecycleFinished, true},
+			{"step", step, LifecycleFinished, true},
+			{"stop", stop, LifecycleFinished, false},
+			{"reset", reset, LifecycleIdle, false},
+			{"start after reset", start, LifecycleRunning, false},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSimulator()
+			s.SetLogger(logging.Discard)
+			s.Seed = 1
+			s.TimeScale = 0.1 // slow enough that a started run is still running
+			s.Reset()
+			defer s.Stop()
+			for _, o := range tt.ops {
+				err := o.do(s)
+				var te *TransitionError
+				if errors.As(err, &te) != o.wantErr || (err != nil && !o.wantErr) {
+					t.Fatalf("%s: err %v, want transition error %v", o.name, err, o.wantErr)
+				}
+				if got := s.GetState().Lifecycle; got != o.want {
+					t.Fatalf("%s: %s, want %s", o.name, got, o.want)
+				}
+			}
+		})
+	}
+}
+
+// TestLifecycleNoLeaks hammers the lifecycle from several goroutines and
+// checks that a stopped simulator is left with no loop, and takes no step.
+func TestLifecycleNoLeaks(t *testing.T) {
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	s.TimeScale = TimeScaleAFAP
+	var wg sync.WaitGroup
+	for g := range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 50 {
+				switch (g + i) % 4 {
+				case 0, 1:
+					s.Start()
+				case 2:
+					s.Stop()
+				case 3:
+					s.Reset()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	s.Stop()
+	if n := s.loops.Load(); n != 0 {
+		t.Fatalf("%d loops still running after Stop", n)
+	}
+	before := s.GetState().Time
+	time.Sleep(5 * loopInterval)
+	if after := s.GetState().Time; after != before {
+		t.Errorf("clock moved from %g to %g after Stop", before, after)
+	}
+}
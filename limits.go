@@ -0,0 +1,93 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// defaultMaxBody bounds request bodies on routes without a limit of their own.
+const defaultMaxBody = 64 << 10
+
+// idleBucket is how long a client's bucket is kept without requests.
+const idleBucket = 10 * time.Minute
+
+// rateLimiter is a token bucket per client address. Each client may make
+// burst requests at once and rate requests per second sustained.
+type rateLimiter struct {
+	rate, burst float64
+
+	mu        sync.Mutex
+	clients   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate, burst float64) *rateLimiter {
+	return &rateLimiter{rate: rate, burst: burst, clients: make(map[string]*bucket)}
+}
+
+// controlLimiter limits state-changing requests; nil disables the limit.
+var controlLimiter = newRateLimiter(20, 40)
+
+// allow takes a token from key's bucket, reporting false when it is empty.
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now.Sub(l.lastSweep) > idleBucket {
+		for k, b := range l.clients {
+			if now.Sub(b.last) > idleBucket {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+	b, ok := l.clients[key]
+	if !ok {
+		b = &bucket{tokens: l.burst, last: now}
+		l.clients[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// clientKey identifies the client a request came from by its address.
+// Forwarding headers are not trusted, as anyone can set them.
+func clientKey(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// limitRequest rate-limits requests that change state and caps the body of
+// every request at maxBody bytes.
+func limitRequest(maxBody int64, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead && controlLimiter != nil {
+			if !controlLimiter.allow(clientKey(r), time.Now()) {
+				w.Header().Set("Retry-After", strconv.Itoa(max(1, int(1/controlLimiter.rate))))
+				writeError(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+		if r.ContentLength > maxBody {
+			writeError(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		h(w, r)
+	}
+}
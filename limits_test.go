@@ -0,0 +1,82 @@
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRateLimiter(t *testing.T) {
+	l := newRateLimiter(2, 3)
+	now := time.Unix(0, 0)
+	tests := []struct {
+		name    string
+		client  string
+		advance time.Duration
+		want    bool
+	}{
+		{"burst 1", "a", 0, true},
+		{"burst 2", "a", 0, true},
+		{"burst 3", "a", 0, true},
+		{"burst spent", "a", 0, false},
+		{"other client", "b", 0, true},
+		{"partial refill", "a", 250 * time.Millisecond, false},
+		{"refilled", "a", 250 * time.Millisecond, true},
+		{"spent again", "a", 0, false},
+		{"capped at burst", "a", time.Hour, true},
+	}
+	for _, tt := range tests {
+		now = now.Add(tt.advance)
+		if got := l.allow(tt.client, now); got != tt.want {
+			t.Errorf("%s: allow = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if _, ok := l.clients["b"]; ok {
+		t.Error("idle client was not swept")
+	}
+}
+
+func TestLimitRequest(t *testing.T) {
+	saved := controlLimiter
+	defer func() { controlLimiter = saved }()
+	read := func(w http.ResponseWriter, r *http.Request) {
+		if _, err := io.ReadAll(r.Body); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+	tests := []struct {
+		name    string
+		method  string
+		body    string
+		chunked bool
+		want    int
+	}{
+		{"small body", http.MethodPost, "{}", false, http.StatusNoContent},
+		{"declared too large", http.MethodPost, strings.Repeat("x", 17), false, http.StatusRequestEntityTooLarge},
+		{"streamed too large", http.MethodPost, strings.Repeat("x", 17), true, http.StatusBadRequest},
+		{"rate limited", http.MethodPost, "", false, http.StatusTooManyRequests},
+		{"reads not limited", http.MethodGet, "", false, http.StatusNoContent},
+	}
+	controlLimiter = newRateLimiter(0.5, 3)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/api/v1/reset", strings.NewReader(tt.body))
+			if tt.chunked {
+				r.ContentLength = -1
+			}
+			rec := httptest.NewRecorder()
+			limitRequest(16, read)(rec, r)
+			if rec.Code != tt.want {
+				t.Errorf("got %d, want %d", rec.Code, tt.want)
+			}
+			if tt.want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "2" {
+				t.Errorf("Retry-After = %q, want 2", rec.Header().Get("Retry-After"))
+			}
+		})
+	}
+}
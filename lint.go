@@ -0,0 +1,156 @@
+package simulation
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/units"
+	"missile-intercept-sim/pkg/intercept"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Diagnostic severities.
+const (
+	SeverityError   = "error"   // the scenario will not load
+	SeverityWarning = "warning" // it loads, but the run is unlikely to be what was meant
+)
+
+// groundTolerance is how far below the terrain an entity may start without
+// a warning, m: launchers sit on the ground, and hill skirts reach a
+// little way everywhere.
+const groundTolerance = 1.0
+
+// Diagnostic is one problem found in a scenario.
+type Diagnostic struct {
+	Severity string `json:"severity"`
+	scenario.FieldError
+}
+
+// ValidateScenario decodes a scenario document and checks it with
+// CheckScenario. Problems with the document come back as diagnostics; the
+// error is for failures to read it at all.
+func ValidateScenario(r io.Reader) ([]Diagnostic, error) {
+	sc, err := scenario.Decode(r)
+	var invalid scenario.ValidationError
+	if errors.As(err, &invalid) {
+		return diagnostics(SeverityError, invalid), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return CheckScenario(sc), nil
+}
+
+// CheckScenario validates sc, returning its structural errors if it has
+// any, and otherwise warnings about physics that would spoil the run:
+// entities below the terrain, interceptors with no speed or more than they
+// can fly, and interceptors that cannot catch their target even flying
+// straight at top speed.
+func CheckScenario(sc *scenario.Scenario) []Diagnostic {
+	if err := sc.Validate(); err != nil {
+		var invalid scenario.ValidationError
+		if errors.As(err, &invalid) {
+			return diagnostics(SeverityError, invalid)
+		}
+		return []Diagnostic{{SeverityError, scenario.FieldError{Message: err.Error()}}}
+	}
+	var unknown scenario.ValidationError
+	for i, e := range sc.Entities {
+		if err := CheckGuidanceMode(e.Guidance); err != nil {
+			unknown = append(unknown, scenario.FieldError{Path: fmt.Sprintf("entities[%d].guidance", i), Message: err.Error()})
+		}
+	}
+	if len(unknown) > 0 {
+		return diagnostics(SeverityError, unknown)
+	}
+	out := []Diagnostic{}
+	warn := func(path, format string, args ...any) {
+		out = append(out, Diagnostic{SeverityWarning, scenario.FieldError{Path: path, Message: fmt.Sprintf(format, args...)}})
+	}
+	terrain := sc.Environment.Terrain.Model()
+	targets := make(map[string]scenario.Entity)
+	for _, e := range sc.Targets() {
+		targets[e.ID] = e
+	}
+	for i, e := range sc.Entities {
+		at := fmt.Sprintf("entities[%d]", i)
+		if ground := terrain.Elevation(e.Position.X, e.Position.Z); e.Position.Y < ground-groundTolerance && (e.Random == nil || e.Random.AltitudeMax == 0) {
+			warn(at+".position", "starts %.0f m below the terrain", ground-e.Position.Y)
+		}
+		speed := e.Velocity.Magnitude()
+		if e.Random != nil && e.Random.SpeedMax > 0 {
+			speed = e.Random.SpeedMax
+		}
+		if e.Role == scenario.RoleTarget {
+			if speed == 0 && !e.Ballistic {
+				warn(at+".velocity", "is zero, so the target holds its position for the whole run")
+			}
+			continue
+		}
+		maxSpeed := entities.NewMissile(e.ID, e.Position, e.Velocity).MaxSpeed
+		switch {
+		case speed == 0:
+			warn(at+".velocity", "is zero, so the interceptor has no direction to launch in")
+			continue
+		case speed > maxSpeed:
+			warn(at+".velocity", "%.0f m/s is above the interceptor's top speed of %.0f m/s", speed, maxSpeed)
+		}
+		aimed := sc.Targets()
+		if t, ok := targets[e.TargetID]; ok {
+			aimed = []scenario.Entity{t}
+		}
+		first, reachable := 0.0, false
+		for _, t := range aimed {
+			if ti, ok := earliestIntercept(e, t, maxSpeed); ok && (!reachable || ti < first) {
+				first, reachable = ti, true
+			}
+		}
+		switch {
+		case !reachable && len(aimed) == 1:
+			warn(at, "cannot reach %s even flying straight at its top speed of %.0f m/s", aimed[0].ID, maxSpeed)
+		case !reachable:
+			warn(at, "cannot reach any target even flying straight at its top speed of %.0f m/s", maxSpeed)
+		case sc.Termination.MaxTime > 0 && first > sc.Termination.MaxTime:
+			warn(at, "reaches its target no sooner than %.1f s, after the %.0f s time limit", first, sc.Termination.MaxTime)
+		}
+	}
+	return out
+}
+
+// earliestIntercept returns the simulated time at which an interceptor
+// launched from ic, flying straight at speed, could first meet target t
+// holding its course, or falling if ballistic.
+func earliestIntercept(ic, t scenario.Entity, speed float64) (float64, bool) {
+	var a vector.Vector3
+	if t.Ballistic {
+		a.Y = -units.G
+	}
+	lt := ic.LaunchTime
+	// Where the target is, and how fast it is going, at launch.
+	r := t.Position.AddScaled(t.Velocity, lt).AddScaled(a, lt*lt/2).Sub(ic.Position)
+	v := t.Velocity.AddScaled(a, lt)
+	ti, ok := intercept.Time(r, v, a, speed)
+	return lt + ti, ok
+}
+
+// diagnostics converts validation errors to diagnostics of one severity.
+func diagnostics(severity string, errs scenario.ValidationError) []Diagnostic {
+	out := make([]Diagnostic, len(errs))
+	for i, e := range errs {
+		out[i] = Diagnostic{severity, e}
+	}
+	return out
+}
+
+// HasErrors reports whether any of ds is an error.
+func HasErrors(ds []Diagnostic) bool {
+	for _, d := range ds {
+		if d.Severity == SeverityError {
+			return true
+		}
+	}
+	return false
+}
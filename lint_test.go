@@ -0,0 +1,76 @@
+package simulation
+
+import (
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestCheckScenario(t *testing.T) {
+	for _, sc := range scenario.Builtins() {
+		if ds := CheckScenario(sc); len(ds) != 0 {
+			t.Errorf("built-in %s: %v", sc.Name, ds)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		edit     func(sc *scenario.Scenario)
+		severity string
+		path     string
+	}{
+		{"unnamed", func(sc *scenario.Scenario) { sc.Name = "" }, SeverityError, "name"},
+		{"interceptor at rest", func(sc *scenario.Scenario) {
+			sc.Entities[1].Velocity = vector.Vector3{}
+		}, SeverityWarning, "entities[1].velocity"},
+		{"target at rest", func(sc *scenario.Scenario) {
+			sc.Entities[0].Velocity = vector.Vector3{}
+		}, SeverityWarning, "entities[0].velocity"},
+		{"underground", func(sc *scenario.Scenario) {
+			sc.Entities[1].Position.Y = -50
+		}, SeverityWarning, "entities[1].position"},
+		{"target outruns", func(sc *scenario.Scenario) {
+			sc.Entities[0].Velocity = vector.Vector3{X: 5000}
+		}, SeverityWarning, "entities[1]"},
+		{"too little time", func(sc *scenario.Scenario) {
+			sc.Termination.MaxTime = 1
+		}, SeverityWarning, "entities[1]"},
+		{"unknown guidance", func(sc *scenario.Scenario) {
+			sc.Entities[1].Guidance = "Wishful"
+		}, SeverityError, "entities[1].guidance"},
+	}
+	for _, tt := range tests {
+		sc := scenario.Default()
+		if sc.Entities[0].Role != scenario.RoleTarget || sc.Entities[1].Role != scenario.RoleInterceptor {
+			t.Fatal("default scenario no longer lists a target then an interceptor")
+		}
+		tt.edit(sc)
+		ds := CheckScenario(sc)
+		if len(ds) != 1 || ds[0].Severity != tt.severity || ds[0].Path != tt.path {
+			t.Errorf("%s: got %v, want one %s at %s", tt.name, ds, tt.severity, tt.path)
+		}
+	}
+}
+
+func TestValidateScenario(t *testing.T) {
+	tests := []struct {
+		name      string
+		doc       string
+		wantError bool
+	}{
+		{"malformed", `{"name": `, true},
+		{"unknown field", `{"name": "x", "bogus": 1}`, true},
+		{"empty", `{}`, true},
+	}
+	for _, tt := range tests {
+		ds, err := ValidateScenario(strings.NewReader(tt.doc))
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if HasErrors(ds) != tt.wantError {
+			t.Errorf("%s: diagnostics %v, want errors %v", tt.name, ds, tt.wantError)
+		}
+	}
+}
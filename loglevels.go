@@ -0,0 +1,48 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"slices"
+	"strings"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+// handleLogLevels reports each module's log level (GET), or changes some
+// of them (POST) with a body such as {"guidance": "debug"}. Every level in
+// the body is checked before any is applied.
+func handleLogLevels(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		var req map[string]string
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		levels := make(map[string]slog.Level, len(req))
+		for module, name := range req {
+			if !slices.Contains(logging.Modules, module) {
+				writeError(w, "Unknown module "+module+"; want one of "+strings.Join(logging.Modules, ", "), http.StatusBadRequest)
+				return
+			}
+			var level slog.Level
+			if err := level.UnmarshalText([]byte(name)); err != nil {
+				writeError(w, "Invalid level "+name+" for "+module, http.StatusBadRequest)
+				return
+			}
+			levels[module] = level
+		}
+		for module, level := range levels {
+			logging.SetLevel(module, level)
+		}
+		slog.Info("log levels changed", "levels", req, "client", clientKey(r))
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(logging.Levels())
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestLogLevels(t *testing.T) {
+	defer logging.ParseLevels("info")
+
+	tests := []struct {
+		name     string
+		method   string
+		body     string
+		code     int
+		guidance slog.Level // after the request
+	}{
+		{"read", http.MethodGet, "", http.StatusOK, slog.LevelInfo},
+		{"set", http.MethodPost, `{"guidance":"debug"}`, http.StatusOK, slog.LevelDebug},
+		{"unknown module", http.MethodPost, `{"guidance":"warn","radar":"debug"}`, http.StatusBadRequest, slog.LevelDebug},
+		{"bad level", http.MethodPost, `{"guidance":"loud"}`, http.StatusBadRequest, slog.LevelDebug},
+		{"bad body", http.MethodPost, `[`, http.StatusBadRequest, slog.LevelDebug},
+		{"method", http.MethodDelete, "", http.StatusMethodNotAllowed, slog.LevelDebug},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handleLogLevels(rec, httptest.NewRequest(tt.method, "/api/admin/log-levels", strings.NewReader(tt.body)))
+			if rec.Code != tt.code {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.code, rec.Body)
+			}
+			if got := logging.Levels()[logging.Guidance]; got != tt.guidance {
+				t.Errorf("guidance level %v, want %v", got, tt.guidance)
+			}
+			if tt.code != http.StatusOK {
+				return
+			}
+			var got map[string]string
+			if err := json.NewDecoder(rec.Body).Decode(&got); err != nil {
+				t.Fatal(err)
+			}
+			if len(got) != len(logging.Modules) || got[logging.Guidance] != tt.guidance.String() {
+				t.Errorf("levels %v", got)
+			}
+		})
+	}
+}
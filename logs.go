@@ -0,0 +1,47 @@
+package simulation
+
+import (
+	"log/slog"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+// loggers are a simulator's logger for each module it logs under.
+type loggers struct {
+	guidance, physics, recording, server *slog.Logger
+}
+
+func newLoggers(base *slog.Logger) loggers {
+	return loggers{
+		guidance:  logging.For(base, logging.Guidance),
+		physics:   logging.For(base, logging.Physics),
+		recording: logging.For(base, logging.Recording),
+		server:    logging.For(base, logging.Server),
+	}
+}
+
+// SetLogger makes the simulator log through l, tagging each record with
+// its module; nil logs through slog.Default(). Headless runs pass
+// logging.Discard.
+func (s *Simulator) SetLogger(l *slog.Logger) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.logs = newLoggers(l)
+}
+
+// eventLogger returns the logger and level an event of type typ is logged
+// at: faults as errors, intercepts as info, the rest as debug.
+func (s *Simulator) eventLogger(typ string) (*slog.Logger, slog.Level) {
+	switch typ {
+	case EventFault:
+		return s.logs.server, slog.LevelError
+	case EventIntercept:
+		return s.logs.physics, slog.LevelInfo
+	case EventCrash, EventOutOfBounds, EventSpent, EventImpact:
+		return s.logs.physics, slog.LevelDebug
+	case EventLaunch, EventLock, EventLockLost, EventRetarget, EventHandoff:
+		return s.logs.guidance, slog.LevelDebug
+	default:
+		return s.logs.server, slog.LevelDebug
+	}
+}
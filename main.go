@@ -1,73 +1,535 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"encoding/json"
-	"log"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/mqtt"
+	"missile-intercept-sim/internal/runstore"
+	"missile-intercept-sim/internal/scenario"
 	"missile-intercept-sim/internal/simulation"
 
 	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-var upgrader = websocket.Upgrader{
-	CheckOrigin: func(r *http.Request) bool {
-		return true // Allow all for dev
-	},
+// upgrader negotiates permessage-deflate with clients that offer it, but a
+// client's frames are only compressed if it also asks with ?compress=1.
+var upgrader = websocket.Upgrader{CheckOrigin: checkOrigin, EnableCompression: true}
+
+// allowedOrigins are the browser origins, besides the server's own, that may
+// open a WebSocket; "*" allows any. The default admits the dev frontend.
+var allowedOrigins = []string{"http://localhost:5173", "http://127.0.0.1:5173"}
+
+// checkOrigin admits requests without an Origin header (non-browser
+// clients), same-origin requests and the allowed origins.
+func checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+	for _, o := range allowedOrigins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+var sessions *SessionManager
+
+// recordingsDir is where finished run recordings are stored.
+var recordingsDir = "recordings"
+
+// campaignsDir is where batch campaigns logged to Parquet are written.
+var campaignsDir = "campaigns"
+
+// autoDegrade lets new sessions reduce fidelity when they overrun real time.
+var autoDegrade bool
+
+// recordPolicy is what new sessions' recordings do when the disk falls behind.
+var recordPolicy = simulation.RecordDrop
+
+// historyLimit and recordRetention bound the frames new sessions keep in
+// memory; the history's duration is set per session.
+var historyLimit, recordRetention = simulation.Retention{}, simulation.DefaultRecordRetention
+
+// serve runs the HTTP server until it is interrupted. Its flags are added
+// to fs, which callers may have given flags of their own; setup, if not
+// nil, runs once the sessions exist and before the server listens.
+func serve(fs *flag.FlagSet, args []string, setup func() error) {
+	addr := fs.String("addr", ":8080", "address to serve HTTP and WebSocket clients on")
+	grpcAddr := fs.String("grpc", "", "address to serve the gRPC API on, e.g. :9090; empty disables it")
+	certFile := fs.String("cert", "", "TLS certificate file; with -key serves HTTPS, WSS and gRPC over TLS")
+	keyFile := fs.String("key", "", "TLS private key file")
+	origins := fs.String("origins", strings.Join(allowedOrigins, ","), `comma-separated browser origins allowed to open WebSockets, "*" for any`)
+	controllers := fs.String("controller-tokens", os.Getenv("SIM_CONTROLLER_TOKENS"), "comma-separated tokens that may control the simulation; with no tokens at all, auth is off")
+	observers := fs.String("observer-tokens", os.Getenv("SIM_OBSERVER_TOKENS"), "comma-separated tokens that may only watch")
+	rate := fs.Float64("rate-limit", 20, "state-changing API requests allowed per second per client; 0 disables the limit")
+	burst := fs.Int("rate-burst", 40, "state-changing API requests a client may make at once")
+	mqttBroker := fs.String("mqtt", "", "MQTT broker to publish telemetry to, tcp://host:port or tls://host:port; empty disables it")
+	mqttTopic := fs.String("mqtt-topic", "missile-intercept", "topic prefix for MQTT telemetry")
+	mqttRate := fs.Float64("mqtt-rate", 5, "MQTT telemetry updates per second")
+	mqttUser := fs.String("mqtt-user", os.Getenv("SIM_MQTT_USER"), "MQTT username")
+	busURL := fs.String("bus", "", "event bus to stream frames and events to: nats://host:4222, or kafka://host:8082 for a Kafka REST Proxy; empty disables it")
+	busPrefix := fs.String("bus-prefix", "missile-intercept", "topic prefix on the event bus")
+	busRate := fs.Float64("bus-rate", 10, "state frames per second streamed to the event bus")
+	disAddr := fs.String("dis", "", "UDP address to send DIS PDUs to, e.g. 255.255.255.255:3000; empty disables DIS")
+	disExercise := fs.Uint("dis-exercise", 1, "DIS exercise ID")
+	disSite := fs.Uint("dis-site", 1, "DIS site number")
+	disApp := fs.Uint("dis-app", 1, "DIS application number")
+	disRate := fs.Float64("dis-rate", 5, "DIS entity state updates per second")
+	disSession := fs.String("dis-session", defaultSessionID, "session sent over DIS")
+	disOrigin := fs.String("dis-origin", "0,0,0", "lat,lon[,alt] the local frame's origin is placed at for DIS")
+	rosURL := fs.String("ros", "", "rosbridge server to bridge entities to ROS 2 through, e.g. ws://localhost:9090; empty disables it")
+	rosNamespace := fs.String("ros-namespace", "/missile_intercept", "ROS topic namespace")
+	rosFrame := fs.String("ros-frame", "map", "frame_id stamped on ROS messages")
+	rosRate := fs.Float64("ros-rate", 10, "ROS pose and twist updates per second")
+	flightSim := fs.String("flightsim", "", "flight simulator to show the engagement in: xplane://host[:49000], or flightgear://host:<native-fdm port>[?mp=<multiplayer port>]; empty disables it")
+	flightSimSession := fs.String("flightsim-session", defaultSessionID, "session shown in the flight simulator")
+	flightSimChase := fs.String("flightsim-chase", "", "entity the flight simulator's own aircraft follows; empty chases the interceptor in flight")
+	flightSimOrigin := fs.String("flightsim-origin", "0,0,0", "lat,lon[,alt] the local frame's origin is placed at in the flight simulator")
+	flightSimRate := fs.Float64("flightsim-rate", 30, "poses per second sent to the flight simulator")
+	cosimAddr := fs.String("cosim", "", "TCP address to accept co-simulation masters on, e.g. 127.0.0.1:5555; empty disables it")
+	cosimSession := fs.String("cosim-session", defaultSessionID, "session a co-simulation master drives unless it names another")
+	dbDSN := fs.String("db", "", "database to keep finished runs in: a SQLite file, or a postgres:// URL; empty disables it")
+	dbFrames := fs.Bool("db-trajectories", false, "also keep the frames of recorded runs in the database")
+	compression := fs.Bool("ws-compression", true, "let WebSocket clients ask for compressed frames with ?compress=1")
+	fs.BoolVar(&autoDegrade, "auto-degrade", false, "cap sensor update rates when a session's loop can no longer keep real time")
+	recordQueue := fs.String("record-queue", string(simulation.RecordDrop), "when recording falls a full queue behind the disk: drop frames, or block the simulation loop until it catches up")
+	fs.IntVar(&historyLimit.Frames, "history-frames", 0, "most frames a session's history keeps before thinning older ones; 0 for no limit")
+	fs.Int64Var(&historyLimit.Bytes, "history-bytes", 0, "most memory a session's history holds before thinning older frames; 0 for no limit")
+	fs.IntVar(&recordRetention.Frames, "record-frames", simulation.DefaultRecordRetention.Frames, "most frames a recording keeps in memory before thinning older ones; files on disk keep every frame")
+	fs.Int64Var(&recordRetention.Bytes, "record-bytes", 0, "most memory a recording holds before thinning older frames; 0 for no limit")
+	logLevel := fs.String("log-level", "info", `log level for every module, or module=level pairs such as "guidance=debug,server=warn"; modules are `+strings.Join(logging.Modules, ", "))
+	logJSON := fs.Bool("log-json", false, "log JSON lines instead of text")
+	fs.Parse(args)
+	if err := logging.ParseLevels(*logLevel); err != nil {
+		fatal("log level", "err", err)
+	}
+	slog.SetDefault(logging.New(os.Stderr, *logJSON))
+	policy, err := simulation.ParseRecordPolicy(*recordQueue)
+	if err != nil {
+		fatal("record queue", "err", err)
+	}
+	recordPolicy = policy
+	for name, r := range map[string]simulation.Retention{"history": historyLimit, "recording": recordRetention} {
+		if err := r.Validate(); err != nil {
+			fatal("invalid retention", "of", name, "err", err)
+		}
+	}
+	controlLimiter = nil
+	if *rate > 0 {
+		controlLimiter = newRateLimiter(*rate, float64(max(1, *burst)))
+	}
+	addTokens(*controllers, roleController)
+	addTokens(*observers, roleObserver)
+	if len(apiTokens) == 0 {
+		slog.Warn("no API tokens configured: every client has full control")
+	}
+	if (*certFile == "") != (*keyFile == "") {
+		fatal("-cert and -key must be given together")
+	}
+	allowedOrigins = nil
+	for _, o := range strings.Split(*origins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			allowedOrigins = append(allowedOrigins, o)
+		}
+	}
+	tlsEnabled := *certFile != ""
+	upgrader.EnableCompression = *compression
+
+	if *dbDSN != "" {
+		store, err := runstore.Open(*dbDSN)
+		if err != nil {
+			fatal("run database", "err", err)
+		}
+		archive = newRunArchive(store, *dbFrames)
+	}
+	sessions = NewSessionManager()
+	if setup != nil {
+		if err := setup(); err != nil {
+			fatal("setup", "err", err)
+		}
+	}
+
+	handleAPI("/sessions", handleSessions)
+	handleAPI("/control", handleControl)
+	handleAPI("/webhooks", handleWebhooks)
+	handleAPI("/state", handleState)
+	handleAPI("/start", handleStart)
+	handleAPI("/stop", handleStop)
+	handleAPI("/reset", handleReset)
+	handleAPI("/rerun", handleRerun)
+	handleAPI("/guidance", handleGuidance)
+	handleAPI("/guidance/external", handleExternalGuidance)
+	handleAPI("/guidance/modes", handleGuidanceModes)
+	handleAPI("/step", handleStep)
+	handleAPI("/timescale", handleTimeScale)
+	handleAPI("/batch", handleBatch)
+	handleAPI("/sweep", handleSweep)
+	handleAPI("/benchmark", handleBenchmark)
+	handleAPI("/perf", handlePerf)
+	handleAPI("/admin/log-levels", handleLogLevels)
+	handleAPI("/envelope", handleEnvelope)
+	handleAPI("/record", handleRecord)
+	handleAPI("/recordings", handleRecordings)
+	handleAPI("/recordings/diff", handleDiffRecordings)
+	handleAPI("/replay", handleReplay)
+	handleAPI("/export/csv", handleExportCSV)
+	handleAPI("/export/acmi", handleExportACMI)
+	handleAPI("/export/globe", handleExportGlobe)
+	handleAPI("/export/geojson", handleExportGeoJSON)
+	handleAPI("/export/frames", handleExportFrames)
+	handleAPI("/replay/control", handleReplayControl)
+	handleAPI("/scenario", handleScenario)
+	handleAPI("/scenarios", handleScenarios)
+	handleAPI("/scenarios/validate", handleValidateScenario)
+	handleAPI("/scenario/validate", handleValidateScenario)
+	handleAPI("/library", handleLibrary)
+	handleAPI("/doctrine", handleDoctrine)
+	handleAPI("/launch", handleLaunch)
+	handleAPI("/snapshots", handleSnapshots)
+	handleAPI("/snapshots/restore", handleSnapshotRestore)
+	handleAPI("/result", handleResult)
+	handleAPI("/results", handleResults)
+	handleAPI("/manifest", handleManifest)
+	handleAPI("/schema", handleSchema)
+	handleAPI("/events", handleEvents)
+	handleAPI("/history", handleHistory)
+	handleAPI("/runs", handleRuns)
+	handleAPI("/runs/compare", handleCompareRuns)
+	http.HandleFunc("/api/", func(w http.ResponseWriter, r *http.Request) {
+		writeError(w, "Unknown endpoint", http.StatusNotFound)
+	})
+	http.HandleFunc("/ws", requireRole(handleWebSocket))
+	http.HandleFunc("/sse", requireRole(handleSSE))
+	// Probes sit outside /api and need no token, so orchestrators can reach them.
+	http.HandleFunc("/healthz", handleHealthz)
+	http.HandleFunc("/readyz", handleReadyz)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go webhooks.Run(ctx)
+	if archive != nil {
+		go archive.Run(ctx)
+	}
+	if *mqttBroker != "" {
+		telemetry, err := newMQTTTelemetry(*mqttBroker, *mqttTopic, *mqttRate, mqtt.Options{
+			ClientID: "missile-intercept-sim",
+			Username: *mqttUser,
+			Password: os.Getenv("SIM_MQTT_PASSWORD"),
+		})
+		if err != nil {
+			fatal("mqtt", "err", err)
+		}
+		go telemetry.Run(ctx)
+	}
+	if *disAddr != "" {
+		origin, err := parseOriginFlag(*disOrigin)
+		if err != nil {
+			fatal("dis origin", "err", err)
+		}
+		if *disExercise == 0 || *disExercise > math.MaxUint8 || *disSite > math.MaxUint16 || *disApp > math.MaxUint16 {
+			fatal("DIS exercise must be 1-255, and site and application 0-65535")
+		}
+		out, err := newDISOutput(*disAddr, *disSession, uint8(*disExercise), uint16(*disSite), uint16(*disApp), origin, *disRate)
+		if err != nil {
+			fatal("dis", "err", err)
+		}
+		go out.Run(ctx)
+	}
+	if *rosURL != "" {
+		bridge, err := newROSBridge(*rosURL, *rosNamespace, *rosFrame, *rosRate)
+		if err != nil {
+			fatal("ros", "err", err)
+		}
+		go bridge.Run(ctx)
+	}
+	if *flightSim != "" {
+		origin, err := parseOriginFlag(*flightSimOrigin)
+		if err != nil {
+			fatal("flight sim origin", "err", err)
+		}
+		bridge, err := newFlightSimBridge(*flightSim, *flightSimSession, *flightSimChase, origin, *flightSimRate)
+		if err != nil {
+			fatal("flight sim", "err", err)
+		}
+		go bridge.Run(ctx)
+	}
+	if *cosimAddr != "" {
+		cosim, err := newCosimServer(*cosimAddr, *cosimSession)
+		if err != nil {
+			fatal("cosim", "err", err)
+		}
+		go cosim.Run(ctx)
+	}
+	if *busURL != "" {
+		streamer, err := newBusStreamer(*busURL, *busPrefix, *busRate)
+		if err != nil {
+			fatal("bus", "err", err)
+		}
+		go streamer.Run(ctx)
+	}
+
+	var grpcSrv *grpc.Server
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			fatal("grpc", "err", err)
+		}
+		opts := grpcAuth()
+		if tlsEnabled {
+			creds, err := credentials.NewServerTLSFromFile(*certFile, *keyFile)
+			if err != nil {
+				fatal("grpc tls", "err", err)
+			}
+			opts = append(opts, grpc.Creds(creds))
+		}
+		grpcSrv = newGRPCServer(opts...)
+		go func() {
+			slog.Info("gRPC API listening", "addr", *grpcAddr)
+			if err := grpcSrv.Serve(lis); err != nil {
+				slog.Error("grpc", "err", err)
+			}
+		}()
+	}
+
+	srv := &http.Server{Addr: *addr, Handler: withAccessLog(http.DefaultServeMux), TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12}}
+	srv.RegisterOnShutdown(func() {
+		for _, sess := range sessions.List() {
+			sess.Hub.CloseStreams()
+		}
+	})
+	errc := make(chan error, 1)
+	go func() {
+		if tlsEnabled {
+			slog.Info("server starting", "addr", *addr, "tls", true)
+			errc <- srv.ListenAndServeTLS(*certFile, *keyFile)
+			return
+		}
+		slog.Info("server starting", "addr", *addr)
+		errc <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		fatal("listen", "err", err)
+	case <-ctx.Done():
+	}
+	stop() // a second signal kills the process outright
+	shuttingDown.Store(true)
+
+	slog.Info("shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutdown", "err", err)
+	}
+	if grpcSrv != nil {
+		stopGRPC(shutdownCtx, grpcSrv)
+	}
+	// Sessions last: WebSocket connections are hijacked, so Shutdown leaves
+	// them to us, and running simulations have recordings to flush.
+	if err := sessions.Shutdown(shutdownCtx); err != nil {
+		slog.Error("shutdown: recordings may be incomplete", "err", err)
+	}
+	if archive != nil {
+		if err := archive.Close(shutdownCtx); err != nil {
+			slog.Error("shutdown: runs", "err", err)
+		}
+	}
 }
 
-var sim *simulation.Simulator
+// fatal logs a startup failure and exits.
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
+// shutdownTimeout bounds how long a graceful shutdown waits for requests,
+// streams and recordings before exiting anyway.
+const shutdownTimeout = 10 * time.Second
 
-func main() {
-	sim = simulation.NewSimulator()
+// apiPrefix is the path prefix of the current API version. The unversioned
+// /api paths stay as aliases for existing clients.
+const apiPrefix = "/api/v1"
 
-	http.HandleFunc("/api/start", handleStart)
-	http.HandleFunc("/api/stop", handleStop)
-	http.HandleFunc("/api/reset", handleReset)
-	http.HandleFunc("/api/guidance", handleGuidance)
-	http.HandleFunc("/ws", handleWebSocket)
+// bodyLimits are the routes that accept bodies larger than defaultMaxBody.
+var bodyLimits = map[string]int64{
+	"/scenario":           maxScenarioBytes,
+	"/scenarios/validate": maxScenarioBytes,
+	"/scenario/validate":  maxScenarioBytes,
+	"/library":            maxScenarioBytes,
+}
 
-	log.Println("Server starting on :8080")
-	if err := http.ListenAndServe(":8080", nil); err != nil {
-		log.Fatal("ListenAndServe:", err)
+// handleAPI registers h under the versioned and the unversioned API path,
+// behind the rate and body limits and the token check.
+func handleAPI(path string, h http.HandlerFunc) {
+	maxBody, ok := bodyLimits[path]
+	if !ok {
+		maxBody = defaultMaxBody
 	}
+	h = limitRequest(maxBody, requireRole(h))
+	http.HandleFunc(apiPrefix+path, h)
+	http.HandleFunc("/api"+path, h)
+}
+
+func handleSessions(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sessions.List())
+	case http.MethodPost:
+		sess := sessions.Create()
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(sess)
+	case http.MethodDelete:
+		id := r.URL.Query().Get("session")
+		if sess, ok := sessions.Get(id); ok && !checkControl(w, r, sess) {
+			return
+		}
+		if !sessions.Delete(id) {
+			writeError(w, "Unknown or protected session", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Session deleted"))
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleState returns the session's current state, the same frame a
+// WebSocket client would get next but without events or trails.
+func handleState(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sess.State())
 }
 
 func handleStart(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	if err := sess.Sim.Start(); err != nil {
+		writeError(w, err.Error(), http.StatusConflict)
 		return
 	}
-	sim.Start()
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Simulation started"))
 }
 
 func handleStop(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
 		return
 	}
-	sim.Stop()
+	sess.Sim.Stop()
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Simulation stopped"))
 }
 
 func handleReset(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
 		return
 	}
-	sim.Stop()
-	sim.Reset()
+	// An optional body fixes the seed so a previous run can be replayed.
+	type ResetRequest struct {
+		Seed *uint64 `json:"seed"`
+	}
+	var req ResetRequest
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+	}
+	sess.Sim.Stop()
+	if req.Seed != nil {
+		sess.Sim.SetSeed(*req.Seed)
+	}
+	sess.Sim.Reset()
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Simulation reset"))
 }
 
+// handleRerun restarts the session's scenario with the current run's seed
+// and the overrides in the body.
+func handleRerun(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	var o simulation.Overrides
+	if err := json.NewDecoder(r.Body).Decode(&o); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	sess.SetPlayer(nil)
+	if err := sess.Sim.Rerun(o); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Rerun started"))
+}
+
 func handleGuidance(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
 		return
 	}
 	type GuidanceRequest struct {
@@ -75,32 +537,892 @@ func handleGuidance(w http.ResponseWriter, r *http.Request) {
 	}
 	var req GuidanceRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid body", http.StatusBadRequest)
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := sess.Sim.SetGuidanceMode(req.Mode); err != nil {
+		writeBadRequest(w, err)
 		return
 	}
-	sim.SetGuidanceMode(req.Mode)
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("Guidance mode updated"))
 }
 
+// writeBadRequest rejects a request with err, listing the valid modes when
+// err names a guidance mode the simulator cannot fly.
+func writeBadRequest(w http.ResponseWriter, err error) {
+	var unknown *simulation.UnknownGuidanceError
+	if errors.As(err, &unknown) {
+		writeErrorDetails(w, err.Error(), http.StatusBadRequest, unknown.Valid)
+		return
+	}
+	writeError(w, err.Error(), http.StatusBadRequest)
+}
+
+// handleGuidanceModes lists the guidance modes /guidance accepts, with what
+// each does and the scenario parameters that shape it.
+func handleGuidanceModes(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(simulation.GuidanceLaws())
+}
+
+// handleExternalGuidance attaches, inspects or detaches the process that
+// computes commands for interceptors flying the External guidance mode.
+func handleExternalGuidance(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		sess, ok := sessionFor(w, r)
+		if !ok {
+			return
+		}
+		status, ok := sess.Sim.ExternalGuidanceStatus()
+		if !ok {
+			writeError(w, "No external guidance attached", http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(status)
+	case http.MethodPost:
+		sess, ok := controlledSession(w, r)
+		if !ok {
+			return
+		}
+		type ExternalRequest struct {
+			Addr      string  `json:"addr"`
+			TimeoutMs float64 `json:"timeoutMs"`
+		}
+		var req ExternalRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		g, err := simulation.DialExternalGuidance(req.Addr, time.Duration(req.TimeoutMs*float64(time.Millisecond)))
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sess.Sim.SetExternalGuidance(g)
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(g.Status())
+	case http.MethodDelete:
+		sess, ok := controlledSession(w, r)
+		if !ok {
+			return
+		}
+		sess.Sim.SetExternalGuidance(nil)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// maxStepCount caps a single frame-advance request.
+const maxStepCount = 10000
+
+func handleStep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	// An empty body advances a single step.
+	type StepRequest struct {
+		Count int `json:"count"`
+	}
+	req := StepRequest{Count: 1}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+	}
+	if req.Count < 1 || req.Count > maxStepCount {
+		writeError(w, fmt.Sprintf("count must be between 1 and %d", maxStepCount), http.StatusBadRequest)
+		return
+	}
+	if _, err := sess.Sim.Advance(req.Count); err != nil {
+		writeError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sess.Sim.GetState())
+}
+
+func handleTimeScale(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	type TimeScaleRequest struct {
+		Scale float64 `json:"scale"`
+	}
+	var req TimeScaleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := sess.Sim.SetTimeScale(req.Scale); err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Time scale updated"))
+}
+
+// maxBatchRuns caps a single batch request so one client cannot tie up the server.
+const maxBatchRuns = 10000
+
+// handleBatch runs a Monte Carlo campaign over a built-in scenario, or over
+// the session's current scenario when none is named. With "parquet" it also
+// logs every step of every replica under campaignsDir and names the
+// directory in the response.
+func handleBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	type BatchRequest struct {
+		simulation.BatchConfig
+		Scenario string `json:"scenario"`
+		Parquet  bool   `json:"parquet"` // log every step of every replica to Parquet
+	}
+	var req BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if req.Runs <= 0 || req.Runs > maxBatchRuns {
+		writeError(w, fmt.Sprintf("runs must be between 1 and %d", maxBatchRuns), http.StatusBadRequest)
+		return
+	}
+	if req.Workers < 0 || req.Workers > simulation.MaxWorkers {
+		writeError(w, fmt.Sprintf("workers must be between 0 and %d", simulation.MaxWorkers), http.StatusBadRequest)
+		return
+	}
+	if err := simulation.CheckGuidanceMode(req.Guidance); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	sc := sess.Sim.GetScenario()
+	if req.Scenario != "" {
+		named, ok := loadScenario(req.Scenario)
+		if !ok {
+			writeError(w, "Unknown scenario", http.StatusNotFound)
+			return
+		}
+		sc = named
+	}
+	if err := simulation.CheckScenarioGuidance(sc); err != nil {
+		writeBadRequest(w, err)
+		return
+	}
+	type BatchResponse struct {
+		simulation.BatchReport
+		Telemetry string `json:"telemetry,omitempty"` // the campaign's Parquet directory
+	}
+	var resp BatchResponse
+	if req.Parquet {
+		resp.Telemetry = filepath.Join(campaignsDir, time.Now().UTC().Format("20060102T150405Z")+"-"+newSessionID()[:6])
+		campaign, err := simulation.NewParquetCampaign(resp.Telemetry, sc.Name)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		resp.BatchReport, err = simulation.RunBatchLogged(sc, req.BatchConfig, campaign)
+		if cerr := campaign.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			writeError(w, "Logging the campaign: "+err.Error(), http.StatusInternalServerError)
+			return
+		}
+	} else {
+		var err error
+		if resp.BatchReport, err = simulation.RunBatch(sc, req.BatchConfig); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+	}
+	report := resp.BatchReport
+	webhooks.Notify(WebhookPayload{Event: HookBatchCompleted, Session: sess.ID, Summary: batchSummary{
+		Runs: len(report.Results), Intercepts: report.Intercepts, Pk: report.Pk,
+		MeanMiss: report.MeanMiss, MeanFlight: report.MeanFlight, WallTime: report.WallTime,
+	}})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// handleBenchmark compares every guidance law across the scenario library.
+// ?format=csv returns the matrix as CSV instead of JSON.
+func handleBenchmark(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var cfg simulation.BatchConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if cfg.Runs <= 0 || simulation.BenchmarkSize(cfg) > maxBatchRuns {
+		writeError(w, fmt.Sprintf("runs must be positive and the benchmark at most %d runs in total", maxBatchRuns), http.StatusBadRequest)
+		return
+	}
+	if cfg.Workers < 0 || cfg.Workers > simulation.MaxWorkers {
+		writeError(w, fmt.Sprintf("workers must be between 0 and %d", simulation.MaxWorkers), http.StatusBadRequest)
+		return
+	}
+	report := simulation.RunBenchmark(cfg)
+	if r.URL.Query().Get("format") == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		report.WriteCSV(w)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handlePerf times the physics step on built-in scenarios and reports the
+// step rate, allocations and latency of each, a baseline for changes to the
+// hot path. Runs on one goroutine, so at most one is allowed at a time.
+func handlePerf(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var cfg simulation.PerfConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if !perfRunning.CompareAndSwap(false, true) {
+		writeError(w, "A perf run is already in progress", http.StatusConflict)
+		return
+	}
+	defer perfRunning.Store(false)
+	report, err := simulation.RunPerf(cfg)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// perfRunning keeps perf runs from timing each other.
+var perfRunning atomic.Bool
+
+// handleEnvelope computes the launch envelope for an interceptor profile
+// against a target track.
+func handleEnvelope(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var cfg simulation.EnvelopeConfig
+	if err := json.NewDecoder(r.Body).Decode(&cfg); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	report, err := simulation.ComputeEnvelope(cfg)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+// handleSweep runs a parameter sweep over a built-in scenario, or over the
+// session's current scenario when none is named.
+func handleSweep(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	type SweepRequest struct {
+		simulation.SweepConfig
+		Scenario string `json:"scenario"`
+	}
+	var req SweepRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if simulation.SweepSize(req.SweepConfig, maxBatchRuns) > maxBatchRuns {
+		writeError(w, fmt.Sprintf("sweep expands to more than %d runs", maxBatchRuns), http.StatusBadRequest)
+		return
+	}
+	sc := sess.Sim.GetScenario()
+	if req.Scenario != "" {
+		named, ok := loadScenario(req.Scenario)
+		if !ok {
+			writeError(w, "Unknown scenario", http.StatusNotFound)
+			return
+		}
+		sc = named
+	}
+	report, err := simulation.RunSweep(sc, req.SweepConfig)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	webhooks.Notify(WebhookPayload{Event: HookSweepCompleted, Session: sess.ID, Summary: sweepSummary{
+		Scenario: report.Scenario, Points: len(report.Points), WallTime: report.WallTime,
+	}})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(report)
+}
+
+func handleRecord(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	type RecordRequest struct {
+		Enabled bool `json:"enabled"`
+	}
+	var req RecordRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := sess.Sim.SetRecording(req.Enabled); err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Recording updated"))
+}
+
+func handleRecordings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	list, err := simulation.ListRecordings(recordingsDir)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+// handleDiffRecordings compares the recordings named by ?a= and ?b=,
+// ignoring differences up to ?tolerance= metres.
+func handleDiffRecordings(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	q := r.URL.Query()
+	tolerance := 0.0
+	if v := q.Get("tolerance"); v != "" {
+		var err error
+		if tolerance, err = strconv.ParseFloat(v, 64); err != nil {
+			writeError(w, "invalid tolerance "+strconv.Quote(v), http.StatusBadRequest)
+			return
+		}
+	}
+	var recs [2]*simulation.Recording
+	for i, name := range []string{q.Get("a"), q.Get("b")} {
+		rec, err := simulation.LoadRecording(recordingsDir, name)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		recs[i] = rec
+	}
+	diff, err := simulation.DiffRuns(recs[0], recs[1], tolerance)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// handleReplay loads a recording into the session's stream (POST) or
+// returns the session to live state (DELETE).
+func handleReplay(w http.ResponseWriter, r *http.Request) {
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodPost:
+		type ReplayRequest struct {
+			Name  string  `json:"name"`
+			Speed float64 `json:"speed"`
+			Rate  float64 `json:"rate"` // frames per simulated second, 0 for as recorded
+		}
+		req := ReplayRequest{Speed: 1}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		if req.Rate < 0 || req.Rate > maxResampleRate {
+			writeError(w, fmt.Sprintf("rate must be at most %d", maxResampleRate), http.StatusBadRequest)
+			return
+		}
+		rec, err := simulation.LoadRecording(recordingsDir, req.Name)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if req.Rate > 0 {
+			rec = rec.Resample(1 / req.Rate)
+		}
+		sess.Sim.Stop()
+		sess.SetPlayer(simulation.NewPlayer(rec, req.Speed))
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Replay started"))
+	case http.MethodDelete:
+		sess.SetPlayer(nil)
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Replay stopped"))
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleReplayControl scrubs and changes speed of the active replay.
+func handleReplayControl(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	player := sess.Player()
+	if player == nil {
+		writeError(w, "No replay loaded", http.StatusConflict)
+		return
+	}
+	type ReplayControlRequest struct {
+		Time  *float64 `json:"time"`
+		Speed *float64 `json:"speed"`
+	}
+	var req ReplayControlRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if req.Speed != nil {
+		player.SetSpeed(*req.Speed)
+	}
+	if req.Time != nil {
+		player.Seek(*req.Time)
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Replay updated"))
+}
+
+// maxScenarioBytes bounds uploaded scenario documents.
+const maxScenarioBytes = 1 << 20
+
+// handleScenario returns the session's scenario (GET) or loads a new one
+// (POST), either a built-in or stored scenario selected with ?name= or a
+// JSON document in the body.
+func handleScenario(w http.ResponseWriter, r *http.Request) {
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sess.Sim.GetScenario())
+	case http.MethodPost:
+		var sc *scenario.Scenario
+		if name := r.URL.Query().Get("name"); name != "" {
+			named, ok := loadScenario(name)
+			if !ok {
+				writeError(w, "Unknown scenario", http.StatusNotFound)
+				return
+			}
+			sc = named
+		} else {
+			decoded, err := scenario.Decode(r.Body)
+			if err != nil {
+				writeScenarioError(w, err)
+				return
+			}
+			sc = decoded
+		}
+		sess.SetPlayer(nil)
+		if err := sess.Sim.LoadScenario(sc); err != nil {
+			writeBadRequest(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Scenario loaded"))
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleDoctrine returns the session's launch doctrine (GET) or switches
+// between hold fire and weapons free (POST).
+func handleDoctrine(w http.ResponseWriter, r *http.Request) {
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sess.Sim.GetDoctrine())
+	case http.MethodPost:
+		type DoctrineRequest struct {
+			HoldFire bool `json:"holdFire"`
+		}
+		var req DoctrineRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		if err := sess.Sim.SetHoldFire(req.HoldFire); err != nil {
+			writeError(w, err.Error(), http.StatusConflict)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("Doctrine updated"))
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleLaunch fires a ready interceptor by hand.
+func handleLaunch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	type LaunchRequest struct {
+		Interceptor string `json:"interceptor"`
+		Target      string `json:"target"`
+	}
+	var req LaunchRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	if err := sess.Sim.Launch(req.Interceptor, req.Target); err != nil {
+		writeError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("Interceptor launched"))
+}
+
+func handleScenarios(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(scenario.Builtins())
+}
+
+// handleResult returns the outcome report of the session's last finished run.
+func handleResult(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	rep := sess.Sim.Result()
+	if rep == nil {
+		writeError(w, "No finished run", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rep)
+}
+
+// handleManifest returns the manifest of the session's current run.
+func handleManifest(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sess.Sim.Manifest())
+}
+
+// handleSchema returns the units of every numeric telemetry field.
+func handleSchema(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(simulation.Schema())
+}
+
+// handleResults returns the session's run history, oldest first.
+func handleResults(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(sess.Sim.Results())
+}
+
+// handleEvents returns the session's event log, optionally only the events
+// after sequence number ?since=.
+func handleEvents(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	var since uint64
+	if v := r.URL.Query().Get("since"); v != "" {
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			writeError(w, "Invalid since", http.StatusBadRequest)
+			return
+		}
+		since = n
+	}
+	events := sess.Sim.Events(since)
+	if events == nil {
+		events = []simulation.Event{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(events)
+}
+
+// handleHistory returns recent states between ?from= and ?to= (simulation
+// seconds, both optional) on GET, or sets how much history is kept on POST:
+// a duration in seconds and optional frame and byte limits.
+func handleHistory(w http.ResponseWriter, r *http.Request) {
+	sess, ok := controlledSession(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		from, to := 0.0, math.Inf(1)
+		q := r.URL.Query()
+		for name, dst := range map[string]*float64{"from": &from, "to": &to} {
+			if v := q.Get(name); v != "" {
+				f, err := strconv.ParseFloat(v, 64)
+				if err != nil {
+					writeError(w, "Invalid "+name, http.StatusBadRequest)
+					return
+				}
+				*dst = f
+			}
+		}
+		frames := sess.Sim.History(from, to)
+		if frames == nil {
+			frames = []simulation.SimulationState{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(frames)
+	case http.MethodPost:
+		var req simulation.Retention
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		if err := sess.Sim.SetHistoryRetention(req); err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte("History updated"))
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func handleSnapshots(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(sess.Snapshots())
+	case http.MethodPost:
+		snap, err := sess.TakeSnapshot()
+		if err != nil {
+			writeError(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(snap)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// handleSnapshotRestore rewinds the session to a snapshot, or with branch set
+// restores it into a brand-new session and leaves the original untouched.
+func handleSnapshotRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	type RestoreRequest struct {
+		ID     string `json:"id"`
+		Branch bool   `json:"branch"`
+	}
+	var req RestoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeError(w, "Invalid body", http.StatusBadRequest)
+		return
+	}
+	snap, found := sess.FindSnapshot(req.ID)
+	if !found {
+		writeError(w, "Unknown snapshot", http.StatusNotFound)
+		return
+	}
+	// A branch leaves the session as it is, so needs no control of it.
+	if !req.Branch && !checkControl(w, r, sess) {
+		return
+	}
+	target := sess
+	if req.Branch {
+		target = sessions.Create()
+	}
+	target.SetPlayer(nil)
+	if err := target.Sim.Restore(snap); err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(target)
+}
+
 func handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	opts, err := parseClientOptions(r)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	c, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
-		log.Print("upgrade:", err)
+		slog.Warn("websocket upgrade", "err", err)
 		return
 	}
 	defer c.Close()
+	sess.Hub.Serve(c, opts)
+}
 
-	// Broadcast loop for this client
-	ticker := time.NewTicker(33 * time.Millisecond) // ~30Hz update for UI
-	defer ticker.Stop()
-
-	for range ticker.C {
-		state := sim.GetState()
-		err := c.WriteJSON(state)
+// parseClientOptions reads a WebSocket client's stream settings from its
+// query. Each message carries the events logged since the previous one, and
+// the last ?trails= seconds of entity trails if the client asked for them.
+// ?format=msgpack switches to binary frames, ?delta=1 to keyframes with only
+// the changes sent in between, and ?rate= lowers the update rate.
+// ?entities= and ?fields= take comma-separated entity IDs and state fields,
+// e.g. fields=time,entities.position for positions only. A reconnecting
+// client passes ?resume=<run>:<time> of the last frame it got to be sent the
+// frames it missed. ?lease= is the control lease commands are sent under.
+// ?compress=1 compresses frames, for clients on slow links; it costs latency
+// and server CPU, so local clients are better off without. ?engine=unity or
+// ?engine=unreal sends frames for game-engine frontends instead of the
+// state, in the engine's axes and units. ?compact=1 sends vectors as
+// [x, y, z] arrays of float32s, about half the size, for viewers on
+// constrained links.
+func parseClientOptions(r *http.Request) (ClientOptions, error) {
+	q := r.URL.Query()
+	opts := ClientOptions{Control: authorize(r) >= roleController, Lease: leaseOf(r), RequestID: RequestID(r.Context())}
+	var err error
+	if opts.Format, err = ParseFormat(q.Get("format")); err != nil {
+		return opts, err
+	}
+	opts.Delta = q.Get("delta") == "1"
+	if opts.Delta && opts.Format != FormatJSON {
+		return opts, errors.New("delta updates are only available as JSON")
+	}
+	opts.Trails, _ = strconv.ParseFloat(q.Get("trails"), 64)
+	opts.Compress = q.Get("compress") == "1"
+	opts.Compact = q.Get("compact") == "1"
+	if opts.Compact && opts.Delta {
+		return opts, errors.New("delta updates have no compact form")
+	}
+	if v := q.Get("rate"); v != "" {
+		opts.Rate, err = strconv.ParseFloat(v, 64)
+		if err != nil || !(opts.Rate > 0 && opts.Rate <= maxClientRate) {
+			return opts, fmt.Errorf("rate must be above 0 and at most %d", maxClientRate)
+		}
+	}
+	if v := q.Get("resume"); v != "" {
+		tok, err := ParseResumeToken(v)
 		if err != nil {
-			log.Println("write:", err)
-			break
+			return opts, err
 		}
+		opts.Resume = &tok
+	}
+	opts.Filter, err = ParseFilter(q.Get("entities"), q.Get("fields"))
+	if err != nil {
+		return opts, err
+	}
+	if opts.Engine, err = ParseEngine(q.Get("engine")); err != nil {
+		return opts, err
+	}
+	if opts.Engine != "" && (opts.Delta || opts.Compact || opts.Trails > 0 || opts.Filter.Fields != nil || opts.Resume != nil) {
+		err = errors.New("engine frames take no delta, compact, trails, fields or resume")
 	}
+	return opts, err
 }
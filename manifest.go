@@ -0,0 +1,119 @@
+package simulation
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+// Version identifies the simulator build in run manifests. Release builds set
+// it with -ldflags "-X missile-intercept-sim/internal/simulation.Version=...";
+// otherwise it is derived from the Go build info.
+var Version string
+
+var (
+	buildVersionOnce sync.Once
+	buildVersion     string
+)
+
+// simulatorVersion returns Version, falling back to the module version and
+// VCS revision embedded by the Go toolchain.
+func simulatorVersion() string {
+	if Version != "" {
+		return Version
+	}
+	buildVersionOnce.Do(func() {
+		buildVersion = "unknown"
+		info, ok := debug.ReadBuildInfo()
+		if !ok {
+			return
+		}
+		buildVersion = info.Main.Version
+		var rev, modified string
+		for _, s := range info.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				rev = s.Value
+			case "vcs.modified":
+				modified = s.Value
+			}
+		}
+		if rev != "" {
+			buildVersion += "+" + rev
+			if modified == "true" {
+				buildVersion += "-dirty"
+			}
+		}
+	})
+	return buildVersion
+}
+
+// Manifest records everything needed to reproduce a run exactly.
+type Manifest struct {
+	Scenario     string             `json:"scenario"`
+	ScenarioHash string             `json:"scenarioHash"` // sha256 of the scenario's JSON encoding
+	Seed         uint64             `json:"seed"`
+	Version      string             `json:"version"`
+	Dt           float64            `json:"dt"`
+	Started      time.Time          `json:"started"`
+	Overrides    []ManifestOverride `json:"overrides"` // applied on top of the scenario, in order
+}
+
+// ManifestOverride is one parameter change applied to a run.
+type ManifestOverride struct {
+	Time  float64 `json:"time"` // simulation time it took effect
+	Param string  `json:"param"`
+	Value any     `json:"value"`
+}
+
+// clone copies the manifest so later overrides don't show through.
+func (m Manifest) clone() Manifest {
+	m.Overrides = append([]ManifestOverride{}, m.Overrides...)
+	return m
+}
+
+// ScenarioHash returns the hex sha256 of the scenario's JSON encoding.
+func ScenarioHash(sc *scenario.Scenario) string {
+	b, err := json.Marshal(sc)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// newManifestLocked starts the manifest for a freshly reset run.
+// Callers must hold s.mu.
+func (s *Simulator) newManifestLocked() {
+	s.manifest = Manifest{
+		Scenario:     s.Scenario.Name,
+		ScenarioHash: ScenarioHash(s.Scenario),
+		Seed:         s.State.Seed,
+		Version:      simulatorVersion(),
+		Dt:           s.Dt,
+		Started:      time.Now(),
+		Overrides:    []ManifestOverride{},
+	}
+}
+
+// overrideLocked records a parameter change in the run's manifest.
+// Callers must hold s.mu.
+func (s *Simulator) overrideLocked(param string, value any) {
+	s.manifest.Overrides = append(s.manifest.Overrides, ManifestOverride{
+		Time:  s.State.Time,
+		Param: param,
+		Value: value,
+	})
+}
+
+// Manifest returns the current run's manifest.
+func (s *Simulator) Manifest() Manifest {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.manifest.clone()
+}
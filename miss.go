@@ -0,0 +1,118 @@
+package simulation
+
+import (
+	"math"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// endgameWindow is how close to intercept, in seconds to go, the miss
+// analysis starts accumulating.
+const endgameWindow = 2.0
+
+// Miss causes named in MissAnalysis.Dominant.
+const (
+	MissTargetManeuver = "targetManeuver"
+	MissGLimit         = "gLimit"
+	MissSensor         = "sensor"
+)
+
+// MissAnalysis breaks down a miss at closest approach. The attributions are
+// estimates of how far each effect alone displaced the target relative to
+// the missile across the line of sight during the endgame; they need not sum
+// to the miss.
+type MissAnalysis struct {
+	Window         float64 `json:"window"`             // s analysed before closest approach
+	AlongLOS       float64 `json:"alongLos"`           // m, miss component along the endgame line of sight
+	CrossLOS       float64 `json:"crossLos"`           // m, miss component across it
+	TargetManeuver float64 `json:"targetManeuver"`     // m, displacement from target acceleration
+	GLimit         float64 `json:"gLimit"`             // m, displacement the missile could not pull
+	Saturated      float64 `json:"saturated"`          // s spent at the acceleration limit
+	SensorError    float64 `json:"sensorError"`        // m RMS, perceived against true target position while tracked
+	Dominant       string  `json:"dominant,omitempty"` // largest attributed cause
+}
+
+// endgame accumulates the terms a miss is attributed to. Accelerations are
+// integrated twice in closed form so the displacement at any later time tc
+// is tc*∫a dt - ∫a t dt.
+type endgame struct {
+	Open  bool
+	Start float64        // s, when the window opened
+	LOS   vector.Vector3 // unit line of sight when the window opened
+
+	TargetAccel, TargetAccelT vector.Vector3 // ∫a dt and ∫a t dt of target maneuver
+	Deficit, DeficitT         vector.Vector3 // same for commanded minus achieved acceleration
+	Saturated                 float64        // s
+	SensorSq                  float64        // ∫err² dt
+}
+
+// add accumulates one step of length dt starting at time t.
+func (e *endgame) add(t, dt float64, targetAccel, deficit vector.Vector3, sensorErr float64) {
+	e.TargetAccel.AddScaledInPlace(targetAccel, dt)
+	e.TargetAccelT.AddScaledInPlace(targetAccel, t*dt)
+	e.Deficit.AddScaledInPlace(deficit, dt)
+	e.DeficitT.AddScaledInPlace(deficit, t*dt)
+	if deficit != (vector.Vector3{}) {
+		e.Saturated += dt
+	}
+	e.SensorSq += sensorErr * sensorErr * dt
+}
+
+// analysis decomposes miss, the target's position relative to the missile
+// at closest approach time tc.
+func (e *endgame) analysis(tc float64, miss vector.Vector3) *MissAnalysis {
+	cross := func(v vector.Vector3) float64 {
+		return v.Reject(e.LOS).Magnitude()
+	}
+	a := &MissAnalysis{
+		Window:         tc - e.Start,
+		AlongLOS:       math.Abs(miss.Dot(e.LOS)),
+		CrossLOS:       cross(miss),
+		TargetManeuver: cross(e.TargetAccel.Mul(tc).Sub(e.TargetAccelT)),
+		GLimit:         cross(e.Deficit.Mul(tc).Sub(e.DeficitT)),
+		Saturated:      e.Saturated,
+	}
+	if a.Window > 0 {
+		a.SensorError = math.Sqrt(e.SensorSq / a.Window)
+	}
+	worst := 0.0
+	for _, c := range []struct {
+		name string
+		m    float64
+	}{
+		{MissTargetManeuver, a.TargetManeuver},
+		{MissGLimit, a.GLimit},
+		{MissSensor, a.SensorError},
+	} {
+		if c.m > worst {
+			a.Dominant, worst = c.name, c.m
+		}
+	}
+	return a
+}
+
+// endgameLocked opens the endgame window of each flying interceptor as it
+// comes within endgameWindow of its target and accumulates the step that is
+// about to be integrated. Callers must hold s.mu.
+func (s *Simulator) endgameLocked(now, dt float64) {
+	for _, ic := range s.Interceptors {
+		if ic.Status != "Flying" || !ic.Target.Live() {
+			continue
+		}
+		eg := &ic.endgame
+		if !eg.Open {
+			_, tgo, _ := closingGeometry(ic.Missile, ic.Target.Entity)
+			if tgo <= 0 || tgo > endgameWindow {
+				continue
+			}
+			eg.Open = true
+			eg.Start = now
+			eg.LOS = ic.Target.Entity.Position.Sub(ic.Missile.Position).Normalize()
+		}
+		maneuver := ic.Target.Entity.Acceleration
+		if ic.Target.Ballistic {
+			maneuver = vector.Vector3{} // falls exactly as the missile does
+		}
+		eg.add(now, dt, maneuver, ic.deficit, ic.sensorErr)
+	}
+}
@@ -0,0 +1,91 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestEndgameAnalysis(t *testing.T) {
+	const (
+		window = 2.0
+		dt     = 0.001
+	)
+	los := vector.Vector3{Z: 1}
+	lateral := vector.Vector3{X: 10} // m/s^2 across the line of sight
+	// Constant acceleration a for T seconds displaces by a*T^2/2.
+	drift := 0.5 * 10 * window * window
+
+	tests := []struct {
+		name      string
+		target    vector.Vector3
+		deficit   vector.Vector3
+		sensor    float64
+		miss      vector.Vector3
+		maneuver  float64
+		glimit    float64
+		saturated float64
+		dominant  string
+	}{
+		{
+			name:     "lateral target maneuver",
+			target:   lateral,
+			miss:     vector.Vector3{X: 3, Z: 4},
+			maneuver: drift,
+			dominant: MissTargetManeuver,
+		},
+		{
+			name:     "maneuver along the line of sight",
+			target:   vector.Vector3{Z: 10},
+			miss:     vector.Vector3{Z: 1},
+			maneuver: 0,
+		},
+		{
+			name:      "acceleration limit",
+			deficit:   lateral,
+			miss:      vector.Vector3{X: 3, Z: 4},
+			glimit:    drift,
+			saturated: window,
+			dominant:  MissGLimit,
+		},
+		{
+			name:     "sensor error",
+			sensor:   4,
+			miss:     vector.Vector3{X: 3, Z: 4},
+			dominant: MissSensor,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := endgame{Open: true, LOS: los}
+			for i := 0; i < window/dt; i++ {
+				e.add(float64(i)*dt, dt, tt.target, tt.deficit, tt.sensor)
+			}
+			a := e.analysis(window, tt.miss)
+			near := func(got, want float64) bool { return math.Abs(got-want) <= 0.01*math.Max(1, want) }
+
+			if !near(a.Window, window) {
+				t.Errorf("window = %g, want %g", a.Window, window)
+			}
+			if !near(a.AlongLOS, math.Abs(tt.miss.Z)) || !near(a.CrossLOS, math.Hypot(tt.miss.X, tt.miss.Y)) {
+				t.Errorf("along, cross = %g, %g for miss %+v", a.AlongLOS, a.CrossLOS, tt.miss)
+			}
+			if !near(a.TargetManeuver, tt.maneuver) {
+				t.Errorf("target maneuver = %g, want %g", a.TargetManeuver, tt.maneuver)
+			}
+			if !near(a.GLimit, tt.glimit) {
+				t.Errorf("g limit = %g, want %g", a.GLimit, tt.glimit)
+			}
+			if !near(a.Saturated, tt.saturated) {
+				t.Errorf("saturated = %g, want %g", a.Saturated, tt.saturated)
+			}
+			if !near(a.SensorError, tt.sensor) {
+				t.Errorf("sensor error = %g, want %g", a.SensorError, tt.sensor)
+			}
+			if a.Dominant != tt.dominant {
+				t.Errorf("dominant = %q, want %q", a.Dominant, tt.dominant)
+			}
+		})
+	}
+}
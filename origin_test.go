@@ -0,0 +1,38 @@
+package main
+
+import (
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCheckOrigin(t *testing.T) {
+	defer func(saved []string) { allowedOrigins = saved }(allowedOrigins)
+
+	tests := []struct {
+		name    string
+		allowed []string
+		origin  string
+		want    bool
+	}{
+		{"no origin header", nil, "", true},
+		{"same origin", nil, "http://sim.example:8080", true},
+		{"same origin, other scheme", nil, "https://sim.example:8080", true},
+		{"listed", []string{"https://ops.example"}, "https://ops.example", true},
+		{"listed, case differs", []string{"https://ops.example"}, "https://OPS.example", true},
+		{"not listed", []string{"https://ops.example"}, "https://evil.example", false},
+		{"other port", nil, "http://sim.example:9999", false},
+		{"wildcard", []string{"*"}, "https://evil.example", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowedOrigins = tt.allowed
+			r := httptest.NewRequest("GET", "http://sim.example:8080/ws", nil)
+			if tt.origin != "" {
+				r.Header.Set("Origin", tt.origin)
+			}
+			if got := checkOrigin(r); got != tt.want {
+				t.Errorf("checkOrigin(%q) with %v = %v, want %v", tt.origin, tt.allowed, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package simulation
+
+import (
+	"fmt"
+	"runtime"
+	"slices"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+)
+
+// Step-rate harness limits.
+const (
+	DefaultPerfSteps = 5000
+	MaxPerfSteps     = 1_000_000
+	perfRunLimit     = 600.0 // s of simulated time before a run that never ends is restarted
+)
+
+// PerfConfig selects what the step-rate harness measures.
+type PerfConfig struct {
+	Scenarios []string `json:"scenarios,omitempty"` // built-in names; empty for the whole library
+	Steps     int      `json:"steps,omitempty"`     // steps timed per scenario; 0 for DefaultPerfSteps
+	Seed      uint64   `json:"seed,omitempty"`      // 0 for a fixed default, so reports compare
+}
+
+// PerfResult is the step rate of one scenario. Runs that end are reset and
+// continued until Steps steps have been timed; the resets are not counted.
+type PerfResult struct {
+	Scenario      string  `json:"scenario"`
+	Entities      int     `json:"entities"`
+	Steps         int     `json:"steps"`
+	Resets        int     `json:"resets"`
+	StepsPerSec   float64 `json:"stepsPerSec"`
+	AllocsPerStep float64 `json:"allocsPerStep"`
+	BytesPerStep  float64 `json:"bytesPerStep"`
+	P50           float64 `json:"p50"` // ms
+	P99           float64 `json:"p99"` // ms
+	Max           float64 `json:"max"` // ms
+}
+
+// PerfReport is a step-rate baseline across scenarios, with enough about
+// the host to tell whether two reports are comparable.
+type PerfReport struct {
+	Config    PerfConfig   `json:"config"`
+	Results   []PerfResult `json:"results"`
+	GoVersion string       `json:"goVersion"`
+	OS        string       `json:"os"`
+	Arch      string       `json:"arch"`
+	CPUs      int          `json:"cpus"`
+	WallTime  float64      `json:"wallTime"` // seconds
+}
+
+// Validate checks the config and fills in its defaults.
+func (cfg *PerfConfig) Validate() error {
+	if cfg.Steps == 0 {
+		cfg.Steps = DefaultPerfSteps
+	}
+	if cfg.Steps < 0 || cfg.Steps > MaxPerfSteps {
+		return fmt.Errorf("steps must be between 1 and %d", MaxPerfSteps)
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = 1
+	}
+	if len(cfg.Scenarios) == 0 {
+		for _, sc := range scenario.Builtins() {
+			cfg.Scenarios = append(cfg.Scenarios, sc.Name)
+		}
+	}
+	for _, name := range cfg.Scenarios {
+		if _, ok := scenario.Builtin(name); !ok {
+			return fmt.Errorf("unknown scenario %q", name)
+		}
+	}
+	return nil
+}
+
+// RunPerf times the physics step of each scenario in turn, headless and on
+// one goroutine so the figures are not blurred by other work. It is meant
+// as a regression baseline: run it before and after a change on the same
+// host and compare.
+func RunPerf(cfg PerfConfig) (PerfReport, error) {
+	if err := cfg.Validate(); err != nil {
+		return PerfReport{}, err
+	}
+	start := time.Now()
+	report := PerfReport{
+		Config:    cfg,
+		GoVersion: runtime.Version(),
+		OS:        runtime.GOOS,
+		Arch:      runtime.GOARCH,
+		CPUs:      runtime.NumCPU(),
+	}
+	for _, name := range cfg.Scenarios {
+		sc, _ := scenario.Builtin(name)
+		report.Results = append(report.Results, perfScenario(sc, cfg.Steps, cfg.Seed))
+	}
+	report.WallTime = time.Since(start).Seconds()
+	return report, nil
+}
+
+// perfScenario times steps steps of sc.
+func perfScenario(sc *scenario.Scenario, steps int, seed uint64) PerfResult {
+	sim := NewSimulator()
+	sim.SetLogger(logging.Discard)
+	sim.HistoryDuration = 0
+	sim.TrailDuration = 0
+	sim.Seed = seed
+	sim.Scenario = sc
+	sim.Reset()
+
+	res := PerfResult{Scenario: sc.Name, Steps: steps, Entities: len(sim.GetState().Entities)}
+	lat := make([]time.Duration, 0, steps)
+	var before, after, resetBefore, resetAfter runtime.MemStats
+	var resetAllocs, resetBytes uint64
+
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	begin := time.Now()
+	var resetTime time.Duration
+	for len(lat) < steps {
+		t0 := time.Now()
+		n, err := sim.Advance(1)
+		d := time.Since(t0)
+		if n == 1 {
+			lat = append(lat, d)
+		}
+		if _, now := sim.progress(); err != nil || n == 0 || now >= perfRunLimit {
+			r0 := time.Now()
+			runtime.ReadMemStats(&resetBefore)
+			sim.Reset()
+			runtime.ReadMemStats(&resetAfter)
+			resetAllocs += resetAfter.Mallocs - resetBefore.Mallocs
+			resetBytes += resetAfter.TotalAlloc - resetBefore.TotalAlloc
+			resetTime += time.Since(r0)
+			res.Resets++
+		}
+	}
+	elapsed := time.Since(begin) - resetTime
+	runtime.ReadMemStats(&after)
+
+	res.StepsPerSec = float64(steps) / elapsed.Seconds()
+	res.AllocsPerStep = float64(after.Mallocs-before.Mallocs-resetAllocs) / float64(steps)
+	res.BytesPerStep = float64(after.TotalAlloc-before.TotalAlloc-resetBytes) / float64(steps)
+	slices.Sort(lat)
+	ms := func(d time.Duration) float64 { return float64(d) / 1e6 }
+	res.P50 = ms(lat[len(lat)/2])
+	res.P99 = ms(lat[min(len(lat)-1, len(lat)*99/100)])
+	res.Max = ms(lat[len(lat)-1])
+	return res
+}
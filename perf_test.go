@@ -0,0 +1,36 @@
+package simulation
+
+import "testing"
+
+func TestRunPerf(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     PerfConfig
+		wantErr bool
+	}{
+		{"default scenario", PerfConfig{Scenarios: []string{"default"}, Steps: 200}, false},
+		{"unknown scenario", PerfConfig{Scenarios: []string{"nope"}, Steps: 10}, true},
+		{"too many steps", PerfConfig{Steps: MaxPerfSteps + 1}, true},
+		{"negative steps", PerfConfig{Steps: -1}, true},
+	}
+	for _, tt := range tests {
+		report, err := RunPerf(tt.cfg)
+		if (err != nil) != tt.wantErr {
+			t.Fatalf("%s: err = %v, want error %v", tt.name, err, tt.wantErr)
+		}
+		if err != nil {
+			continue
+		}
+		if len(report.Results) != len(tt.cfg.Scenarios) {
+			t.Fatalf("%s: %d results, want %d", tt.name, len(report.Results), len(tt.cfg.Scenarios))
+		}
+		for _, r := range report.Results {
+			if r.Steps != tt.cfg.Steps || !(r.StepsPerSec > 0) {
+				t.Errorf("%s: %d steps at %g/s", tt.name, r.Steps, r.StepsPerSec)
+			}
+			if !(r.P50 <= r.P99 && r.P99 <= r.Max) {
+				t.Errorf("%s: latencies p50 %g, p99 %g, max %g out of order", tt.name, r.P50, r.P99, r.Max)
+			}
+		}
+	}
+}
@@ -0,0 +1,180 @@
+// Package intercept solves for when a pursuer flying straight at constant
+// speed can meet a target moving with constant velocity or constant
+// acceleration, and the polynomial equations that reduces to.
+package intercept
+
+import (
+	"math"
+	"slices"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Time returns the earliest time t > 0 at which a pursuer leaving the
+// origin at speed can meet a target at relative position r, velocity v and
+// constant acceleration a, or false if it never can. With a zero the
+// target flies straight and the equation is quadratic; otherwise it is the
+// quartic
+//
+//	|r + v t + ½ a t²|² = (speed t)².
+func Time(r, v, a vector.Vector3, speed float64) (float64, bool) {
+	c := r.Dot(r)
+	if c == 0 {
+		return 0, true
+	}
+	roots := Quartic(
+		a.Dot(a)/4,
+		a.Dot(v),
+		v.Dot(v)+a.Dot(r)-speed*speed,
+		2*r.Dot(v),
+		c,
+	)
+	for _, t := range roots {
+		if t > 0 {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// Point returns where the target is at time t.
+func Point(r, v, a vector.Vector3, t float64) vector.Vector3 {
+	return r.Add(v.Mul(t)).Add(a.Mul(t * t / 2))
+}
+
+// Linear returns the real root of b x + c = 0, if b is nonzero.
+func Linear(b, c float64) []float64 {
+	if b == 0 {
+		return nil
+	}
+	return []float64{-c / b}
+}
+
+// Quadratic returns the real roots of a x² + b x + c = 0 in ascending
+// order, a double root once. A zero a leaves a linear equation.
+func Quadratic(a, b, c float64) []float64 {
+	if a == 0 {
+		return Linear(b, c)
+	}
+	d := b*b - 4*a*c
+	switch {
+	case d < 0:
+		return nil
+	case d == 0:
+		return []float64{-b / (2 * a)}
+	}
+	// Avoid cancelling b against the root of the discriminant.
+	q := -(b + math.Copysign(math.Sqrt(d), b)) / 2
+	x1, x2 := q/a, c/q
+	if q == 0 {
+		x2 = -x1
+	}
+	return sorted(x1, x2)
+}
+
+// Cubic returns the real roots of a x³ + b x² + c x + d = 0 in ascending
+// order. A zero a leaves a quadratic.
+func Cubic(a, b, c, d float64) []float64 {
+	if a == 0 {
+		return Quadratic(b, c, d)
+	}
+	return roots([]float64{a, b, c, d})
+}
+
+// Quartic returns the real roots of a x⁴ + b x³ + c x² + d x + e = 0 in
+// ascending order. A zero a leaves a cubic.
+func Quartic(a, b, c, d, e float64) []float64 {
+	if a == 0 {
+		return Cubic(b, c, d, e)
+	}
+	return roots([]float64{a, b, c, d, e})
+}
+
+// roots finds the real roots of the polynomial with coefficients p, highest
+// power first and p[0] nonzero. Between consecutive roots of its derivative
+// the polynomial is monotonic, so each of those intervals holds at most one
+// root, found by bisection; the outermost intervals are closed off by
+// Cauchy's bound on the roots' size. This is slower than the closed forms
+// but does not lose roots to cancellation, which Ferrari's method does when
+// the target barely accelerates.
+func roots(p []float64) []float64 {
+	n := len(p) - 1
+	if n <= 2 {
+		if n == 1 {
+			return Linear(p[0], p[1])
+		}
+		return Quadratic(p[0], p[1], p[2])
+	}
+	deriv := make([]float64, n)
+	for i := range deriv {
+		deriv[i] = p[i] * float64(n-i)
+	}
+	bound := 0.0
+	for _, c := range p[1:] {
+		bound = max(bound, math.Abs(c/p[0]))
+	}
+	bound++
+	knots := append([]float64{-bound}, roots(deriv)...)
+	knots = append(knots, bound)
+	var out []float64
+	for i := 1; i < len(knots); i++ {
+		lo, hi := knots[i-1], knots[i]
+		flo, fhi := eval(p, lo), eval(p, hi)
+		switch {
+		case nearZero(p, lo):
+			// Most likely a double root, where p touches zero at a root of
+			// its derivative without changing sign.
+			out = append(out, lo)
+		case (flo < 0) != (fhi < 0) && !nearZero(p, hi):
+			out = append(out, bisect(p, lo, hi, flo))
+		}
+	}
+	return slices.Compact(out)
+}
+
+// bisect narrows [lo, hi], across which p changes sign, to its root.
+func bisect(p []float64, lo, hi, flo float64) float64 {
+	for range 200 {
+		mid := lo + (hi-lo)/2
+		if mid == lo || mid == hi {
+			break
+		}
+		fm := eval(p, mid)
+		if fm == 0 {
+			return mid
+		}
+		if (fm < 0) == (flo < 0) {
+			lo, flo = mid, fm
+		} else {
+			hi = mid
+		}
+	}
+	return lo + (hi-lo)/2
+}
+
+// nearZero reports whether p(x) is zero to within the rounding error of
+// evaluating it.
+func nearZero(p []float64, x float64) bool {
+	y, scale := 0.0, 0.0
+	for _, c := range p {
+		y = y*x + c
+		scale = scale*math.Abs(x) + math.Abs(c)
+	}
+	return math.Abs(y) <= 1e-12*scale
+}
+
+// eval evaluates p at x by Horner's rule.
+func eval(p []float64, x float64) float64 {
+	y := 0.0
+	for _, c := range p {
+		y = y*x + c
+	}
+	return y
+}
+
+func sorted(x1, x2 float64) []float64 {
+	if x1 > x2 {
+		x1, x2 = x2, x1
+	}
+	return []float64{x1, x2}
+}
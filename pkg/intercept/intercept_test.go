@@ -0,0 +1,91 @@
+package intercept
+
+import (
+	"math"
+	"slices"
+	"testing"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+func sameRoots(got, want []float64) bool {
+	if len(got) != len(want) {
+		return false
+	}
+	for i := range got {
+		if math.Abs(got[i]-want[i]) > 1e-7*max(1, math.Abs(want[i])) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPolynomials(t *testing.T) {
+	tests := []struct {
+		name string
+		got  []float64
+		want []float64
+	}{
+		{"linear", Quadratic(0, 2, -3), []float64{1.5}},
+		{"constant", Quadratic(0, 0, 1), nil},
+		{"two roots", Quadratic(1, -3, 2), []float64{1, 2}},
+		{"double root", Quadratic(1, -2, 1), []float64{1}},
+		{"no real roots", Quadratic(1, 0, 1), nil},
+		{"cancellation", Quadratic(1, -1e8, 1), []float64{1e-8, 1e8}},
+		{"roots at zero", Quadratic(2, 0, 0), []float64{0}},
+		{"cubic, three roots", Cubic(1, -6, 11, -6), []float64{1, 2, 3}},
+		{"cubic, one root", Cubic(1, 0, 1, -2), []float64{1}},
+		{"cubic, double root", Cubic(1, -4, 5, -2), []float64{1, 2}},
+		{"quartic, four roots", Quartic(1, -10, 35, -50, 24), []float64{1, 2, 3, 4}},
+		{"quartic, two roots", Quartic(1, 0, -1, 0, -2), []float64{-math.Sqrt2, math.Sqrt2}},
+		{"quartic, none", Quartic(1, 0, 2, 0, 1), nil},
+		{"quartic, double roots", Quartic(1, -2, -3, 4, 4), []float64{-1, 2}},
+		{"quartic, scaled", Quartic(1e-6, -1e-5, 3.5e-5, -5e-5, 2.4e-5), []float64{1, 2, 3, 4}},
+		{"quartic, spread", Quartic(1, -1111, 112110, -1111000, 1e6), []float64{1, 10, 100, 1000}},
+		{"quartic degenerate", Quartic(0, 0, 1, -3, 2), []float64{1, 2}},
+	}
+	for _, tt := range tests {
+		if !sameRoots(tt.got, tt.want) || !slices.IsSorted(tt.got) {
+			t.Errorf("%s: roots %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestTime(t *testing.T) {
+	g := vector.Vector3{Y: -9.81}
+	tests := []struct {
+		name    string
+		r, v, a vector.Vector3
+		speed   float64
+		ok      bool
+		want    float64 // s, 0 to check only that the pursuer arrives
+	}{
+		{"stationary", vector.Vector3{X: 3000}, vector.Vector3{}, vector.Vector3{}, 300, true, 10},
+		{"head-on", vector.Vector3{Z: 10000}, vector.Vector3{Z: -200}, vector.Vector3{}, 300, true, 20},
+		{"tail chase", vector.Vector3{Z: 1000}, vector.Vector3{Z: 200}, vector.Vector3{}, 300, true, 10},
+		{"crossing", vector.Vector3{Z: 4000}, vector.Vector3{X: 300}, vector.Vector3{}, 500, true, 10},
+		{"outrun", vector.Vector3{Z: 1000}, vector.Vector3{Z: 400}, vector.Vector3{}, 300, false, 0},
+		{"falling onto the pursuer", vector.Vector3{Y: 490.5}, vector.Vector3{}, g, 0, true, 10},
+		{"caught just as it pulls away", vector.Vector3{Z: 1000}, vector.Vector3{Z: 100}, vector.Vector3{Z: 20}, 300, true, 10},
+		{"accelerating away", vector.Vector3{Z: 1000}, vector.Vector3{Z: 100}, vector.Vector3{Z: 30}, 300, false, 0},
+		{"ballistic crossing", vector.Vector3{X: 8000, Y: 3000}, vector.Vector3{X: -400, Y: 300}, g, 600, true, 0},
+		{"at the origin", vector.Vector3{}, vector.Vector3{X: 1}, vector.Vector3{}, 0, true, 0},
+	}
+	for _, tt := range tests {
+		got, ok := Time(tt.r, tt.v, tt.a, tt.speed)
+		if ok != tt.ok {
+			t.Errorf("%s: intercept %v at %g s, want %v", tt.name, ok, got, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if tt.want != 0 && math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("%s: %g s, want %g s", tt.name, got, tt.want)
+		}
+		// However it was found, the pursuer must arrive when the target does.
+		if p := Point(tt.r, tt.v, tt.a, got); math.Abs(p.Magnitude()-tt.speed*got) > 1e-6*max(1, p.Magnitude()) {
+			t.Errorf("%s: target %g m out at %g s, pursuer %g m", tt.name, p.Magnitude(), got, tt.speed*got)
+		}
+	}
+}
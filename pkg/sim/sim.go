@@ -0,0 +1,192 @@
+// Package sim flies intercept scenarios inside another Go program, with no
+// HTTP server: load a scenario, run it to the end or step through it, and
+// subscribe to its events as they happen.
+//
+//	s, err := sim.NewSimulator(sim.Options{Seed: 42, Guidance: "ProNav"})
+//	if err != nil { ... }
+//	sc, _ := sim.Builtin("crossing")
+//	if err := s.LoadScenario(sc); err != nil { ... }
+//	report, err := s.RunToCompletion(ctx)
+//
+// A Simulator is safe for concurrent use, so one goroutine may run it while
+// others read its state or events.
+package sim
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// The types a Simulator takes and gives, so callers need import only this
+// package.
+type (
+	Scenario     = scenario.Scenario
+	State        = simulation.SimulationState
+	Event        = simulation.Event
+	Report       = simulation.OutcomeReport
+	Subscription = simulation.Subscription
+
+	// TransitionError is returned for an operation the run's stage does not
+	// allow, such as running one that has finished.
+	TransitionError = simulation.TransitionError
+)
+
+// DefaultMaxTime is the simulated time, in s, a run with no MaxTime of
+// its own is stopped at as a timeout.
+const DefaultMaxTime = simulation.DefaultMaxTime
+
+// Options configure a Simulator. The zero value flies each scenario as
+// written, with a fresh seed per run and nothing logged or recorded.
+type Options struct {
+	Seed      uint64       // replays a run bit for bit; 0 uses the scenario's, or draws one
+	Dt        float64      // s per step; 0 for the server's 0.016
+	Guidance  string       // law for every interceptor, one of GuidanceModes; empty keeps the scenario's
+	MaxTime   float64      // s of simulated time before RunToCompletion stops a run as a timeout; 0 for DefaultMaxTime
+	Logger    *slog.Logger // receives the simulator's log; nil discards it
+	RecordDir string       // where each run is recorded as it is flown; empty records nothing
+}
+
+// Simulator flies one scenario at a time.
+type Simulator struct {
+	sim  *simulation.Simulator
+	opts Options
+}
+
+// NewSimulator returns a simulator set up by opts with the default
+// scenario loaded. Close it when done if it records.
+func NewSimulator(opts Options) (*Simulator, error) {
+	if err := simulation.CheckGuidanceMode(opts.Guidance); err != nil {
+		return nil, err
+	}
+	if opts.Dt < 0 || opts.MaxTime < 0 {
+		return nil, fmt.Errorf("dt and max time must not be negative")
+	}
+	if opts.MaxTime == 0 {
+		opts.MaxTime = DefaultMaxTime
+	}
+	s := &Simulator{sim: simulation.NewSimulator(), opts: opts}
+	if opts.Logger == nil {
+		opts.Logger = logging.Discard
+	}
+	s.sim.SetLogger(opts.Logger)
+	if opts.Dt > 0 {
+		s.sim.Dt = opts.Dt
+	}
+	s.sim.Seed = opts.Seed
+	s.sim.RecordDir = opts.RecordDir
+	// Library runs are read back through the recording, not the server's
+	// history and trails.
+	s.sim.HistoryDuration = 0
+	s.sim.TrailDuration = 0
+	if err := s.LoadScenario(scenario.Default()); err != nil {
+		return nil, err
+	}
+	if opts.RecordDir != "" {
+		if err := s.sim.SetRecording(true); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// LoadScenario validates sc and resets the simulator to fly it. sc is not
+// modified, and may be loaded into other simulators.
+func (s *Simulator) LoadScenario(sc *Scenario) error {
+	if s.opts.Guidance != "" {
+		sc = sc.Clone()
+		for i := range sc.Entities {
+			if e := &sc.Entities[i]; e.Role == scenario.RoleInterceptor {
+				e.Guidance = s.opts.Guidance
+			}
+		}
+	}
+	return s.sim.LoadScenario(sc)
+}
+
+// LoadScenarioJSON loads a scenario in the server's JSON format.
+func (s *Simulator) LoadScenarioJSON(r io.Reader) error {
+	sc, err := scenario.Decode(r)
+	if err != nil {
+		return err
+	}
+	return s.LoadScenario(sc)
+}
+
+// Reset starts the loaded scenario over, as a new run.
+func (s *Simulator) Reset() {
+	s.sim.Reset()
+}
+
+// RunToCompletion flies the current run as fast as it will go until it
+// ends or reaches Options.MaxTime, and returns its report. If ctx is done
+// first, it returns ctx's error and leaves the run paused where it stopped,
+// to be resumed by another call. Once the run has finished it fails with a
+// *TransitionError until Reset. A run that panics fails with an error, its
+// Fault event holding the stack.
+func (s *Simulator) RunToCompletion(ctx context.Context) (*Report, error) {
+	st, err := s.sim.RunContext(ctx, s.opts.MaxTime)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status == simulation.StatusFaulted {
+		return nil, fmt.Errorf("run faulted at %g s; its Fault event has the stack", st.Time)
+	}
+	return s.sim.Result(), nil
+}
+
+// Step flies up to n steps of Options.Dt, stopping early if the run ends,
+// and returns how many it took. It fails once the run has ended.
+func (s *Simulator) Step(n int) (int, error) {
+	return s.sim.Advance(n)
+}
+
+// State returns a copy of the current state.
+func (s *Simulator) State() State {
+	return s.sim.GetState()
+}
+
+// Result returns the report of the last finished run, or nil if none has
+// finished.
+func (s *Simulator) Result() *Report {
+	return s.sim.Result()
+}
+
+// Events returns the current run's events numbered after since, oldest
+// first; 0 returns them all.
+func (s *Simulator) Events(since uint64) []Event {
+	return s.sim.Events(since)
+}
+
+// Subscribe delivers each event on the returned subscription's C as it is
+// logged, with room for buffer undelivered ones; events that find it full
+// are counted as dropped. Close the subscription when done.
+func (s *Simulator) Subscribe(buffer int) *Subscription {
+	return s.sim.Subscribe(buffer)
+}
+
+// Close finishes the recording in progress, returning once it is on disk.
+// The simulator stays usable, and recording resumes with the next run.
+func (s *Simulator) Close() {
+	s.sim.Close()
+}
+
+// GuidanceModes lists the guidance laws Options.Guidance takes.
+func GuidanceModes() []string {
+	return simulation.ValidGuidanceModes()
+}
+
+// Builtin returns a copy of the built-in scenario with the given name.
+func Builtin(name string) (*Scenario, bool) {
+	return scenario.Builtin(name)
+}
+
+// Builtins returns copies of the built-in scenarios.
+func Builtins() []*Scenario {
+	return scenario.Builtins()
+}
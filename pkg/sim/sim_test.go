@@ -0,0 +1,136 @@
+package sim
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestNewSimulatorOptions(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    Options
+		wantErr string
+	}{
+		{"zero", Options{}, ""},
+		{"guidance", Options{Guidance: "LeadPursuit"}, ""},
+		{"unknown guidance", Options{Guidance: "Magic"}, "unknown guidance"},
+		{"negative dt", Options{Dt: -1}, "negative"},
+		{"negative max time", Options{MaxTime: -1}, "negative"},
+		{"recording", Options{RecordDir: t.TempDir()}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := NewSimulator(tt.opts)
+			if err == nil {
+				defer s.Close()
+			}
+			if tt.wantErr == "" && err != nil || tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Errorf("err %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunToCompletion(t *testing.T) {
+	run := func(opts Options) (*Report, []Event) {
+		t.Helper()
+		s, err := NewSimulator(opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		sc, _ := Builtin("crossing")
+		if err := s.LoadScenario(sc); err != nil {
+			t.Fatal(err)
+		}
+		sub := s.Subscribe(1000)
+		defer sub.Close()
+		report, err := s.RunToCompletion(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []Event
+		for len(sub.C) > 0 {
+			got = append(got, <-sub.C)
+		}
+		return report, got
+	}
+
+	a, events := run(Options{Seed: 7, Guidance: "PurePursuit"})
+	if a == nil || a.Scenario != "crossing" || a.Seed != 7 {
+		t.Fatalf("report %+v", a)
+	}
+	for _, eng := range a.Engagements {
+		if eng.Guidance != "PurePursuit" {
+			t.Errorf("%s flew %s, want the PurePursuit override", eng.MissileID, eng.Guidance)
+		}
+	}
+	if len(events) == 0 || events[len(events)-1].Type != simulation.EventPhase {
+		t.Errorf("subscribed events %+v, want them to end with the run's phase change", events)
+	}
+	for i := 1; i < len(events); i++ {
+		if events[i].Seq != events[i-1].Seq+1 {
+			t.Fatalf("events %d and %d not consecutive", events[i-1].Seq, events[i].Seq)
+		}
+	}
+
+	b, _ := run(Options{Seed: 7, Guidance: "PurePursuit"})
+	if a.Result != b.Result || a.Time != b.Time || a.MissDistance != b.MissDistance {
+		t.Errorf("seeded runs differ: %s at %g s (%g m) vs %s at %g s (%g m)", a.Result, a.Time, a.MissDistance, b.Result, b.Time, b.MissDistance)
+	}
+
+	short, _ := run(Options{Seed: 7, MaxTime: 1})
+	if short.Result != "Timeout" || short.Time > 1.1 {
+		t.Errorf("1 s limit ended %s at %g s", short.Result, short.Time)
+	}
+}
+
+func TestRunToCompletionCanceled(t *testing.T) {
+	s, err := NewSimulator(Options{Seed: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := s.RunToCompletion(ctx); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err %v, want context.Canceled", err)
+	}
+	if s.Result() != nil {
+		t.Fatalf("a canceled run finished")
+	}
+	stopped := s.State().Time
+	if stopped <= 0 {
+		t.Fatalf("time %g after a canceled run", stopped)
+	}
+	report, err := s.RunToCompletion(context.Background())
+	if err != nil || report == nil || report.Time <= stopped {
+		t.Errorf("resumed run: %+v, %v", report, err)
+	}
+	if _, err := s.Step(1); err == nil {
+		t.Errorf("stepped a finished run")
+	}
+}
+
+func TestRecordDir(t *testing.T) {
+	dir := t.TempDir()
+	s, err := NewSimulator(Options{Seed: 3, MaxTime: 1, RecordDir: dir})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RunToCompletion(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s.Close()
+	names, _ := filepath.Glob(filepath.Join(dir, "*.json"))
+	if len(names) != 1 {
+		t.Fatalf("recordings %v, want one", names)
+	}
+	rec, err := simulation.LoadRecording(dir, strings.TrimSuffix(filepath.Base(names[0]), ".json"))
+	if err != nil || rec.Seed != 3 || len(rec.Frames) == 0 {
+		t.Errorf("recording seed %d with %d frames, %v", rec.Seed, len(rec.Frames), err)
+	}
+}
@@ -0,0 +1,114 @@
+package vector
+
+import "testing"
+
+// The per-step math of a few hundred entities: guidance, attitude and an
+// Euler step. None of it should allocate; run with -benchmem to confirm.
+
+const benchEntities = 500
+
+type benchBody struct {
+	pos, vel, acc Vector3
+	att           Quaternion
+}
+
+func benchBodies() []benchBody {
+	bodies := make([]benchBody, benchEntities)
+	for i := range bodies {
+		f := float64(i)
+		bodies[i] = benchBody{
+			pos: Vector3{f * 10, 1000 + f, -f * 5},
+			vel: Vector3{200, f / 10, 300},
+			acc: Vector3{0, -9.81, f / 100},
+			att: AxisAngle(Vector3{0, 1, 0}, f/100),
+		}
+	}
+	return bodies
+}
+
+func BenchmarkStepChained(b *testing.B) {
+	bodies, dt := benchBodies(), 0.01
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range bodies {
+			e := &bodies[i]
+			e.pos = e.pos.Add(e.vel.Mul(dt)).Add(e.acc.Mul(0.5 * dt * dt))
+			e.vel = e.vel.Add(e.acc.Mul(dt))
+		}
+	}
+}
+
+func BenchmarkStepInPlace(b *testing.B) {
+	bodies, dt := benchBodies(), 0.01
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range bodies {
+			e := &bodies[i]
+			e.pos.AddScaledInPlace(e.vel, dt)
+			e.pos.AddScaledInPlace(e.acc, 0.5*dt*dt)
+			e.vel.AddScaledInPlace(e.acc, dt)
+		}
+	}
+}
+
+func BenchmarkGuidance(b *testing.B) {
+	bodies := benchBodies()
+	target := Vector3{5000, 3000, 8000}
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range bodies {
+			e := &bodies[i]
+			los := target.Sub(e.pos)
+			omega := los.Cross(e.vel.Mul(-1)).Div(los.MagnitudeSquared())
+			e.acc = omega.Cross(e.vel).Mul(4).ClampMagnitude(300)
+		}
+	}
+}
+
+func BenchmarkNormalize(b *testing.B) {
+	bodies := benchBodies()
+	var sink Vector3
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range bodies {
+			sink = sink.Add(bodies[i].vel.Normalize())
+		}
+	}
+	_ = sink
+}
+
+func BenchmarkNormalizeOr(b *testing.B) {
+	bodies := benchBodies()
+	var sink Vector3
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range bodies {
+			sink = sink.Add(bodies[i].vel.NormalizeOr(Vector3{Z: 1}))
+		}
+	}
+	_ = sink
+}
+
+func BenchmarkQuaternionRotate(b *testing.B) {
+	bodies := benchBodies()
+	var sink Vector3
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range bodies {
+			sink = sink.Add(bodies[i].att.Rotate(bodies[i].vel))
+		}
+	}
+	_ = sink
+}
+
+func BenchmarkMatrixMulVec(b *testing.B) {
+	bodies := benchBodies()
+	var sink Vector3
+	b.ReportAllocs()
+	for b.Loop() {
+		for i := range bodies {
+			sink = sink.Add(bodies[i].att.Matrix().MulVec(bodies[i].vel))
+		}
+	}
+	_ = sink
+}
@@ -0,0 +1,29 @@
+package vector
+
+import "testing"
+
+func TestInPlace(t *testing.T) {
+	a, b := Vector3{1, 2, 3}, Vector3{5, -2, 7}
+	tests := []struct {
+		name string
+		got  func() Vector3
+		want Vector3
+	}{
+		{"AddScaled", func() Vector3 { return a.AddScaled(b, 0.5) }, a.Add(b.Mul(0.5))},
+		{"AddInPlace", func() Vector3 { v := a; v.AddInPlace(b); return v }, a.Add(b)},
+		{"SubInPlace", func() Vector3 { v := a; v.SubInPlace(b); return v }, a.Sub(b)},
+		{"ScaleInPlace", func() Vector3 { v := a; v.ScaleInPlace(-3); return v }, a.Mul(-3)},
+		{"AddScaledInPlace", func() Vector3 { v := a; v.AddScaledInPlace(b, 0.01); return v }, a.Add(b.Mul(0.01))},
+	}
+	for _, tt := range tests {
+		if got := tt.got(); !near(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+	if got, want := b.MagnitudeSquared(), b.Magnitude()*b.Magnitude(); got-want > 1e-9 || want-got > 1e-9 {
+		t.Errorf("MagnitudeSquared %g, want %g", got, want)
+	}
+	if got, want := a.DistanceSquared(b), a.Sub(b).Dot(a.Sub(b)); got != want {
+		t.Errorf("DistanceSquared %g, want %g", got, want)
+	}
+}
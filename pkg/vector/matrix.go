@@ -0,0 +1,176 @@
+package vector
+
+import "math"
+
+// Matrix3 is a 3x3 matrix, indexed [row][column]. A rotation matrix, or
+// direction cosine matrix (DCM), maps vectors in a rotated frame, such as a
+// body's, to the reference frame: v_ref = C v_body, and back with the
+// transpose.
+type Matrix3 [3][3]float64
+
+// Identity3 is the 3x3 identity matrix.
+var Identity3 = Matrix3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}}
+
+// Diagonal returns the matrix with d on its diagonal and zeros elsewhere,
+// such as a covariance of independent axes.
+func Diagonal(d Vector3) Matrix3 {
+	return Matrix3{{d.X, 0, 0}, {0, d.Y, 0}, {0, 0, d.Z}}
+}
+
+// Outer returns the outer product a bᵀ.
+func Outer(a, b Vector3) Matrix3 {
+	return Matrix3{
+		{a.X * b.X, a.X * b.Y, a.X * b.Z},
+		{a.Y * b.X, a.Y * b.Y, a.Y * b.Z},
+		{a.Z * b.X, a.Z * b.Y, a.Z * b.Z},
+	}
+}
+
+// FromAxes returns the DCM of a frame whose X, Y and Z axes, given in the
+// reference frame, are x, y and z: they become its columns.
+func FromAxes(x, y, z Vector3) Matrix3 {
+	return Matrix3{
+		{x.X, y.X, z.X},
+		{x.Y, y.Y, z.Y},
+		{x.Z, y.Z, z.Z},
+	}
+}
+
+// RotationX returns the rotation by angle radians about the X axis,
+// counter-clockwise looking down the axis in a right-handed frame.
+func RotationX(angle float64) Matrix3 {
+	s, c := math.Sincos(angle)
+	return Matrix3{{1, 0, 0}, {0, c, -s}, {0, s, c}}
+}
+
+// RotationY returns the rotation by angle radians about the Y axis.
+func RotationY(angle float64) Matrix3 {
+	s, c := math.Sincos(angle)
+	return Matrix3{{c, 0, s}, {0, 1, 0}, {-s, 0, c}}
+}
+
+// RotationZ returns the rotation by angle radians about the Z axis.
+func RotationZ(angle float64) Matrix3 {
+	s, c := math.Sincos(angle)
+	return Matrix3{{c, -s, 0}, {s, c, 0}, {0, 0, 1}}
+}
+
+// DCMFromEuler returns the DCM of a body rotated by yaw, pitch and roll in
+// radians, in the sequence FromEuler documents.
+func DCMFromEuler(roll, pitch, yaw float64) Matrix3 {
+	return RotationZ(yaw).Mul(RotationY(pitch)).Mul(RotationX(roll))
+}
+
+// Column returns column i of m, which for a DCM is the rotated frame's
+// axis i in the reference frame.
+func (m Matrix3) Column(i int) Vector3 {
+	return Vector3{X: m[0][i], Y: m[1][i], Z: m[2][i]}
+}
+
+// Mul returns the matrix product m n.
+func (m Matrix3) Mul(n Matrix3) Matrix3 {
+	var p Matrix3
+	for i := range 3 {
+		for j := range 3 {
+			p[i][j] = m[i][0]*n[0][j] + m[i][1]*n[1][j] + m[i][2]*n[2][j]
+		}
+	}
+	return p
+}
+
+// MulVec returns m v: for a DCM, v expressed in the reference frame.
+func (m Matrix3) MulVec(v Vector3) Vector3 {
+	return Vector3{
+		X: m[0][0]*v.X + m[0][1]*v.Y + m[0][2]*v.Z,
+		Y: m[1][0]*v.X + m[1][1]*v.Y + m[1][2]*v.Z,
+		Z: m[2][0]*v.X + m[2][1]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+// MulVecT returns mᵀ v without forming the transpose: for a DCM, a
+// reference-frame v expressed in the rotated frame.
+func (m Matrix3) MulVecT(v Vector3) Vector3 {
+	return Vector3{
+		X: m[0][0]*v.X + m[1][0]*v.Y + m[2][0]*v.Z,
+		Y: m[0][1]*v.X + m[1][1]*v.Y + m[2][1]*v.Z,
+		Z: m[0][2]*v.X + m[1][2]*v.Y + m[2][2]*v.Z,
+	}
+}
+
+// Add returns m + n.
+func (m Matrix3) Add(n Matrix3) Matrix3 {
+	for i := range 3 {
+		for j := range 3 {
+			m[i][j] += n[i][j]
+		}
+	}
+	return m
+}
+
+// Scale returns m with every element multiplied by s.
+func (m Matrix3) Scale(s float64) Matrix3 {
+	for i := range 3 {
+		for j := range 3 {
+			m[i][j] *= s
+		}
+	}
+	return m
+}
+
+// Transpose returns mᵀ, the inverse of a rotation.
+func (m Matrix3) Transpose() Matrix3 {
+	return Matrix3{
+		{m[0][0], m[1][0], m[2][0]},
+		{m[0][1], m[1][1], m[2][1]},
+		{m[0][2], m[1][2], m[2][2]},
+	}
+}
+
+// Det returns the determinant of m, 1 for a rotation.
+func (m Matrix3) Det() float64 {
+	return m[0][0]*(m[1][1]*m[2][2]-m[1][2]*m[2][1]) -
+		m[0][1]*(m[1][0]*m[2][2]-m[1][2]*m[2][0]) +
+		m[0][2]*(m[1][0]*m[2][1]-m[1][1]*m[2][0])
+}
+
+// Trace returns the sum of m's diagonal.
+func (m Matrix3) Trace() float64 {
+	return m[0][0] + m[1][1] + m[2][2]
+}
+
+// Inverse returns m⁻¹, or false if m is singular. Rotations are better
+// inverted with Transpose.
+func (m Matrix3) Inverse() (Matrix3, bool) {
+	det := m.Det()
+	if det == 0 || math.IsNaN(det) {
+		return Matrix3{}, false
+	}
+	// The adjugate, the transpose of the cofactors, over the determinant.
+	inv := Matrix3{
+		{m[1][1]*m[2][2] - m[1][2]*m[2][1], m[0][2]*m[2][1] - m[0][1]*m[2][2], m[0][1]*m[1][2] - m[0][2]*m[1][1]},
+		{m[1][2]*m[2][0] - m[1][0]*m[2][2], m[0][0]*m[2][2] - m[0][2]*m[2][0], m[0][2]*m[1][0] - m[0][0]*m[1][2]},
+		{m[1][0]*m[2][1] - m[1][1]*m[2][0], m[0][1]*m[2][0] - m[0][0]*m[2][1], m[0][0]*m[1][1] - m[0][1]*m[1][0]},
+	}
+	return inv.Scale(1 / det), true
+}
+
+// TransformCovariance returns m p mᵀ: covariance p of a vector carried
+// into the frame, or through the linear map, m.
+func (m Matrix3) TransformCovariance(p Matrix3) Matrix3 {
+	return m.Mul(p).Mul(m.Transpose())
+}
+
+// Orthonormalize rebuilds m as a rotation from its first two columns by
+// Gram-Schmidt, keeping the X axis's direction, to undo the drift of
+// integrating a DCM.
+func (m Matrix3) Orthonormalize() Matrix3 {
+	x := m.Column(0).Normalize()
+	y := m.Column(1)
+	y = y.Sub(x.Mul(x.Dot(y))).Normalize()
+	return FromAxes(x, y, x.Cross(y))
+}
+
+// Quaternion returns the unit quaternion of rotation m.
+func (m Matrix3) Quaternion() Quaternion {
+	return QuaternionFromMatrix(m)
+}
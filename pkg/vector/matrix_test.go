@@ -0,0 +1,105 @@
+package vector
+
+import (
+	"math"
+	"testing"
+)
+
+func nearMatrix(a, b Matrix3) bool {
+	for i := range 3 {
+		for j := range 3 {
+			if math.Abs(a[i][j]-b[i][j]) > 1e-9 {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func TestMatrixRotations(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix3
+		q    Quaternion
+	}{
+		{"X", RotationX(0.4), AxisAngle(Vector3{X: 1}, 0.4)},
+		{"Y", RotationY(-1.1), AxisAngle(Vector3{Y: 1}, -1.1)},
+		{"Z", RotationZ(2.9), AxisAngle(Vector3{Z: 1}, 2.9)},
+		{"Euler", DCMFromEuler(0.3, -0.7, 1.9), FromEuler(0.3, -0.7, 1.9)},
+		{"axes", FromAxes(Vector3{Y: 1}, Vector3{X: -1}, Vector3{Z: 1}), AxisAngle(Vector3{Z: 1}, math.Pi/2)},
+	}
+	v := Vector3{1, -2, 3}
+	for _, tt := range tests {
+		if !nearMatrix(tt.m, tt.q.Matrix()) {
+			t.Errorf("%s: %v, want %v", tt.name, tt.m, tt.q.Matrix())
+		}
+		if got, want := tt.m.MulVec(v), tt.q.Rotate(v); !near(got, want) {
+			t.Errorf("%s: rotated %v to %v, want %v", tt.name, v, got, want)
+		}
+		if got := tt.m.MulVecT(tt.m.MulVec(v)); !near(got, v) {
+			t.Errorf("%s: Cᵀ C v = %v", tt.name, got)
+		}
+		if !nearMatrix(tt.m.Mul(tt.m.Transpose()), Identity3) || math.Abs(tt.m.Det()-1) > 1e-12 {
+			t.Errorf("%s: not a rotation", tt.name)
+		}
+		if !sameRotation(tt.m.Quaternion(), tt.q) {
+			t.Errorf("%s: quaternion %v, want %v", tt.name, tt.m.Quaternion(), tt.q)
+		}
+	}
+}
+
+func TestMatrixInverse(t *testing.T) {
+	tests := []struct {
+		name string
+		m    Matrix3
+		ok   bool
+	}{
+		{"identity", Identity3, true},
+		{"general", Matrix3{{2, 1, 0}, {1, 3, 1}, {0, 1, 4}}, true},
+		{"diagonal", Diagonal(Vector3{2, 4, 8}), true},
+		{"singular", Outer(Vector3{1, 2, 3}, Vector3{4, 5, 6}), false},
+		{"zero", Matrix3{}, false},
+	}
+	for _, tt := range tests {
+		inv, ok := tt.m.Inverse()
+		if ok != tt.ok {
+			t.Errorf("%s: invertible %v, want %v", tt.name, ok, tt.ok)
+			continue
+		}
+		if ok && (!nearMatrix(tt.m.Mul(inv), Identity3) || !nearMatrix(inv.Mul(tt.m), Identity3)) {
+			t.Errorf("%s: m m⁻¹ = %v", tt.name, tt.m.Mul(inv))
+		}
+	}
+}
+
+func TestMatrixOps(t *testing.T) {
+	m := Matrix3{{1, 2, 3}, {4, 5, 6}, {7, 8, 10}}
+	if got := m.Transpose().Transpose(); got != m {
+		t.Errorf("double transpose %v", got)
+	}
+	if m.Det() != -3 || m.Trace() != 16 {
+		t.Errorf("det %g, trace %g", m.Det(), m.Trace())
+	}
+	if got := m.Add(m.Scale(-1)); got != (Matrix3{}) {
+		t.Errorf("m - m = %v", got)
+	}
+	if got := Outer(Vector3{1, 2, 3}, Vector3{0, 1, 0}).Column(1); got != (Vector3{1, 2, 3}) {
+		t.Errorf("outer product column %v", got)
+	}
+
+	// A covariance along X, carried into a frame turned a quarter about Z,
+	// lies along Y.
+	p := Diagonal(Vector3{9, 1, 1})
+	if got := RotationZ(math.Pi / 2).TransformCovariance(p); !nearMatrix(got, Diagonal(Vector3{1, 9, 1})) {
+		t.Errorf("rotated covariance %v", got)
+	}
+
+	drifted := DCMFromEuler(0.2, 0.4, -1).Add(Matrix3{{1e-3, 2e-3, 0}, {0, -1e-3, 1e-3}, {2e-3, 0, 1e-3}})
+	fixed := drifted.Orthonormalize()
+	if !nearMatrix(fixed.Mul(fixed.Transpose()), Identity3) || math.Abs(fixed.Det()-1) > 1e-12 {
+		t.Errorf("orthonormalized %v is not a rotation", fixed)
+	}
+	if d := fixed.Column(0).Dot(drifted.Column(0).Normalize()); math.Abs(d-1) > 1e-12 {
+		t.Errorf("X axis moved: %g", d)
+	}
+}
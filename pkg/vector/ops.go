@@ -0,0 +1,71 @@
+package vector
+
+import "math"
+
+// IsFinite reports whether every component of v is a number and finite.
+func (v Vector3) IsFinite() bool {
+	return !math.IsNaN(v.X+v.Y+v.Z) && !math.IsInf(v.X+v.Y+v.Z, 0)
+}
+
+// NormalizeOr returns v scaled to unit length, or fallback when v has no
+// usable direction: zero, too small to divide by, or not finite.
+func (v Vector3) NormalizeOr(fallback Vector3) Vector3 {
+	// Scale by the largest component first so tiny and huge vectors keep
+	// their direction instead of underflowing or overflowing the length.
+	s := max(math.Abs(v.X), math.Abs(v.Y), math.Abs(v.Z))
+	if s == 0 || math.IsInf(s, 0) || math.IsNaN(v.X+v.Y+v.Z) {
+		return fallback
+	}
+	v = v.Div(s)
+	return v.Div(v.Magnitude())
+}
+
+// Lerp returns the point a fraction t of the way from v to w: v at t = 0,
+// w at t = 1, extrapolating beyond.
+func (v Vector3) Lerp(w Vector3, t float64) Vector3 {
+	return Vector3{v.X + t*(w.X-v.X), v.Y + t*(w.Y-v.Y), v.Z + t*(w.Z-v.Z)}
+}
+
+// Clamp limits each component of v to the range given by lo and hi.
+func (v Vector3) Clamp(lo, hi Vector3) Vector3 {
+	return Vector3{
+		X: min(max(v.X, lo.X), hi.X),
+		Y: min(max(v.Y, lo.Y), hi.Y),
+		Z: min(max(v.Z, lo.Z), hi.Z),
+	}
+}
+
+// ClampMagnitude returns v shortened to at most limit long, keeping its
+// direction, as when capping a commanded acceleration.
+func (v Vector3) ClampMagnitude(limit float64) Vector3 {
+	n := v.Magnitude()
+	if n <= limit {
+		return v
+	}
+	if limit <= 0 {
+		return Vector3{}
+	}
+	return v.Mul(limit / n)
+}
+
+// Project returns the component of v along onto, zero if onto is zero.
+func (v Vector3) Project(onto Vector3) Vector3 {
+	d := onto.Dot(onto)
+	if d == 0 {
+		return Vector3{}
+	}
+	return onto.Mul(v.Dot(onto) / d)
+}
+
+// Reject returns the component of v across onto, all of v if onto is zero.
+// Against a line of sight it is the part of a velocity that turns the line.
+func (v Vector3) Reject(onto Vector3) Vector3 {
+	return v.Sub(v.Project(onto))
+}
+
+// AngleTo returns the angle between v and w in radians, from 0 to π, or 0
+// when either is zero. Unlike the arccosine of their normalized dot product
+// it stays accurate for nearly parallel vectors.
+func (v Vector3) AngleTo(w Vector3) float64 {
+	return math.Atan2(v.Cross(w).Magnitude(), v.Dot(w))
+}
@@ -0,0 +1,94 @@
+package vector
+
+import (
+	"math"
+	"testing"
+)
+
+func TestNormalizeOr(t *testing.T) {
+	up := Vector3{Y: 1}
+	tests := []struct {
+		name string
+		v    Vector3
+		want Vector3
+	}{
+		{"unit", Vector3{X: 1}, Vector3{X: 1}},
+		{"general", Vector3{3, 0, -4}, Vector3{0.6, 0, -0.8}},
+		{"zero", Vector3{}, up},
+		{"subnormal", Vector3{X: 5e-324, Z: 5e-324}, Vector3{X: math.Sqrt2 / 2, Z: math.Sqrt2 / 2}},
+		{"huge", Vector3{X: 1e308, Y: 1e308}, Vector3{X: math.Sqrt2 / 2, Y: math.Sqrt2 / 2}},
+		{"NaN", Vector3{X: math.NaN()}, up},
+		{"infinite", Vector3{Z: math.Inf(-1)}, up},
+	}
+	for _, tt := range tests {
+		if got := tt.v.NormalizeOr(up); !near(got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIsFinite(t *testing.T) {
+	tests := []struct {
+		v    Vector3
+		want bool
+	}{
+		{Vector3{1, -2, 3}, true},
+		{Vector3{}, true},
+		{Vector3{Y: math.NaN()}, false},
+		{Vector3{Z: math.Inf(1)}, false},
+		{Vector3{X: math.Inf(1), Y: math.Inf(-1)}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.v.IsFinite(); got != tt.want {
+			t.Errorf("%v: finite %v, want %v", tt.v, got, tt.want)
+		}
+	}
+}
+
+func TestVectorOps(t *testing.T) {
+	a, b := Vector3{1, 2, 3}, Vector3{5, -2, 7}
+	tests := []struct {
+		name string
+		got  Vector3
+		want Vector3
+	}{
+		{"lerp start", a.Lerp(b, 0), a},
+		{"lerp middle", a.Lerp(b, 0.5), Vector3{3, 0, 5}},
+		{"lerp end", a.Lerp(b, 1), b},
+		{"lerp beyond", a.Lerp(b, 2), Vector3{9, -6, 11}},
+		{"clamp", Vector3{-5, 0.5, 9}.Clamp(Vector3{-1, -1, -1}, Vector3{1, 1, 1}), Vector3{-1, 0.5, 1}},
+		{"clamp magnitude under", a.ClampMagnitude(10), a},
+		{"clamp magnitude over", Vector3{30, 0, 40}.ClampMagnitude(10), Vector3{6, 0, 8}},
+		{"clamp magnitude to zero", a.ClampMagnitude(0), Vector3{}},
+		{"project", Vector3{3, 4, 0}.Project(Vector3{X: 2}), Vector3{X: 3}},
+		{"project onto zero", a.Project(Vector3{}), Vector3{}},
+		{"reject", Vector3{3, 4, 0}.Reject(Vector3{X: 2}), Vector3{Y: 4}},
+		{"reject from zero", a.Reject(Vector3{}), a},
+		{"project plus reject", a.Project(b).Add(a.Reject(b)), a},
+	}
+	for _, tt := range tests {
+		if !near(tt.got, tt.want) {
+			t.Errorf("%s: %v, want %v", tt.name, tt.got, tt.want)
+		}
+	}
+}
+
+func TestAngleTo(t *testing.T) {
+	tests := []struct {
+		name string
+		v, w Vector3
+		want float64
+	}{
+		{"parallel", Vector3{X: 1}, Vector3{X: 3}, 0},
+		{"right angle", Vector3{X: 1}, Vector3{Z: -2}, math.Pi / 2},
+		{"opposite", Vector3{Y: 1}, Vector3{Y: -1}, math.Pi},
+		{"diagonal", Vector3{X: 1}, Vector3{1, 1, 0}, math.Pi / 4},
+		{"nearly parallel", Vector3{X: 1}, Vector3{X: 1, Y: 1e-9}, 1e-9},
+		{"zero", Vector3{}, Vector3{X: 1}, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.v.AngleTo(tt.w); math.Abs(got-tt.want) > 1e-15 {
+			t.Errorf("%s: %g, want %g", tt.name, got, tt.want)
+		}
+	}
+}
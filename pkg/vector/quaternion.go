@@ -0,0 +1,184 @@
+package vector
+
+import "math"
+
+// Quaternion is a rotation W + Xi + Yj + Zk. Rotations compose and act on
+// vectors by the Hamilton product, so q.Rotate(v) is q v q*, and
+// q.Mul(r) rotates by r first, then by q.
+type Quaternion struct {
+	W float64 `json:"w"`
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+	Z float64 `json:"z"`
+}
+
+// IdentityQuaternion is the rotation that leaves every vector unchanged.
+var IdentityQuaternion = Quaternion{W: 1}
+
+// AxisAngle returns the rotation by angle radians about axis, counter-
+// clockwise looking down the axis in a right-handed frame. A zero axis
+// gives the identity.
+func AxisAngle(axis Vector3, angle float64) Quaternion {
+	n := math.Sqrt(axis.X*axis.X + axis.Y*axis.Y + axis.Z*axis.Z)
+	if n == 0 {
+		return IdentityQuaternion
+	}
+	s, c := math.Sincos(angle / 2)
+	s /= n
+	return Quaternion{W: c, X: axis.X * s, Y: axis.Y * s, Z: axis.Z * s}
+}
+
+// Norm returns the quaternion's length, 1 for a rotation.
+func (q Quaternion) Norm() float64 {
+	return math.Sqrt(q.Dot(q))
+}
+
+// Normalize scales q to unit length, undoing the drift of repeated
+// products. The zero quaternion normalizes to the identity.
+func (q Quaternion) Normalize() Quaternion {
+	n := q.Norm()
+	if n == 0 {
+		return IdentityQuaternion
+	}
+	return Quaternion{q.W / n, q.X / n, q.Y / n, q.Z / n}
+}
+
+// Dot returns the four-dimensional dot product of q and r.
+func (q Quaternion) Dot(r Quaternion) float64 {
+	return q.W*r.W + q.X*r.X + q.Y*r.Y + q.Z*r.Z
+}
+
+// Mul returns the Hamilton product q r: the rotation r followed by q.
+func (q Quaternion) Mul(r Quaternion) Quaternion {
+	return Quaternion{
+		W: q.W*r.W - q.X*r.X - q.Y*r.Y - q.Z*r.Z,
+		X: q.W*r.X + q.X*r.W + q.Y*r.Z - q.Z*r.Y,
+		Y: q.W*r.Y - q.X*r.Z + q.Y*r.W + q.Z*r.X,
+		Z: q.W*r.Z + q.X*r.Y - q.Y*r.X + q.Z*r.W,
+	}
+}
+
+// Conjugate returns q with its vector part negated, the inverse of a unit
+// quaternion.
+func (q Quaternion) Conjugate() Quaternion {
+	return Quaternion{q.W, -q.X, -q.Y, -q.Z}
+}
+
+// Inverse returns the quaternion that undoes q, of any length.
+func (q Quaternion) Inverse() Quaternion {
+	n := q.Dot(q)
+	if n == 0 {
+		return IdentityQuaternion
+	}
+	return Quaternion{q.W / n, -q.X / n, -q.Y / n, -q.Z / n}
+}
+
+// Rotate returns v rotated by the unit quaternion q.
+func (q Quaternion) Rotate(v Vector3) Vector3 {
+	// v + 2w(u×v) + 2u×(u×v) for u the vector part, cheaper than two
+	// products.
+	cx := q.Y*v.Z - q.Z*v.Y
+	cy := q.Z*v.X - q.X*v.Z
+	cz := q.X*v.Y - q.Y*v.X
+	return Vector3{
+		X: v.X + 2*(q.W*cx+q.Y*cz-q.Z*cy),
+		Y: v.Y + 2*(q.W*cy+q.Z*cx-q.X*cz),
+		Z: v.Z + 2*(q.W*cz+q.X*cy-q.Y*cx),
+	}
+}
+
+// Angle returns how far q rotates, in radians from 0 to π.
+func (q Quaternion) Angle() float64 {
+	q = q.Normalize()
+	s := math.Sqrt(q.X*q.X + q.Y*q.Y + q.Z*q.Z)
+	return 2 * math.Atan2(s, math.Abs(q.W))
+}
+
+// Slerp interpolates along the shorter arc from unit quaternions q to r,
+// returning q at t = 0 and r at t = 1 at a constant angular rate.
+func (q Quaternion) Slerp(r Quaternion, t float64) Quaternion {
+	d := q.Dot(r)
+	if d < 0 {
+		// q and -q are the same rotation; take the nearer.
+		r, d = Quaternion{-r.W, -r.X, -r.Y, -r.Z}, -d
+	}
+	if d > 0.9995 {
+		// Nearly parallel: lerp is as accurate and avoids dividing by
+		// a vanishing sine.
+		return Quaternion{
+			q.W + t*(r.W-q.W), q.X + t*(r.X-q.X), q.Y + t*(r.Y-q.Y), q.Z + t*(r.Z-q.Z),
+		}.Normalize()
+	}
+	theta := math.Acos(d)
+	sin := math.Sin(theta)
+	a, b := math.Sin((1-t)*theta)/sin, math.Sin(t*theta)/sin
+	return Quaternion{a*q.W + b*r.W, a*q.X + b*r.X, a*q.Y + b*r.Y, a*q.Z + b*r.Z}
+}
+
+// FromEuler returns the rotation by yaw about Z, then pitch about the
+// rotated Y, then roll about the twice-rotated X, in radians: the intrinsic
+// Z-Y-X sequence of aerospace, where a body frame is X forward, Y right and
+// Z down relative to a north-east-down frame.
+func FromEuler(roll, pitch, yaw float64) Quaternion {
+	sr, cr := math.Sincos(roll / 2)
+	sp, cp := math.Sincos(pitch / 2)
+	sy, cy := math.Sincos(yaw / 2)
+	return Quaternion{
+		W: cr*cp*cy + sr*sp*sy,
+		X: sr*cp*cy - cr*sp*sy,
+		Y: cr*sp*cy + sr*cp*sy,
+		Z: cr*cp*sy - sr*sp*cy,
+	}
+}
+
+// Euler returns the roll, pitch and yaw FromEuler would build q from, in
+// radians, pitch within ±π/2. At pitch ±π/2 roll and yaw are one degree of
+// freedom; Euler puts it all in yaw.
+func (q Quaternion) Euler() (roll, pitch, yaw float64) {
+	q = q.Normalize()
+	sinp := 2 * (q.W*q.Y - q.Z*q.X)
+	if math.Abs(sinp) >= 1-1e-12 {
+		pitch = math.Copysign(math.Pi/2, sinp)
+		yaw = -2 * math.Atan2(q.X, q.W) * math.Copysign(1, sinp)
+		return 0, pitch, math.Remainder(yaw, 2*math.Pi)
+	}
+	roll = math.Atan2(2*(q.W*q.X+q.Y*q.Z), 1-2*(q.X*q.X+q.Y*q.Y))
+	pitch = math.Asin(sinp)
+	yaw = math.Atan2(2*(q.W*q.Z+q.X*q.Y), 1-2*(q.Y*q.Y+q.Z*q.Z))
+	return roll, pitch, yaw
+}
+
+// Matrix returns the rotation matrix of unit quaternion q, which rotates
+// vectors as q.Rotate does.
+func (q Quaternion) Matrix() Matrix3 {
+	w, x, y, z := q.W, q.X, q.Y, q.Z
+	return Matrix3{
+		{1 - 2*(y*y+z*z), 2 * (x*y - w*z), 2 * (x*z + w*y)},
+		{2 * (x*y + w*z), 1 - 2*(x*x+z*z), 2 * (y*z - w*x)},
+		{2 * (x*z - w*y), 2 * (y*z + w*x), 1 - 2*(x*x+y*y)},
+	}
+}
+
+// QuaternionFromMatrix returns the unit quaternion of rotation matrix m,
+// picking the numerically best-conditioned of the four formulas.
+func QuaternionFromMatrix(m Matrix3) Quaternion {
+	var q Quaternion
+	switch tr := m[0][0] + m[1][1] + m[2][2]; {
+	case tr > 0:
+		s := 2 * math.Sqrt(tr+1)
+		q = Quaternion{s / 4, (m[2][1] - m[1][2]) / s, (m[0][2] - m[2][0]) / s, (m[1][0] - m[0][1]) / s}
+	case m[0][0] > m[1][1] && m[0][0] > m[2][2]:
+		s := 2 * math.Sqrt(1+m[0][0]-m[1][1]-m[2][2])
+		q = Quaternion{(m[2][1] - m[1][2]) / s, s / 4, (m[0][1] + m[1][0]) / s, (m[0][2] + m[2][0]) / s}
+	case m[1][1] > m[2][2]:
+		s := 2 * math.Sqrt(1+m[1][1]-m[0][0]-m[2][2])
+		q = Quaternion{(m[0][2] - m[2][0]) / s, (m[0][1] + m[1][0]) / s, s / 4, (m[1][2] + m[2][1]) / s}
+	default:
+		s := 2 * math.Sqrt(1+m[2][2]-m[0][0]-m[1][1])
+		q = Quaternion{(m[1][0] - m[0][1]) / s, (m[0][2] + m[2][0]) / s, (m[1][2] + m[2][1]) / s, s / 4}
+	}
+	if q.W < 0 {
+		q = Quaternion{-q.W, -q.X, -q.Y, -q.Z}
+	}
+	return q.Normalize()
+}
@@ -0,0 +1,116 @@
+package vector
+
+import (
+	"math"
+	"testing"
+)
+
+func near(a, b Vector3) bool {
+	return math.Abs(a.X-b.X) < 1e-9 && math.Abs(a.Y-b.Y) < 1e-9 && math.Abs(a.Z-b.Z) < 1e-9
+}
+
+// sameRotation compares what two rotations do to the axes.
+func sameRotation(q, r Quaternion) bool {
+	for _, v := range []Vector3{{X: 1}, {Y: 1}, {Z: 1}} {
+		if !near(q.Rotate(v), r.Rotate(v)) {
+			return false
+		}
+	}
+	return true
+}
+
+func TestQuaternionRotate(t *testing.T) {
+	tests := []struct {
+		name string
+		q    Quaternion
+		v    Vector3
+		want Vector3
+	}{
+		{"identity", IdentityQuaternion, Vector3{1, 2, 3}, Vector3{1, 2, 3}},
+		{"quarter turn about Z", AxisAngle(Vector3{Z: 1}, math.Pi/2), Vector3{X: 1}, Vector3{Y: 1}},
+		{"half turn about Y", AxisAngle(Vector3{Y: 2}, math.Pi), Vector3{X: 1, Z: 1}, Vector3{X: -1, Z: -1}},
+		{"third turn about the diagonal", AxisAngle(Vector3{1, 1, 1}, 2*math.Pi/3), Vector3{X: 1}, Vector3{Y: 1}},
+		{"yaw east in NED", FromEuler(0, 0, math.Pi/2), Vector3{X: 1}, Vector3{Y: 1}},
+		{"pitch up in NED", FromEuler(0, math.Pi/2, 0), Vector3{X: 1}, Vector3{Z: -1}},
+		{"roll right in NED", FromEuler(math.Pi/2, 0, 0), Vector3{Y: 1}, Vector3{Z: 1}},
+		{"zero axis", AxisAngle(Vector3{}, 1), Vector3{X: 1}, Vector3{X: 1}},
+	}
+	for _, tt := range tests {
+		if got := tt.q.Rotate(tt.v); !near(got, tt.want) {
+			t.Errorf("%s: rotated %v to %v, want %v", tt.name, tt.v, got, tt.want)
+		}
+		// The matrix rotates as the quaternion does.
+		m := tt.q.Matrix()
+		if got := m.MulVec(tt.v); !near(got, tt.want) {
+			t.Errorf("%s: matrix rotated %v to %v, want %v", tt.name, tt.v, got, tt.want)
+		}
+		if back := QuaternionFromMatrix(m); !sameRotation(back, tt.q) {
+			t.Errorf("%s: matrix converts back to %v", tt.name, back)
+		}
+	}
+}
+
+func TestQuaternionAlgebra(t *testing.T) {
+	a := AxisAngle(Vector3{1, 2, 3}, 0.7)
+	b := FromEuler(0.3, -0.2, 2.5)
+	v := Vector3{4, -5, 6}
+	if got, want := a.Mul(b).Rotate(v), a.Rotate(b.Rotate(v)); !near(got, want) {
+		t.Errorf("(ab)v = %v, want a(bv) = %v", got, want)
+	}
+	if got := a.Conjugate().Rotate(a.Rotate(v)); !near(got, v) {
+		t.Errorf("conjugate does not undo the rotation: %v", got)
+	}
+	scaled := Quaternion{a.W * 3, a.X * 3, a.Y * 3, a.Z * 3}
+	if got := scaled.Mul(scaled.Inverse()); !sameRotation(got, IdentityQuaternion) || math.Abs(got.W-1) > 1e-12 {
+		t.Errorf("q q⁻¹ = %v", got)
+	}
+	if n := scaled.Normalize().Norm(); math.Abs(n-1) > 1e-12 {
+		t.Errorf("normalized norm %g", n)
+	}
+	if got := (Quaternion{}).Normalize(); got != IdentityQuaternion {
+		t.Errorf("zero normalizes to %v", got)
+	}
+	if got := AxisAngle(Vector3{Z: 1}, 3).Angle(); math.Abs(got-3) > 1e-12 {
+		t.Errorf("angle %g, want 3", got)
+	}
+}
+
+func TestQuaternionEuler(t *testing.T) {
+	for _, e := range [][3]float64{
+		{0, 0, 0}, {0.1, 0.2, 0.3}, {-2.5, 1.2, -3}, {3, -1.5, 0.5},
+		{0, math.Pi / 2, 0.4}, {0.3, -math.Pi / 2, -1}, // gimbal lock
+	} {
+		q := FromEuler(e[0], e[1], e[2])
+		roll, pitch, yaw := q.Euler()
+		if !sameRotation(FromEuler(roll, pitch, yaw), q) {
+			t.Errorf("Euler %v came back as %v %v %v, a different rotation", e, roll, pitch, yaw)
+		}
+		if math.Abs(e[1]) < math.Pi/2 && (math.Abs(roll-e[0]) > 1e-9 || math.Abs(pitch-e[1]) > 1e-9 || math.Abs(yaw-e[2]) > 1e-9) {
+			t.Errorf("Euler %v came back as %v %v %v", e, roll, pitch, yaw)
+		}
+	}
+}
+
+func TestSlerp(t *testing.T) {
+	a := AxisAngle(Vector3{Z: 1}, 0)
+	b := AxisAngle(Vector3{Z: 1}, math.Pi/2)
+	tests := []struct {
+		t     float64
+		angle float64
+	}{{0, 0}, {0.25, math.Pi / 8}, {0.5, math.Pi / 4}, {1, math.Pi / 2}}
+	for _, tt := range tests {
+		got := a.Slerp(b, tt.t)
+		if !sameRotation(got, AxisAngle(Vector3{Z: 1}, tt.angle)) {
+			t.Errorf("slerp at %g = %v, want a turn of %g", tt.t, got, tt.angle)
+		}
+	}
+	// -b is b; slerp takes the short way round either way.
+	neg := Quaternion{-b.W, -b.X, -b.Y, -b.Z}
+	if got := a.Slerp(neg, 0.5); !sameRotation(got, AxisAngle(Vector3{Z: 1}, math.Pi/4)) {
+		t.Errorf("slerp to -b went the long way: %v", got)
+	}
+	tiny := AxisAngle(Vector3{X: 1}, 1e-4)
+	if got := a.Slerp(tiny, 0.5); math.Abs(got.Angle()-5e-5) > 1e-12 {
+		t.Errorf("slerp between close rotations turned %g", got.Angle())
+	}
+}
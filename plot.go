@@ -0,0 +1,328 @@
+package simulation
+
+import (
+	"archive/zip"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"io"
+	"math"
+
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Plot image limits.
+const (
+	DefaultPlotWidth = 960  // px, both panels together
+	MinPlotWidth     = 128  // px
+	MaxPlotWidth     = 4096 // px
+	MaxPlotFrames    = 5000 // images in one sequence
+)
+
+// plotMargin is the space around each panel, px; the time bar sits in the
+// bottom one.
+const plotMargin = 12
+
+var (
+	plotBackground = color.RGBA{17, 24, 39, 255}
+	plotPanel      = color.RGBA{31, 41, 55, 255}
+	plotGround     = color.RGBA{120, 98, 60, 255}
+	plotEventColor = color.RGBA{250, 204, 21, 255}
+	plotTimeBar    = color.RGBA{148, 163, 184, 255}
+)
+
+// PlotOptions controls how a recording is drawn.
+type PlotOptions struct {
+	Width int  // px of the whole image, half as tall; 0 for DefaultPlotWidth
+	Every int  // draw every nth frame, and always the last; 0 draws them all
+	Last  bool // draw only the last frame, the whole run at a glance
+}
+
+// Validate checks the options and fills in their defaults.
+func (o *PlotOptions) Validate() error {
+	if o.Width == 0 {
+		o.Width = DefaultPlotWidth
+	}
+	if o.Width < MinPlotWidth || o.Width > MaxPlotWidth {
+		return fmt.Errorf("width must be between %d and %d", MinPlotWidth, MaxPlotWidth)
+	}
+	if o.Every < 0 {
+		return fmt.Errorf("every must not be negative")
+	}
+	o.Every = max(o.Every, 1)
+	return nil
+}
+
+// Images returns how many images n frames are drawn as.
+func (o *PlotOptions) Images(n int) int {
+	if o.Last {
+		return min(n, 1)
+	}
+	return (n-1)/o.Every + 1
+}
+
+// PlotFrames draws the recording as images for reports and bug reports,
+// calling emit with each in turn, numbered from zero. Each image has a plan
+// view on the left, east to the right and north up at equal scale, and a
+// profile on the right, altitude over the longer horizontal axis. Entities
+// are drawn at their position with their trail so far, events as crosses
+// where they happened, and a bar along the bottom shows the time elapsed.
+// Every image of a recording shares one scale so they can be played as a
+// sequence.
+func PlotFrames(rec *Recording, opts PlotOptions, emit func(i int, img image.Image) error) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	n := len(rec.Frames)
+	if n == 0 {
+		return fmt.Errorf("recording %s has no frames", rec.Name)
+	}
+	if images := opts.Images(n); images > MaxPlotFrames {
+		return fmt.Errorf("%d images is more than %d; draw fewer frames or resample", images, MaxPlotFrames)
+	}
+	p := newPlotter(rec, opts.Width)
+	out := image.NewRGBA(p.trails.Bounds())
+	start, end := rec.Frames[0].Time, rec.Frames[n-1].Time
+	prev := make(map[string]vector.Vector3)
+	ev, drawn := 0, 0
+	for i, st := range rec.Frames {
+		for _, e := range st.Entities {
+			if last, ok := prev[e.ID]; ok {
+				p.line(p.trails, last, e.Position, dim(plotColor(string(e.Type))))
+			}
+			prev[e.ID] = e.Position
+		}
+		for ; ev < len(p.events) && p.events[ev].time <= st.Time; ev++ {
+			p.cross(p.trails, p.events[ev].at, plotEventColor)
+		}
+		if i != n-1 && (opts.Last || i%opts.Every != 0) {
+			continue
+		}
+		draw.Draw(out, out.Bounds(), p.trails, image.Point{}, draw.Src)
+		for _, e := range st.Entities {
+			p.marker(out, e.Position, plotColor(string(e.Type)))
+		}
+		if end > start {
+			w := int(float64(out.Bounds().Dx()-2*plotMargin) * (st.Time - start) / (end - start))
+			draw.Draw(out, image.Rect(plotMargin, out.Bounds().Dy()-plotMargin/2-1, plotMargin+w, out.Bounds().Dy()-plotMargin/2+1), image.NewUniform(plotTimeBar), image.Point{}, draw.Src)
+		}
+		if err := emit(drawn, out); err != nil {
+			return err
+		}
+		drawn++
+	}
+	return nil
+}
+
+// WritePlotZip writes the images of PlotFrames to w as a ZIP archive of
+// PNG files named after the recording.
+func WritePlotZip(w io.Writer, rec *Recording, opts PlotOptions) error {
+	zw := zip.NewWriter(w)
+	enc := png.Encoder{CompressionLevel: png.BestSpeed}
+	err := PlotFrames(rec, opts, func(i int, img image.Image) error {
+		// PNGs are compressed already.
+		f, err := zw.CreateHeader(&zip.FileHeader{Name: fmt.Sprintf("%s-%05d.png", rec.Name, i), Method: zip.Store})
+		if err != nil {
+			return err
+		}
+		return enc.Encode(f, img)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// WritePlotPNG writes the last image of PlotFrames to w as a PNG: the
+// whole run at a glance.
+func WritePlotPNG(w io.Writer, rec *Recording, opts PlotOptions) error {
+	opts.Last = true
+	return PlotFrames(rec, opts, func(_ int, img image.Image) error {
+		return png.Encode(w, img)
+	})
+}
+
+// plotter maps positions onto the two panels of an image.
+type plotter struct {
+	trails         *image.RGBA // background, panels, trails and events so far
+	plan, profile  image.Rectangle
+	minX, minZ     float64 // m at the plan panel's bottom-left
+	planScale      float64 // px per m, both ways
+	alongX         bool    // the profile runs along X rather than Z
+	minH, minY     float64 // m at the profile panel's bottom-left
+	hScale, yScale float64 // px per m along and up
+	events         []plotEvent
+}
+
+// plotEvent is an event drawn at the place it happened.
+type plotEvent struct {
+	time float64
+	at   vector.Vector3
+}
+
+func newPlotter(rec *Recording, width int) *plotter {
+	half := width / 2
+	p := &plotter{
+		trails:  image.NewRGBA(image.Rect(0, 0, 2*half, half)),
+		plan:    image.Rect(plotMargin, plotMargin, half-plotMargin/2, half-plotMargin),
+		profile: image.Rect(half+plotMargin/2, plotMargin, 2*half-plotMargin, half-plotMargin),
+	}
+	lo := vector.Vector3{X: math.Inf(1), Y: 0, Z: math.Inf(1)} // the ground is always in the profile
+	hi := vector.Vector3{X: math.Inf(-1), Y: 1, Z: math.Inf(-1)}
+	all := tracks(rec)
+	for _, tr := range all {
+		for _, pt := range tr.Points {
+			lo = vector.Vector3{X: min(lo.X, pt.X), Y: min(lo.Y, pt.Y), Z: min(lo.Z, pt.Z)}
+			hi = vector.Vector3{X: max(hi.X, pt.X), Y: max(hi.Y, pt.Y), Z: max(hi.Z, pt.Z)}
+		}
+	}
+	if len(all) == 0 {
+		lo.X, lo.Z, hi.X, hi.Z = 0, 0, 1, 1
+	}
+	// Pad by a twentieth, and by a metre so a point has some extent.
+	pad := hi.Sub(lo).Mul(0.05).Add(vector.Vector3{X: 1, Y: 1, Z: 1})
+	lo, hi = lo.Sub(pad), hi.Add(pad)
+
+	pw, ph := float64(p.plan.Dx()), float64(p.plan.Dy())
+	p.planScale = min(pw/(hi.X-lo.X), ph/(hi.Z-lo.Z))
+	// Centre the shorter axis.
+	p.minX = (lo.X+hi.X)/2 - pw/p.planScale/2
+	p.minZ = (lo.Z+hi.Z)/2 - ph/p.planScale/2
+
+	p.alongX = hi.X-lo.X >= hi.Z-lo.Z
+	p.minH, p.minY = lo.Z, lo.Y
+	if p.alongX {
+		p.minH = lo.X
+	}
+	p.hScale = float64(p.profile.Dx()) / (hi.X - lo.X)
+	if !p.alongX {
+		p.hScale = float64(p.profile.Dx()) / (hi.Z - lo.Z)
+	}
+	p.yScale = float64(p.profile.Dy()) / (hi.Y - lo.Y)
+
+	draw.Draw(p.trails, p.trails.Bounds(), image.NewUniform(plotBackground), image.Point{}, draw.Src)
+	draw.Draw(p.trails, p.plan, image.NewUniform(plotPanel), image.Point{}, draw.Src)
+	draw.Draw(p.trails, p.profile, image.NewUniform(plotPanel), image.Point{}, draw.Src)
+	_, ground := p.toProfile(vector.Vector3{})
+	draw.Draw(p.trails, image.Rect(p.profile.Min.X, ground, p.profile.Max.X, p.profile.Max.Y), image.NewUniform(plotGround), image.Point{}, draw.Src)
+
+	byID := make(map[string]*track, len(all))
+	for _, tr := range all {
+		byID[tr.ID] = tr
+	}
+	for _, ev := range rec.Events {
+		switch ev.Type {
+		case EventIntercept, EventImpact, EventCrash:
+			if tr, ok := byID[ev.EntityID]; ok {
+				p.events = append(p.events, plotEvent{ev.Time, tr.at(ev.Time)})
+			}
+		}
+	}
+	return p
+}
+
+// toPlan returns the pixel of pos in the plan panel.
+func (p *plotter) toPlan(pos vector.Vector3) (int, int) {
+	x := float64(p.plan.Min.X) + (pos.X-p.minX)*p.planScale
+	y := float64(p.plan.Max.Y) - (pos.Z-p.minZ)*p.planScale
+	return int(math.Round(x)), int(math.Round(y))
+}
+
+// toProfile returns the pixel of pos in the profile panel.
+func (p *plotter) toProfile(pos vector.Vector3) (int, int) {
+	h := pos.Z
+	if p.alongX {
+		h = pos.X
+	}
+	x := float64(p.profile.Min.X) + (h-p.minH)*p.hScale
+	y := float64(p.profile.Max.Y) - (pos.Y-p.minY)*p.yScale
+	return int(math.Round(x)), int(math.Round(y))
+}
+
+// line draws from a to b in both panels.
+func (p *plotter) line(img *image.RGBA, a, b vector.Vector3, c color.RGBA) {
+	x0, y0 := p.toPlan(a)
+	x1, y1 := p.toPlan(b)
+	plotLine(img, p.plan, x0, y0, x1, y1, c)
+	x0, y0 = p.toProfile(a)
+	x1, y1 = p.toProfile(b)
+	plotLine(img, p.profile, x0, y0, x1, y1, c)
+}
+
+// marker draws a square at pos in both panels.
+func (p *plotter) marker(img *image.RGBA, pos vector.Vector3, c color.RGBA) {
+	for _, pt := range p.points(pos) {
+		r := image.Rect(pt.X-2, pt.Y-2, pt.X+3, pt.Y+3).Intersect(pt.panel)
+		draw.Draw(img, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+}
+
+// cross draws an X at pos in both panels.
+func (p *plotter) cross(img *image.RGBA, pos vector.Vector3, c color.RGBA) {
+	for _, pt := range p.points(pos) {
+		plotLine(img, pt.panel, pt.X-3, pt.Y-3, pt.X+3, pt.Y+3, c)
+		plotLine(img, pt.panel, pt.X-3, pt.Y+3, pt.X+3, pt.Y-3, c)
+	}
+}
+
+// panelPoint is a pixel and the panel it is drawn in.
+type panelPoint struct {
+	image.Point
+	panel image.Rectangle
+}
+
+func (p *plotter) points(pos vector.Vector3) [2]panelPoint {
+	px, py := p.toPlan(pos)
+	qx, qy := p.toProfile(pos)
+	return [2]panelPoint{{image.Pt(px, py), p.plan}, {image.Pt(qx, qy), p.profile}}
+}
+
+// plotLine draws a line with Bresenham's algorithm, clipped to clip.
+func plotLine(img *image.RGBA, clip image.Rectangle, x0, y0, x1, y1 int, c color.RGBA) {
+	dx, dy := abs(x1-x0), -abs(y1-y0)
+	sx, sy := 1, 1
+	if x0 > x1 {
+		sx = -1
+	}
+	if y0 > y1 {
+		sy = -1
+	}
+	for e := dx + dy; ; {
+		if image.Pt(x0, y0).In(clip) {
+			img.SetRGBA(x0, y0, c)
+		}
+		if x0 == x1 && y0 == y1 {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			x0 += sx
+		}
+		if e2 <= dx {
+			e += dx
+			y0 += sy
+		}
+	}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// plotColor is the color an entity type is drawn in, as on the globe.
+func plotColor(typ string) color.RGBA {
+	c := globeColor(typ)
+	return color.RGBA{uint8(c[0]), uint8(c[1]), uint8(c[2]), uint8(c[3])}
+}
+
+// dim is c at two thirds brightness, for trails.
+func dim(c color.RGBA) color.RGBA {
+	scale := func(v uint8) uint8 { return uint8(uint16(v) * 2 / 3) }
+	return color.RGBA{scale(c.R), scale(c.G), scale(c.B), c.A}
+}
@@ -0,0 +1,147 @@
+package simulation
+
+import (
+	"archive/zip"
+	"bytes"
+	"image"
+	"image/png"
+	"testing"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// plotRecording is a missile climbing towards a target over n frames.
+func plotRecording(n int) *Recording {
+	rec := &Recording{Name: "plot", Dt: 0.1}
+	for i := range n {
+		f := float64(i) / float64(n-1)
+		rec.Frames = append(rec.Frames, SimulationState{
+			Time: float64(i) * rec.Dt,
+			Entities: []*entities.Entity{
+				entities.NewTarget("target-1", vector.Vector3{X: 4000 - 2000*f, Y: 2000, Z: 1000}, vector.Vector3{}),
+				entities.NewMissile("missile-1", vector.Vector3{X: 1500 * f, Y: 1500 * f, Z: 1000 * f}, vector.Vector3{}),
+			},
+		})
+	}
+	rec.Events = []Event{{Time: rec.Frames[n-1].Time, Type: EventIntercept, EntityID: "missile-1"}}
+	return rec
+}
+
+func TestPlotOptionsValidate(t *testing.T) {
+	tests := []struct {
+		opts    PlotOptions
+		want    PlotOptions
+		wantErr bool
+	}{
+		{PlotOptions{}, PlotOptions{Width: DefaultPlotWidth, Every: 1}, false},
+		{PlotOptions{Width: 400, Every: 5}, PlotOptions{Width: 400, Every: 5}, false},
+		{PlotOptions{Last: true}, PlotOptions{Width: DefaultPlotWidth, Every: 1, Last: true}, false},
+		{PlotOptions{Width: 10}, PlotOptions{}, true},
+		{PlotOptions{Width: MaxPlotWidth + 1}, PlotOptions{}, true},
+		{PlotOptions{Every: -1}, PlotOptions{}, true},
+	}
+	for _, tt := range tests {
+		got := tt.opts
+		err := got.Validate()
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%+v: err = %v, wantErr %v", tt.opts, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("%+v: got %+v, want %+v", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestPlotFrames(t *testing.T) {
+	tests := []struct {
+		name   string
+		frames int
+		opts   PlotOptions
+		images int
+	}{
+		{"every frame", 10, PlotOptions{Width: 400}, 10},
+		{"every third keeps the last", 10, PlotOptions{Width: 400, Every: 3}, 4},
+		{"every ninth", 10, PlotOptions{Width: 400, Every: 9}, 2},
+		{"last only", 10, PlotOptions{Width: 400, Every: 3, Last: true}, 1},
+		{"single frame", 1, PlotOptions{Width: 400}, 1},
+	}
+	for _, tt := range tests {
+		rec := plotRecording(max(tt.frames, 2))
+		rec.Frames = rec.Frames[:tt.frames]
+		var last image.Image
+		images := 0
+		err := PlotFrames(rec, tt.opts, func(i int, img image.Image) error {
+			if i != images {
+				t.Errorf("%s: image %d numbered %d", tt.name, images, i)
+			}
+			images++
+			last = img
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if images != tt.images {
+			t.Errorf("%s: %d images, want %d", tt.name, images, tt.images)
+		}
+		if b := last.Bounds(); b.Dx() != 400 || b.Dy() != 200 {
+			t.Errorf("%s: image is %v, want 400x200", tt.name, b)
+		}
+	}
+
+	// Both entities are drawn where they end up, in both panels.
+	rec := plotRecording(20)
+	var buf bytes.Buffer
+	if err := WritePlotPNG(&buf, rec, PlotOptions{Width: 400}); err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := newPlotter(rec, 400)
+	for _, e := range rec.Frames[len(rec.Frames)-1].Entities {
+		for _, pt := range p.points(e.Position) {
+			if got := img.At(pt.X, pt.Y); got != plotColor(string(e.Type)) {
+				t.Errorf("%s at %v is %v, want its marker", e.ID, pt.Point, got)
+			}
+		}
+	}
+
+	if err := PlotFrames(&Recording{Name: "empty"}, PlotOptions{}, nil); err == nil {
+		t.Error("plotted a recording with no frames")
+	}
+	if err := PlotFrames(plotRecording(MaxPlotFrames+1), PlotOptions{}, nil); err == nil {
+		t.Error("plotted more images than the limit")
+	}
+}
+
+func TestWritePlotZip(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WritePlotZip(&buf, plotRecording(6), PlotOptions{Width: 200, Every: 2}); err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"plot-00000.png", "plot-00001.png", "plot-00002.png", "plot-00003.png"}
+	if len(zr.File) != len(want) {
+		t.Fatalf("%d files, want %d", len(zr.File), len(want))
+	}
+	for i, f := range zr.File {
+		if f.Name != want[i] {
+			t.Errorf("file %d is %s, want %s", i, f.Name, want[i])
+		}
+		r, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := png.Decode(r); err != nil {
+			t.Errorf("%s: %v", f.Name, err)
+		}
+		r.Close()
+	}
+}
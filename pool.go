@@ -0,0 +1,57 @@
+package simulation
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// MaxWorkers bounds the workers a campaign may ask for.
+const MaxWorkers = 256
+
+// workerCount is the number of workers to run n jobs on when asked for
+// workers, 0 meaning one per available CPU.
+func workerCount(workers, n int) int {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	return max(1, min(workers, n, MaxWorkers))
+}
+
+// forEach calls job(i) for every i in [0, n) on a fixed pool of workers, 0
+// for one per available CPU. Jobs share nothing through forEach and must
+// write their results to slots of their own. After the first error no
+// further jobs start; forEach waits for those running and returns the error
+// of the lowest index that failed.
+func forEach(n, workers int, job func(i int) error) error {
+	var (
+		next   atomic.Int64
+		failed atomic.Bool
+		mu     sync.Mutex
+		errAt  = n
+		first  error
+		wg     sync.WaitGroup
+	)
+	for range workerCount(workers, n) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for !failed.Load() {
+				i := int(next.Add(1) - 1)
+				if i >= n {
+					return
+				}
+				if err := job(i); err != nil {
+					mu.Lock()
+					if i < errAt {
+						errAt, first = i, err
+					}
+					mu.Unlock()
+					failed.Store(true)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	return first
+}
@@ -0,0 +1,83 @@
+package simulation
+
+import (
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"testing"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+func TestForEach(t *testing.T) {
+	tests := []struct {
+		n, workers int
+		failAt     []int // indices whose job fails
+		wantErr    int   // index named by the returned error, -1 for none
+	}{
+		{100, 0, nil, -1},
+		{100, 1, nil, -1},
+		{7, 32, nil, -1},
+		{0, 4, nil, -1},
+		{50, 1, []int{10, 20}, 10},
+		{50, 4, []int{30, 3}, 3},
+	}
+	for _, tt := range tests {
+		var ran atomic.Int64
+		seen := make([]atomic.Int32, tt.n)
+		err := forEach(tt.n, tt.workers, func(i int) error {
+			ran.Add(1)
+			seen[i].Add(1)
+			if slices.Contains(tt.failAt, i) {
+				return fmt.Errorf("job %d", i)
+			}
+			return nil
+		})
+		want := error(nil)
+		if tt.wantErr >= 0 {
+			want = fmt.Errorf("job %d", tt.wantErr)
+		}
+		if fmt.Sprint(err) != fmt.Sprint(want) {
+			t.Errorf("n=%d workers=%d: err %v, want %v", tt.n, tt.workers, err, want)
+		}
+		for i := range seen {
+			if c := seen[i].Load(); c > 1 || (tt.failAt == nil && c != 1) {
+				t.Errorf("n=%d workers=%d: job %d ran %d times", tt.n, tt.workers, i, c)
+			}
+		}
+		if tt.failAt != nil && tt.workers == 1 && ran.Load() != int64(tt.wantErr+1) {
+			t.Errorf("n=%d: %d jobs ran after the failure at %d", tt.n, ran.Load(), tt.wantErr)
+		}
+	}
+}
+
+func TestBatchWorkersAgree(t *testing.T) {
+	cfg := BatchConfig{Runs: 12, Seed: 7, MaxTime: 40}
+	serial := cfg
+	serial.Workers = 1
+	parallel := cfg
+	parallel.Workers = 6
+	a, err := RunBatch(scenario.Default(), serial)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := RunBatch(scenario.Default(), parallel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(a.Results, b.Results) || a.Pk != b.Pk {
+		t.Errorf("one worker %+v, six %+v", a.Results, b.Results)
+	}
+}
+
+func TestWorkerCount(t *testing.T) {
+	if got := workerCount(0, 1_000_000); got < 1 || got > MaxWorkers {
+		t.Errorf("default workers %d", got)
+	}
+	if got := workerCount(MaxWorkers*2, 1_000_000); got != MaxWorkers {
+		t.Errorf("%d workers for an oversized request", got)
+	}
+	if got := workerCount(8, 3); got != 3 {
+		t.Errorf("%d workers for 3 jobs", got)
+	}
+}
@@ -0,0 +1,50 @@
+package simulation
+
+import (
+	"missile-intercept-sim/internal/spatial"
+)
+
+// proximity indexes the live threats each step so the proximity fuze can
+// find whatever an interceptor passes close to without checking every pair.
+type proximity struct {
+	grid    *spatial.Grid
+	threats []*Threat // by grid point number
+	found   []int     // query scratch
+}
+
+// indexThreatsLocked rebuilds the threat index from this step's positions.
+// Callers must hold s.mu.
+func (s *Simulator) indexThreatsLocked() {
+	px := &s.proximity
+	if px.grid == nil || px.grid.Size() != s.InterceptRadius {
+		px.grid = spatial.NewGrid(s.InterceptRadius)
+	}
+	px.grid.Reset()
+	px.threats = px.threats[:0]
+	for _, th := range s.Threats {
+		if th.Live() {
+			px.grid.Insert(th.Entity.Position)
+			px.threats = append(px.threats, th)
+		}
+	}
+}
+
+// fuzeLocked returns the nearest live threat other than ic's own target
+// inside the intercept radius of ic, and its distance, or nil if there is
+// none. Callers must hold s.mu and have indexed the threats this step.
+func (s *Simulator) fuzeLocked(ic *Interceptor) (*Threat, float64) {
+	px := &s.proximity
+	px.found = px.grid.Within(ic.Missile.Position, s.InterceptRadius, px.found[:0])
+	var best *Threat
+	bestDist := s.InterceptRadius
+	for _, i := range px.found {
+		th := px.threats[i]
+		if th == ic.Target || !th.Live() {
+			continue
+		}
+		if d := ic.Missile.Position.Distance(th.Entity.Position); d < bestDist {
+			best, bestDist = th, d
+		}
+	}
+	return best, bestDist
+}
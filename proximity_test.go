@@ -0,0 +1,51 @@
+package simulation
+
+import (
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestProximityFuze(t *testing.T) {
+	tests := []struct {
+		name      string
+		crossing  vector.Vector3 // where the unassigned threat starts
+		wantKill  string
+		wantAlive string
+	}{
+		{"passes through another threat", vector.Vector3{X: 1, Y: 2, Z: 3}, "crossing", "assigned"},
+		{"clear of other threats", vector.Vector3{X: 3000, Y: 2000}, "", "crossing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := &scenario.Scenario{Name: "fuze", Seed: 1, Entities: []scenario.Entity{
+				{ID: "assigned", Role: scenario.RoleTarget, Position: vector.Vector3{Y: 5000, Z: 40000}},
+				{ID: "crossing", Role: scenario.RoleTarget, Position: tt.crossing},
+				{ID: "missile", Role: scenario.RoleInterceptor, TargetID: "assigned"},
+			}}
+			s := NewSimulator()
+			s.SetLogger(logging.Discard)
+			if err := s.LoadScenario(sc); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := s.Advance(1); err != nil {
+				t.Fatal(err)
+			}
+			ic := s.Interceptors[0]
+			killed := ""
+			if ic.Status == "Intercepted" {
+				killed = ic.Target.Entity.ID
+			}
+			if killed != tt.wantKill {
+				t.Errorf("interceptor %s killed %q, want %q", ic.Status, killed, tt.wantKill)
+			}
+			for _, th := range s.Threats {
+				if th.Entity.ID == tt.wantAlive && !th.Live() {
+					t.Errorf("%s was destroyed", th.Entity.ID)
+				}
+			}
+		})
+	}
+}
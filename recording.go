@@ -0,0 +1,301 @@
+package simulation
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/sensors"
+)
+
+// Recording is the full per-step state history of one run.
+type Recording struct {
+	Name     string            `json:"name"`
+	Created  time.Time         `json:"created"`
+	Seed     uint64            `json:"seed"`
+	Dt       float64           `json:"dt"`
+	Manifest *Manifest         `json:"manifest,omitempty"`
+	Frames   []SimulationState `json:"frames"`
+	Events   []Event           `json:"events,omitempty"`  // logged while recording
+	Dropped  int               `json:"dropped,omitempty"` // frames left out of the file because the disk fell behind
+
+	start float64 // s, simulated time recording began
+}
+
+// Duration returns the simulated time spanned by the recording.
+func (r *Recording) Duration() float64 {
+	if len(r.Frames) == 0 {
+		return 0
+	}
+	return r.Frames[len(r.Frames)-1].Time
+}
+
+// RecordingInfo describes a recording stored on disk.
+type RecordingInfo struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
+
+// recordingExt is the file extension used for stored recordings.
+const recordingExt = ".json"
+
+// cloneState copies the state so later physics steps cannot mutate it.
+func cloneState(st SimulationState) SimulationState {
+	out := st
+	out.Entities = make([]*entities.Entity, len(st.Entities))
+	for i, e := range st.Entities {
+		cp := *e
+		out.Entities[i] = &cp
+	}
+	out.Sensors = append([]sensors.Status(nil), st.Sensors...)
+	out.Engagements = append([]EngagementStatus(nil), st.Engagements...)
+	for i, eng := range out.Engagements {
+		if eng.PIP != nil {
+			pip := *eng.PIP
+			out.Engagements[i].PIP = &pip
+		}
+	}
+	out.Threats = append([]ThreatAssessment(nil), st.Threats...)
+	return out
+}
+
+// SetRecording turns per-step recording on or off. Each run is streamed to
+// RecordDir as it goes and appears there when it ends, when recording is
+// disabled, or on Reset.
+func (s *Simulator) SetRecording(enabled bool) error {
+	s.lock()
+	defer s.mu.Unlock()
+	if enabled && s.RecordDir == "" {
+		return fmt.Errorf("no recording directory configured")
+	}
+	s.recordEnabled = enabled
+	if enabled {
+		if s.recording == nil {
+			s.beginRecordingLocked()
+		}
+	} else {
+		s.finishRecordingLocked()
+	}
+	return nil
+}
+
+// beginRecordingLocked starts a new recording at the current frame.
+func (s *Simulator) beginRecordingLocked() {
+	now := time.Now()
+	s.recording = &Recording{
+		// Reruns and fixed-seed sessions share seeds, so the name carries
+		// the start time to the nanosecond.
+		Name:    fmt.Sprintf("%s-%09d-%d", now.Format("20060102-150405"), now.Nanosecond(), s.State.Seed),
+		Created: now,
+		Seed:    s.State.Seed,
+		Dt:      s.Dt,
+		start:   s.State.Time,
+	}
+	s.recordFrames = frameStore{limit: s.RecordRetention}
+	if s.writer == nil {
+		s.writer = newRecordWriter(s.logs.recording)
+	}
+	s.writer.send(recordOp{begin: &recordingHeader{
+		dir:     s.RecordDir,
+		Name:    s.recording.Name,
+		Created: now,
+		Seed:    s.recording.Seed,
+		Dt:      s.recording.Dt,
+	}}, false)
+	s.appendFrameLocked()
+}
+
+// recordFrameLocked appends the current state to the active recording and
+// finishes it once the run has ended.
+func (s *Simulator) recordFrameLocked() {
+	if s.recording == nil {
+		return
+	}
+	s.appendFrameLocked()
+	if s.finishedLocked() {
+		s.finishRecordingLocked()
+	}
+}
+
+// appendFrameLocked copies the current state into the recording, kept in
+// memory within RecordRetention, and queues the copy for the disk, dropping
+// it there if the writer is behind and the policy allows.
+func (s *Simulator) appendFrameLocked() {
+	st := cloneState(s.State)
+	s.recordFrames.push(st)
+	if !s.writer.send(recordOp{frame: &st}, s.RecordPolicy != RecordBlock) {
+		s.recording.Dropped++
+		s.recordDropped.Add(1)
+	}
+}
+
+// finishRecordingLocked closes the active recording, queueing its manifest
+// and events behind its frames.
+func (s *Simulator) finishRecordingLocked() {
+	rec := s.recording
+	s.recording = nil
+	s.recordFrames = frameStore{}
+	if rec == nil {
+		return
+	}
+	m := s.manifest.clone()
+	foot := &recordingFooter{Manifest: &m, Dropped: rec.Dropped}
+	for _, ev := range s.events {
+		if ev.Time >= rec.start {
+			foot.Events = append(foot.Events, ev)
+		}
+	}
+	if rec.Dropped > 0 {
+		s.logs.recording.Warn("frames dropped because the disk fell behind", "recording", rec.Name, "dropped", rec.Dropped)
+	}
+	s.writer.send(recordOp{end: foot}, false)
+}
+
+// Close stops the loop and finishes the recording in progress, returning
+// once every recorded frame is on disk. The simulator stays usable
+// afterwards.
+func (s *Simulator) Close() {
+	s.Stop()
+	s.lock()
+	s.finishRecordingLocked()
+	w := s.writer
+	s.writer = nil
+	s.mu.Unlock()
+	s.SetExternalGuidance(nil)
+	if w != nil {
+		w.close()
+	}
+}
+
+// SaveRecording writes a recording to dir as <name>.json. It never
+// overwrites an existing recording.
+func SaveRecording(dir string, rec *Recording) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(filepath.Join(dir, rec.Name+recordingExt), os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(f).Encode(rec); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// LoadRecording reads a named recording from dir.
+func LoadRecording(dir, name string) (*Recording, error) {
+	if name == "" || name != filepath.Base(name) || strings.ContainsAny(name, `/\`) {
+		return nil, fmt.Errorf("invalid recording name %q", name)
+	}
+	f, err := os.Open(filepath.Join(dir, strings.TrimSuffix(name, recordingExt)+recordingExt))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	var rec Recording
+	if err := json.NewDecoder(f).Decode(&rec); err != nil {
+		return nil, fmt.Errorf("decode recording %s: %w", name, err)
+	}
+	if len(rec.Frames) == 0 {
+		return nil, fmt.Errorf("recording %s has no frames", name)
+	}
+	return &rec, nil
+}
+
+// ListRecordings returns the recordings stored in dir, newest first.
+func ListRecordings(dir string) ([]RecordingInfo, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return []RecordingInfo{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := []RecordingInfo{}
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != recordingExt {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		list = append(list, RecordingInfo{
+			Name:     strings.TrimSuffix(e.Name(), recordingExt),
+			Size:     info.Size(),
+			Modified: info.ModTime(),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Modified.After(list[j].Modified)
+	})
+	return list, nil
+}
+
+// Player plays a recording back against the wall clock at an adjustable speed.
+type Player struct {
+	mu     sync.Mutex
+	rec    *Recording
+	speed  float64
+	base   float64   // simulated time at anchor
+	anchor time.Time // wall-clock time playback resumed from base
+}
+
+// NewPlayer starts playback of rec from the beginning.
+func NewPlayer(rec *Recording, speed float64) *Player {
+	return &Player{rec: rec, speed: speed, anchor: time.Now()}
+}
+
+// Recording returns the recording being played.
+func (p *Player) Recording() *Recording {
+	return p.rec
+}
+
+// timeLocked returns the current playback position in simulated seconds.
+func (p *Player) timeLocked(now time.Time) float64 {
+	t := p.base + now.Sub(p.anchor).Seconds()*p.speed
+	return max(0, min(t, p.rec.Duration()))
+}
+
+// Seek jumps playback to simulated time t.
+func (p *Player) Seek(t float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.base = max(0, min(t, p.rec.Duration()))
+	p.anchor = time.Now()
+}
+
+// SetSpeed changes the playback rate; 0 pauses.
+func (p *Player) SetSpeed(speed float64) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	p.base = p.timeLocked(now)
+	p.anchor = now
+	p.speed = speed
+}
+
+// State returns the recorded frame at the current playback position.
+func (p *Player) State() SimulationState {
+	p.mu.Lock()
+	t := p.timeLocked(time.Now())
+	p.mu.Unlock()
+
+	frames := p.rec.Frames
+	i := sort.Search(len(frames), func(i int) bool { return frames[i].Time > t })
+	if i > 0 {
+		i--
+	}
+	st := frames[i]
+	st.Replay = true
+	return st
+}
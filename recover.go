@@ -0,0 +1,32 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+)
+
+// recovered, deferred at the top of a goroutine the server starts, logs a
+// panic with its stack instead of letting it take the process, and every
+// other session, down with it. cleanup, if not nil, then runs.
+func recovered(what string, cleanup func()) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	slog.Error("panic recovered", "in", what, "panic", p, "stack", string(debug.Stack()))
+	if cleanup != nil {
+		cleanup()
+	}
+}
+
+// recoverError, deferred with the address of a function's error result,
+// turns a panic in the function into that error, logged with its stack.
+func recoverError(err *error, what string) {
+	p := recover()
+	if p == nil {
+		return
+	}
+	slog.Error("panic recovered", "in", what, "panic", p, "stack", string(debug.Stack()))
+	*err = fmt.Errorf("%s failed: internal error", what)
+}
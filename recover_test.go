@@ -0,0 +1,48 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRecoverError(t *testing.T) {
+	tests := []struct {
+		name    string
+		fn      func() error
+		wantErr string
+	}{
+		{"ok", func() error { return nil }, ""},
+		{"error", func() error { return errors.New("refused") }, "refused"},
+		{"panic", func() error { panic("boom") }, "step command failed: internal error"},
+		{"nil deref", func() error { var c *Command; return errors.New(c.Type) }, "step command failed: internal error"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := func() (err error) {
+				defer recoverError(&err, "step command")
+				return tt.fn()
+			}()
+			var got string
+			if err != nil {
+				got = err.Error()
+			}
+			if got != tt.wantErr {
+				t.Errorf("err %q, want %q", got, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRecovered(t *testing.T) {
+	var cleaned bool
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer recovered("test", func() { cleaned = true })
+		panic("boom")
+	}()
+	<-done
+	if !cleaned {
+		t.Error("cleanup did not run")
+	}
+}
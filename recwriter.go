@@ -0,0 +1,207 @@
+package simulation
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// RecordPolicy is what recording does with a frame when the disk writer has
+// fallen a full queue behind.
+type RecordPolicy string
+
+const (
+	RecordDrop  RecordPolicy = "drop"  // skip the frame and count it; the loop never waits
+	RecordBlock RecordPolicy = "block" // wait for room; no frame is lost, but a slow disk slows the loop
+)
+
+// recordQueueFrames bounds the frames waiting for the disk: about 17 s of
+// a 60 Hz run.
+const recordQueueFrames = 1024
+
+// ParseRecordPolicy checks a policy name; empty means RecordDrop.
+func ParseRecordPolicy(name string) (RecordPolicy, error) {
+	switch p := RecordPolicy(name); p {
+	case "":
+		return RecordDrop, nil
+	case RecordDrop, RecordBlock:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown recording queue policy %q (want drop or block)", name)
+}
+
+// recordWriter streams recordings to disk on its own goroutine, a frame at a
+// time, so the step that produced a frame never waits for the file system.
+// A recording is written to <name>.json.part and renamed into place when it
+// ends, so ListRecordings never sees half a file.
+type recordWriter struct {
+	queue chan recordOp
+	done  chan struct{}
+	log   *slog.Logger
+}
+
+// recordOp is one message to the writer; exactly one field is set.
+type recordOp struct {
+	begin *recordingHeader // open a new recording
+	frame *SimulationState // append a frame to it
+	end   *recordingFooter // close it
+}
+
+// recordingHeader and recordingFooter are the parts of a Recording written
+// before and after its frames.
+type recordingHeader struct {
+	dir     string
+	Name    string    `json:"name"`
+	Created time.Time `json:"created"`
+	Seed    uint64    `json:"seed"`
+	Dt      float64   `json:"dt"`
+}
+
+type recordingFooter struct {
+	Manifest *Manifest `json:"manifest,omitempty"`
+	Events   []Event   `json:"events,omitempty"`
+	Dropped  int       `json:"dropped,omitempty"`
+}
+
+func newRecordWriter(log *slog.Logger) *recordWriter {
+	w := &recordWriter{
+		queue: make(chan recordOp, recordQueueFrames),
+		done:  make(chan struct{}),
+		log:   log,
+	}
+	go w.run()
+	return w
+}
+
+// send queues op, waiting for room unless drop is set, and reports whether
+// it was queued.
+func (w *recordWriter) send(op recordOp, drop bool) bool {
+	if !drop {
+		w.queue <- op
+		return true
+	}
+	select {
+	case w.queue <- op:
+		return true
+	default:
+		return false
+	}
+}
+
+// close waits for everything queued to reach the disk and stops the writer.
+// Nothing may be sent afterwards.
+func (w *recordWriter) close() {
+	close(w.queue)
+	<-w.done
+}
+
+func (w *recordWriter) run() {
+	defer close(w.done)
+	var f *recordingFile
+	for op := range w.queue {
+		var err error
+		switch {
+		case op.begin != nil:
+			if f != nil {
+				f.abort()
+			}
+			f, err = createRecordingFile(op.begin)
+		case f == nil:
+			// The recording failed to open or has failed since; skip the rest of it.
+		case op.frame != nil:
+			err = f.frame(op.frame)
+		case op.end != nil:
+			err = f.finish(op.end)
+			f = nil
+		}
+		if err != nil {
+			w.log.Error("write failed", "err", err)
+			if f != nil {
+				f.abort()
+				f = nil
+			}
+		}
+	}
+	if f != nil {
+		f.abort()
+	}
+}
+
+// recordingFile is a recording being streamed to disk.
+type recordingFile struct {
+	f      *os.File
+	w      *bufio.Writer
+	enc    *json.Encoder
+	path   string // final name
+	frames int
+}
+
+func createRecordingFile(h *recordingHeader) (*recordingFile, error) {
+	if err := os.MkdirAll(h.dir, 0o755); err != nil {
+		return nil, err
+	}
+	path := filepath.Join(h.dir, h.Name+recordingExt)
+	f, err := os.OpenFile(path+".part", os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	rf := &recordingFile{f: f, w: bufio.NewWriter(f), path: path}
+	rf.enc = json.NewEncoder(rf.w)
+	b, err := json.Marshal(h)
+	if err == nil {
+		rf.w.Write(b[:len(b)-1])
+		_, err = rf.w.WriteString(`,"frames":[`)
+	}
+	if err != nil {
+		rf.abort()
+		return nil, err
+	}
+	return rf, nil
+}
+
+func (rf *recordingFile) frame(st *SimulationState) error {
+	if rf.frames > 0 {
+		rf.w.WriteByte(',')
+	}
+	rf.frames++
+	return rf.enc.Encode(st)
+}
+
+// finish completes the file and moves it into place, or removes it if it
+// holds too little to replay. It never overwrites an existing recording.
+func (rf *recordingFile) finish(foot *recordingFooter) error {
+	if rf.frames < 2 {
+		rf.abort()
+		return nil
+	}
+	rf.w.WriteByte(']')
+	b, err := json.Marshal(foot)
+	if err != nil {
+		rf.abort()
+		return err
+	}
+	if len(b) > 2 {
+		rf.w.WriteByte(',')
+	}
+	rf.w.Write(b[1:])
+	if err := rf.w.Flush(); err != nil {
+		rf.abort()
+		return err
+	}
+	if err := rf.f.Close(); err != nil {
+		os.Remove(rf.f.Name())
+		return err
+	}
+	defer os.Remove(rf.f.Name())
+	return os.Link(rf.f.Name(), rf.path)
+}
+
+// abort discards the partial file.
+func (rf *recordingFile) abort() {
+	rf.f.Close()
+	os.Remove(rf.f.Name())
+}
@@ -0,0 +1,138 @@
+package simulation
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestRecordWriter(t *testing.T) {
+	tests := []struct {
+		name   string
+		policy RecordPolicy
+	}{
+		{"drop", RecordDrop},
+		{"block", RecordBlock},
+		{"default", ""},
+	}
+	for _, tt := range tests {
+		s := NewSimulator()
+		s.SetLogger(logging.Discard)
+		s.Seed = 42
+		s.RecordDir = t.TempDir()
+		s.RecordPolicy = tt.policy
+		s.Reset()
+		if err := s.SetRecording(true); err != nil {
+			t.Fatal(err)
+		}
+		s.RunToCompletion(5)
+		s.Close()
+
+		list, err := ListRecordings(s.RecordDir)
+		if err != nil || len(list) != 1 {
+			t.Fatalf("%s: recordings %v, %v", tt.name, list, err)
+		}
+		rec, err := LoadRecording(s.RecordDir, list[0].Name)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if rec.Manifest == nil {
+			t.Errorf("%s: recording has no manifest", tt.name)
+		}
+		if got := s.Health().RecordDropped; uint64(rec.Dropped) != got {
+			t.Errorf("%s: recording dropped %d frames, health reports %d", tt.name, rec.Dropped, got)
+		}
+		if tt.policy == RecordBlock && rec.Dropped != 0 {
+			t.Errorf("%s: blocking writer dropped %d frames", tt.name, rec.Dropped)
+		}
+		for i := 1; i < len(rec.Frames); i++ {
+			if rec.Frames[i].Time <= rec.Frames[i-1].Time {
+				t.Fatalf("%s: frame %d at %g s follows %g s", tt.name, i, rec.Frames[i].Time, rec.Frames[i-1].Time)
+			}
+		}
+		if rec.Dropped == 0 && rec.Duration() != s.GetState().Time {
+			t.Errorf("%s: recording ends at %g s, run at %g s", tt.name, rec.Duration(), s.GetState().Time)
+		}
+		if part, _ := filepath.Glob(filepath.Join(s.RecordDir, "*.part")); len(part) != 0 {
+			t.Errorf("%s: partial files left behind: %v", tt.name, part)
+		}
+	}
+}
+
+func TestRecordWriterDiscardsEmpty(t *testing.T) {
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	s.RecordDir = t.TempDir()
+	if err := s.SetRecording(true); err != nil {
+		t.Fatal(err)
+	}
+	s.Close() // a single frame is not worth keeping
+	entries, err := os.ReadDir(s.RecordDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 0 {
+		t.Errorf("recording dir holds %d files, want none", len(entries))
+	}
+}
+
+func TestParseRecordPolicy(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    RecordPolicy
+		wantErr bool
+	}{
+		{"", RecordDrop, false},
+		{"drop", RecordDrop, false},
+		{"block", RecordBlock, false},
+		{"wait", "", true},
+	}
+	for _, tt := range tests {
+		got, err := ParseRecordPolicy(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseRecordPolicy(%q) = %q, %v", tt.in, got, err)
+		}
+	}
+}
+
+// archiveFunc adapts a function to RunArchiver.
+type archiveFunc func(RunArchive)
+
+func (f archiveFunc) ArchiveRun(a RunArchive) { f(a) }
+
+func TestRecordRetention(t *testing.T) {
+	var archived []SimulationState
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	s.Seed = 42
+	s.RecordDir = t.TempDir()
+	s.RecordPolicy = RecordBlock
+	s.RecordRetention = Retention{Frames: 50}
+	s.Archive = archiveFunc(func(a RunArchive) { archived = a.Frames })
+	s.Reset()
+	if err := s.SetRecording(true); err != nil {
+		t.Fatal(err)
+	}
+	s.RunToCompletion(5)
+	s.Close()
+
+	list, err := ListRecordings(s.RecordDir)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("recordings %v, %v", list, err)
+	}
+	rec, err := LoadRecording(s.RecordDir, list[0].Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(archived) == 0 || len(archived) > 50 {
+		t.Fatalf("archived %d frames, want 1 to 50", len(archived))
+	}
+	if len(rec.Frames) <= 50 {
+		t.Errorf("file holds %d frames, want every step", len(rec.Frames))
+	}
+	if archived[0].Time != rec.Frames[0].Time {
+		t.Errorf("archive starts at %g s, recording at %g s", archived[0].Time, rec.Frames[0].Time)
+	}
+}
@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Overrides are the parameters changed for a rerun. Params takes the same
+// names as a sweep axis; navGain multiplies the guidance command, so raising
+// ProNav's N from 3 to 5 is a navGain of 5/3.
+type Overrides struct {
+	Guidance string             `json:"guidance,omitempty"`
+	Params   map[string]float64 `json:"params,omitempty"`
+}
+
+// Rerun restarts the current scenario with the seed of the current run and
+// the given overrides applied, so the only difference from the last run is
+// what was overridden. The overridden scenario becomes the current one.
+func (s *Simulator) Rerun(o Overrides) error {
+	if err := CheckGuidanceMode(o.Guidance); err != nil {
+		return err
+	}
+	for name, v := range o.Params {
+		if err := checkSweepParam(name); err != nil {
+			return err
+		}
+		if err := checkSweepValue(name, v); err != nil {
+			return err
+		}
+	}
+
+	s.lock()
+	defer s.mu.Unlock()
+
+	sc := s.Scenario.Clone()
+	for name, v := range o.Params {
+		applySweepParam(sc, name, v)
+	}
+	if o.Guidance != "" {
+		for i := range sc.Entities {
+			sc.Entities[i].Guidance = o.Guidance
+		}
+	}
+	if err := sc.Validate(); err != nil {
+		return fmt.Errorf("overrides: %w", err)
+	}
+
+	s.haltLocked()
+	s.finishRecordingLocked()
+	s.Scenario = sc
+	seed := s.Seed
+	s.Seed = s.State.Seed
+	s.resetLocked()
+	s.Seed = seed
+	if o.Guidance != "" {
+		s.overrideLocked("guidance", o.Guidance)
+	}
+	for _, name := range sortedKeys(o.Params) {
+		s.overrideLocked(name, o.Params[name])
+	}
+	s.transitionLocked("start", LifecycleRunning)
+	s.startLocked()
+	return nil
+}
+
+// sortedKeys returns the map's keys in order, so overrides are recorded
+// deterministically.
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
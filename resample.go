@@ -0,0 +1,68 @@
+package simulation
+
+import (
+	"sort"
+
+	"missile-intercept-sim/internal/trajectory"
+)
+
+// Track returns entity id's recorded trajectory, from the frames it
+// appears in.
+func (r *Recording) Track(id string) trajectory.Track {
+	var tr trajectory.Track
+	for i := range r.Frames {
+		f := &r.Frames[i]
+		if len(tr) > 0 && f.Time <= tr.End() {
+			continue // a frame restating the last time adds nothing
+		}
+		for _, e := range f.Entities {
+			if e.ID == id {
+				tr = append(tr, trajectory.Sample{T: f.Time, Position: e.Position, Velocity: e.Velocity, Acceleration: e.Acceleration})
+				break
+			}
+		}
+	}
+	return tr
+}
+
+// Resample returns a copy of the recording with a frame every dt seconds
+// from its first, for exports and replays at a rate other than the
+// simulation step's. Entities' positions, velocities and accelerations are
+// interpolated along their trajectories; everything else is as of the last
+// recorded frame at or before each time.
+func (r *Recording) Resample(dt float64) *Recording {
+	out := *r
+	out.Dt = dt
+	out.Frames = nil
+	if len(r.Frames) == 0 {
+		return &out
+	}
+	tracks := make(map[string]trajectory.Track)
+	times := trajectory.Times(r.Frames[0].Time, r.Duration(), dt)
+	out.Frames = make([]SimulationState, 0, len(times))
+	for _, t := range times {
+		i := sort.Search(len(r.Frames), func(i int) bool { return r.Frames[i].Time > t }) - 1
+		f := cloneState(r.Frames[i])
+		f.Time = t
+		f.Events = nil // the recording's events carry their own times
+		for _, e := range f.Entities {
+			tr, ok := tracks[e.ID]
+			if !ok {
+				tr = r.Track(e.ID)
+				tracks[e.ID] = tr
+			}
+			if s, ok := tr.At(t); ok {
+				e.Position, e.Velocity, e.Acceleration = s.Position, s.Velocity, s.Acceleration
+			}
+		}
+		out.Frames = append(out.Frames, f)
+	}
+	return &out
+}
+
+// ClosestApproach returns when and how closely entities a and b passed,
+// interpolating between frames, or false if they were never recorded
+// together.
+func (r *Recording) ClosestApproach(a, b string) (t, dist float64, ok bool) {
+	return trajectory.ClosestApproach(r.Track(a), r.Track(b))
+}
@@ -0,0 +1,75 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+
+	"missile-intercept-sim/internal/entities"
+)
+
+func TestResample(t *testing.T) {
+	rec := recordRun(t, 30)
+	tests := []struct {
+		name string
+		dt   float64
+	}{
+		{"coarser", 0.5},
+		{"finer", rec.Dt / 4},
+		{"same", rec.Dt},
+	}
+	for _, tt := range tests {
+		out := rec.Resample(tt.dt)
+		if out.Dt != tt.dt || len(out.Frames) == 0 || out.Frames[0].Time != rec.Frames[0].Time {
+			t.Fatalf("%s: %d frames from %g s every %g s", tt.name, len(out.Frames), out.Frames[0].Time, out.Dt)
+		}
+		if last := out.Duration(); last > rec.Duration()+1e-9 || rec.Duration()-last >= tt.dt {
+			t.Errorf("%s: ends at %g s, recording at %g s", tt.name, last, rec.Duration())
+		}
+		for i, f := range out.Frames {
+			if want := rec.Frames[0].Time + float64(i)*tt.dt; math.Abs(f.Time-want) > 1e-9 {
+				t.Fatalf("%s: frame %d at %g s, want %g s", tt.name, i, f.Time, want)
+			}
+		}
+		// Where a resampled frame falls on a recorded one, it is that frame.
+		mid := out.Frames[len(out.Frames)/2]
+		for _, f := range rec.Frames {
+			if math.Abs(f.Time-mid.Time) > 1e-9 {
+				continue
+			}
+			for j, e := range f.Entities {
+				if got := mid.Entities[j].Position; got.Distance(e.Position) > 1e-6 {
+					t.Errorf("%s: %s at %v, recorded at %v", tt.name, e.ID, got, e.Position)
+				}
+			}
+		}
+	}
+	if rec.Frames[1].Entities[0] == rec.Resample(rec.Dt).Frames[1].Entities[0] {
+		t.Error("resampled frames share entities with the recording")
+	}
+}
+
+func TestRecordingClosestApproach(t *testing.T) {
+	rec := recordRun(t, 30)
+	coarsest := math.Inf(1)
+	for _, f := range rec.Frames {
+		var m, tg *entities.Entity
+		for _, e := range f.Entities {
+			switch e.ID {
+			case "missile-1":
+				m = e
+			case "target-1":
+				tg = e
+			}
+		}
+		if m != nil && tg != nil {
+			coarsest = min(coarsest, m.Position.Distance(tg.Position))
+		}
+	}
+	tc, dist, ok := rec.ClosestApproach("missile-1", "target-1")
+	if !ok || dist > coarsest || tc <= 0 || tc > rec.Duration() {
+		t.Errorf("closest approach %g m at %g s (%v); frames come within %g m", dist, tc, ok, coarsest)
+	}
+	if _, _, ok := rec.ClosestApproach("missile-1", "nobody"); ok {
+		t.Error("closest approach to a missing entity")
+	}
+}
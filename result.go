@@ -0,0 +1,124 @@
+package simulation
+
+import (
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/units"
+)
+
+// maxResultHistory bounds how many finished runs a simulator remembers.
+const maxResultHistory = 100
+
+// OutcomeReport is the structured summary of a finished run.
+type OutcomeReport struct {
+	Scenario     string             `json:"scenario"`
+	Seed         uint64             `json:"seed"`
+	Result       string             `json:"result"` // Intercepted, Crashed, Leaked, Timeout
+	Reason       string             `json:"reason"` // termination condition that ended the run
+	Time         float64            `json:"time"`   // s, simulation time at the end of the run
+	MissDistance float64            `json:"missDistance"`
+	Finished     time.Time          `json:"finished"`
+	Manifest     Manifest           `json:"manifest"`
+	Engagements  []EngagementReport `json:"engagements"`
+}
+
+// EngagementReport is the outcome of one interceptor's flight.
+type EngagementReport struct {
+	MissileID       string        `json:"missileId"`
+	TargetID        string        `json:"targetId"`
+	Guidance        string        `json:"guidance"`
+	Result          string        `json:"result"`
+	MissDistance    float64       `json:"missDistance"`    // m, at closest approach
+	ClosestApproach float64       `json:"closestApproach"` // s, time of closest approach
+	TimeOfFlight    float64       `json:"timeOfFlight"`    // s
+	MaxG            float64       `json:"maxG"`            // peak commanded load factor
+	BurnoutTime     float64       `json:"burnoutTime"`     // s, time of peak speed
+	Speed           float64       `json:"speed"`           // m/s at end of flight
+	Energy          float64       `json:"energy"`          // J/kg, specific energy at end of flight
+	Miss            *MissAnalysis `json:"miss,omitempty"`  // missed after reaching the endgame
+}
+
+// RunArchive is everything kept of a finished run: its report, its event
+// log and, if it was being recorded, its frames so far.
+type RunArchive struct {
+	Report OutcomeReport
+	Events []Event
+	Frames []SimulationState
+}
+
+// RunArchiver receives every run a simulator finishes. ArchiveRun is called
+// with the simulator's lock held, so it must neither block nor call back
+// into the simulator.
+type RunArchiver interface {
+	ArchiveRun(RunArchive)
+}
+
+// specificEnergy is kinetic plus potential energy per unit mass.
+func specificEnergy(e *entities.Entity) float64 {
+	v := e.Velocity.Magnitude()
+	return 0.5*v*v + units.G*e.Position.Y
+}
+
+// reportLocked builds the outcome report for the run that just ended and
+// appends it to the history.
+func (s *Simulator) reportLocked() {
+	rep := OutcomeReport{
+		Scenario:     s.State.Scenario,
+		Seed:         s.State.Seed,
+		Result:       s.State.Status,
+		Reason:       s.State.Reason,
+		Time:         s.State.Time,
+		MissDistance: s.State.MissDistance,
+		Finished:     time.Now(),
+		Manifest:     s.manifest.clone(),
+	}
+	for _, ic := range s.Interceptors {
+		er := EngagementReport{
+			MissileID:       ic.Missile.ID,
+			TargetID:        ic.Target.Entity.ID,
+			Guidance:        ic.GuidanceName,
+			Result:          ic.Status,
+			MissDistance:    ic.MissDistance,
+			ClosestApproach: ic.ClosestApproach,
+			TimeOfFlight:    ic.FlightTime,
+			MaxG:            ic.MaxG,
+			BurnoutTime:     ic.BurnoutTime,
+			Speed:           ic.Missile.Velocity.Magnitude(),
+			Energy:          specificEnergy(ic.Missile),
+		}
+		if ic.Status != "Intercepted" && ic.cpa.Open {
+			er.Miss = ic.cpa.analysis(ic.ClosestApproach, ic.MissVector)
+		}
+		rep.Engagements = append(rep.Engagements, er)
+	}
+	s.result = &rep
+	s.results = append(s.results, rep)
+	if len(s.results) > maxResultHistory {
+		s.results = s.results[len(s.results)-maxResultHistory:]
+	}
+	if s.Archive != nil {
+		a := RunArchive{Report: rep, Events: append([]Event(nil), s.events...)}
+		if s.recording != nil {
+			// Copied, since the store thins its frames in place. The
+			// frames themselves are never modified.
+			a.Frames = append([]SimulationState(nil), s.recordFrames.frames...)
+		}
+		s.Archive.ArchiveRun(a)
+	}
+}
+
+// Result returns the report of the most recently finished run, or nil if
+// no run has finished yet.
+func (s *Simulator) Result() *OutcomeReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.result
+}
+
+// Results returns the reports of finished runs, oldest first.
+func (s *Simulator) Results() []OutcomeReport {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return append([]OutcomeReport(nil), s.results...)
+}
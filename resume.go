@@ -0,0 +1,105 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+// maxResumeFrames bounds the missed frames replayed to a reconnecting
+// client. A longer gap is not filled; the client gets the live state.
+const maxResumeFrames = 900
+
+// ResumeToken names the last frame a client received, by the run and time
+// fields of that frame. A client reconnects with ?resume=<run>:<time>.
+type ResumeToken struct {
+	Run  uint64
+	Time float64
+}
+
+func (t ResumeToken) String() string {
+	return strconv.FormatUint(t.Run, 10) + ":" + strconv.FormatFloat(t.Time, 'g', -1, 64)
+}
+
+// ParseResumeToken parses a "<run>:<time>" token.
+func ParseResumeToken(s string) (ResumeToken, error) {
+	run, t, ok := strings.Cut(s, ":")
+	var tok ResumeToken
+	var err1, err2 error
+	tok.Run, err1 = strconv.ParseUint(run, 10, 64)
+	tok.Time, err2 = strconv.ParseFloat(t, 64)
+	if !ok || err1 != nil || err2 != nil || tok.Time < 0 {
+		return tok, fmt.Errorf("resume token must be <run>:<time>, got %q", s)
+	}
+	return tok, nil
+}
+
+// ResumeResult is the first message to a client that connected with a
+// resume token. When OK, Frames missed frames follow before the live
+// stream; otherwise the run was reset or the gap is older than the history,
+// and the stream carries on from the live state.
+type ResumeResult struct {
+	Resume string `json:"resume"`
+	OK     bool   `json:"ok"`
+	Frames int    `json:"frames"`
+}
+
+// resume returns what c missed since its token, to be written straight to
+// the client before it joins the broadcast: the ResumeResult, then the
+// missed frames from the simulator's history, thinned to c's update rate and
+// without trails.
+func (h *Hub) resume(c *hubClient) ([]outgoing, error) {
+	res := ResumeResult{Resume: c.Resume.String()}
+	var frames []simulation.SimulationState
+	if h.sess.Player() == nil {
+		frames, res.OK = h.sess.Sim.Resume(c.Resume.Run, c.Resume.Time)
+	}
+	spacing := broadcastInterval.Seconds()
+	if c.interval > 0 {
+		spacing = c.interval.Seconds()
+	}
+	frames = thinFrames(frames, c.Resume.Time, spacing)
+	if len(frames) > maxResumeFrames {
+		frames, res.OK = nil, false
+	}
+	c.resumed = res.OK
+
+	msgs := []outgoing{{event: "resume"}}
+	h.mu.Lock()
+	for _, st := range frames {
+		tf := newTickFrames()
+		msg, err := h.frameLocked(c, st, 0, tf)
+		tf.release()
+		if err != nil {
+			h.mu.Unlock()
+			return nil, err
+		}
+		msgs = append(msgs, msg)
+	}
+	h.mu.Unlock()
+	res.Frames = len(msgs) - 1
+	var err error
+	if msgs[0].data, err = marshal(res, c.Format); err != nil {
+		return nil, err
+	}
+	return msgs, nil
+}
+
+// thinFrames keeps about one frame per spacing seconds of simulated time
+// after from, the way the broadcast would have sent them.
+func thinFrames(frames []simulation.SimulationState, from, spacing float64) []simulation.SimulationState {
+	var out []simulation.SimulationState
+	next := from + spacing
+	for _, st := range frames {
+		if st.Time < next {
+			continue
+		}
+		out = append(out, st)
+		if next += spacing; next <= st.Time {
+			next = st.Time + spacing
+		}
+	}
+	return out
+}
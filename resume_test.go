@@ -0,0 +1,104 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/simulation"
+)
+
+func TestParseResumeToken(t *testing.T) {
+	tests := []struct {
+		in   string
+		want ResumeToken
+		err  bool
+	}{
+		{"3:12.5", ResumeToken{3, 12.5}, false},
+		{"1:0", ResumeToken{1, 0}, false},
+		{"12.5", ResumeToken{}, true},
+		{"x:1", ResumeToken{}, true},
+		{"1:-2", ResumeToken{}, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseResumeToken(tt.in)
+		if (err != nil) != tt.err || (!tt.err && got != tt.want) {
+			t.Errorf("ParseResumeToken(%q) = %v, %v", tt.in, got, err)
+		}
+		if !tt.err && got.String() != tt.in {
+			t.Errorf("%v.String() = %q, want %q", got, got.String(), tt.in)
+		}
+	}
+}
+
+func TestThinFrames(t *testing.T) {
+	var frames []simulation.SimulationState
+	for i := 1; i <= 125; i++ {
+		frames = append(frames, simulation.SimulationState{Time: float64(i) * 0.016})
+	}
+	got := thinFrames(frames, 0, broadcastInterval.Seconds())
+	// 2s of 60Hz steps thinned to the 30Hz broadcast rate.
+	if n := len(got); n < 58 || n > 61 {
+		t.Errorf("kept %d of %d frames", n, len(frames))
+	}
+}
+
+func TestResume(t *testing.T) {
+	sess := newSession("test")
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	if _, err := sess.Sim.Advance(200); err != nil {
+		t.Fatal(err)
+	}
+	state := sess.State()
+	history := sess.Sim.History(0, math.Inf(1))
+	last := history[100].Time // the client saw frames up to here
+
+	tests := []struct {
+		name   string
+		token  ResumeToken
+		ok     bool
+		frames int // at least
+	}{
+		{"within history", ResumeToken{state.Run, last}, true, 40},
+		{"after a reset", ResumeToken{state.Run - 1, last}, false, 0},
+		{"from the future", ResumeToken{state.Run, state.Time + 1}, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := dialHub(t, sess, "resume="+tt.token.String())
+			conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+			var res ResumeResult
+			if err := conn.ReadJSON(&res); err != nil {
+				t.Fatal(err)
+			}
+			if res.OK != tt.ok || res.Frames < tt.frames || res.Resume != tt.token.String() {
+				t.Fatalf("got %+v, want ok=%v with %d+ frames", res, tt.ok, tt.frames)
+			}
+			prev := tt.token.Time
+			for i := 0; i < res.Frames; i++ {
+				var st simulation.SimulationState
+				if err := conn.ReadJSON(&st); err != nil {
+					t.Fatal(err)
+				}
+				if st.Time <= prev {
+					t.Fatalf("frame %d at t=%g after t=%g", i, st.Time, prev)
+				}
+				prev = st.Time
+			}
+			var live simulation.SimulationState
+			if err := conn.ReadJSON(&live); err != nil {
+				t.Fatal(err)
+			}
+			if live.Time != state.Time {
+				t.Errorf("live frame at t=%g, want %g", live.Time, state.Time)
+			}
+			for _, e := range live.Events {
+				if tt.ok && e.Time <= tt.token.Time {
+					t.Errorf("resent event %+v", e)
+				}
+			}
+		})
+	}
+}
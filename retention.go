@@ -0,0 +1,129 @@
+package simulation
+
+import (
+	"fmt"
+	"sort"
+	"unsafe"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/sensors"
+)
+
+// Retention bounds how much of a run a store of frames keeps in memory.
+// Past the frame or byte limit the older half of the store is thinned to
+// every other frame, so recent data stays at the step rate and old data
+// grows sparser the older it gets; frames older than Duration go entirely.
+// Zero fields impose no limit.
+type Retention struct {
+	Duration float64 `json:"duration,omitempty"` // s of simulated time back from the newest frame
+	Frames   int     `json:"frames,omitempty"`
+	Bytes    int64   `json:"bytes,omitempty"` // estimated memory held by the frames
+}
+
+// DefaultRecordRetention caps the frames a recording holds in memory for
+// the run archive: about 18 minutes of a 60 Hz run before thinning starts.
+// The file on disk always has every frame.
+var DefaultRecordRetention = Retention{Frames: 1 << 16}
+
+// Validate checks the limits are usable.
+func (r Retention) Validate() error {
+	switch {
+	case r.Duration < 0:
+		return fmt.Errorf("retention duration must not be negative")
+	case r.Frames < 0:
+		return fmt.Errorf("retention frame count must not be negative")
+	case r.Frames == 1:
+		return fmt.Errorf("retention must keep at least 2 frames")
+	case r.Bytes < 0:
+		return fmt.Errorf("retention bytes must not be negative")
+	}
+	return nil
+}
+
+// frameStore holds a run's frames, oldest first, within a Retention.
+type frameStore struct {
+	limit  Retention
+	frames []SimulationState
+	bytes  int64
+	full   int // frames[full:] are consecutive steps; older ones may have been thinned
+}
+
+// push appends a frame, then evicts and thins older ones to stay within
+// the limits.
+func (fs *frameStore) push(st SimulationState) {
+	fs.frames = append(fs.frames, st)
+	fs.bytes += stateSize(&st)
+	if fs.limit.Duration > 0 {
+		for len(fs.frames) > 1 && fs.frames[0].Time < st.Time-fs.limit.Duration {
+			fs.evict()
+		}
+	}
+	for fs.over() {
+		if !fs.thin() {
+			fs.evict()
+		}
+	}
+}
+
+func (fs *frameStore) over() bool {
+	return (fs.limit.Frames > 0 && len(fs.frames) > fs.limit.Frames) ||
+		(fs.limit.Bytes > 0 && fs.bytes > fs.limit.Bytes && len(fs.frames) > 1)
+}
+
+// evict drops the oldest frame.
+func (fs *frameStore) evict() {
+	fs.bytes -= stateSize(&fs.frames[0])
+	fs.frames[0] = SimulationState{} // let the collector have its entities
+	fs.frames = fs.frames[1:]
+	fs.full = max(0, fs.full-1)
+}
+
+// thin drops every other frame from the older half of the store, keeping
+// the oldest. It reports false if there are too few frames to thin.
+func (fs *frameStore) thin() bool {
+	old := len(fs.frames) / 2
+	if old < 3 {
+		return false
+	}
+	j := 1
+	for i := 1; i < old; i++ {
+		if i%2 == 0 {
+			fs.frames[j] = fs.frames[i]
+			j++
+		} else {
+			fs.bytes -= stateSize(&fs.frames[i])
+		}
+	}
+	n := j + copy(fs.frames[j:], fs.frames[old:])
+	clear(fs.frames[n:])
+	fs.frames = fs.frames[:n]
+	fs.full = max(fs.full, old) - (old - j)
+	return true
+}
+
+// between returns the frames with from <= Time <= to, oldest first.
+func (fs *frameStore) between(from, to float64) []SimulationState {
+	lo := sort.Search(len(fs.frames), func(i int) bool { return fs.frames[i].Time >= from })
+	hi := sort.Search(len(fs.frames), func(i int) bool { return fs.frames[i].Time > to })
+	if lo >= hi {
+		return nil
+	}
+	return append([]SimulationState(nil), fs.frames[lo:hi]...)
+}
+
+// fullFrom returns the time from which every step is stored.
+func (fs *frameStore) fullFrom() (float64, bool) {
+	if fs.full >= len(fs.frames) {
+		return 0, false
+	}
+	return fs.frames[fs.full].Time, true
+}
+
+// stateSize estimates the memory a cloned state holds.
+func stateSize(st *SimulationState) int64 {
+	return int64(unsafe.Sizeof(*st)) +
+		int64(len(st.Entities))*int64(unsafe.Sizeof(entities.Entity{})+unsafe.Sizeof(&entities.Entity{})) +
+		int64(len(st.Engagements))*int64(unsafe.Sizeof(EngagementStatus{})) +
+		int64(len(st.Threats))*int64(unsafe.Sizeof(ThreatAssessment{})) +
+		int64(len(st.Sensors))*int64(unsafe.Sizeof(sensors.Status{}))
+}
@@ -0,0 +1,308 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// ROS 2 message types the bridge speaks.
+const (
+	rosPoseType    = "geometry_msgs/msg/PoseStamped"
+	rosTwistType   = "geometry_msgs/msg/TwistStamped"
+	rosCommandType = "std_msgs/msg/String"
+)
+
+// rosBridge connects the simulator to a ROS 2 graph through a rosbridge
+// server (rosbridge_suite's WebSocket JSON protocol), so RViz and other ROS
+// tooling see entities without a DDS stack in this process. For each session
+// it publishes, under <namespace>/<session>/:
+//
+//	<entity>/pose     PoseStamped, nose along the velocity
+//	<entity>/twist    TwistStamped, linear velocity only
+//
+// and subscribes to <namespace>/<session>/command, a String holding either a
+// command name such as "start" or a JSON Command as sent over WebSockets.
+// Poses are in the REP 103 east-north-up convention, in frame.
+type rosBridge struct {
+	url       string
+	namespace string
+	frame     string
+	interval  time.Duration
+}
+
+// newROSBridge configures a bridge to the rosbridge server at rawURL,
+// ws://host:9090 or wss://host:9090.
+func newROSBridge(rawURL, namespace, frame string, rate float64) (*rosBridge, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "ws" && u.Scheme != "wss") || u.Host == "" {
+		return nil, fmt.Errorf("rosbridge URL must be ws://host:port or wss://host:port, got %q", rawURL)
+	}
+	if !(rate > 0 && rate <= maxClientRate) {
+		return nil, fmt.Errorf("ROS rate must be above 0 and at most %d", maxClientRate)
+	}
+	if !strings.HasPrefix(namespace, "/") {
+		return nil, fmt.Errorf("ROS namespace must start with /, got %q", namespace)
+	}
+	return &rosBridge{
+		url:       rawURL,
+		namespace: strings.TrimSuffix(namespace, "/"),
+		frame:     frame,
+		interval:  time.Duration(float64(time.Second) / rate),
+	}, nil
+}
+
+// Run bridges until ctx is done, reconnecting with backoff whenever the
+// rosbridge server is unreachable or drops the connection.
+func (b *rosBridge) Run(ctx context.Context) {
+	backoff := reconnectMinBackoff
+	for ctx.Err() == nil {
+		conn, _, err := websocket.DefaultDialer.DialContext(ctx, b.url, nil)
+		if err == nil {
+			slog.Info("ros: bridging", "url", b.url)
+			backoff = reconnectMinBackoff
+			err = b.serve(ctx, conn)
+			conn.Close()
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		slog.Warn("ros: retrying", "err", err, "backoff", backoff)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(2*backoff, reconnectMaxBackoff)
+	}
+}
+
+// rosLink is the bridge's state on one connection. Topics are advertised
+// and subscribed once per connection, as rosbridge forgets them when it
+// drops.
+type rosLink struct {
+	bridge     *rosBridge
+	advertised map[string]bool
+	mu         sync.Mutex
+	commands   map[string]string // command topic to session ID
+}
+
+// serve publishes on conn until ctx is done or the connection fails. Only
+// this goroutine writes to conn; a reader goroutine handles commands.
+func (b *rosBridge) serve(ctx context.Context, conn *websocket.Conn) error {
+	link := &rosLink{bridge: b, advertised: make(map[string]bool), commands: make(map[string]string)}
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			link.receive(data)
+		}
+	}()
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case now := <-ticker.C:
+			if err := link.tick(conn, now); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// rosWriter is the part of a WebSocket connection the bridge writes with.
+type rosWriter interface {
+	WriteJSON(v any) error
+}
+
+// rosOp is a rosbridge protocol message.
+type rosOp struct {
+	Op    string          `json:"op"`
+	Topic string          `json:"topic"`
+	Type  string          `json:"type,omitempty"`
+	Msg   json.RawMessage `json:"msg,omitempty"`
+}
+
+// tick publishes every session's entities, advertising and subscribing to
+// topics the first time they are needed.
+func (l *rosLink) tick(w rosWriter, now time.Time) error {
+	stamp := rosTime{Sec: now.Unix(), Nanosec: uint32(now.Nanosecond())}
+	for _, sess := range sessions.List() {
+		base := l.bridge.namespace + "/" + rosName(sess.ID)
+		cmdTopic := base + "/command"
+		l.mu.Lock()
+		_, subscribed := l.commands[cmdTopic]
+		l.commands[cmdTopic] = sess.ID
+		l.mu.Unlock()
+		if !subscribed {
+			if err := w.WriteJSON(rosOp{Op: "subscribe", Topic: cmdTopic, Type: rosCommandType}); err != nil {
+				return err
+			}
+		}
+		header := rosHeader{Stamp: stamp, FrameID: l.bridge.frame}
+		for _, e := range sess.State().Entities {
+			topic := base + "/" + rosName(e.ID)
+			if err := l.publish(w, topic+"/pose", rosPoseType, rosPoseStamped{header, rosPose(e)}); err != nil {
+				return err
+			}
+			twist := rosTwistStamped{Header: header}
+			twist.Twist.Linear = rosVector(e.Velocity)
+			if err := l.publish(w, topic+"/twist", rosTwistType, twist); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// publish sends msg on topic, advertising it first if need be.
+func (l *rosLink) publish(w rosWriter, topic, typ string, msg any) error {
+	if !l.advertised[topic] {
+		if err := w.WriteJSON(rosOp{Op: "advertise", Topic: topic, Type: typ}); err != nil {
+			return err
+		}
+		l.advertised[topic] = true
+	}
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return w.WriteJSON(rosOp{Op: "publish", Topic: topic, Msg: data})
+}
+
+// receive runs a command published on one of the subscribed topics. Commands
+// are refused while a client holds the session's control lease.
+func (l *rosLink) receive(data []byte) {
+	var op rosOp
+	if err := json.Unmarshal(data, &op); err != nil || op.Op != "publish" {
+		return
+	}
+	l.mu.Lock()
+	id, ok := l.commands[op.Topic]
+	l.mu.Unlock()
+	if !ok {
+		return
+	}
+	sess, ok := sessions.Get(id)
+	if !ok {
+		return
+	}
+	var msg struct {
+		Data string `json:"data"`
+	}
+	if err := json.Unmarshal(op.Msg, &msg); err != nil {
+		slog.Warn("ros: invalid command", "topic", op.Topic)
+		return
+	}
+	cmd := Command{Type: strings.TrimSpace(msg.Data)}
+	if strings.HasPrefix(cmd.Type, "{") {
+		cmd = Command{}
+		if err := json.Unmarshal([]byte(msg.Data), &cmd); err != nil {
+			slog.Warn("ros: invalid command", "topic", op.Topic)
+			return
+		}
+	}
+	if err := sess.CheckControl(cmd.Lease, time.Now()); err != nil {
+		slog.Warn("ros: command refused", "command", cmd.Type, "err", err)
+		return
+	}
+	if err := runCommand(sess, cmd); err != nil {
+		slog.Warn("ros: command failed", "command", cmd.Type, "err", err)
+		return
+	}
+	sess.Sim.LogCommand("", cmd.Type+" command from ROS")
+}
+
+// rosName makes s usable as one token of a ROS 2 topic name: letters,
+// digits and underscores, not starting with a digit.
+func rosName(s string) string {
+	b := []byte(s)
+	for i, c := range b {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_') {
+			b[i] = '_'
+		}
+	}
+	if len(b) == 0 || b[0] >= '0' && b[0] <= '9' {
+		b = append([]byte{'_'}, b...)
+	}
+	return string(b)
+}
+
+// ROS 2 message layouts, as rosbridge encodes them.
+type (
+	rosTime struct {
+		Sec     int64  `json:"sec"`
+		Nanosec uint32 `json:"nanosec"`
+	}
+	rosHeader struct {
+		Stamp   rosTime `json:"stamp"`
+		FrameID string  `json:"frame_id"`
+	}
+	rosVector3 struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+		Z float64 `json:"z"`
+	}
+	rosQuaternion struct {
+		X float64 `json:"x"`
+		Y float64 `json:"y"`
+		Z float64 `json:"z"`
+		W float64 `json:"w"`
+	}
+	rosPoseMsg struct {
+		Position    rosVector3    `json:"position"`
+		Orientation rosQuaternion `json:"orientation"`
+	}
+	rosPoseStamped struct {
+		Header rosHeader  `json:"header"`
+		Pose   rosPoseMsg `json:"pose"`
+	}
+	rosTwistStamped struct {
+		Header rosHeader `json:"header"`
+		Twist  struct {
+			Linear  rosVector3 `json:"linear"`
+			Angular rosVector3 `json:"angular"`
+		} `json:"twist"`
+	}
+)
+
+// rosVector converts a local-frame vector to east-north-up.
+func rosVector(v vector.Vector3) rosVector3 {
+	return rosVector3{v.X, v.Z, v.Y}
+}
+
+// rosPose places e with its nose along its velocity: yawed from east
+// towards north, then pitched up. A body with no velocity keeps the
+// identity orientation.
+func rosPose(e *entities.Entity) rosPoseMsg {
+	v := rosVector(e.Velocity)
+	q := rosQuaternion{W: 1}
+	if v.X != 0 || v.Y != 0 || v.Z != 0 {
+		yaw := math.Atan2(v.Y, v.X)
+		// ROS bodies pitch about their left axis, so climbing is negative.
+		pitch := -math.Atan2(v.Z, math.Hypot(v.X, v.Y))
+		sy, cy := math.Sincos(yaw / 2)
+		sp, cp := math.Sincos(pitch / 2)
+		q = rosQuaternion{X: -sy * sp, Y: cy * sp, Z: sy * cp, W: cy * cp}
+	}
+	return rosPoseMsg{Position: rosVector(e.Position), Orientation: q}
+}
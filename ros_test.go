@@ -0,0 +1,116 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestROSName(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{"default", "default"},
+		{"interceptor-1", "interceptor_1"},
+		{"3a", "_3a"},
+		{"", "_"},
+	}
+	for _, tt := range tests {
+		if got := rosName(tt.in); got != tt.want {
+			t.Errorf("rosName(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestROSPose(t *testing.T) {
+	h := math.Sqrt(0.5)
+	tests := []struct {
+		name     string
+		velocity vector.Vector3 // local frame: X east, Y up, Z north
+		want     rosQuaternion
+	}{
+		{"still", vector.Vector3{}, rosQuaternion{W: 1}},
+		{"east", vector.Vector3{X: 100}, rosQuaternion{W: 1}},
+		{"north", vector.Vector3{Z: 100}, rosQuaternion{Z: h, W: h}},
+		{"straight up", vector.Vector3{Y: 100}, rosQuaternion{Y: -h, W: h}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := rosPose(&entities.Entity{Position: vector.Vector3{X: 1, Y: 2, Z: 3}, Velocity: tt.velocity})
+			if p.Position != (rosVector3{1, 3, 2}) {
+				t.Errorf("position %+v, want east-north-up", p.Position)
+			}
+			q := p.Orientation
+			if math.Abs(q.X-tt.want.X)+math.Abs(q.Y-tt.want.Y)+math.Abs(q.Z-tt.want.Z)+math.Abs(q.W-tt.want.W) > 1e-9 {
+				t.Errorf("orientation %+v, want %+v", q, tt.want)
+			}
+		})
+	}
+}
+
+// rosRecorder collects the rosbridge messages the bridge writes.
+type rosRecorder []rosOp
+
+func (r *rosRecorder) WriteJSON(v any) error {
+	*r = append(*r, v.(rosOp))
+	return nil
+}
+
+func TestROSBridge(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	defer sess.Sim.Stop()
+	b, err := newROSBridge("ws://localhost:9090", "/sim/", "map", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	link := &rosLink{bridge: b, advertised: make(map[string]bool), commands: make(map[string]string)}
+
+	var first, second rosRecorder
+	if err := link.tick(&first, time.Unix(100, 5)); err != nil {
+		t.Fatal(err)
+	}
+	if err := link.tick(&second, time.Unix(101, 0)); err != nil {
+		t.Fatal(err)
+	}
+	count := func(rec rosRecorder) map[string]int {
+		ops := make(map[string]int)
+		for _, op := range rec {
+			ops[op.Op]++
+		}
+		return ops
+	}
+	n := len(sess.State().Entities)
+	if got := count(first); got["subscribe"] != 1 || got["advertise"] != 2*n || got["publish"] != 2*n {
+		t.Errorf("first tick sent %v for %d entities", got, n)
+	}
+	if got := count(second); got["subscribe"] != 0 || got["advertise"] != 0 || got["publish"] != 2*n {
+		t.Errorf("second tick sent %v, want publishes only", got)
+	}
+	if first[0].Topic != "/sim/default/command" {
+		t.Errorf("subscribed to %q", first[0].Topic)
+	}
+
+	tests := []struct {
+		name   string
+		topic  string
+		data   string
+		status string
+	}{
+		{"unknown topic", "/sim/other/command", "start", "Stopped"},
+		{"name", "/sim/default/command", "start", "Running"},
+		{"json", "/sim/default/command", `{"type": "stop"}`, "Stopped"},
+		{"bad json", "/sim/default/command", `{"type": `, "Stopped"},
+	}
+	for _, tt := range tests {
+		msg, _ := json.Marshal(map[string]string{"data": tt.data})
+		data, _ := json.Marshal(rosOp{Op: "publish", Topic: tt.topic, Msg: msg})
+		link.receive(data)
+		if got := sess.State().Status; got != tt.status {
+			t.Errorf("%s: status %q, want %q", tt.name, got, tt.status)
+		}
+	}
+}
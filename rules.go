@@ -0,0 +1,91 @@
+package simulation
+
+import "fmt"
+
+// Rule is custom per-step logic: a termination criterion, a scoring hook or
+// a scripted intervention. Rules run after every physics step of a run that
+// is still going, in the order they were added.
+type Rule interface {
+	Apply(ctx *RuleContext)
+}
+
+// RuleFunc adapts a function to the Rule interface.
+type RuleFunc func(ctx *RuleContext)
+
+// Apply calls f(ctx).
+func (f RuleFunc) Apply(ctx *RuleContext) {
+	f(ctx)
+}
+
+// RuleContext is a rule's view of the world. It is only valid during Apply,
+// while the simulator is locked: rules may read and modify the state, threats
+// and interceptors, but must not call Simulator methods.
+type RuleContext struct {
+	State        *SimulationState
+	Threats      []*Threat
+	Interceptors []*Interceptor
+	sim          *Simulator
+}
+
+// End finishes the run with the given status and reason. Any status other
+// than Running and Stopped is treated as terminal.
+func (c *RuleContext) End(status, reason string) {
+	c.sim.endRunLocked(status, reason)
+}
+
+// Log appends an entry to the event log.
+func (c *RuleContext) Log(typ, entityID, msg string) {
+	c.sim.logEventLocked(typ, entityID, msg)
+}
+
+// namedRule pairs a rule with its registration name.
+type namedRule struct {
+	name string
+	rule Rule
+}
+
+// AddRule registers a rule under a unique name.
+func (s *Simulator) AddRule(name string, r Rule) error {
+	s.lock()
+	defer s.mu.Unlock()
+	for _, nr := range s.rules {
+		if nr.name == name {
+			return fmt.Errorf("rule %q already registered", name)
+		}
+	}
+	s.rules = append(s.rules, namedRule{name: name, rule: r})
+	return nil
+}
+
+// RemoveRule unregisters a rule. It reports whether one was found.
+func (s *Simulator) RemoveRule(name string) bool {
+	s.lock()
+	defer s.mu.Unlock()
+	for i, nr := range s.rules {
+		if nr.name == name {
+			s.rules = append(s.rules[:i], s.rules[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// applyRulesLocked runs the registered rules until one ends the run.
+// Callers must hold s.mu.
+func (s *Simulator) applyRulesLocked() {
+	if len(s.rules) == 0 {
+		return
+	}
+	ctx := &RuleContext{
+		State:        &s.State,
+		Threats:      s.Threats,
+		Interceptors: s.Interceptors,
+		sim:          s,
+	}
+	for _, nr := range s.rules {
+		if s.finishedLocked() {
+			return
+		}
+		nr.rule.Apply(ctx)
+	}
+}
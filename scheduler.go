@@ -0,0 +1,38 @@
+package simulation
+
+// sensorScheduler decides which sensors are due on a given physics step so
+// each sensor can run at its own rate independent of Dt.
+type sensorScheduler struct {
+	next    map[string]float64
+	maxRate float64 // Hz every sensor is held to, 0 for none; set in degraded mode
+}
+
+func newSensorScheduler() *sensorScheduler {
+	return &sensorScheduler{next: make(map[string]float64)}
+}
+
+// schedulerEpsilon absorbs floating point error in accumulated step times.
+const schedulerEpsilon = 1e-9
+
+// due reports whether the sensor with the given ID should update at time t.
+// A rate <= 0 updates every step; rates above the physics rate are capped by it.
+func (sc *sensorScheduler) due(id string, rate, t float64) bool {
+	if sc.maxRate > 0 && (rate <= 0 || rate > sc.maxRate) {
+		rate = sc.maxRate
+	}
+	if rate <= 0 {
+		return true
+	}
+	period := 1.0 / rate
+	next, ok := sc.next[id]
+	if ok && t < next-schedulerEpsilon {
+		return false
+	}
+	// Resynchronize after a gap (e.g. the sensor was added mid-run)
+	// instead of firing a burst of catch-up updates.
+	if !ok || t-next >= period {
+		next = t
+	}
+	sc.next[id] = next + period
+	return true
+}
@@ -0,0 +1,59 @@
+package simulation
+
+import "testing"
+
+func TestSensorSchedulerCadence(t *testing.T) {
+	tests := []struct {
+		name    string
+		rate    float64 // Hz
+		dt      float64 // s
+		maxRate float64 // Hz, degraded mode's cap
+		want    int     // updates in the first second
+	}{
+		{"every step", 0, 0.01, 0, 100},
+		{"divides the step rate", 10, 0.01, 0, 10},
+		{"does not divide the step rate", 30, 0.01, 0, 30},
+		{"faster than the steps", 1000, 0.01, 0, 100},
+		{"slower than one per second", 0.5, 0.01, 0, 1},
+		{"every step, degraded", 0, 0.01, 10, 10},
+		{"capped", 50, 0.01, 10, 10},
+		{"under the cap", 5, 0.01, 10, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc := newSensorScheduler()
+			sc.maxRate = tt.maxRate
+			got := 0
+			// Accumulate time as the simulator does, floating point error included.
+			now := 0.0
+			for i := 0; i < int(1/tt.dt+0.5); i++ {
+				if sc.due("s", tt.rate, now) {
+					got++
+				}
+				now += tt.dt
+			}
+			if got != tt.want {
+				t.Errorf("%g Hz at dt %g: %d updates, want %d", tt.rate, tt.dt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSensorSchedulerResyncsAfterGap(t *testing.T) {
+	sc := newSensorScheduler()
+	steps := []struct {
+		t    float64
+		want bool
+	}{
+		{0, true},
+		{0.05, false},
+		{5, true}, // long gap: fires once instead of a burst
+		{5.05, false},
+		{5.1, true},
+	}
+	for _, st := range steps {
+		if got := sc.due("s", 10, st.t); got != st.want {
+			t.Errorf("due at %g = %v, want %v", st.t, got, st.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package simulation
+
+import "missile-intercept-sim/internal/units"
+
+// TelemetrySchema gives the unit of every numeric field the simulator
+// reports, by JSON field or column name, so frontends and exports need not
+// guess. Fields left out are counts, flags or text. Vectors are in the
+// local frame, Frame.
+type TelemetrySchema struct {
+	Frame      string                `json:"frame"`
+	State      map[string]units.Unit `json:"state"`
+	Entity     map[string]units.Unit `json:"entity"`
+	Engagement map[string]units.Unit `json:"engagement"`
+	Threat     map[string]units.Unit `json:"threat"`
+	Sensor     map[string]units.Unit `json:"sensor"`
+	CSV        map[string]units.Unit `json:"csv"`     // WriteCSV's columns
+	Parquet    map[string]units.Unit `json:"parquet"` // campaign telemetry columns
+}
+
+// LocalFrame describes the axes of every position, velocity and
+// acceleration vector.
+const LocalFrame = "X east, Y up, Z north, metres from the scenario origin"
+
+// csvUnits are the units of WriteCSV's columns.
+var csvUnits = map[string]units.Unit{
+	"t": units.Second,
+	"x": units.Metre, "y": units.Metre, "z": units.Metre,
+	"vx": units.MetresPerSecond, "vy": units.MetresPerSecond, "vz": units.MetresPerSecond,
+	"ax": units.MetresPerSecond2, "ay": units.MetresPerSecond2, "az": units.MetresPerSecond2,
+	"commanded_g": units.StandardGravity,
+	"los_rate":    units.RadiansPerSecond,
+}
+
+// Schema returns the units of the simulator's telemetry.
+func Schema() TelemetrySchema {
+	s := TelemetrySchema{
+		Frame: LocalFrame,
+		State: map[string]units.Unit{
+			"time":         units.Second,
+			"missDistance": units.Metre,
+		},
+		Entity: map[string]units.Unit{
+			"position":     units.Metre,
+			"velocity":     units.MetresPerSecond,
+			"acceleration": units.MetresPerSecond2,
+			"mass":         units.Kilogram,
+			"maxSpeed":     units.MetresPerSecond,
+			"maxAccel":     units.MetresPerSecond2,
+		},
+		Engagement: map[string]units.Unit{
+			"missDistance":         units.Metre,
+			"closingVelocity":      units.MetresPerSecond,
+			"timeToGo":             units.Second,
+			"pip":                  units.Metre,
+			"aspectAngle":          units.Degree,
+			"antennaTrainAngle":    units.Degree,
+			"headingCrossingAngle": units.Degree,
+		},
+		Threat: map[string]units.Unit{
+			"speed":       units.MetresPerSecond,
+			"range":       units.Metre,
+			"timeToAsset": units.Second,
+		},
+		Sensor: map[string]units.Unit{
+			"range":     units.Metre,
+			"rangeRate": units.MetresPerSecond,
+			"maxRange":  units.Metre,
+			"lookAngle": units.Degree,
+			"time":      units.Second,
+		},
+		CSV:     csvUnits,
+		Parquet: make(map[string]units.Unit),
+	}
+	for _, c := range telemetryColumns {
+		if c.Unit != "" {
+			s.Parquet[c.Name] = units.Unit(c.Unit)
+		}
+	}
+	return s
+}
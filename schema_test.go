@@ -0,0 +1,57 @@
+package simulation
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/sensors"
+	"missile-intercept-sim/internal/units"
+)
+
+// jsonNames returns the JSON field names of struct type t.
+func jsonNames(t reflect.Type) []string {
+	var names []string
+	for i := range t.NumField() {
+		if name, _, _ := strings.Cut(t.Field(i).Tag.Get("json"), ","); name != "" && name != "-" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func TestSchemaNamesFields(t *testing.T) {
+	s := Schema()
+	var columns []string
+	for _, c := range telemetryColumns {
+		columns = append(columns, c.Name)
+	}
+	tests := []struct {
+		name   string
+		units  map[string]units.Unit
+		fields []string
+	}{
+		{"state", s.State, jsonNames(reflect.TypeFor[SimulationState]())},
+		{"entity", s.Entity, jsonNames(reflect.TypeFor[entities.Entity]())},
+		{"engagement", s.Engagement, jsonNames(reflect.TypeFor[EngagementStatus]())},
+		{"threat", s.Threat, jsonNames(reflect.TypeFor[ThreatAssessment]())},
+		{"sensor", s.Sensor, jsonNames(reflect.TypeFor[sensors.Status]())},
+		{"csv", s.CSV, csvHeader},
+		{"parquet", s.Parquet, columns},
+	}
+	for _, tt := range tests {
+		if len(tt.units) == 0 {
+			t.Errorf("%s: no units", tt.name)
+		}
+		for name, unit := range tt.units {
+			if !slices.Contains(tt.fields, name) || unit == units.None {
+				t.Errorf("%s: unit %q given for %q, which is not a field", tt.name, unit, name)
+			}
+		}
+	}
+	if s.Parquet["vx"] != units.MetresPerSecond || s.CSV["commanded_g"] != units.StandardGravity {
+		t.Errorf("parquet vx in %q, csv commanded_g in %q", s.Parquet["vx"], s.CSV["commanded_g"])
+	}
+}
@@ -0,0 +1,197 @@
+package simulation
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/units"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// selfTestLimit is how much simulated time a self-test case may take, s.
+const selfTestLimit = 300.0
+
+// SelfTestResult is the outcome of one self-test case: how far the
+// simulator strayed from the analytic answer, and how far it may.
+type SelfTestResult struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Passed      bool    `json:"passed"`
+	Error       float64 `json:"error"`
+	Tolerance   float64 `json:"tolerance"`
+	Unit        string  `json:"unit"`
+	Detail      string  `json:"detail,omitempty"`
+}
+
+// SelfTestReport is the outcome of the whole self-test.
+type SelfTestReport struct {
+	Results  []SelfTestResult `json:"results"`
+	Passed   bool             `json:"passed"`
+	WallTime float64          `json:"wallTime"` // seconds
+}
+
+// selfTestCase is a run with a known analytic outcome.
+type selfTestCase struct {
+	name, description string
+	run               func() SelfTestResult
+}
+
+var selfTestCases = []selfTestCase{
+	{"ballistic-range", "unpowered body launched at 45° lands v²/g downrange", selfTestBallisticRange},
+	{"energy", "specific energy of a falling body is conserved without drag", selfTestEnergy},
+	{"pronav-intercept", "ProNav intercepts a target flying straight and level", selfTestProNav},
+}
+
+// RunSelfTest flies each case with a known analytic solution and compares
+// the simulator with it, to check the integrator and guidance after a
+// change. It runs headless and takes a second or so.
+func RunSelfTest() SelfTestReport {
+	start := time.Now()
+	report := SelfTestReport{Passed: true}
+	for _, c := range selfTestCases {
+		res := c.run()
+		res.Name, res.Description = c.name, c.description
+		report.Passed = report.Passed && res.Passed
+		report.Results = append(report.Results, res)
+	}
+	report.WallTime = time.Since(start).Seconds()
+	return report
+}
+
+// selfTestScenario is a scenario with a single target and an interceptor
+// that, unless launch is set, stays on its launcher well out of the way.
+func selfTestScenario(target scenario.Entity, launch bool) *scenario.Scenario {
+	target.ID, target.Role = "target-1", scenario.RoleTarget
+	ic := scenario.Entity{
+		ID:         "missile-1",
+		Role:       scenario.RoleInterceptor,
+		Position:   vector.Vector3{X: -50000},
+		Velocity:   vector.Vector3{Y: 10},
+		Guidance:   "ProNav",
+		LaunchTime: 2 * selfTestLimit,
+	}
+	if launch {
+		ic.Position = vector.Vector3{}
+		ic.Velocity = vector.Vector3{X: 10, Y: 10, Z: 10}
+		ic.LaunchTime = 0
+	}
+	return &scenario.Scenario{
+		Name:        "selftest",
+		Entities:    []scenario.Entity{target, ic},
+		Termination: scenario.Termination{MaxTime: selfTestLimit},
+	}
+}
+
+// flySelfTest runs sc to its end, calling each with its target before
+// every step.
+func flySelfTest(sc *scenario.Scenario, each func(target *entities.Entity)) (*Simulator, error) {
+	sim := NewSimulator()
+	sim.SetLogger(logging.Discard)
+	sim.HistoryDuration = 0
+	sim.TrailDuration = 0
+	sim.Seed = 1
+	if err := sim.LoadScenario(sc); err != nil {
+		return nil, err
+	}
+	for stage, _ := sim.progress(); stage != LifecycleFinished; stage, _ = sim.progress() {
+		if each != nil {
+			each(sim.Threats[0].Entity)
+		}
+		if _, err := sim.Advance(1); err != nil {
+			return nil, err
+		}
+	}
+	return sim, nil
+}
+
+// failed is the result of a case that could not be flown at all.
+func failed(err error) SelfTestResult {
+	return SelfTestResult{Error: math.Inf(1), Detail: err.Error()}
+}
+
+func selfTestBallisticRange() SelfTestResult {
+	const speed = 300.0
+	v := speed / math.Sqrt2
+	want := speed * speed / units.G
+	sc := selfTestScenario(scenario.Entity{Velocity: vector.Vector3{X: v, Y: v}, Ballistic: true}, false)
+	// The step that reaches the ground clamps the body to it, so land it
+	// analytically from the last state above ground.
+	var last entities.Entity
+	sim, err := flySelfTest(sc, func(t *entities.Entity) { last = *t })
+	if err != nil {
+		return failed(err)
+	}
+	if sim.State.Reason != ReasonTargetImpact {
+		return SelfTestResult{Error: math.Inf(1), Unit: "m", Detail: "never landed: " + sim.State.Reason}
+	}
+	fall := (last.Velocity.Y + math.Sqrt(last.Velocity.Y*last.Velocity.Y+2*units.G*last.Position.Y)) / units.G
+	got := last.Position.X + last.Velocity.X*fall
+	res := SelfTestResult{
+		Error:     math.Abs(got - want),
+		Tolerance: want * 1e-3,
+		Unit:      "m",
+		Detail:    fmt.Sprintf("landed %.1f m downrange, want %.1f m", got, want),
+	}
+	res.Passed = res.Error <= res.Tolerance
+	return res
+}
+
+func selfTestEnergy() SelfTestResult {
+	sc := selfTestScenario(scenario.Entity{
+		Position:  vector.Vector3{Y: 5000},
+		Velocity:  vector.Vector3{X: 250, Y: 50},
+		Ballistic: true,
+	}, false)
+	energy := func(t *entities.Entity) float64 {
+		return t.Velocity.Dot(t.Velocity)/2 + units.G*t.Position.Y
+	}
+	e0 := energy(&entities.Entity{Position: sc.Entities[0].Position, Velocity: sc.Entities[0].Velocity})
+	drift, steps := 0.0, 0
+	_, err := flySelfTest(sc, func(t *entities.Entity) {
+		if t.Position.Y > 0 { // the landing step stops the body dead
+			drift = max(drift, math.Abs(energy(t)-e0)/e0)
+			steps++
+		}
+	})
+	if err != nil {
+		return failed(err)
+	}
+	res := SelfTestResult{
+		Error:     drift,
+		Tolerance: 1e-3,
+		Unit:      "relative",
+		Detail:    fmt.Sprintf("worst of %d steps from %.0f J/kg", steps, e0),
+	}
+	res.Passed = steps > 1 && res.Error <= res.Tolerance
+	return res
+}
+
+func selfTestProNav() SelfTestResult {
+	sc := selfTestScenario(scenario.Entity{
+		Position: vector.Vector3{X: 5000, Y: 2000, Z: 5000},
+		Velocity: vector.Vector3{X: -200, Z: -100},
+	}, true)
+	sim, err := flySelfTest(sc, nil)
+	if err != nil {
+		return failed(err)
+	}
+	res := SelfTestResult{
+		Error:     sim.State.MissDistance,
+		Tolerance: sim.InterceptRadius,
+		Unit:      "m",
+		Passed:    sim.State.Reason == ReasonIntercept,
+	}
+	ic := sim.Interceptors[0].Missile
+	if first, ok := earliestIntercept(sc.Entities[1], sc.Entities[0], ic.MaxSpeed); ok && sim.State.Time < first {
+		// Faster than flying straight at top speed: the physics is wrong.
+		res.Passed = false
+		res.Detail = fmt.Sprintf("ended at %.2f s, before the earliest possible intercept at %.2f s", sim.State.Time, first)
+		return res
+	}
+	res.Detail = fmt.Sprintf("%s at %.2f s", sim.State.Reason, sim.State.Time)
+	return res
+}
@@ -0,0 +1,33 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestRunSelfTest(t *testing.T) {
+	report := RunSelfTest()
+	if !report.Passed {
+		t.Errorf("self-test failed: %+v", report.Results)
+	}
+	tests := []struct {
+		name string
+		unit string
+	}{
+		{"ballistic-range", "m"},
+		{"energy", "relative"},
+		{"pronav-intercept", "m"},
+	}
+	if len(report.Results) != len(tests) {
+		t.Fatalf("%d results, want %d", len(report.Results), len(tests))
+	}
+	for i, tt := range tests {
+		res := report.Results[i]
+		if res.Name != tt.name || res.Unit != tt.unit {
+			t.Errorf("result %d is %s in %s, want %s in %s", i, res.Name, res.Unit, tt.name, tt.unit)
+		}
+		if !res.Passed || math.IsInf(res.Error, 0) || res.Error > res.Tolerance {
+			t.Errorf("%s: error %g %s, tolerance %g (%s)", res.Name, res.Error, res.Unit, res.Tolerance, res.Detail)
+		}
+	}
+}
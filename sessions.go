@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+
+	"log/slog"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// defaultSessionID is used by clients that do not pass a session parameter.
+const defaultSessionID = "default"
+
+// Session is an isolated simulator instance.
+type Session struct {
+	ID      string                `json:"id"`
+	Created time.Time             `json:"created"`
+	Sim     *simulation.Simulator `json:"-"`
+	Hub     *Hub                  `json:"-"`
+
+	mu        sync.Mutex
+	player    *simulation.Player
+	snapshots []*simulation.Snapshot
+	snapSeq   int
+	control   *controlLease // nil while nobody controls the session
+}
+
+// maxSnapshots bounds the snapshots kept per session; the oldest is dropped first.
+const maxSnapshots = 32
+
+// TakeSnapshot captures the session's simulator and stores the snapshot.
+func (s *Session) TakeSnapshot() (*simulation.Snapshot, error) {
+	s.mu.Lock()
+	s.snapSeq++
+	id := fmt.Sprintf("snap-%d", s.snapSeq)
+	s.mu.Unlock()
+
+	snap, err := s.Sim.Snapshot(id)
+	if err != nil {
+		return nil, err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.snapshots = append(s.snapshots, snap)
+	if len(s.snapshots) > maxSnapshots {
+		s.snapshots = s.snapshots[len(s.snapshots)-maxSnapshots:]
+	}
+	return snap, nil
+}
+
+// Snapshots lists the stored snapshots, oldest first.
+func (s *Session) Snapshots() []*simulation.Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*simulation.Snapshot(nil), s.snapshots...)
+}
+
+// FindSnapshot looks up a stored snapshot by ID.
+func (s *Session) FindSnapshot(id string) (*simulation.Snapshot, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, snap := range s.snapshots {
+		if snap.ID == id {
+			return snap, true
+		}
+	}
+	return nil, false
+}
+
+func newSession(id string) *Session {
+	sim := simulation.NewSimulator()
+	sim.SetLogger(slog.Default().With("session", id))
+	sim.RecordDir = recordingsDir
+	sim.AutoDegrade = autoDegrade
+	sim.RecordPolicy = recordPolicy
+	sim.RecordRetention = recordRetention
+	// The flags were validated at startup, so this cannot fail.
+	sim.SetHistoryRetention(simulation.Retention{Duration: sim.HistoryDuration, Frames: historyLimit.Frames, Bytes: historyLimit.Bytes})
+	if archive != nil {
+		sim.Archive = sessionArchiver{archive, id}
+	}
+	sess := &Session{ID: id, Created: time.Now(), Sim: sim}
+	sess.Hub = newHub(sess)
+	return sess
+}
+
+// State returns the frame clients should see: the replay if one is loaded,
+// otherwise the live simulation.
+func (s *Session) State() simulation.SimulationState {
+	if p := s.Player(); p != nil {
+		return p.State()
+	}
+	return s.Sim.GetState()
+}
+
+// Player returns the active replay, or nil when showing the live simulation.
+func (s *Session) Player() *simulation.Player {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.player
+}
+
+// SetPlayer switches the session to a replay; nil returns to live state.
+func (s *Session) SetPlayer(p *simulation.Player) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.player = p
+}
+
+// SessionManager owns every live session.
+type SessionManager struct {
+	mu       sync.RWMutex
+	sessions map[string]*Session
+}
+
+// NewSessionManager creates a manager holding only the default session.
+func NewSessionManager() *SessionManager {
+	m := &SessionManager{sessions: make(map[string]*Session)}
+	m.sessions[defaultSessionID] = newSession(defaultSessionID)
+	return m
+}
+
+// Create starts a new session with a fresh simulator.
+func (m *SessionManager) Create() *Session {
+	sess := newSession(newSessionID())
+	m.mu.Lock()
+	m.sessions[sess.ID] = sess
+	m.mu.Unlock()
+	return sess
+}
+
+// Get looks up a session by ID.
+func (m *SessionManager) Get(id string) (*Session, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	sess, ok := m.sessions[id]
+	return sess, ok
+}
+
+// Delete stops and removes a session. The default session cannot be deleted.
+func (m *SessionManager) Delete(id string) bool {
+	if id == defaultSessionID {
+		return false
+	}
+	m.mu.Lock()
+	sess, ok := m.sessions[id]
+	delete(m.sessions, id)
+	m.mu.Unlock()
+	if ok {
+		sess.Sim.Stop()
+		sess.Sim.SetExternalGuidance(nil)
+		sess.Hub.Close()
+	}
+	return ok
+}
+
+// Shutdown disconnects every client and closes every simulator, flushing
+// recordings to disk. It gives up when ctx is done.
+func (m *SessionManager) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		var wg sync.WaitGroup
+		for _, sess := range m.List() {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				sess.Hub.Close()
+				sess.Sim.Close()
+			}()
+		}
+		wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// List returns all sessions ordered by creation time.
+func (m *SessionManager) List() []*Session {
+	m.mu.RLock()
+	list := make([]*Session, 0, len(m.sessions))
+	for _, sess := range m.sessions {
+		list = append(list, sess)
+	}
+	m.mu.RUnlock()
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Created.Before(list[j].Created)
+	})
+	return list
+}
+
+func newSessionID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// sessionFor resolves the session named by the "session" query parameter,
+// falling back to the default session. It writes a 404 if the session is unknown.
+func sessionFor(w http.ResponseWriter, r *http.Request) (*Session, bool) {
+	id := r.URL.Query().Get("session")
+	if id == "" {
+		id = defaultSessionID
+	}
+	sess, ok := sessions.Get(id)
+	if !ok {
+		writeError(w, "Unknown session", http.StatusNotFound)
+		return nil, false
+	}
+	return sess, true
+}
+
+// eventCursor tracks, per session, the last event a consumer has handled.
+// Sessions are followed from when the cursor first sees them.
+type eventCursor map[*Session]uint64
+
+// next returns the events sess logged since the previous call. The first
+// call for a session returns none.
+func (c eventCursor) next(sess *Session) []simulation.Event {
+	last, watched := c[sess]
+	events := sess.Sim.Events(last)
+	if n := len(events); n > 0 {
+		c[sess] = events[n-1].Seq
+	} else {
+		c[sess] = last
+	}
+	if !watched {
+		return nil
+	}
+	return events
+}
+
+// prune forgets the sessions that are not in live.
+func (c eventCursor) prune(live []*Session) {
+	for sess := range c {
+		if !slices.Contains(live, sess) {
+			delete(c, sess)
+		}
+	}
+}
@@ -1,156 +1,691 @@
 package simulation
 
 import (
-	"log"
+	"context"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"missile-intercept-sim/internal/entities"
-	"missile-intercept-sim/internal/guidance"
 	"missile-intercept-sim/internal/physics"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/internal/sensors"
+	"missile-intercept-sim/internal/units"
 	"missile-intercept-sim/pkg/vector"
 )
 
 // SimulationState holds the current state of the world.
 type SimulationState struct {
-	Entities  []*entities.Entity `json:"entities"`
-	Status    string             `json:"status"` // Running, Stopped, Intercepted
-	Time      float64            `json:"time"`
-	Intercept bool               `json:"intercept"`
+	Entities     []*entities.Entity      `json:"entities"`
+	Status       string                  `json:"status"`           // Running, Stopped, Intercepted
+	Lifecycle    Lifecycle               `json:"lifecycle"`        // Idle, Running, Paused or Finished
+	Reason       string                  `json:"reason,omitempty"` // which termination condition ended the run
+	Time         float64                 `json:"time"`
+	Run          uint64                  `json:"run"` // counts resets and restores, so a time identifies one frame
+	Intercept    bool                    `json:"intercept"`
+	MissDistance float64                 `json:"missDistance"` // closest approach so far
+	Seed         uint64                  `json:"seed"`         // replays this run bit-identically
+	TimeScale    float64                 `json:"timeScale"`    // 0 = as fast as possible
+	Replay       bool                    `json:"replay,omitempty"`
+	Scenario     string                  `json:"scenario"`
+	Engagements  []EngagementStatus      `json:"engagements"`
+	Threats      []ThreatAssessment      `json:"threats,omitempty"` // live threats in engagement priority order
+	Sensors      []sensors.Status        `json:"sensors"`
+	Events       []Event                 `json:"events,omitempty"` // new since the client's last message, WebSocket only
+	Trails       map[string][]TrailPoint `json:"trails,omitempty"` // per-entity trails for clients that asked, WebSocket only
 }
 
 // Simulator manages the simulation loop and state.
 type Simulator struct {
-	State        SimulationState
-	mu           sync.RWMutex
-	ticker       *time.Ticker
-	stopChan     chan bool
-	Target       *entities.Entity
-	Missile      *entities.Entity
-	GuidanceLaw  guidance.GuidanceLaw
-	GuidanceName string
-	Dt           float64
+	State           SimulationState
+	mu              sync.RWMutex              // write with lock, so readers see the change
+	gen             atomic.Uint64             // write-lock acquisitions, which make a published state stale
+	published       atomic.Pointer[published] // the state as readers last copied it
+	cancelLoop      context.CancelFunc        // stops the loop goroutine, nil if none
+	loopDone        chan struct{}             // closed when that loop has returned
+	loops           atomic.Int32              // loop goroutines running
+	lastStep        atomic.Int64              // wall-clock UnixNano of the last step or loop start
+	Scenario        *scenario.Scenario
+	Threats         []*Threat
+	Interceptors    []*Interceptor
+	Target          *entities.Entity // first target, for single-engagement callers
+	Missile         *entities.Entity // first interceptor, for single-engagement callers
+	GuidanceName    string
+	Dt              float64
+	Terrain         sensors.Terrain
+	Weather         sensors.Weather
+	Radar           *sensors.Radar
+	InterceptRadius float64
+	MaxTime         float64 // scenario time limit, 0 for none
+	Bounds          *scenario.Bounds
+	MinSpeed        float64            // m/s, 0 disables the minimum-energy cutoff
+	Doctrine        *scenario.Doctrine // nil launches each interceptor at its LaunchTime
+	Asset           vector.Vector3     // defended point for threat evaluation
+	sensorSched     *sensorScheduler
+	logs            loggers
+	Seed            uint64 // RNG seed applied on Reset; 0 draws a fresh one each time
+	TimeScale       float64
+	RecordDir       string      // where finished recordings are written
+	Archive         RunArchiver // receives finished runs, nil for none
+	pcg             *rand.PCG   // kept alongside rng so snapshots can capture its state
+	rng             *rand.Rand
+	recordEnabled   bool
+	recording       *Recording
+	RecordRetention Retention       // bounds the frames a recording keeps in memory for the archive
+	recordFrames    frameStore      // the active recording's frames
+	writer          *recordWriter   // streams recordings to disk, nil until the first
+	RecordPolicy    RecordPolicy    // when the disk falls behind; empty drops frames
+	recordDropped   atomic.Uint64   // frames the disk writer could not take
+	result          *OutcomeReport  // last finished run
+	results         []OutcomeReport // run history, oldest first
+	events          []Event         // current run's event log
+	subscribers     []*Subscription
+	eventSeq        uint64
+	runSeq          uint64
+	HistoryDuration float64 // s of simulated time kept for /api/history, 0 disables
+	HistoryFrames   int     // most frames the history holds before thinning old ones, 0 for no limit
+	HistoryBytes    int64   // most memory it holds, likewise
+	history         frameStore
+	TrailDuration   float64 // s of downsampled trail kept per entity, 0 disables
+	trails          map[string][]TrailPoint
+	nextTrail       float64
+	rules           []namedRule
+	manifest        Manifest
+	external        ExternalLaw // attached external guidance, nil if none
+	proximity       proximity   // live threats indexed for the fuze
+	swarm           swarm       // moving entities, propagated together
+	AutoDegrade     bool        // cap sensor rates when the real-time loop keeps overrunning
+	budget          stepBudget
+	faults          atomic.Uint64 // panics recovered from
 }
 
 // NewSimulator creates a new simulator instance.
 func NewSimulator() *Simulator {
 	sim := &Simulator{
 		State: SimulationState{
-			Entities: []*entities.Entity{},
-			Status:   "Stopped",
-			Time:     0.0,
+			Entities:  []*entities.Entity{},
+			Status:    "Stopped",
+			Lifecycle: LifecycleIdle,
+			Time:      0.0,
 		},
-		Dt: 0.016, // Approx 60Hz
+		Dt:              0.016, // Approx 60Hz
+		TimeScale:       1.0,
+		Scenario:        scenario.Default(),
+		HistoryDuration: DefaultHistoryDuration,
+		RecordRetention: DefaultRecordRetention,
+		TrailDuration:   DefaultTrailDuration,
+		logs:            newLoggers(nil),
 	}
 	// Initialize default entities for reset
 	sim.Reset()
 	return sim
 }
 
-// Reset restores the simulation to initial state.
+// Reset stops the loop and restores the simulation to the initial state,
+// Idle. It returns once the loop has exited.
 func (s *Simulator) Reset() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	s.lock()
+	done := s.haltLocked()
+	// A reset ends the current run; save whatever was recorded of it.
+	s.finishRecordingLocked()
+	s.resetLocked()
+	s.mu.Unlock()
+	waitLoop(done)
+}
+
+// LoadScenario validates a scenario and resets the simulation to it.
+func (s *Simulator) LoadScenario(sc *scenario.Scenario) error {
+	if err := sc.Validate(); err != nil {
+		return err
+	}
+	if err := CheckScenarioGuidance(sc); err != nil {
+		return err
+	}
+	s.lock()
+	done := s.haltLocked()
+	s.finishRecordingLocked()
+	s.Scenario = sc
+	s.resetLocked()
+	s.mu.Unlock()
+	waitLoop(done)
+	return nil
+}
+
+// resetLocked builds the world from the current scenario. Callers must hold s.mu.
+func (s *Simulator) resetLocked() {
+	sc := s.Scenario
+
+	// Every random draw in a run comes from this source so the reported
+	// seed reproduces it exactly. An explicit seed beats the scenario's.
+	seed := s.Seed
+	if seed == 0 {
+		seed = sc.Seed
+	}
+	if seed == 0 {
+		seed = uint64(time.Now().UnixNano())
+	}
+	s.pcg = rand.NewPCG(seed, seed)
+	s.rng = rand.New(s.pcg)
+	sc = sc.Randomized(s.rng)
 
-	// Default Scenario: Target flying level, Missile launching from ground
 	// Y-UP System: X=East, Y=Alt, Z=North
-	// Target at 5000m East, 2000m Alt, 5000m North
-	target := entities.NewTarget("target-1",
-		vector.Vector3{X: 5000, Y: 2000, Z: 5000},
-		vector.Vector3{X: -200, Y: 0, Z: -100}, // Moving West and South
-	)
-
-	missile := entities.NewMissile("missile-1",
-		vector.Vector3{X: 0, Y: 0, Z: 0},
-		vector.Vector3{X: 0, Y: 0, Z: 0}, // Launch velocity zero? Or minimal.
-	)
-	// Give initial boost
-	// Launch Upwards (Y+) and slightly towards target
-	missile.Velocity = vector.Vector3{X: 10, Y: 10, Z: 10}
-
-	s.Target = target
-	s.Missile = missile
-	s.GuidanceName = "ProNav" // Default
-	s.GuidanceLaw = guidance.GetFactory(s.GuidanceName)
+	var all []*entities.Entity
+	s.Threats = nil
+	byID := make(map[string]*Threat)
+	for _, spec := range sc.Targets() {
+		e := entities.NewTarget(spec.ID, spec.Position, spec.Velocity)
+		if spec.MaxAccel > 0 {
+			e.MaxAccel = spec.MaxAccel
+		}
+		th := &Threat{Entity: e, Maneuvers: spec.Maneuvers, Ballistic: spec.Ballistic}
+		s.Threats = append(s.Threats, th)
+		byID[spec.ID] = th
+		all = append(all, e)
+	}
+
+	// Sensors: search radar near the launcher, a seeker on each missile.
+	radarSpec := sc.Radar
+	if radarSpec == nil {
+		radarSpec = &scenario.RadarSpec{Position: vector.Vector3{X: 0, Y: 10, Z: 0}}
+	}
+	s.Radar = newRadar("radar-1", radarSpec)
+	s.sensorSched = newSensorScheduler()
+	s.budget.degraded.Store(false)
+
+	// Unassigned interceptors go to the most threatening target.
+	s.Asset = defendedAsset(sc)
+	s.evaluateThreatsLocked()
+	top := s.topThreatLocked()
+
+	s.Interceptors = nil
+	for i, spec := range sc.Interceptors() {
+		m := entities.NewMissile(spec.ID, spec.Position, spec.Velocity)
+		if spec.MaxAccel > 0 {
+			m.MaxAccel = spec.MaxAccel
+		}
+		name := spec.Guidance
+		if name == "" {
+			name = "ProNav" // Default
+		}
+		m.GuidanceMode = name
+		target, ok := byID[spec.TargetID]
+		if !ok {
+			target = top
+		}
+		gain := spec.Gain
+		if gain == 0 {
+			gain = 1
+		}
+		s.Interceptors = append(s.Interceptors, &Interceptor{
+			Missile:      m,
+			Target:       target,
+			Seeker:       newSeeker(fmt.Sprintf("seeker-%d", i+1), spec.Seeker),
+			GuidanceLaw:  s.guidanceLawLocked(name),
+			GuidanceName: name,
+			Tier:         spec.Tier,
+			Gain:         gain,
+			LaunchTime:   spec.LaunchTime,
+			Status:       "Ready",
+			MissDistance: m.Position.Distance(target.Entity.Position),
+		})
+		all = append(all, m)
+	}
+	s.Target = s.Threats[0].Entity
+	s.Missile = s.Interceptors[0].Missile
+	s.GuidanceName = s.Interceptors[0].GuidanceName
+
+	s.Terrain = sc.Environment.Terrain.Model()
+	s.Weather = sc.Environment.Weather
+
+	s.InterceptRadius = sc.Termination.InterceptRadius
+	if s.InterceptRadius <= 0 {
+		s.InterceptRadius = scenario.DefaultInterceptRadius
+	}
+	s.MaxTime = sc.Termination.MaxTime
+	s.Bounds = sc.Termination.Bounds
+	s.MinSpeed = sc.Termination.MinSpeed
+	s.Doctrine = copyDoctrine(sc.Doctrine)
 
 	s.State = SimulationState{
-		Entities:  []*entities.Entity{target, missile},
-		Status:    "Stopped",
-		Time:      0.0,
-		Intercept: false,
+		Entities:     all,
+		Status:       "Stopped",
+		Lifecycle:    LifecycleIdle,
+		Time:         0.0,
+		Intercept:    false,
+		MissDistance: s.Interceptors[0].MissDistance,
+		Seed:         seed,
+		TimeScale:    s.TimeScale,
+		Scenario:     sc.Name,
+	}
+	s.runSeq++
+	s.State.Run = s.runSeq
+	s.State.Engagements = s.engagementsLocked()
+	s.evaluateThreatsLocked()
+	s.events = nil
+	s.newManifestLocked()
+	s.resetHistoryLocked()
+	s.resetTrailsLocked()
+	if s.recordEnabled {
+		s.beginRecordingLocked()
 	}
 }
 
-// Start resumes the simulation loop.
-func (s *Simulator) Start() {
-	s.mu.Lock()
-	if s.State.Status == "Running" {
-		s.mu.Unlock()
+// GetScenario returns the scenario the simulation was last reset to.
+func (s *Simulator) GetScenario() *scenario.Scenario {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.Scenario
+}
+
+// newRadar builds the surveillance radar from its scenario spec.
+func newRadar(id string, spec *scenario.RadarSpec) *sensors.Radar {
+	r := sensors.NewRadar(id, spec.Position)
+	if spec.MaxRange > 0 {
+		r.MaxRange = spec.MaxRange
+	}
+	if spec.UpdateRate > 0 {
+		r.UpdateRate = spec.UpdateRate
+	}
+	r.NotchWidth = spec.NotchWidth
+	r.Noise = spec.Noise
+	return r
+}
+
+// newSeeker builds an interceptor seeker, applying any scenario overrides.
+func newSeeker(id string, spec *scenario.SeekerSpec) *sensors.Seeker {
+	sk := sensors.NewSeeker(id)
+	if spec == nil {
+		return sk
+	}
+	if spec.Band != "" {
+		sk.Band = spec.Band
+	}
+	if spec.MaxRange > 0 {
+		sk.MaxRange = spec.MaxRange
+	}
+	if spec.GimbalLimit > 0 {
+		sk.GimbalLimit = spec.GimbalLimit
+	}
+	if spec.UpdateRate > 0 {
+		sk.UpdateRate = spec.UpdateRate
+	}
+	sk.NotchWidth = spec.NotchWidth
+	sk.Noise = spec.Noise
+	return sk
+}
+
+// SetSeed fixes the RNG seed used by subsequent resets. 0 restores
+// a fresh seed per run.
+func (s *Simulator) SetSeed(seed uint64) {
+	s.lock()
+	defer s.mu.Unlock()
+	s.Seed = seed
+}
+
+// Start runs the simulation loop from Idle or Paused. Starting a running
+// simulation does nothing; starting a finished one fails with a
+// *TransitionError.
+func (s *Simulator) Start() error {
+	s.lock()
+	defer s.mu.Unlock()
+	if s.State.Lifecycle == LifecycleRunning {
+		return nil
+	}
+	if err := s.transitionLocked("start", LifecycleRunning); err != nil {
+		return err
+	}
+	s.startLocked()
+	return nil
+}
+
+// Time scale limits. Scale 0 means as fast as possible.
+const (
+	TimeScaleAFAP = 0.0
+	MinTimeScale  = 0.1
+	MaxTimeScale  = 100.0
+)
+
+// SetTimeScale changes how fast simulated time advances relative to wall-clock
+// time. It takes effect immediately if the simulation is running.
+func (s *Simulator) SetTimeScale(scale float64) error {
+	if scale != TimeScaleAFAP && (scale < MinTimeScale || scale > MaxTimeScale) {
+		return fmt.Errorf("time scale must be 0 (as fast as possible) or between %g and %g", MinTimeScale, MaxTimeScale)
+	}
+	s.lock()
+	defer s.mu.Unlock()
+	s.TimeScale = scale
+	s.State.TimeScale = scale
+	if s.State.Lifecycle == LifecycleRunning {
+		s.haltLocked()
+		s.startLocked()
+	}
+	return nil
+}
+
+// startLocked launches the loop goroutine for the current time scale.
+// Callers must hold s.mu.
+func (s *Simulator) startLocked() {
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	s.cancelLoop, s.loopDone = cancel, done
+	if s.TimeScale == TimeScaleAFAP {
+		go s.loopFast(ctx, done)
 		return
 	}
-	s.State.Status = "Running"
-	s.stopChan = make(chan bool)
-	s.ticker = time.NewTicker(time.Duration(s.Dt * float64(time.Second)))
+	go s.loop(ctx, done, s.TimeScale)
+}
+
+// Stop pauses a running simulation and returns once its loop has exited.
+// Stopping one that is not running does nothing.
+func (s *Simulator) Stop() {
+	s.lock()
+	if s.State.Lifecycle == LifecycleRunning {
+		s.transitionLocked("stop", LifecyclePaused)
+	}
+	done := s.haltLocked()
 	s.mu.Unlock()
+	waitLoop(done)
+}
 
-	go s.loop()
+// haltLocked cancels the loop goroutine, if any, and returns a channel
+// closed once it has exited. Callers must hold s.mu, which lets Step end the
+// run without re-entering Stop; the loop checks for cancellation under it,
+// so it takes no step after this returns. Callers other than the loop may
+// wait for the channel with waitLoop once they release the lock.
+func (s *Simulator) haltLocked() <-chan struct{} {
+	if s.cancelLoop == nil {
+		return nil
+	}
+	s.cancelLoop()
+	done := s.loopDone
+	s.cancelLoop, s.loopDone = nil, nil
+	return done
 }
 
-// Stop pauses the simulation loop.
-func (s *Simulator) Stop() {
-	s.mu.Lock()
-	defer s.mu.Unlock()
-	if s.State.Status == "Running" {
-		s.State.Status = "Stopped"
-		if s.ticker != nil {
-			s.ticker.Stop()
+// waitLoop waits for a halted loop to exit; done may be nil.
+func waitLoop(done <-chan struct{}) {
+	if done != nil {
+		<-done
+	}
+}
+
+// RunToCompletion steps the simulation synchronously, without the ticker,
+// until it terminates or maxTime seconds of simulated time elapse. A
+// finished run is returned as it is.
+func (s *Simulator) RunToCompletion(maxTime float64) SimulationState {
+	state, _ := s.runLogged(maxTime, nil)
+	return state
+}
+
+// RunContext is RunToCompletion giving up with ctx's error once ctx is
+// done, leaving the run paused. It fails with a *TransitionError if the
+// run has already finished.
+func (s *Simulator) RunContext(ctx context.Context, maxTime float64) (SimulationState, error) {
+	return s.runLogged(maxTime, stepFunc(func(*SimulationState) error { return ctx.Err() }))
+}
+
+// stepFunc adapts a function to StepLogger.
+type stepFunc func(state *SimulationState) error
+
+func (f stepFunc) LogStep(state *SimulationState) error { return f(state) }
+
+// runLogged is RunToCompletion handing the state after every step to log,
+// if not nil. It gives up with log's error, leaving the run unfinished.
+func (s *Simulator) runLogged(maxTime float64, log StepLogger) (SimulationState, error) {
+	s.lock()
+	done := s.haltLocked()
+	err := s.transitionLocked("run", LifecycleRunning)
+	s.mu.Unlock()
+	waitLoop(done)
+	if err != nil {
+		return s.GetState(), err
+	}
+
+	for {
+		s.Step()
+		if log != nil {
+			s.mu.RLock()
+			err := log.LogStep(&s.State)
+			s.mu.RUnlock()
+			if err != nil {
+				s.pauseRun()
+				return s.GetState(), err
+			}
 		}
-		if s.stopChan != nil {
-			close(s.stopChan)
+		stage, now := s.progress()
+		if stage != LifecycleRunning {
+			return s.GetState(), nil
+		}
+		if now >= maxTime {
+			s.lock()
+			s.endRunLocked("Timeout", ReasonMaxTime)
+			s.finishRecordingLocked()
+			s.mu.Unlock()
+			return s.GetState(), nil
 		}
 	}
 }
 
-// SetGuidanceMode changes the active guidance law.
-func (s *Simulator) SetGuidanceMode(mode string) {
-	s.mu.Lock()
+// pauseRun pauses a run RunToCompletion gave up on.
+func (s *Simulator) pauseRun() {
+	s.lock()
+	defer s.mu.Unlock()
+	if s.State.Lifecycle == LifecycleRunning {
+		s.transitionLocked("stop", LifecyclePaused)
+	}
+}
+
+// SetGuidanceMode changes the active guidance law of every interceptor. An
+// unknown mode fails with an *UnknownGuidanceError and changes nothing.
+func (s *Simulator) SetGuidanceMode(mode string) error {
+	if valid := ValidGuidanceModes(); !slices.Contains(valid, mode) {
+		return &UnknownGuidanceError{Mode: mode, Valid: valid}
+	}
+	s.lock()
 	defer s.mu.Unlock()
 	s.GuidanceName = mode
-	s.GuidanceLaw = guidance.GetFactory(mode)
-	s.Missile.GuidanceMode = mode
+	s.overrideLocked("guidance", mode)
+	for _, ic := range s.Interceptors {
+		ic.GuidanceName = mode
+		ic.GuidanceLaw = s.guidanceLawLocked(mode)
+		ic.Missile.GuidanceMode = mode
+	}
+	s.State.Engagements = s.engagementsLocked()
+	return nil
 }
 
-// loop is the main physics loop running in a goroutine.
-func (s *Simulator) loop() {
+// loopInterval is the wall-clock pacing of the real-time loop.
+const loopInterval = 16 * time.Millisecond
+
+// maxCatchUpSteps bounds the steps run on one tick. If the host can't keep
+// up, the backlog is dropped and simulated time falls behind rather than the
+// loop spiraling further behind each tick.
+const maxCatchUpSteps = 1000
+
+// loop is the main physics loop running in a goroutine. Ticks only pace it:
+// each one measures the elapsed monotonic time, scales it, and runs as many
+// fixed Dt steps as have accumulated, so late or dropped ticks don't slow
+// simulated time.
+func (s *Simulator) loop(ctx context.Context, done chan<- struct{}, scale float64) {
+	s.loops.Add(1)
+	defer s.loops.Add(-1)
+	defer close(done)
+	defer s.recoverLoop(ctx)
+	ticker := time.NewTicker(loopInterval)
+	defer ticker.Stop()
+	last := time.Now()
+	s.lastStep.Store(last.UnixNano())
+	acc := 0.0
+	budget := time.Duration(s.Dt / scale * float64(time.Second))
 	for {
 		select {
-		case <-s.stopChan:
+		case <-ctx.Done():
 			return
-		case <-s.ticker.C:
-			s.Step()
+		case <-ticker.C:
+			now := time.Now()
+			acc += now.Sub(last).Seconds() * scale
+			last = now
+			// A long catch-up must still notice a stop promptly.
+			for n := 0; acc >= s.Dt; n++ {
+				if n == maxCatchUpSteps {
+					acc = 0
+					break
+				}
+				t0 := time.Now()
+				if !s.loopStep(ctx) {
+					return
+				}
+				s.timeStep(time.Since(t0), budget)
+				acc -= s.Dt
+			}
+			s.publish()
+		}
+	}
+}
+
+// loopFast steps back to back with no pacing, for as-fast-as-possible runs.
+func (s *Simulator) loopFast(ctx context.Context, done chan<- struct{}) {
+	s.loops.Add(1)
+	defer s.loops.Add(-1)
+	defer close(done)
+	defer s.recoverLoop(ctx)
+	last := time.Now()
+	s.lastStep.Store(last.UnixNano())
+	for s.loopStep(ctx) {
+		// Publish at the real-time loop's pace, not every step.
+		if now := time.Now(); now.Sub(last) >= loopInterval {
+			s.publish()
+			last = now
 		}
 	}
 }
 
-// Step performs one physics integration step.
+// loopStep takes one step for the loop whose context is ctx, reporting
+// false if the loop should exit instead. Checking ctx under the lock keeps
+// a loop that was halted while it waited for the lock from stepping a run
+// it no longer owns.
+func (s *Simulator) loopStep(ctx context.Context) bool {
+	s.lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil || s.State.Lifecycle != LifecycleRunning {
+		return false
+	}
+	s.stepLocked()
+	return true
+}
+
+// Step performs one physics integration step if the simulation is running.
 func (s *Simulator) Step() {
-	s.mu.Lock()
+	s.lock()
 	defer s.mu.Unlock()
 
-	if s.State.Status != "Running" {
+	if s.State.Lifecycle != LifecycleRunning {
 		return
 	}
+	s.stepLocked()
+}
+
+// Advance runs up to n physics steps while the simulation is paused, stopping
+// early if the run ends. It returns the number of steps taken.
+func (s *Simulator) Advance(n int) (int, error) {
+	s.lock()
+	defer s.mu.Unlock()
 
+	if err := s.steppableLocked("step"); err != nil {
+		return 0, err
+	}
+	s.transitionLocked("step", LifecyclePaused)
+	taken := 0
+	for taken < n && !s.finishedLocked() {
+		s.stepLocked()
+		taken++
+	}
+	return taken, nil
+}
+
+// AdvanceTo steps the paused simulation until its clock reaches t, to within
+// half a step, for masters that keep it in lockstep with their own clock. It
+// stops early if the run ends and returns the number of steps taken.
+func (s *Simulator) AdvanceTo(t float64) (int, error) {
+	s.lock()
+	defer s.mu.Unlock()
+
+	if err := s.steppableLocked("step"); err != nil {
+		return 0, err
+	}
+	if t < s.State.Time-s.Dt/2 {
+		return 0, fmt.Errorf("time %g is behind the simulation at %g", t, s.State.Time)
+	}
+	s.transitionLocked("step", LifecyclePaused)
+	taken := 0
+	for s.State.Time+s.Dt/2 <= t && !s.finishedLocked() {
+		s.stepLocked()
+		taken++
+	}
+	return taken, nil
+}
+
+// finishedLocked reports whether the run has ended.
+func (s *Simulator) finishedLocked() bool {
+	return s.State.Lifecycle == LifecycleFinished
+}
+
+// stepLocked advances the world by one Dt, faulting the run if it panics.
+// Callers must hold s.mu.
+func (s *Simulator) stepLocked() {
+	defer s.recoverStepLocked()
+	s.lastStep.Store(time.Now().UnixNano())
 	dt := s.Dt
+	now := s.State.Time
 
-	// 1. Calculate Guidance Interceptor
-	// Missile guidance logic
-	// Accel command
-	accelCmd := s.GuidanceLaw.CalculateAcceleration(s.Missile, s.Target, dt)
+	// 0. Launches
+	// Interceptors sit on the launcher, untouched by physics, until their
+	// launch time or until the doctrine commits them.
+	if s.Doctrine != nil {
+		s.doctrineLocked(now)
+	} else {
+		for _, ic := range s.Interceptors {
+			if ic.Status == "Ready" && now >= ic.LaunchTime {
+				s.launchLocked(ic, ic.Target, "")
+			}
+		}
+	}
 
-	// Limit acceleration (structural limits)
-	accelCmd = physics.LimitAcceleration(accelCmd, s.Missile.MaxAccel)
+	// 1. Sensors
+	// Guidance only sees what the seeker sees. When terrain masks the target
+	// the seeker coasts on its last track. Each sensor runs at its own rate.
+	env := sensors.Environment{Terrain: s.Terrain, Weather: s.Weather, Rand: s.rng}
+	if s.sensorSched.due(s.Radar.ID, s.Radar.UpdateRate, now) {
+		s.Radar.Update(env, s.liveTargetsLocked(), now)
+	}
+	statuses := append([]sensors.Status(nil), s.Radar.Statuses()...)
+	for _, ic := range s.Interceptors {
+		if ic.Status != "Flying" {
+			continue
+		}
+		if !ic.Target.Live() && !s.retargetLocked(ic) {
+			continue
+		}
+		if s.sensorSched.due(ic.Seeker.ID, ic.Seeker.UpdateRate, now) {
+			ic.Seeker.Update(env, ic.Missile, ic.Target.Entity, now)
+			if detected := ic.Seeker.Status().Detected; detected != ic.Locked {
+				ic.Locked = detected
+				if detected {
+					s.logEventLocked(EventLock, ic.Missile.ID, "Seeker locked on "+ic.Target.Entity.ID)
+				} else {
+					s.logEventLocked(EventLockLost, ic.Missile.ID, "Seeker lost "+ic.Target.Entity.ID)
+				}
+			}
+		}
+		statuses = append(statuses, ic.Seeker.Status())
+	}
+	s.State.Sensors = statuses
 
+	// 2. Calculate Guidance Interceptor
 	// Apply Gravity?
 	// Real missiles fight gravity.
 	// If we want realistic trajectories, we need gravity.
@@ -173,21 +708,7 @@ func (s *Simulator) Step() {
 	// NOTE: physics.LimitTurnRate is complex without full aerodynamics.
 	// Let's rely on LimitAcceleration magnitude for now.
 
-	s.Missile.Acceleration = accelCmd
-
-	// Basic target movement (constant velocity for now, or simple evade?)
-	// Let's make target circle or wave if requested. For now constant velocity.
-	s.Target.Acceleration = vector.Vector3{}
-
-	// 2. Physics Integration
-	// Missile
-	// Total Accel = Command + Gravity ??
-	// If we just use Command, it flies like a spaceship.
-	// Let's add Gravity to both.
-	// Physics engine should handle forces.
-	// Here we are setting acceleration directly.
-	// Let's do: TrueAccel = Cmd + Gravity
-
+	// Physics Integration
 	// But ProNav expects to control acceleration. Only need to compensate for gravity if it pulls us off course.
 	// A simple approach for "Realistic looking" without full autopilot:
 	// Missile has Thrust (forward), Drag (backward), Lift (steer).
@@ -195,61 +716,339 @@ func (s *Simulator) Step() {
 	// So Accel = Cmd.
 	// If we want gravity drop, we add it.
 	// Let's add gravity for realism.
-	// But then ProNav needs to "bias" upward to compensate.
-	// Let's stick to zero gravity for the "Interceptor" logic clarity unless requested "Ballistic".
 	// User asked for "Aeropace software engineer" level.
 	// Gravity is essential.
 	// Let's add Gravity.
 
-	gravity := vector.Vector3{Y: -9.81}
+	gravity := vector.Vector3{Y: -units.G}
 
 	// Tweak: Guidance command is "Acceleration needed to intercept".
 	// It doesn't know about gravity.
 	// If we add gravity to the physics update, the missile will sag.
 	// The next guidance step will see the sag (velocity error) and correct it.
 	// This is how closed-loop guidance works! It automatically compensates for gravity bias.
+	for _, ic := range s.Interceptors {
+		if ic.Status != "Flying" {
+			continue
+		}
+		// Missile guidance logic
+		// Accel command
+		accelCmd := vector.Vector3{}
+		ic.sensorErr = 0
+		if perceived := ic.Seeker.Perceived(ic.Target.Entity, now); perceived != nil {
+			accelCmd = ic.GuidanceLaw.CalculateAcceleration(ic.Missile, perceived, dt)
+			ic.sensorErr = perceived.Position.Distance(ic.Target.Entity.Position)
+		}
+		accelCmd = accelCmd.Mul(ic.Gain)
+		// Limit acceleration (structural limits)
+		limited := physics.LimitAcceleration(accelCmd, ic.Missile.MaxAccel)
+		ic.deficit = accelCmd.Sub(limited)
+		accelCmd = limited
+		ic.Missile.Acceleration = accelCmd.Add(gravity)
+		g := units.ToG(accelCmd.Magnitude())
+		if g > ic.MaxG {
+			ic.MaxG = g
+		}
+		if l := s.logs.guidance; l.Enabled(context.Background(), slog.LevelDebug) {
+			l.Debug("command", "missile", ic.Missile.ID, "target", ic.Target.Entity.ID, "law", ic.GuidanceName, "t", now,
+				"g", g, "saturated", ic.deficit != vector.Vector3{}, "locked", ic.Locked, "sensorError", ic.sensorErr)
+		}
+	}
 
-	s.Missile.Acceleration = s.Missile.Acceleration.Add(gravity)
-	s.Target.Acceleration = s.Target.Acceleration.Add(gravity) // Target also falls if not generating lift?
+	// 3. Target movement
 	// Target is usually an airplane maintaining altitude.
-	// Assume Logic keeps target level (Autopilot).
-	// So Reset Target Accel to zero net (Lift = -Gravity).
-	s.Target.Acceleration = vector.Vector3{} // Target flies straight.
+	// Assume Logic keeps target level (Autopilot), so Lift = -Gravity and only
+	// scripted maneuvers accelerate it. Ballistic targets have no lift.
+	for _, th := range s.Threats {
+		if !th.Live() {
+			continue
+		}
+		if th.Ballistic {
+			th.Entity.Acceleration = gravity
+			continue
+		}
+		accel := vector.Vector3{}
+		for _, m := range th.Maneuvers {
+			accel = accel.Add(m.Acceleration(th.Entity, now))
+		}
+		th.Entity.Acceleration = physics.LimitAcceleration(accel, th.Entity.MaxAccel)
+	}
 
-	// Update Missile
-	newPosM, newVelM := physics.KinematicsUpdate(s.Missile.Position, s.Missile.Velocity, s.Missile.Acceleration, dt)
-	s.Missile.Position = newPosM
-	s.Missile.Velocity = newVelM
+	s.endgameLocked(now, dt)
 
-	// Update Target
-	newPosT, newVelT := physics.KinematicsUpdate(s.Target.Position, s.Target.Velocity, s.Target.Acceleration, dt)
-	s.Target.Position = newPosT
-	s.Target.Velocity = newVelT
+	// 4. Physics Integration
+	s.swarm.reset()
+	for _, ic := range s.Interceptors {
+		if ic.Status == "Flying" {
+			s.swarm.add(ic.Missile)
+		}
+	}
+	for _, th := range s.Threats {
+		if th.Live() {
+			s.swarm.add(th.Entity)
+		}
+	}
+	s.swarm.step(dt)
 
 	s.State.Time += dt
 
-	// 3. Intercept Check
-	dist := s.Missile.Position.Distance(s.Target.Position)
-	if dist < 5.0 { // Threshold 5 meters
+	// 5. Intercept Check
+	// The proximity fuze fires on any live threat inside the intercept
+	// radius, not only the assigned one.
+	s.indexThreatsLocked()
+	for _, ic := range s.Interceptors {
+		if ic.Status != "Flying" {
+			continue
+		}
+		ic.FlightTime = s.State.Time - ic.LaunchTime
+		if speed := ic.Missile.Velocity.Magnitude(); speed > ic.PeakSpeed {
+			ic.PeakSpeed = speed
+			ic.BurnoutTime = s.State.Time
+		}
+		dist := ic.Missile.Position.Distance(ic.Target.Entity.Position)
+		if dist < ic.MissDistance {
+			ic.MissDistance = dist
+			ic.ClosestApproach = s.State.Time
+			ic.MissVector = ic.Target.Entity.Position.Sub(ic.Missile.Position)
+			ic.cpa = ic.endgame
+		}
+		if dist >= s.InterceptRadius {
+			if th, d := s.fuzeLocked(ic); th != nil {
+				ic.Target = th
+				ic.MissDistance, dist = d, d
+				ic.ClosestApproach = s.State.Time
+				ic.MissVector = th.Entity.Position.Sub(ic.Missile.Position)
+				ic.cpa = endgame{} // the endgame was flown against another target
+			}
+		}
+		if dist < s.InterceptRadius {
+			ic.Status = "Intercepted"
+			ic.Target.Destroyed = true
+			s.Radar.Drop(ic.Target.Entity.ID)
+			s.logEventLocked(EventIntercept, ic.Missile.ID, fmt.Sprintf("Intercepted %s, miss %.2fm", ic.Target.Entity.ID, dist))
+			continue
+		}
+
+		// Ground collision check
+		if ground := s.Terrain.Elevation(ic.Missile.Position.X, ic.Missile.Position.Z); ic.Missile.Position.Y < ground {
+			ic.Missile.Position.Y = ground
+			ic.Missile.Velocity = vector.Vector3{}
+			ic.Status = "Crashed"
+			s.logEventLocked(EventCrash, ic.Missile.ID, "Hit the ground")
+			continue
+		}
+
+		if s.Bounds != nil && !s.Bounds.Contains(ic.Missile.Position) {
+			ic.Status = "OutOfBounds"
+			s.logEventLocked(EventOutOfBounds, ic.Missile.ID, "Left the engagement area")
+			continue
+		}
+		// Minimum-energy cutoff, armed once the missile has been faster than
+		// the limit so a slow launch doesn't trip it.
+		if s.MinSpeed > 0 && ic.PeakSpeed >= s.MinSpeed && ic.Missile.Velocity.Magnitude() < s.MinSpeed {
+			ic.Status = "Spent"
+			s.logEventLocked(EventSpent, ic.Missile.ID, "Below minimum speed")
+		}
+	}
+
+	// A target that reaches the ground has leaked through the defense.
+	for _, th := range s.Threats {
+		if !th.Live() {
+			continue
+		}
+		if ground := s.Terrain.Elevation(th.Entity.Position.X, th.Entity.Position.Z); th.Entity.Position.Y < ground {
+			th.Entity.Position.Y = ground
+			th.Entity.Velocity = vector.Vector3{}
+			th.Entity.Acceleration = vector.Vector3{}
+			th.Impacted = true
+			s.Radar.Drop(th.Entity.ID)
+			s.logEventLocked(EventImpact, th.Entity.ID, "Reached the ground")
+		}
+	}
+
+	s.updateOutcomeLocked()
+	s.applyRulesLocked()
+	s.recordFrameLocked()
+	s.historyFrameLocked()
+	s.sampleTrailsLocked()
+}
+
+// Termination reasons reported in the state once a run ends.
+const (
+	ReasonIntercept    = "Intercept"    // every target destroyed
+	ReasonTargetImpact = "TargetImpact" // every surviving target reached the ground
+	ReasonGround       = "Ground"       // last interceptor hit the ground
+	ReasonOutOfBounds  = "OutOfBounds"  // last interceptor left the bounds
+	ReasonMinEnergy    = "MinEnergy"    // last interceptor fell below the minimum speed
+	ReasonMaxTime      = "MaxTime"
+	ReasonFault        = "Fault" // a step panicked
+)
+
+// updateOutcomeLocked refreshes the run-level summary and ends the run once
+// every target is destroyed or down, no interceptor is left flying, or time
+// runs out.
+func (s *Simulator) updateOutcomeLocked() {
+	flying, intercepts := 0, 0 // flying includes interceptors still waiting to launch
+	miss := -1.0
+	for _, ic := range s.Interceptors {
+		switch ic.Status {
+		case "Flying", "Ready":
+			flying++
+		case "Intercepted":
+			intercepts++
+		}
+		if miss < 0 || ic.MissDistance < miss {
+			miss = ic.MissDistance
+		}
+	}
+	s.State.MissDistance = miss
+	s.State.Engagements = s.engagementsLocked()
+	s.evaluateThreatsLocked()
+
+	destroyed, live := 0, 0
+	for _, th := range s.Threats {
+		switch {
+		case th.Destroyed:
+			destroyed++
+		case th.Live():
+			live++
+		}
+	}
+
+	switch {
+	case destroyed == len(s.Threats):
 		s.State.Intercept = true
-		s.State.Status = "Intercepted"
-		s.Stop()
-		log.Println("INTERCEPT SUCCESS!")
+		s.endRunLocked("Intercepted", ReasonIntercept)
+	case live == 0:
+		s.endRunLocked("Leaked", ReasonTargetImpact)
+	case flying == 0 && intercepts == 0:
+		s.endRunLocked("Crashed", s.lossReasonLocked())
+	case flying == 0:
+		s.endRunLocked("Leaked", s.lossReasonLocked())
+	case s.MaxTime > 0 && s.State.Time >= s.MaxTime:
+		s.endRunLocked("Timeout", ReasonMaxTime)
 	}
+}
 
-	// Ground collision check
-	if s.Missile.Position.Y < 0 {
-		s.Missile.Position.Y = 0
-		s.Missile.Velocity = vector.Vector3{}
-		s.State.Status = "Crashed"
-		s.Stop()
+// endRunLocked finishes the run with the given status and reason.
+// Callers must hold s.mu.
+func (s *Simulator) endRunLocked(status, reason string) {
+	s.State.Reason = reason
+	s.State.Lifecycle = LifecycleFinished
+	s.setStatusLocked(status)
+	s.haltLocked()
+	s.reportLocked()
+}
+
+// lossReasonLocked names the condition that removed the last interceptor to
+// stop flying without an intercept.
+func (s *Simulator) lossReasonLocked() string {
+	var last *Interceptor
+	for _, ic := range s.Interceptors {
+		if ic.Status == "Intercepted" {
+			continue
+		}
+		if last == nil || ic.LaunchTime+ic.FlightTime >= last.LaunchTime+last.FlightTime {
+			last = ic
+		}
+	}
+	if last == nil {
+		return ""
+	}
+	switch last.Status {
+	case "OutOfBounds":
+		return ReasonOutOfBounds
+	case "Spent":
+		return ReasonMinEnergy
+	default:
+		return ReasonGround
 	}
 }
 
-// GetState returns the thread-safe state.
+// published is a copy of the state shared by every reader, with the write
+// generation it was taken at.
+type published struct {
+	gen   uint64
+	state SimulationState
+}
+
+// lock takes s.mu for writing and retires the published state.
+func (s *Simulator) lock() {
+	s.mu.Lock()
+	s.gen.Add(1)
+}
+
+// GetState returns a deep copy of the current state that stays consistent
+// while the loop keeps stepping. The loop publishes one after every tick, so
+// between ticks readers take it without touching the lock; after any other
+// change the first reader copies the state under the read lock and publishes
+// that. The copy is shared: callers may reassign its fields but must not
+// modify the entities or slices it holds.
 func (s *Simulator) GetState() SimulationState {
+	if p := s.published.Load(); p != nil && p.gen == s.gen.Load() {
+		return p.state
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.publishRLocked().state
+}
+
+// publish copies the state for readers.
+func (s *Simulator) publish() {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	s.publishRLocked()
+}
+
+// publishRLocked copies the state for readers. Callers must hold s.mu, for
+// reading at least.
+func (s *Simulator) publishRLocked() *published {
+	p := &published{gen: s.gen.Load(), state: cloneState(s.State)}
+	s.published.Store(p)
+	return p
+}
+
+// LoopStallTimeout is how long a running loop may go without stepping before
+// Health reports it stalled.
+const LoopStallTimeout = 5 * time.Second
+
+// LoopHealth describes the simulation loop goroutine.
+type LoopHealth struct {
+	Running  bool      `json:"running"`  // a loop goroutine is active
+	LastStep time.Time `json:"lastStep"` // zero if the simulator has never stepped
+	Stalled  bool      `json:"stalled"`  // running but not stepping
+
+	// Real-time step budget, for steps of the paced loop.
+	StepTime    float64 `json:"stepTime"`    // ms, the latest step
+	MaxStepTime float64 `json:"maxStepTime"` // ms, the longest step
+	Overruns    uint64  `json:"overruns"`    // steps that took longer than their budget
+	Degraded    bool    `json:"degraded"`    // sensor rates are capped to keep up
+
+	RecordDropped uint64 `json:"recordDropped"` // recorded frames the disk writer fell too far behind to take
+	Faults        uint64 `json:"faults"`        // panics recovered from, each of which faulted a run
+}
+
+// Health reports on the loop goroutine. It does not take the lock, so it
+// still answers when a wedged step is holding it.
+func (s *Simulator) Health() LoopHealth {
+	h := LoopHealth{Running: s.loops.Load() > 0}
+	if ns := s.lastStep.Load(); ns != 0 {
+		h.LastStep = time.Unix(0, ns)
+	}
+	h.Stalled = h.Running && time.Since(h.LastStep) > LoopStallTimeout
+	h.StepTime = float64(s.budget.last.Load()) / 1e6
+	h.MaxStepTime = float64(s.budget.max.Load()) / 1e6
+	h.Overruns = s.budget.overruns.Load()
+	h.Degraded = s.budget.degraded.Load()
+	h.RecordDropped = s.recordDropped.Load()
+	h.Faults = s.faults.Load()
+	return h
+}
+
+// progress returns the lifecycle stage and simulation time without copying
+// the state.
+func (s *Simulator) progress() (Lifecycle, float64) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	// Return copy?
-	return s.State
+	return s.State.Lifecycle, s.State.Time
 }
@@ -0,0 +1,125 @@
+package simulation
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestGetStateIsDetached(t *testing.T) {
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	s.Seed = 42
+	s.Reset()
+
+	before := s.GetState()
+	want := before.Entities[0].Position
+	if _, err := s.Advance(10); err != nil {
+		t.Fatal(err)
+	}
+	if got := before.Entities[0].Position; got != want {
+		t.Errorf("stepping moved an entity in a returned state: %+v, was %+v", got, want)
+	}
+	if after := s.GetState(); after.Entities[0] == before.Entities[0] {
+		t.Error("states share entity pointers")
+	}
+}
+
+func TestGetStatePublished(t *testing.T) {
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	first := s.GetState()
+	if again := s.GetState(); again.Entities[0] != first.Entities[0] {
+		t.Error("an unchanged state was copied again")
+	}
+	if _, err := s.Advance(1); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetState(); got.Time == first.Time || got.Entities[0] == first.Entities[0] {
+		t.Errorf("state at %gs after a step from %gs", got.Time, first.Time)
+	}
+
+	// Between ticks the loop leaves readers a current state.
+	s.Start()
+	defer s.Stop()
+	time.Sleep(5 * loopInterval)
+	if p := s.published.Load(); p == nil || p.state.Time == 0 {
+		t.Error("running loop published nothing")
+	}
+}
+
+func TestHealth(t *testing.T) {
+	tests := []struct {
+		name        string
+		loops       int32
+		ago         time.Duration
+		wantRunning bool
+		wantStalled bool
+	}{
+		{"stopped", 0, time.Hour, false, false},
+		{"stepping", 1, 10 * time.Millisecond, true, false},
+		{"wedged", 1, 2 * LoopStallTimeout, true, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewSimulator()
+			s.loops.Store(tt.loops)
+			s.lastStep.Store(time.Now().Add(-tt.ago).UnixNano())
+			// A wedged step holds the lock; Health must not wait for it.
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			h := s.Health()
+			if h.Running != tt.wantRunning || h.Stalled != tt.wantStalled {
+				t.Errorf("got running=%v stalled=%v, want %v %v", h.Running, h.Stalled, tt.wantRunning, tt.wantStalled)
+			}
+		})
+	}
+}
+
+// BenchmarkStep times one physics step of a raid of a hundred targets, each
+// engaged by its own interceptor.
+func BenchmarkStep(b *testing.B) {
+	sc := &scenario.Scenario{Name: "raid", Seed: 1}
+	for i := range 100 {
+		x := float64(i) * 200
+		sc.Entities = append(sc.Entities,
+			scenario.Entity{ID: fmt.Sprintf("target-%d", i), Role: scenario.RoleTarget,
+				Position: vector.Vector3{X: x, Y: 8000, Z: 60000}, Velocity: vector.Vector3{Z: -250}},
+			scenario.Entity{ID: fmt.Sprintf("missile-%d", i), Role: scenario.RoleInterceptor,
+				Position: vector.Vector3{X: x}, TargetID: fmt.Sprintf("target-%d", i)},
+		)
+	}
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	if err := s.LoadScenario(sc); err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	for b.Loop() {
+		if s.finishedLocked() {
+			b.StopTimer()
+			s.Reset()
+			b.StartTimer()
+		}
+		s.stepLocked()
+	}
+}
+
+// BenchmarkGetState reads the state from many goroutines while the loop
+// steps, as stream clients do.
+func BenchmarkGetState(b *testing.B) {
+	s := NewSimulator()
+	s.SetLogger(logging.Discard)
+	s.Start()
+	defer s.Stop()
+	b.ReportAllocs()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			_ = s.GetState()
+		}
+	})
+}
@@ -0,0 +1,141 @@
+package simulation
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+// Snapshot is a complete copy of simulator state that can be restored later,
+// into the same simulator or a different one.
+type Snapshot struct {
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	Time    float64   `json:"time"` // simulation time captured
+	Status  string    `json:"status"`
+
+	world        world
+	scenario     *scenario.Scenario
+	guidanceName string
+	radius       float64
+	maxTime      float64
+	bounds       *scenario.Bounds
+	minSpeed     float64
+	sched        map[string]float64
+	manifest     Manifest
+	doctrine     *scenario.Doctrine
+	events       []Event
+	rng          []byte
+}
+
+// Snapshot captures the simulator's full state, including the RNG position,
+// so restoring it continues the run exactly as it would have gone.
+func (s *Simulator) Snapshot(id string) (*Snapshot, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	rngState, err := s.pcg.MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("snapshot rng: %w", err)
+	}
+	snap := &Snapshot{
+		ID:           id,
+		Created:      time.Now(),
+		Time:         s.State.Time,
+		Status:       s.State.Status,
+		world:        s.worldLocked().clone(),
+		scenario:     s.Scenario,
+		guidanceName: s.GuidanceName,
+		radius:       s.InterceptRadius,
+		maxTime:      s.MaxTime,
+		bounds:       s.Bounds,
+		minSpeed:     s.MinSpeed,
+		sched:        make(map[string]float64, len(s.sensorSched.next)),
+		manifest:     s.manifest.clone(),
+		doctrine:     copyDoctrine(s.Doctrine),
+		events:       append([]Event(nil), s.events...),
+		rng:          rngState,
+	}
+	for k, v := range s.sensorSched.next {
+		snap.sched[k] = v
+	}
+	return snap, nil
+}
+
+// Restore replaces the simulator's state with a snapshot. The simulation is
+// left paused so it can be inspected or modified before resuming.
+func (s *Simulator) Restore(snap *Snapshot) error {
+	pcg := &rand.PCG{}
+	if err := pcg.UnmarshalBinary(snap.rng); err != nil {
+		return fmt.Errorf("restore rng: %w", err)
+	}
+
+	s.lock()
+	defer s.mu.Unlock()
+
+	s.haltLocked()
+	s.finishRecordingLocked()
+
+	// Copy again so the snapshot can be restored any number of times.
+	w := snap.world.clone()
+	s.State = w.state
+	s.runSeq++
+	s.State.Run = s.runSeq
+	if s.State.Lifecycle == LifecycleRunning {
+		s.State.Lifecycle = LifecyclePaused
+		s.State.Status = "Stopped"
+	}
+	// The log rewinds with the world, dropping the abandoned timeline. The
+	// sequence counter does not, so cursors held by clients stay valid and
+	// events logged from here on are still seen as new.
+	s.events = append([]Event(nil), snap.events...)
+	s.logEventLocked(EventPhase, "", "Restored snapshot "+snap.ID)
+	s.State.TimeScale = s.TimeScale
+	s.Threats = w.threats
+	s.Interceptors = w.interceptors
+	for _, ic := range s.Interceptors {
+		ic.GuidanceLaw = s.guidanceLawLocked(ic.GuidanceName)
+	}
+	s.Target = s.Threats[0].Entity
+	s.Missile = s.Interceptors[0].Missile
+	s.Radar = w.radar
+	s.Scenario = snap.scenario
+	s.Asset = defendedAsset(snap.scenario)
+	s.Terrain = snap.scenario.Environment.Terrain.Model()
+	s.Weather = snap.scenario.Environment.Weather
+	s.GuidanceName = snap.guidanceName
+	s.InterceptRadius = snap.radius
+	s.MaxTime = snap.maxTime
+	s.Bounds = snap.bounds
+	s.MinSpeed = snap.minSpeed
+	s.Doctrine = copyDoctrine(snap.doctrine)
+	s.sensorSched = newSensorScheduler()
+	for k, v := range snap.sched {
+		s.sensorSched.next[k] = v
+	}
+	if s.budget.degraded.Load() {
+		s.sensorSched.maxRate = degradedSensorRate // rewinding does not make the host faster
+	}
+	s.pcg = pcg
+	s.rng = rand.New(pcg)
+	s.manifest = snap.manifest.clone()
+
+	s.resetHistoryLocked()
+	s.resetTrailsLocked()
+	if s.recordEnabled {
+		s.beginRecordingLocked()
+	}
+	return nil
+}
+
+// worldLocked bundles the live world for cloning. Callers must hold s.mu.
+func (s *Simulator) worldLocked() world {
+	return world{
+		state:        s.State,
+		threats:      s.Threats,
+		interceptors: s.Interceptors,
+		radar:        s.Radar,
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// sseRetry is the reconnect delay EventSource clients are told to use.
+const sseRetry = time.Second
+
+// handleSSE streams a session's state as server-sent events, for clients
+// behind proxies that block WebSockets. It takes the WebSocket stream's
+// query options except ?format=, as events are always JSON. Each state
+// event's id is its resume token, so a reconnecting EventSource's
+// Last-Event-ID header resumes the stream. The stream is read-only; commands
+// go through the REST API.
+func handleSSE(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	sess, ok := sessionFor(w, r)
+	if !ok {
+		return
+	}
+	opts, err := parseClientOptions(r)
+	if err == nil && opts.Format != FormatJSON {
+		err = errors.New("server-sent events are only available as JSON")
+	}
+	if id := r.Header.Get("Last-Event-ID"); err == nil && id != "" && opts.Resume == nil {
+		var tok ResumeToken
+		if tok, err = ParseResumeToken(id); err == nil {
+			opts.Resume = &tok
+		}
+	}
+	if err != nil {
+		writeError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	opts.Control = false
+	sess.Hub.ServeSSE(w, r, opts)
+}
+
+// ServeSSE registers an event stream client and writes frames to w until the
+// request ends, the client falls too far behind or the hub is closed.
+// Comment lines take the place of pings to keep idle proxies from closing
+// the stream.
+func (h *Hub) ServeSSE(w http.ResponseWriter, r *http.Request, opts ClientOptions) {
+	c := newHubClient(opts, nil, r.RemoteAddr)
+	rc := http.NewResponseController(w)
+	write := func(msg outgoing) error {
+		// Not every writer supports deadlines; the stream works without.
+		rc.SetWriteDeadline(time.Now().Add(clientWriteWait))
+		if msg.event != "" {
+			fmt.Fprintf(w, "event: %s\n", msg.event)
+		}
+		if msg.token != "" {
+			fmt.Fprintf(w, "id: %s\n", msg.token)
+		}
+		if _, err := fmt.Fprintf(w, "data: %s\n\n", msg.data); err != nil {
+			return err
+		}
+		return rc.Flush()
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("X-Accel-Buffering", "no") // stop nginx holding events back
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintf(w, "retry: %d\n\n", sseRetry.Milliseconds())
+	if opts.Resume != nil {
+		msgs, err := h.resume(c)
+		for _, msg := range msgs {
+			if err != nil {
+				break
+			}
+			err = write(msg)
+		}
+		if err != nil {
+			slog.Warn("sse resume", "err", err)
+			return
+		}
+	}
+	if err := h.register(c); err != nil {
+		slog.Warn("sse", "err", err)
+		return
+	}
+	defer h.unregister(c)
+
+	ping := time.NewTicker(clientPingPeriod)
+	defer ping.Stop()
+	for {
+		select {
+		case msg, ok := <-c.send:
+			if !ok {
+				return
+			}
+			if err := write(msg); err != nil {
+				return
+			}
+		case <-ping.C:
+			rc.SetWriteDeadline(time.Now().Add(clientWriteWait))
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil || rc.Flush() != nil {
+				return
+			}
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// CloseStreams ends every event stream. The HTTP server waits for them on
+// shutdown, unlike hijacked WebSocket connections.
+func (h *Hub) CloseStreams() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for c := range h.clients {
+		if c.conn == nil {
+			h.removeLocked(c)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/simulation"
+)
+
+// sseEvent is one parsed server-sent event.
+type sseEvent struct {
+	event, id, data string
+}
+
+// nextEvent reads up to the next blank line, skipping comments and events
+// without data.
+func nextEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+		if line == "" {
+			if ev.data != "" {
+				return ev
+			}
+			continue
+		}
+		field, value, _ := strings.Cut(line, ": ")
+		switch field {
+		case "event":
+			ev.event = value
+		case "id":
+			ev.id = value
+		case "data":
+			ev.data = value
+		}
+	}
+}
+
+func TestSSE(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	if _, err := sess.Sim.Advance(200); err != nil {
+		t.Fatal(err)
+	}
+	state := sess.State()
+	seen := ResumeToken{state.Run, sess.Sim.History(0, math.Inf(1))[100].Time}
+	srv := httptest.NewServer(http.HandlerFunc(handleSSE))
+	defer srv.Close()
+
+	tests := []struct {
+		name        string
+		query       string
+		lastEventID string
+		want        int
+		resumed     bool
+	}{
+		{"live", "", "", http.StatusOK, false},
+		{"filtered", "fields=time,run", "", http.StatusOK, false},
+		{"reconnect", "", seen.String(), http.StatusOK, true},
+		{"bad last event id", "", "nonsense", http.StatusBadRequest, false},
+		{"binary format", "format=msgpack", "", http.StatusBadRequest, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+"/?"+tt.query, nil)
+			if tt.lastEventID != "" {
+				req.Header.Set("Last-Event-ID", tt.lastEventID)
+			}
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Fatalf("got %d, want %d", resp.StatusCode, tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+				t.Errorf("Content-Type %q", ct)
+			}
+			r := bufio.NewReader(resp.Body)
+			ev := nextEvent(t, r)
+			if tt.resumed {
+				var res ResumeResult
+				if ev.event != "resume" || json.Unmarshal([]byte(ev.data), &res) != nil || !res.OK || res.Frames == 0 {
+					t.Fatalf("first event %+v, want a successful resume", ev)
+				}
+				ev = nextEvent(t, r)
+			}
+			var st simulation.SimulationState
+			if err := json.Unmarshal([]byte(ev.data), &st); err != nil {
+				t.Fatal(err)
+			}
+			if want := (ResumeToken{st.Run, st.Time}).String(); ev.id != want {
+				t.Errorf("id %q, want %q", ev.id, want)
+			}
+			if tt.resumed && st.Time <= seen.Time {
+				t.Errorf("first frame at t=%g, want after t=%g", st.Time, seen.Time)
+			}
+		})
+	}
+}
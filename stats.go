@@ -0,0 +1,115 @@
+package simulation
+
+import (
+	"math"
+	"sort"
+)
+
+// pkConfidenceZ is the normal quantile for the 95% Pk confidence interval.
+const pkConfidenceZ = 1.96
+
+// histogramBins is the number of equal-width bins in batch histograms.
+const histogramBins = 20
+
+// Histogram counts samples in equal-width bins starting at Min.
+type Histogram struct {
+	Min    float64 `json:"min"`
+	Width  float64 `json:"width"`
+	Counts []int   `json:"counts"`
+}
+
+// BatchStats aggregates a campaign's outcomes.
+type BatchStats struct {
+	PkLow           float64   `json:"pkLow"`  // 95% Wilson score interval, lower bound
+	PkHigh          float64   `json:"pkHigh"` // upper bound
+	CEP             float64   `json:"cep"`    // m, median miss distance
+	Miss90          float64   `json:"miss90"` // m, 90th percentile miss distance
+	MissStdDev      float64   `json:"missStdDev"`
+	MaxMiss         float64   `json:"maxMiss"`
+	MissHistogram   Histogram `json:"missHistogram"`
+	FlightHistogram Histogram `json:"flightHistogram"`
+}
+
+// batchStats computes the aggregate statistics of a finished campaign.
+func batchStats(results []RunResult, intercepts int) BatchStats {
+	var st BatchStats
+	n := len(results)
+	if n == 0 {
+		return st
+	}
+	st.PkLow, st.PkHigh = wilsonInterval(intercepts, n, pkConfidenceZ)
+
+	miss := make([]float64, n)
+	flight := make([]float64, n)
+	for i, r := range results {
+		miss[i] = r.MissDistance
+		flight[i] = r.TimeOfFlight
+	}
+	sort.Float64s(miss)
+	st.CEP = percentile(miss, 0.5)
+	st.Miss90 = percentile(miss, 0.9)
+	st.MaxMiss = miss[n-1]
+	st.MissStdDev = stdDev(miss)
+	st.MissHistogram = histogram(miss, histogramBins)
+	st.FlightHistogram = histogram(flight, histogramBins)
+	return st
+}
+
+// wilsonInterval returns the Wilson score interval for k successes in n
+// trials, which stays inside [0, 1] even when k is 0 or n.
+func wilsonInterval(k, n int, z float64) (float64, float64) {
+	p := float64(k) / float64(n)
+	nf := float64(n)
+	denom := 1 + z*z/nf
+	center := (p + z*z/(2*nf)) / denom
+	half := z * math.Sqrt(p*(1-p)/nf+z*z/(4*nf*nf)) / denom
+	return math.Max(0, center-half), math.Min(1, center+half)
+}
+
+// percentile interpolates linearly between the closest ranks of sorted.
+func percentile(sorted []float64, q float64) float64 {
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func stdDev(xs []float64) float64 {
+	if len(xs) < 2 {
+		return 0
+	}
+	mean := 0.0
+	for _, x := range xs {
+		mean += x
+	}
+	mean /= float64(len(xs))
+	ss := 0.0
+	for _, x := range xs {
+		ss += (x - mean) * (x - mean)
+	}
+	return math.Sqrt(ss / float64(len(xs)-1))
+}
+
+// histogram bins xs into the given number of bins spanning its range.
+func histogram(xs []float64, bins int) Histogram {
+	lo, hi := xs[0], xs[0]
+	for _, x := range xs {
+		lo = math.Min(lo, x)
+		hi = math.Max(hi, x)
+	}
+	h := Histogram{Min: lo, Counts: make([]int, bins)}
+	if hi == lo {
+		h.Width = 0
+		h.Counts[0] = len(xs)
+		return h
+	}
+	h.Width = (hi - lo) / float64(bins)
+	for _, x := range xs {
+		i := int((x - lo) / h.Width)
+		if i >= bins {
+			i = bins - 1 // the maximum lands on the last bin's upper edge
+		}
+		h.Counts[i]++
+	}
+	return h
+}
@@ -0,0 +1,82 @@
+package simulation
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		k, n     int
+		low, upp float64
+	}{
+		{"half", 5, 10, 0.2366, 0.7634},
+		{"none", 0, 10, 0, 0.2775},
+		{"all", 10, 10, 0.7225, 1},
+		{"single success", 1, 1, 0.2065, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			low, upp := wilsonInterval(tt.k, tt.n, pkConfidenceZ)
+			if math.Abs(low-tt.low) > 1e-4 || math.Abs(upp-tt.upp) > 1e-4 {
+				t.Errorf("wilsonInterval(%d, %d) = (%.4f, %.4f), want (%.4f, %.4f)", tt.k, tt.n, low, upp, tt.low, tt.upp)
+			}
+		})
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	tests := []struct {
+		name   string
+		sorted []float64
+		q      float64
+		want   float64
+	}{
+		{"min", []float64{1, 2, 3, 4}, 0, 1},
+		{"max", []float64{1, 2, 3, 4}, 1, 4},
+		{"median between ranks", []float64{1, 2, 3, 4}, 0.5, 2.5},
+		{"median on a rank", []float64{1, 2, 3}, 0.5, 2},
+		{"interpolated", []float64{1, 2, 3, 4}, 0.9, 3.7},
+		{"single sample", []float64{7}, 0.9, 7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := percentile(tt.sorted, tt.q); math.Abs(got-tt.want) > 1e-12 {
+				t.Errorf("percentile(%v, %g) = %g, want %g", tt.sorted, tt.q, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHistogram(t *testing.T) {
+	tests := []struct {
+		name  string
+		xs    []float64
+		bins  int
+		min   float64
+		width float64
+		want  []int
+	}{
+		{"max on last edge", []float64{0, 1, 2, 3, 4}, 2, 0, 2, []int{2, 3}},
+		{"offset range", []float64{10, 12, 19, 20}, 5, 10, 2, []int{1, 1, 0, 0, 2}},
+		{"constant", []float64{5, 5, 5}, 4, 5, 0, []int{3, 0, 0, 0}},
+		{"single sample", []float64{3}, 3, 3, 0, []int{1, 0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := histogram(tt.xs, tt.bins)
+			if h.Min != tt.min || math.Abs(h.Width-tt.width) > 1e-12 {
+				t.Errorf("min, width = %g, %g, want %g, %g", h.Min, h.Width, tt.min, tt.width)
+			}
+			if len(h.Counts) != len(tt.want) {
+				t.Fatalf("counts = %v, want %v", h.Counts, tt.want)
+			}
+			for i := range tt.want {
+				if h.Counts[i] != tt.want[i] {
+					t.Fatalf("counts = %v, want %v", h.Counts, tt.want)
+				}
+			}
+		})
+	}
+}
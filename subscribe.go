@@ -0,0 +1,56 @@
+package simulation
+
+import (
+	"slices"
+	"sync/atomic"
+)
+
+// Subscription delivers a simulator's events on C as they are logged, for
+// programs that would rather be told than poll Events. An event that finds
+// C full is dropped rather than holding up the simulation; Events still
+// has it.
+type Subscription struct {
+	C       <-chan Event
+	c       chan Event
+	s       *Simulator
+	dropped atomic.Uint64
+}
+
+// Subscribe starts delivering events to a new subscription with room for
+// buffer undelivered ones. Close it when done.
+func (s *Simulator) Subscribe(buffer int) *Subscription {
+	c := make(chan Event, max(buffer, 1))
+	sub := &Subscription{C: c, c: c, s: s}
+	s.lock()
+	defer s.mu.Unlock()
+	s.subscribers = append(s.subscribers, sub)
+	return sub
+}
+
+// Close stops delivery and closes C. It may be called more than once.
+func (sub *Subscription) Close() {
+	s := sub.s
+	s.lock()
+	defer s.mu.Unlock()
+	if i := slices.Index(s.subscribers, sub); i >= 0 {
+		s.subscribers = slices.Delete(s.subscribers, i, i+1)
+		close(sub.c)
+	}
+}
+
+// Dropped returns how many events found C full.
+func (sub *Subscription) Dropped() uint64 {
+	return sub.dropped.Load()
+}
+
+// publishEventLocked hands ev to every subscription. Callers must hold s.mu,
+// which keeps Close from closing a channel mid-send.
+func (s *Simulator) publishEventLocked(ev Event) {
+	for _, sub := range s.subscribers {
+		select {
+		case sub.c <- ev:
+		default:
+			sub.dropped.Add(1)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package simulation
+
+import (
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestSubscribe(t *testing.T) {
+	sim := NewSimulator()
+	sim.SetLogger(logging.Discard)
+	full := sim.Subscribe(1)
+	all := sim.Subscribe(10)
+	closed := sim.Subscribe(10)
+	closed.Close()
+	closed.Close()
+
+	for _, msg := range []string{"a", "b", "c"} {
+		sim.LogCommand("req-"+msg, msg)
+	}
+	if got := full.Dropped(); got != 2 {
+		t.Errorf("full subscription dropped %d, want 2", got)
+	}
+	if ev := <-full.C; ev.Message != "a" {
+		t.Errorf("full subscription got %q, want the first event", ev.Message)
+	}
+	for _, msg := range []string{"a", "b", "c"} {
+		ev := <-all.C
+		if ev.Message != msg || ev.RequestID != "req-"+msg || ev.Type != EventCommand {
+			t.Errorf("got %+v, want command %q", ev, msg)
+		}
+	}
+	if _, ok := <-closed.C; ok {
+		t.Errorf("closed subscription delivered an event")
+	}
+	all.Close()
+	if _, ok := <-all.C; ok {
+		t.Errorf("event after close")
+	}
+}
@@ -0,0 +1,45 @@
+package simulation
+
+import (
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/physics"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// swarm propagates the kinematics of every moving entity as a structure of
+// arrays. Positions, velocities and accelerations are copied out of the
+// entities into contiguous slices, stepped in one tight loop that the
+// processor can stream through, and copied back. With hundreds of entities
+// that beats following a pointer per entity through the integrator. The
+// entities remain the state everything else reads; the slices are scratch
+// kept from step to step.
+type swarm struct {
+	entities      []*entities.Entity
+	pos, vel, acc []vector.Vector3
+}
+
+// reset empties the swarm, keeping its storage.
+func (w *swarm) reset() {
+	w.entities = w.entities[:0]
+	w.pos, w.vel, w.acc = w.pos[:0], w.vel[:0], w.acc[:0]
+}
+
+// add includes e in the next step.
+func (w *swarm) add(e *entities.Entity) {
+	w.entities = append(w.entities, e)
+	w.pos = append(w.pos, e.Position)
+	w.vel = append(w.vel, e.Velocity)
+	w.acc = append(w.acc, e.Acceleration)
+}
+
+// step advances every entity in the swarm by dt and writes the result back.
+func (w *swarm) step(dt float64) {
+	pos := w.pos
+	vel, acc := w.vel[:len(pos)], w.acc[:len(pos)] // one bounds check for the loop
+	for i := range pos {
+		pos[i], vel[i] = physics.KinematicsUpdate(pos[i], vel[i], acc[i], dt)
+	}
+	for i, e := range w.entities {
+		e.Position, e.Velocity = pos[i], vel[i]
+	}
+}
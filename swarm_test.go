@@ -0,0 +1,55 @@
+package simulation
+
+import (
+	"fmt"
+	"testing"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/physics"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func swarmEntities(n int) []*entities.Entity {
+	out := make([]*entities.Entity, n)
+	for i := range out {
+		f := float64(i)
+		out[i] = entities.NewTarget(fmt.Sprint(i), vector.Vector3{X: f * 100, Y: 5000, Z: -f}, vector.Vector3{X: 250, Z: f})
+		out[i].Acceleration = vector.Vector3{Y: -f / 10, Z: 3}
+	}
+	return out
+}
+
+func TestSwarmMatchesEntityStep(t *testing.T) {
+	const dt = 0.016
+	ents, want := swarmEntities(300), swarmEntities(300)
+	var w swarm
+	for range 3 {
+		w.reset()
+		for _, e := range ents {
+			w.add(e)
+		}
+		w.step(dt)
+		for _, e := range want {
+			e.Position, e.Velocity = physics.KinematicsUpdate(e.Position, e.Velocity, e.Acceleration, dt)
+		}
+	}
+	for i := range ents {
+		if ents[i].Position != want[i].Position || ents[i].Velocity != want[i].Velocity {
+			t.Fatalf("entity %d: swarm %v %v, one at a time %v %v", i,
+				ents[i].Position, ents[i].Velocity, want[i].Position, want[i].Velocity)
+		}
+	}
+}
+
+func BenchmarkSwarmStep(b *testing.B) {
+	ents := swarmEntities(500)
+	var w swarm
+	b.ReportAllocs()
+	for b.Loop() {
+		w.reset()
+		for _, e := range ents {
+			w.add(e)
+		}
+		w.step(0.016)
+	}
+}
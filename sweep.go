@@ -0,0 +1,218 @@
+package simulation
+
+import (
+	"fmt"
+	"time"
+
+	"missile-intercept-sim/internal/scenario"
+)
+
+// Sweepable parameters.
+const (
+	SweepNavGain     = "navGain"     // guidance command multiplier on every interceptor
+	SweepLaunchDelay = "launchDelay" // s, launch time of every interceptor
+	SweepTargetSpeed = "targetSpeed" // m/s, speed of every target along its initial heading
+)
+
+// SweepAxis is one swept parameter. Values lists the points explicitly;
+// otherwise Steps points are spaced evenly from Min to Max inclusive.
+type SweepAxis struct {
+	Param  string    `json:"param"`
+	Values []float64 `json:"values,omitempty"`
+	Min    float64   `json:"min,omitempty"`
+	Max    float64   `json:"max,omitempty"`
+	Steps  int       `json:"steps,omitempty"`
+}
+
+// count is the number of values the axis covers, without expanding it.
+func (a SweepAxis) count() int {
+	if len(a.Values) > 0 {
+		return len(a.Values)
+	}
+	return max(a.Steps, 1)
+}
+
+// points expands the axis into the values it covers.
+func (a SweepAxis) points() []float64 {
+	if len(a.Values) > 0 {
+		return a.Values
+	}
+	if a.Steps <= 1 {
+		return []float64{a.Min}
+	}
+	out := make([]float64, a.Steps)
+	for i := range out {
+		out[i] = a.Min + (a.Max-a.Min)*float64(i)/float64(a.Steps-1)
+	}
+	return out
+}
+
+// SweepConfig describes a full-factorial parameter sweep over a scenario.
+type SweepConfig struct {
+	Axes    []SweepAxis `json:"axes"`
+	Seed    uint64      `json:"seed"` // shared by every point so only the swept parameters differ; 0 picks one from the clock
+	MaxTime float64     `json:"maxTime"`
+	Workers int         `json:"workers,omitempty"` // points flown at once, 0 for one per CPU
+}
+
+// SweepPoint is the outcome at one combination of parameter values.
+type SweepPoint struct {
+	Values       []float64 `json:"values"` // in Axes order
+	Status       string    `json:"status"`
+	Reason       string    `json:"reason"`
+	Intercept    bool      `json:"intercept"`
+	MissDistance float64   `json:"missDistance"`
+	TimeOfFlight float64   `json:"timeOfFlight"`
+}
+
+// SweepReport is the grid of outcomes, with the last axis varying fastest.
+type SweepReport struct {
+	Config   SweepConfig  `json:"config"`
+	Scenario string       `json:"scenario"`
+	Points   []SweepPoint `json:"points"`
+	WallTime float64      `json:"wallTime"` // seconds
+}
+
+// maxSweepRuns bounds a sweep for callers that apply no tighter limit.
+const maxSweepRuns = 1 << 20
+
+// SweepSize returns the number of runs cfg expands to, or limit+1 as soon as
+// it is known to exceed limit, so oversized grids are rejected without
+// overflowing or expanding any axis.
+func SweepSize(cfg SweepConfig, limit int) int {
+	n := 1
+	for _, a := range cfg.Axes {
+		c := a.count()
+		if c > limit || n > limit/c {
+			return limit + 1
+		}
+		n *= c
+	}
+	return n
+}
+
+// RunSweep runs sc headlessly once for every combination of the swept
+// parameter values, cfg.Workers runs at a time.
+func RunSweep(sc *scenario.Scenario, cfg SweepConfig) (SweepReport, error) {
+	if len(cfg.Axes) == 0 {
+		return SweepReport{}, fmt.Errorf("sweep needs at least one axis")
+	}
+	size := SweepSize(cfg, maxSweepRuns)
+	if size > maxSweepRuns {
+		return SweepReport{}, fmt.Errorf("sweep expands to more than %d runs", maxSweepRuns)
+	}
+	for _, a := range cfg.Axes {
+		if err := checkSweepAxis(a); err != nil {
+			return SweepReport{}, err
+		}
+	}
+	if cfg.Workers < 0 || cfg.Workers > MaxWorkers {
+		return SweepReport{}, fmt.Errorf("workers must be between 0 and %d", MaxWorkers)
+	}
+	if cfg.Seed == 0 {
+		cfg.Seed = uint64(time.Now().UnixNano())
+	}
+	if cfg.MaxTime <= 0 {
+		cfg.MaxTime = DefaultMaxTime
+	}
+	start := time.Now()
+
+	grid := make([][]float64, len(cfg.Axes))
+	for i, a := range cfg.Axes {
+		grid[i] = a.points()
+	}
+	report := SweepReport{Config: cfg, Scenario: sc.Name, Points: make([]SweepPoint, 0, size)}
+	idx := make([]int, len(grid))
+	for {
+		values := make([]float64, len(grid))
+		for i := range grid {
+			values[i] = grid[i][idx[i]]
+		}
+		report.Points = append(report.Points, SweepPoint{Values: values})
+
+		// Odometer increment, last axis fastest.
+		i := len(idx) - 1
+		for ; i >= 0; i-- {
+			idx[i]++
+			if idx[i] < len(grid[i]) {
+				break
+			}
+			idx[i] = 0
+		}
+		if i < 0 {
+			break
+		}
+	}
+
+	err := forEach(len(report.Points), cfg.Workers, func(n int) error {
+		p := &report.Points[n]
+		run := sc.Clone()
+		for i, a := range cfg.Axes {
+			applySweepParam(run, a.Param, p.Values[i])
+		}
+		state, err := runHeadless(run, cfg.Seed, cfg.MaxTime)
+		if err != nil {
+			return fmt.Errorf("point %v: %w", p.Values, err)
+		}
+		p.Status = state.Status
+		p.Reason = state.Reason
+		p.Intercept = state.Intercept
+		p.MissDistance = state.MissDistance
+		p.TimeOfFlight = state.Time
+		return nil
+	})
+	if err != nil {
+		return SweepReport{}, err
+	}
+	report.WallTime = time.Since(start).Seconds()
+	return report, nil
+}
+
+// checkSweepParam rejects names that are not sweepable parameters.
+func checkSweepParam(name string) error {
+	switch name {
+	case SweepNavGain, SweepLaunchDelay, SweepTargetSpeed:
+		return nil
+	}
+	return fmt.Errorf("unknown sweep parameter %q", name)
+}
+
+// checkSweepValue rejects values the parameter cannot take. A zero gain
+// would be read as the default of 1 and mislabel the point.
+func checkSweepValue(param string, v float64) error {
+	switch {
+	case param == SweepNavGain && v <= 0:
+		return fmt.Errorf("%s must be positive, got %g", param, v)
+	case v < 0:
+		return fmt.Errorf("%s must not be negative, got %g", param, v)
+	}
+	return nil
+}
+
+// checkSweepAxis validates an axis's parameter and every value it covers.
+func checkSweepAxis(a SweepAxis) error {
+	if err := checkSweepParam(a.Param); err != nil {
+		return err
+	}
+	for _, v := range a.points() {
+		if err := checkSweepValue(a.Param, v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// applySweepParam sets one swept parameter on every relevant entity.
+func applySweepParam(sc *scenario.Scenario, param string, v float64) {
+	for i := range sc.Entities {
+		e := &sc.Entities[i]
+		switch {
+		case param == SweepNavGain && e.Role == scenario.RoleInterceptor:
+			e.Gain = v
+		case param == SweepLaunchDelay && e.Role == scenario.RoleInterceptor:
+			e.LaunchTime = v
+		case param == SweepTargetSpeed && e.Role == scenario.RoleTarget:
+			e.Velocity = e.Velocity.Normalize().Mul(v)
+		}
+	}
+}
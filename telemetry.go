@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"time"
+
+	"missile-intercept-sim/internal/mqtt"
+)
+
+// Reconnect backoff of the telemetry and event bus publishers.
+const (
+	reconnectMinBackoff = time.Second
+	reconnectMaxBackoff = 30 * time.Second
+)
+
+// telemetryState is the part of the state published on a session's state
+// topic; entities and events have topics of their own.
+var telemetryState = StreamFilter{Fields: []string{"time", "run", "status", "reason", "intercept", "missDistance", "scenario", "replay"}}
+
+// publisher is the part of an MQTT client the telemetry loop uses.
+type publisher interface {
+	Publish(topic string, payload []byte, retain bool) error
+}
+
+// mqttTelemetry publishes every session's state to an MQTT broker at a fixed
+// rate, under <prefix>/<session>/:
+//
+//	state               run status and time, retained
+//	entities/<id>       each entity's full state
+//	events              each event as it is logged
+type mqttTelemetry struct {
+	broker   string // host:port
+	opts     mqtt.Options
+	prefix   string
+	interval time.Duration
+}
+
+// newMQTTTelemetry configures a publisher for a broker URL of the form
+// tcp://host:port, or tls://host:port for TLS.
+func newMQTTTelemetry(broker, prefix string, rate float64, opts mqtt.Options) (*mqttTelemetry, error) {
+	u, err := url.Parse(broker)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("MQTT broker must be tcp://host:port or tls://host:port, got %q", broker)
+	}
+	switch u.Scheme {
+	case "tcp", "mqtt":
+	case "tls", "ssl", "mqtts":
+		opts.TLS = &tls.Config{MinVersion: tls.VersionTLS12, ServerName: u.Hostname()}
+	default:
+		return nil, fmt.Errorf("unknown MQTT scheme %q", u.Scheme)
+	}
+	if !(rate > 0 && rate <= maxClientRate) {
+		return nil, fmt.Errorf("MQTT rate must be above 0 and at most %d", maxClientRate)
+	}
+	if opts.KeepAlive == 0 {
+		opts.KeepAlive = 30 * time.Second
+	}
+	return &mqttTelemetry{
+		broker:   u.Host,
+		opts:     opts,
+		prefix:   prefix,
+		interval: time.Duration(float64(time.Second) / rate),
+	}, nil
+}
+
+// Run publishes until ctx is done, reconnecting with backoff whenever the
+// broker is unreachable or drops the connection.
+func (t *mqttTelemetry) Run(ctx context.Context) {
+	backoff := reconnectMinBackoff
+	for ctx.Err() == nil {
+		c, err := mqtt.Dial(t.broker, t.opts)
+		if err != nil {
+			slog.Warn("mqtt: retrying", "err", err, "backoff", backoff)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(2*backoff, reconnectMaxBackoff)
+			continue
+		}
+		slog.Info("mqtt: publishing telemetry", "broker", t.broker)
+		backoff = reconnectMinBackoff
+		t.publish(ctx, c)
+		c.Close()
+	}
+}
+
+// publish runs the publishing loop on one connection until it fails.
+func (t *mqttTelemetry) publish(ctx context.Context, c *mqtt.Client) {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+	seen := make(eventCursor)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-c.Done():
+			slog.Warn("mqtt: connection lost", "err", c.Err())
+			return
+		case <-ticker.C:
+			if err := t.tick(c, seen); err != nil {
+				slog.Error("mqtt", "err", err)
+				return
+			}
+		}
+	}
+}
+
+// tick publishes each session's current state and the events it logged
+// since the last tick.
+func (t *mqttTelemetry) tick(p publisher, seen eventCursor) error {
+	live := sessions.List()
+	for _, sess := range live {
+		base := t.prefix + "/" + sess.ID + "/"
+		state := sess.State()
+		msg, err := telemetryState.marshal(state)
+		if err != nil {
+			return err
+		}
+		if err := p.Publish(base+"state", msg, true); err != nil {
+			return err
+		}
+		for _, e := range state.Entities {
+			msg, err := json.Marshal(e)
+			if err != nil {
+				return err
+			}
+			if err := p.Publish(base+"entities/"+e.ID, msg, false); err != nil {
+				return err
+			}
+		}
+		for _, ev := range seen.next(sess) {
+			msg, err := json.Marshal(ev)
+			if err != nil {
+				return err
+			}
+			if err := p.Publish(base+"events", msg, false); err != nil {
+				return err
+			}
+		}
+	}
+	seen.prune(live)
+	return nil
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+	"missile-intercept-sim/internal/mqtt"
+)
+
+// topicRecorder collects what the telemetry loop publishes.
+type topicRecorder map[string][][]byte
+
+func (r topicRecorder) Publish(topic string, payload []byte, retain bool) error {
+	r[topic] = append(r[topic], payload)
+	return nil
+}
+
+func TestNewMQTTTelemetry(t *testing.T) {
+	tests := []struct {
+		broker string
+		rate   float64
+		tls    bool
+		ok     bool
+	}{
+		{"tcp://localhost:1883", 5, false, true},
+		{"tls://broker.example.com:8883", 1, true, true},
+		{"localhost:1883", 5, false, false},
+		{"ws://localhost:9001", 5, false, false},
+		{"tcp://localhost:1883", 0, false, false},
+	}
+	for _, tt := range tests {
+		tel, err := newMQTTTelemetry(tt.broker, "sim", tt.rate, mqtt.Options{})
+		if (err == nil) != tt.ok {
+			t.Errorf("%s at %gHz: err = %v", tt.broker, tt.rate, err)
+			continue
+		}
+		if err == nil && (tel.opts.TLS != nil) != tt.tls {
+			t.Errorf("%s: TLS = %v, want %v", tt.broker, tel.opts.TLS != nil, tt.tls)
+		}
+	}
+}
+
+func TestTelemetryTick(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+	tel, err := newMQTTTelemetry("tcp://localhost:1883", "sim", 5, mqtt.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := make(eventCursor)
+	first := topicRecorder{}
+	if err := tel.tick(first, seen); err != nil {
+		t.Fatal(err)
+	}
+	if len(first["sim/default/events"]) != 0 {
+		t.Error("events from before the publisher started were published")
+	}
+	var state map[string]any
+	if err := json.Unmarshal(first["sim/default/state"][0], &state); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := state["entities"]; ok || state["status"] == nil {
+		t.Errorf("state topic carried %v", state)
+	}
+	var entityTopics int
+	for topic := range first {
+		if strings.HasPrefix(topic, "sim/default/entities/") {
+			entityTopics++
+		}
+	}
+	if entityTopics != len(sess.State().Entities) {
+		t.Errorf("%d entity topics for %d entities", entityTopics, len(sess.State().Entities))
+	}
+
+	if _, err := sess.Sim.Advance(1200); err != nil {
+		t.Fatal(err)
+	}
+	next := topicRecorder{}
+	if err := tel.tick(next, seen); err != nil {
+		t.Fatal(err)
+	}
+	if len(next["sim/default/events"]) == 0 {
+		t.Error("new events were not published")
+	}
+}
@@ -0,0 +1,127 @@
+package simulation
+
+import (
+	"sort"
+
+	"missile-intercept-sim/internal/scenario"
+	"missile-intercept-sim/pkg/vector"
+)
+
+// Threat types used for weighting.
+const (
+	ThreatAircraft  = "aircraft"
+	ThreatBallistic = "ballistic"
+)
+
+// threatWeights scale the score by threat type; a warhead on a ballistic
+// path cannot be deterred or turned away.
+var threatWeights = map[string]float64{
+	ThreatAircraft:  1,
+	ThreatBallistic: 2,
+}
+
+// urgencyHorizon is the time to the defended asset, in seconds, at which a
+// threat's urgency has halved.
+const urgencyHorizon = 60.0
+
+// ThreatAssessment is one threat's place in the engagement priority list.
+type ThreatAssessment struct {
+	TargetID    string  `json:"targetId"`
+	Rank        int     `json:"rank"` // 1 is engaged first
+	Score       float64 `json:"score"`
+	Type        string  `json:"type"`
+	Speed       float64 `json:"speed"`                 // m/s
+	Range       float64 `json:"range"`                 // m to the defended asset
+	TimeToAsset float64 `json:"timeToAsset,omitempty"` // s at the current closing speed, 0 when not inbound
+	Inbound     bool    `json:"inbound"`
+	Tracked     bool    `json:"tracked"` // held by the radar; untracked threats rank last
+}
+
+// defendedAsset returns the point threats are evaluated against.
+func defendedAsset(sc *scenario.Scenario) vector.Vector3 {
+	switch {
+	case sc.Asset != nil:
+		return *sc.Asset
+	case sc.Radar != nil:
+		return sc.Radar.Position
+	}
+	return vector.Vector3{X: 0, Y: 10, Z: 0}
+}
+
+// assessThreat scores a threat from its radar track when it has one, or
+// from truth otherwise. Higher scores are more threatening: urgency rises as
+// the time to the asset falls, speed adds a little, and the type weight
+// scales the lot.
+func (s *Simulator) assessThreat(th *Threat) ThreatAssessment {
+	pos, vel := th.Entity.Position, th.Entity.Velocity
+	track, tracked := s.Radar.Tracks[th.Entity.ID]
+	if tracked {
+		pos, vel = track.Position, track.Velocity
+	}
+	a := ThreatAssessment{
+		TargetID: th.Entity.ID,
+		Type:     ThreatAircraft,
+		Speed:    vel.Magnitude(),
+		Tracked:  tracked,
+	}
+	if th.Ballistic {
+		a.Type = ThreatBallistic
+	}
+	los := s.Asset.Sub(pos)
+	a.Range = los.Magnitude()
+	urgency := 0.0
+	if a.Range > 0 {
+		if closing := vel.Dot(los) / a.Range; closing > 0 {
+			a.Inbound = true
+			a.TimeToAsset = a.Range / closing
+			urgency = urgencyHorizon / (urgencyHorizon + a.TimeToAsset)
+		}
+	}
+	a.Score = threatWeights[a.Type] * (urgency + a.Speed/1000)
+	return a
+}
+
+// evaluateThreatsLocked ranks the live threats, records each one's Priority
+// and publishes the list in the state. Callers must hold s.mu.
+func (s *Simulator) evaluateThreatsLocked() {
+	var list []ThreatAssessment
+	byID := make(map[string]*Threat, len(s.Threats))
+	for _, th := range s.Threats {
+		th.Priority = 0
+		if th.Live() {
+			list = append(list, s.assessThreat(th))
+			byID[th.Entity.ID] = th
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Tracked != list[j].Tracked {
+			return list[i].Tracked
+		}
+		return list[i].Score > list[j].Score
+	})
+	for i := range list {
+		list[i].Rank = i + 1
+		byID[list[i].TargetID].Priority = i + 1
+	}
+	s.State.Threats = list
+}
+
+// rankedThreatsLocked returns the live threats in priority order.
+func (s *Simulator) rankedThreatsLocked() []*Threat {
+	var out []*Threat
+	for _, th := range s.Threats {
+		if th.Live() && th.Priority > 0 {
+			out = append(out, th)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Priority < out[j].Priority })
+	return out
+}
+
+// topThreatLocked returns the highest-priority live threat, or nil.
+func (s *Simulator) topThreatLocked() *Threat {
+	if ranked := s.rankedThreatsLocked(); len(ranked) > 0 {
+		return ranked[0]
+	}
+	return nil
+}
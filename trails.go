@@ -0,0 +1,58 @@
+package simulation
+
+import "missile-intercept-sim/pkg/vector"
+
+// DefaultTrailDuration is how many seconds of trail each entity keeps.
+const DefaultTrailDuration = 10.0
+
+// trailInterval is the simulated time between trail samples.
+const trailInterval = 0.1
+
+// TrailPoint is one downsampled trajectory sample.
+type TrailPoint struct {
+	Time     float64        `json:"time"`
+	Position vector.Vector3 `json:"position"`
+}
+
+// resetTrailsLocked clears every trail. Callers must hold s.mu.
+func (s *Simulator) resetTrailsLocked() {
+	s.trails = make(map[string][]TrailPoint)
+	s.nextTrail = s.State.Time
+	s.sampleTrailsLocked()
+}
+
+// sampleTrailsLocked appends a sample per entity every trailInterval and
+// drops samples older than TrailDuration.
+func (s *Simulator) sampleTrailsLocked() {
+	if s.TrailDuration <= 0 || s.State.Time+schedulerEpsilon < s.nextTrail {
+		return
+	}
+	s.nextTrail = s.State.Time + trailInterval
+	oldest := s.State.Time - s.TrailDuration
+	for _, e := range s.State.Entities {
+		tr := append(s.trails[e.ID], TrailPoint{Time: s.State.Time, Position: e.Position})
+		i := 0
+		for i < len(tr) && tr[i].Time < oldest {
+			i++
+		}
+		s.trails[e.ID] = tr[i:]
+	}
+}
+
+// Trails returns a copy of each entity's trajectory over the last window
+// seconds, keyed by entity ID, oldest sample first. Trails never reach back
+// further than TrailDuration.
+func (s *Simulator) Trails(window float64) map[string][]TrailPoint {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	oldest := s.State.Time - window
+	out := make(map[string][]TrailPoint, len(s.trails))
+	for id, tr := range s.trails {
+		i := 0
+		for i < len(tr) && tr[i].Time < oldest {
+			i++
+		}
+		out[id] = append([]TrailPoint(nil), tr[i:]...)
+	}
+	return out
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/simulation"
+	"missile-intercept-sim/internal/units"
+
+	"github.com/gorilla/websocket"
+)
+
+// watchEvents is how many recent events the dashboard shows.
+const watchEvents = 6
+
+// cmdWatch follows a session's stream and redraws a dashboard in the
+// terminal for every frame, for servers with no browser at hand.
+func cmdWatch(args []string) error {
+	fs := newFlagSet("watch", "")
+	server := fs.String("url", "ws://localhost:8080/ws", "WebSocket stream of the server to watch")
+	session := fs.String("session", defaultSessionID, "session to watch")
+	token := fs.String("token", os.Getenv("SIM_TOKEN"), "API token, if the server requires one")
+	rate := fs.Float64("rate", 10, "frames per second to redraw at")
+	width := fs.Int("width", 72, "plan view width in characters")
+	height := fs.Int("height", 20, "plan view height in characters")
+	fs.Parse(args)
+	if *width < 10 || *height < 5 {
+		return errors.New("the plan view needs at least 10 by 5 characters")
+	}
+	u, err := url.Parse(*server)
+	if err != nil {
+		return err
+	}
+	q := u.Query()
+	q.Set("session", *session)
+	q.Set("rate", strconv.FormatFloat(*rate, 'g', -1, 64))
+	u.RawQuery = q.Encode()
+	header := http.Header{}
+	if *token != "" {
+		header.Set("Authorization", "Bearer "+*token)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	conn, resp, err := websocket.DefaultDialer.DialContext(ctx, u.String(), header)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("%s: %s", u.Redacted(), resp.Status)
+		}
+		return err
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	fmt.Print("\x1b[?25l") // hide the cursor while redrawing
+	defer fmt.Print("\x1b[?25h")
+	d := &dashboard{width: *width, height: *height}
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		var msg struct {
+			Ack string `json:"ack"`
+			simulation.SimulationState
+		}
+		if err := json.Unmarshal(data, &msg); err != nil || msg.Ack != "" {
+			continue // acknowledgements and anything else that is not a frame
+		}
+		var b strings.Builder
+		b.WriteString("\x1b[H\x1b[2J")
+		d.render(&b, &msg.SimulationState)
+		io.WriteString(os.Stdout, b.String())
+	}
+}
+
+// dashboard renders frames as text, remembering recent events across them.
+type dashboard struct {
+	width, height int
+	events        []simulation.Event
+}
+
+// render writes the header, the engagement table, the plan view and the
+// latest events for st.
+func (d *dashboard) render(w io.Writer, st *simulation.SimulationState) {
+	d.events = append(d.events, st.Events...)
+	if n := len(d.events); n > watchEvents {
+		d.events = d.events[n-watchEvents:]
+	}
+	fmt.Fprintf(w, "%s  run %d  t=%.2f s  %s", st.Scenario, st.Run, st.Time, st.Status)
+	if st.Reason != "" {
+		fmt.Fprintf(w, " (%s)", st.Reason)
+	}
+	fmt.Fprintf(w, "  x%g\n\n", st.TimeScale)
+
+	byID := make(map[string]*entities.Entity, len(st.Entities))
+	for _, e := range st.Entities {
+		byID[e.ID] = e
+	}
+	fmt.Fprintf(w, "%-12s %-12s %-11s %9s %9s %7s %6s %9s\n", "MISSILE", "TARGET", "STATUS", "RANGE m", "VC m/s", "TGO s", "G", "MISS m")
+	for _, eng := range st.Engagements {
+		rng, g := "-", "-"
+		m, t := byID[eng.MissileID], byID[eng.TargetID]
+		if m != nil && t != nil {
+			rng = fmt.Sprintf("%.0f", m.Position.Distance(t.Position))
+		}
+		if m != nil && eng.Status == "Flying" {
+			acc := m.Acceleration
+			acc.Y += units.G // the command, without gravity
+			g = fmt.Sprintf("%.1f", acc.Magnitude()/units.G)
+		}
+		vc, tgo := "-", "-"
+		if eng.Status == "Flying" {
+			vc = fmt.Sprintf("%.0f", eng.ClosingVelocity)
+			if eng.TimeToGo > 0 {
+				tgo = fmt.Sprintf("%.2f", eng.TimeToGo)
+			}
+		}
+		fmt.Fprintf(w, "%-12s %-12s %-11s %9s %9s %7s %6s %9.1f\n", eng.MissileID, eng.TargetID, eng.Status, rng, vc, tgo, g, eng.MissDistance)
+	}
+	fmt.Fprintln(w)
+	d.plan(w, st.Entities)
+	fmt.Fprintln(w)
+	for _, ev := range d.events {
+		fmt.Fprintf(w, "%8.2f  %-10s %s\n", ev.Time, ev.Type, ev.Message)
+	}
+}
+
+// plan draws the entities from above, east to the right and north up, on a
+// grid scaled to fit them all with equal metres per character both ways.
+// Terminal cells are about twice as tall as wide, so a row covers two
+// columns' worth of ground.
+func (d *dashboard) plan(w io.Writer, ents []*entities.Entity) {
+	minX, maxX, minZ, maxZ := math.Inf(1), math.Inf(-1), math.Inf(1), math.Inf(-1)
+	for _, e := range ents {
+		minX, maxX = min(minX, e.Position.X), max(maxX, e.Position.X)
+		minZ, maxZ = min(minZ, e.Position.Z), max(maxZ, e.Position.Z)
+	}
+	if len(ents) == 0 {
+		minX, maxX, minZ, maxZ = 0, 1, 0, 1
+	}
+	cols, rows := d.width-2, d.height-2
+	// Metres per column, leaving a cell of margin.
+	scale := max((maxX-minX)/float64(cols-2), (maxZ-minZ)/float64(2*(rows-2)), 1)
+	cx, cz := (minX+maxX)/2, (minZ+maxZ)/2
+
+	grid := make([][]byte, rows)
+	for i := range grid {
+		grid[i] = []byte(strings.Repeat(" ", cols))
+	}
+	for _, e := range ents {
+		c := int(math.Round(float64(cols-1)/2 + (e.Position.X-cx)/scale))
+		r := int(math.Round(float64(rows-1)/2 - (e.Position.Z-cz)/(2*scale)))
+		if c < 0 || c >= cols || r < 0 || r >= rows {
+			continue
+		}
+		grid[r][c] = planSymbol(e)
+	}
+	fmt.Fprintf(w, "+%s+ %.0f m/col\n", strings.Repeat("-", cols), scale)
+	for _, row := range grid {
+		fmt.Fprintf(w, "|%s|\n", row)
+	}
+	fmt.Fprintf(w, "+%s+ T target  M missile  N up\n", strings.Repeat("-", cols))
+}
+
+// planSymbol is the character an entity is drawn with.
+func planSymbol(e *entities.Entity) byte {
+	switch t := string(e.Type); {
+	case t == "":
+		return '?'
+	case strings.EqualFold(t, "target"):
+		return 'T'
+	case strings.EqualFold(t, "missile"):
+		return 'M'
+	default:
+		return strings.ToUpper(t)[0]
+	}
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"missile-intercept-sim/internal/entities"
+	"missile-intercept-sim/internal/simulation"
+	"missile-intercept-sim/pkg/vector"
+)
+
+func TestDashboardPlan(t *testing.T) {
+	tests := []struct {
+		name string
+		ents []*entities.Entity
+		want []string // symbols expected in the plan view, top row first
+	}{
+		{"empty", nil, nil},
+		{"north of", []*entities.Entity{
+			entities.NewTarget("t", vector.Vector3{Z: 5000}, vector.Vector3{}),
+			{ID: "m", Type: "Missile"},
+		}, []string{"T", "M"}},
+		{"east of", []*entities.Entity{
+			{ID: "m", Type: "Missile"},
+			entities.NewTarget("t", vector.Vector3{X: 5000}, vector.Vector3{}),
+		}, []string{"M T"}},
+	}
+	for _, tt := range tests {
+		var b strings.Builder
+		d := &dashboard{width: 30, height: 10}
+		d.plan(&b, tt.ents)
+		lines := strings.Split(strings.TrimSpace(b.String()), "\n")
+		if len(lines) != d.height {
+			t.Fatalf("%s: %d lines, want %d", tt.name, len(lines), d.height)
+		}
+		var got []string
+		for _, l := range lines[1 : len(lines)-1] {
+			if len(l) != d.width {
+				t.Fatalf("%s: row %q is %d wide, want %d", tt.name, l, len(l), d.width)
+			}
+			if s := strings.Join(strings.Fields(strings.Trim(l, "|")), " "); s != "" {
+				got = append(got, s)
+			}
+		}
+		if strings.Join(got, "/") != strings.Join(tt.want, "/") {
+			t.Errorf("%s: plan shows %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestDashboardEvents(t *testing.T) {
+	d := &dashboard{width: 20, height: 6}
+	for i := range 10 {
+		d.render(&strings.Builder{}, &simulation.SimulationState{Events: []simulation.Event{{Seq: uint64(i)}}})
+	}
+	if len(d.events) != watchEvents || d.events[0].Seq != 10-watchEvents {
+		t.Errorf("kept %d events from %d, want the last %d", len(d.events), d.events[0].Seq, watchEvents)
+	}
+}
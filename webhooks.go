@@ -0,0 +1,309 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"sync"
+	"time"
+
+	"missile-intercept-sim/internal/simulation"
+)
+
+// Webhook event names.
+const (
+	HookRunStarted     = "run.started"
+	HookIntercept      = "intercept"
+	HookCrash          = "crash"
+	HookRunFaulted     = "run.faulted"
+	HookBatchCompleted = "batch.completed"
+	HookSweepCompleted = "sweep.completed"
+)
+
+var hookEvents = []string{HookRunStarted, HookIntercept, HookCrash, HookRunFaulted, HookBatchCompleted, HookSweepCompleted}
+
+// Webhook delivery limits.
+const (
+	maxWebhooks      = 32
+	hookQueueSize    = 256 // deliveries waiting before new ones are dropped
+	hookTimeout      = 5 * time.Second
+	hookAttempts     = 3
+	hookRetryBackoff = time.Second // doubled after each failed attempt
+	hookPollInterval = 250 * time.Millisecond
+)
+
+// Webhook is a URL that is POSTed a WebhookPayload on each of its events.
+// With a secret, each POST carries an X-Signature header of
+// "sha256=<hex HMAC of the body>" so the receiver can check its origin.
+type Webhook struct {
+	ID      string   `json:"id"`
+	URL     string   `json:"url"`
+	Events  []string `json:"events"`            // empty for every event
+	Session string   `json:"session,omitempty"` // empty for every session
+	Secret  string   `json:"secret,omitempty"`
+}
+
+// wants reports whether h is sent event from session.
+func (h *Webhook) wants(event, session string) bool {
+	return (len(h.Events) == 0 || slices.Contains(h.Events, event)) && (h.Session == "" || h.Session == session)
+}
+
+// WebhookPayload is the body POSTed to a webhook.
+type WebhookPayload struct {
+	Event   string            `json:"event"`
+	Session string            `json:"session"`
+	Time    time.Time         `json:"time"`
+	Sim     *simulation.Event `json:"simEvent,omitempty"` // the simulation event behind run and engagement events
+	Summary any               `json:"summary,omitempty"`  // batch and sweep results
+}
+
+// batchSummary is the summary of a batch.completed payload.
+type batchSummary struct {
+	Runs       int     `json:"runs"`
+	Intercepts int     `json:"intercepts"`
+	Pk         float64 `json:"pk"`
+	MeanMiss   float64 `json:"meanMiss"`
+	MeanFlight float64 `json:"meanFlight"`
+	WallTime   float64 `json:"wallTime"`
+}
+
+// sweepSummary is the summary of a sweep.completed payload.
+type sweepSummary struct {
+	Scenario string  `json:"scenario"`
+	Points   int     `json:"points"`
+	WallTime float64 `json:"wallTime"`
+}
+
+type delivery struct {
+	hook    *Webhook
+	payload []byte
+}
+
+// webhookRegistry holds the registered webhooks and delivers to them from a
+// bounded queue, so a slow receiver never holds up the simulation or API.
+type webhookRegistry struct {
+	mu    sync.Mutex
+	hooks []*Webhook
+
+	queue  chan delivery
+	client *http.Client
+}
+
+func newWebhookRegistry() *webhookRegistry {
+	return &webhookRegistry{queue: make(chan delivery, hookQueueSize), client: &http.Client{Timeout: hookTimeout}}
+}
+
+var webhooks = newWebhookRegistry()
+
+// Add validates and registers h, assigning its ID.
+func (r *webhookRegistry) Add(h Webhook) (*Webhook, error) {
+	u, err := url.Parse(h.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, errors.New("url must be an absolute http or https URL")
+	}
+	for _, ev := range h.Events {
+		if !slices.Contains(hookEvents, ev) {
+			return nil, fmt.Errorf("unknown event %q", ev)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.hooks) >= maxWebhooks {
+		return nil, fmt.Errorf("at most %d webhooks may be registered", maxWebhooks)
+	}
+	h.ID = newSessionID()
+	r.hooks = append(r.hooks, &h)
+	return &h, nil
+}
+
+// Remove unregisters the webhook with id, reporting whether it existed.
+func (r *webhookRegistry) Remove(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := len(r.hooks)
+	r.hooks = slices.DeleteFunc(r.hooks, func(h *Webhook) bool { return h.ID == id })
+	return len(r.hooks) < n
+}
+
+// List returns the registered webhooks, secrets withheld.
+func (r *webhookRegistry) List() []Webhook {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	list := make([]Webhook, 0, len(r.hooks))
+	for _, h := range r.hooks {
+		hook := *h
+		hook.Secret = ""
+		list = append(list, hook)
+	}
+	return list
+}
+
+// Notify queues p for every webhook that wants it. When the queue is full
+// the delivery is dropped and logged.
+func (r *webhookRegistry) Notify(p WebhookPayload) {
+	r.mu.Lock()
+	var targets []*Webhook
+	for _, h := range r.hooks {
+		if h.wants(p.Event, p.Session) {
+			targets = append(targets, h)
+		}
+	}
+	r.mu.Unlock()
+	if len(targets) == 0 {
+		return
+	}
+	if p.Time.IsZero() {
+		p.Time = time.Now().UTC()
+	}
+	body, err := json.Marshal(p)
+	if err != nil {
+		slog.Error("webhook", "err", err)
+		return
+	}
+	for _, h := range targets {
+		select {
+		case r.queue <- delivery{h, body}:
+		default:
+			slog.Warn("webhook: queue full, dropping", "event", p.Event, "url", h.URL)
+		}
+	}
+}
+
+// Run delivers queued payloads and turns new simulation events in every
+// session into notifications, until ctx is done.
+func (r *webhookRegistry) Run(ctx context.Context) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case d := <-r.queue:
+				r.deliver(ctx, d)
+			}
+		}
+	}()
+	seen := make(eventCursor)
+	ticker := time.NewTicker(hookPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.poll(seen)
+		}
+	}
+}
+
+// poll notifies the events each session logged since the last poll. A
+// session is watched from the first poll that sees it, not from its start.
+func (r *webhookRegistry) poll(seen eventCursor) {
+	live := sessions.List()
+	for _, sess := range live {
+		for _, ev := range seen.next(sess) {
+			if name := hookEvent(ev); name != "" {
+				r.Notify(WebhookPayload{Event: name, Session: sess.ID, Sim: &ev})
+			}
+		}
+	}
+	seen.prune(live)
+}
+
+// hookEvent names the webhook event a simulation event raises, if any.
+func hookEvent(ev simulation.Event) string {
+	switch {
+	case ev.Type == simulation.EventPhase && ev.Message == "Running":
+		return HookRunStarted
+	case ev.Type == simulation.EventIntercept:
+		return HookIntercept
+	case ev.Type == simulation.EventCrash:
+		return HookCrash
+	case ev.Type == simulation.EventFault:
+		return HookRunFaulted
+	}
+	return ""
+}
+
+// deliver POSTs d, retrying with backoff on network errors and 5xx replies.
+func (r *webhookRegistry) deliver(ctx context.Context, d delivery) {
+	backoff := hookRetryBackoff
+	for attempt := 1; ; attempt++ {
+		err := r.post(ctx, d)
+		if err == nil {
+			return
+		}
+		if attempt == hookAttempts || ctx.Err() != nil {
+			slog.Warn("webhook: giving up", "url", d.hook.URL, "attempts", attempt, "err", err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (r *webhookRegistry) post(ctx context.Context, d delivery) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.hook.URL, bytes.NewReader(d.payload))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if d.hook.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(d.hook.Secret))
+		mac.Write(d.payload)
+		req.Header.Set("X-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("status %s", resp.Status)
+	}
+	return nil
+}
+
+// handleWebhooks lists webhooks on GET, registers one on POST and removes
+// the one named by ?id= on DELETE.
+func handleWebhooks(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(webhooks.List())
+	case http.MethodPost:
+		var req Webhook
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, "Invalid body", http.StatusBadRequest)
+			return
+		}
+		hook, err := webhooks.Add(req)
+		if err != nil {
+			writeError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(Webhook{ID: hook.ID, URL: hook.URL, Events: hook.Events, Session: hook.Session})
+	case http.MethodDelete:
+		if !webhooks.Remove(r.URL.Query().Get("id")) {
+			writeError(w, "Unknown webhook", http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeError(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"missile-intercept-sim/internal/logging"
+)
+
+func TestWebhookAdd(t *testing.T) {
+	tests := []struct {
+		name string
+		hook Webhook
+		ok   bool
+	}{
+		{"every event", Webhook{URL: "https://hooks.example.com/sim"}, true},
+		{"some events", Webhook{URL: "http://ci:8000/", Events: []string{HookIntercept, HookBatchCompleted}}, true},
+		{"unknown event", Webhook{URL: "http://ci:8000/", Events: []string{"explode"}}, false},
+		{"relative url", Webhook{URL: "/hook"}, false},
+		{"other scheme", Webhook{URL: "file:///etc/passwd"}, false},
+	}
+	r := newWebhookRegistry()
+	for _, tt := range tests {
+		hook, err := r.Add(tt.hook)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: err = %v", tt.name, err)
+		}
+		if err == nil && hook.ID == "" {
+			t.Errorf("%s: no ID assigned", tt.name)
+		}
+	}
+	if n := len(r.List()); n != 2 {
+		t.Errorf("%d webhooks registered, want 2", n)
+	}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	sessions = NewSessionManager()
+	sess, _ := sessions.Get(defaultSessionID)
+	defer sess.Hub.Close()
+	sess.Sim.SetLogger(logging.Discard)
+
+	got := make(chan *http.Request, 1)
+	bodies := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- r
+		bodies <- body
+	}))
+	defer srv.Close()
+
+	reg := newWebhookRegistry()
+	if _, err := reg.Add(Webhook{URL: srv.URL, Events: []string{HookIntercept}, Secret: "s3cret"}); err != nil {
+		t.Fatal(err)
+	}
+	seen := make(eventCursor)
+	reg.poll(seen)                                    // start watching
+	if _, err := sess.Sim.Advance(1200); err != nil { // past the default intercept at t=10.4
+		t.Fatal(err)
+	}
+	reg.poll(seen)
+	if n := len(reg.queue); n != 1 {
+		t.Fatalf("%d deliveries queued, want 1", n)
+	}
+	reg.deliver(context.Background(), <-reg.queue)
+
+	r, body := <-got, <-bodies
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	if want := "sha256=" + hex.EncodeToString(mac.Sum(nil)); r.Header.Get("X-Signature") != want {
+		t.Errorf("X-Signature %q, want %q", r.Header.Get("X-Signature"), want)
+	}
+	var p WebhookPayload
+	if err := json.Unmarshal(body, &p); err != nil {
+		t.Fatal(err)
+	}
+	if p.Event != HookIntercept || p.Session != sess.ID || p.Sim == nil {
+		t.Errorf("payload %+v", p)
+	}
+}